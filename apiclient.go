@@ -4,10 +4,12 @@ import (
 	"context"
 	"github.com/k-yomo/moodle/pkg/urlutil"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
+	"sync"
+	"time"
 )
 
 type apiClient struct {
@@ -15,10 +17,19 @@ type apiClient struct {
 	httpClient *http.Client
 	serviceURL *url.URL
 	apiURL     *url.URL
-	debug      bool
+	// handler sends the requests through the middlewares, send is used if it's nil
+	handler Handler
+
+	// checkFunction is called with the wsfunction before the call, and the call fails if it returns an error
+	checkFunction func(ctx context.Context, wsfunction string) error
+
+	clockSkewMu sync.RWMutex
+	// clockSkew is the difference between the server clock and the local clock (server - local)
+	// measured with the Date header of the latest response.
+	clockSkew *time.Duration
 }
 
-func newAPIClient(httpClient *http.Client, serviceURL *url.URL, authToken string, debug bool) *apiClient {
+func newAPIClient(httpClient *http.Client, serviceURL *url.URL, authToken string, middlewares []Middleware) *apiClient {
 	apiURL := urlutil.Copy(serviceURL)
 	apiURL.Path = path.Join(apiURL.Path, "/webservice/rest/server.php")
 	urlutil.SetQueries(apiURL, map[string]string{
@@ -26,13 +37,14 @@ func newAPIClient(httpClient *http.Client, serviceURL *url.URL, authToken string
 		"wstoken":            authToken,
 	})
 
-	return &apiClient{
+	a := &apiClient{
 		authToken:  authToken,
 		httpClient: httpClient,
 		serviceURL: serviceURL,
 		apiURL:     apiURL,
-		debug:      debug,
 	}
+	a.handler = chainMiddlewares(a.send, middlewares)
+	return a
 }
 
 func (a *apiClient) updateToken(authToken string) {
@@ -44,41 +56,99 @@ func (a *apiClient) updateToken(authToken string) {
 
 // callMoodleFunction call moodle's service function and map the response json to `to` param.
 func (a *apiClient) callMoodleFunction(ctx context.Context, to interface{}, queryParams ...map[string]string) error {
+	return a.callMoodleFunctionWithMethod(ctx, http.MethodGet, to, queryParams...)
+}
+
+// postMoodleFunction calls moodle's service function changing data with the params in the form body,
+// so that the secrets like passwords and feedback are not left in the access logs and large params fit in the request.
+func (a *apiClient) postMoodleFunction(ctx context.Context, to interface{}, params ...map[string]string) error {
+	return a.callMoodleFunctionWithMethod(ctx, http.MethodPost, to, params...)
+}
+
+func (a *apiClient) callMoodleFunctionWithMethod(ctx context.Context, method string, to interface{}, queryParams ...map[string]string) error {
+	if a.checkFunction != nil {
+		for _, params := range queryParams {
+			if wsfunction, ok := params["wsfunction"]; ok {
+				if err := a.checkFunction(ctx, wsfunction); err != nil {
+					return err
+				}
+			}
+		}
+	}
 	u := urlutil.CopyWithQueries(a.apiURL, queryParams...)
-	return a.getAndUnmarshal(ctx, u, to)
+	return a.doAndUnmarshal(ctx, method, u, to)
 }
 
-func (a *apiClient) getAndUnmarshal(ctx context.Context, u *url.URL, to interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+func (a *apiClient) doAndUnmarshal(ctx context.Context, method string, u *url.URL, to interface{}) error {
+	handler := a.handler
+	if handler == nil {
+		handler = a.send
+	}
+	res, err := handler(ctx, newCallRequest(method, u))
 	if err != nil {
 		return err
 	}
-	if a.debug {
-		log.Printf(`[INFO] make http request
-	method: %s
-	url: %s
-`, req.Method, req.URL.String())
+	return mapResponseBodyToStruct(res.Body, to)
+}
+
+// send is the innermost handler sending the request to the site
+func (a *apiClient) send(ctx context.Context, callReq *CallRequest) (*CallResponse, error) {
+	var req *http.Request
+	var err error
+	if callReq.Method == http.MethodPost {
+		u, form := callReq.form()
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, callReq.url().String(), nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range callReq.Header {
+		req.Header[k] = v
 	}
 
+	sentAt := time.Now()
 	resp, err := a.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
+	a.recordServerTime(resp.Header.Get("Date"), sentAt, time.Now())
 
 	bodyBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if a.debug {
-		log.Printf(`[INFO] raw response
-	status: %s
-	body: %s
+	return &CallResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: bodyBytes}, nil
+}
 
-`, resp.Status, string(bodyBytes))
+func (a *apiClient) recordServerTime(date string, sentAt, receivedAt time.Time) {
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return
 	}
-	if err := mapResponseBodyToStruct(bodyBytes, to); err != nil {
-		return err
+	// Date header is truncated to seconds, so the server time is assumed to be in the middle of the second.
+	serverTime = serverTime.Add(500 * time.Millisecond)
+	localTime := sentAt.Add(receivedAt.Sub(sentAt) / 2)
+	skew := serverTime.Sub(localTime)
+
+	a.clockSkewMu.Lock()
+	defer a.clockSkewMu.Unlock()
+	a.clockSkew = &skew
+}
+
+// serverClockSkew returns the difference between the server clock and the local clock (server - local).
+// false is returned if no response with Date header has been received yet.
+func (a *apiClient) serverClockSkew() (time.Duration, bool) {
+	a.clockSkewMu.RLock()
+	defer a.clockSkewMu.RUnlock()
+	if a.clockSkew == nil {
+		return 0, false
 	}
-	return nil
+	return *a.clockSkew, true
 }
@@ -3,6 +3,7 @@ package moodle
 import (
 	"context"
 	"github.com/k-yomo/moodle/pkg/urlutil"
+	"net/http"
 	"path"
 )
 
@@ -23,6 +24,7 @@ type LoginResponse struct {
 	PrivateToken string `json:"privatetoken"`
 }
 
+// Login gets the token of the user, the credentials are sent in the form body not to be left in the access logs
 func (a *authAPI) Login(ctx context.Context, username, password string) (*LoginResponse, error) {
 	u := urlutil.CopyWithQueries(a.serviceURL, map[string]string{
 		"username": username,
@@ -31,7 +33,7 @@ func (a *authAPI) Login(ctx context.Context, username, password string) (*LoginR
 	})
 	u.Path = path.Join(u.Path, "/login/token.php")
 	res := LoginResponse{}
-	if err := a.getAndUnmarshal(ctx, u, &res); err != nil {
+	if err := a.doAndUnmarshal(ctx, http.MethodPost, u, &res); err != nil {
 		return nil, err
 	}
 	return &res, nil
@@ -0,0 +1,270 @@
+package moodle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrBatchCallNotExecuted is returned by a call in the batch which is not executed,
+// because the batch is not done yet or a preceding call in the batch failed.
+var ErrBatchCallNotExecuted = errors.New("batch call is not executed")
+
+// Batch queues web service function calls and executes them in one request with tool_mobile_call_external_functions,
+// which is available in Moodle 3.7 or later.
+// The result of each call is got from the handle returned when the call is queued, after Do is called.
+type Batch struct {
+	client *Client
+	calls  []*batchCall
+	done   bool
+}
+
+type batchCall struct {
+	function string
+	args     map[string]interface{}
+	decode   func(data []byte) error
+	err      error
+}
+
+// NewBatch creates an empty batch
+func (c *Client) NewBatch() *Batch {
+	return &Batch{client: c}
+}
+
+// Len returns the number of the queued calls
+func (b *Batch) Len() int {
+	return len(b.calls)
+}
+
+func (b *Batch) add(function string, args map[string]interface{}, decode func(data []byte) error) *batchCall {
+	call := &batchCall{function: function, args: args, decode: decode, err: ErrBatchCallNotExecuted}
+	b.calls = append(b.calls, call)
+	return call
+}
+
+type callExternalFunctionsResponse struct {
+	Responses []*struct {
+		Error     bool    `json:"error"`
+		Data      *string `json:"data"`
+		Exception *string `json:"exception"`
+	} `json:"responses"`
+}
+
+// Do executes the queued calls in one request.
+// Error is returned only when the request itself fails, and the error of each call is returned from its handle.
+// Moodle stops executing the calls at the first failure, so the following calls fail with ErrBatchCallNotExecuted.
+func (b *Batch) Do(ctx context.Context) error {
+	if b.done {
+		return errors.New("batch is already done")
+	}
+	if len(b.calls) == 0 {
+		b.done = true
+		return nil
+	}
+
+	params := map[string]string{
+		"wsfunction": "tool_mobile_call_external_functions",
+	}
+	for i, call := range b.calls {
+		args, err := json.Marshal(call.args)
+		if err != nil {
+			return fmt.Errorf("encode arguments of %s: %w", call.function, err)
+		}
+		params[fmt.Sprintf("requests[%d][function]", i)] = call.function
+		params[fmt.Sprintf("requests[%d][arguments]", i)] = string(args)
+	}
+
+	res := callExternalFunctionsResponse{}
+	if err := b.client.apiClient.callMoodleFunction(ctx, &res, params); err != nil {
+		return err
+	}
+	b.done = true
+
+	for i, callRes := range res.Responses {
+		if i >= len(b.calls) {
+			break
+		}
+		call := b.calls[i]
+		if callRes.Error {
+			call.err = mapToBatchCallError(call.function, callRes.Exception)
+			continue
+		}
+		data := []byte("null")
+		if callRes.Data != nil {
+			data = []byte(*callRes.Data)
+		}
+		call.err = call.decode(data)
+	}
+	return nil
+}
+
+func mapToBatchCallError(function string, exception *string) error {
+	if exception != nil {
+		apiError := APIError{}
+		if err := json.Unmarshal([]byte(*exception), &apiError); err == nil && apiError.ErrorCode != "" {
+			return &apiError
+		}
+	}
+	return fmt.Errorf("batch call of %s failed", function)
+}
+
+// decodeInto returns the decode function to unmarshal the data into the response with warnings
+func decodeInto(res interface{}, warnings func() Warnings) func(data []byte) error {
+	return func(data []byte) error {
+		if err := mapResponseBodyToStruct(data, res); err != nil {
+			return err
+		}
+		if w := warnings(); len(w) > 0 {
+			return w
+		}
+		return nil
+	}
+}
+
+// BatchCall is the handle of a call queued by Batch.Call
+type BatchCall struct {
+	call *batchCall
+}
+
+// Err returns the error of the call
+func (c *BatchCall) Err() error {
+	return c.call.err
+}
+
+// Call queues the web service function with the args, and the result is decoded into out.
+// Unlike Client.Call, args are encoded as json as they are (e.g. {"courseids": []int{1, 2}}).
+func (b *Batch) Call(wsfunction string, args map[string]interface{}, out interface{}) *BatchCall {
+	return &BatchCall{
+		call: b.add(wsfunction, args, func(data []byte) error {
+			if out == nil {
+				return nil
+			}
+			return mapResponseBodyToStruct(data, out)
+		}),
+	}
+}
+
+// CoursesBatchCall is the handle of a call returning courses
+type CoursesBatchCall struct {
+	call    *batchCall
+	courses []*Course
+}
+
+// Result returns the courses or the error of the call
+func (c *CoursesBatchCall) Result() ([]*Course, error) {
+	return c.courses, c.call.err
+}
+
+// GetEnrolledCoursesByTimelineClassification queues CourseAPI.GetEnrolledCoursesByTimelineClassification
+func (b *Batch) GetEnrolledCoursesByTimelineClassification(classification CourseClassification) *CoursesBatchCall {
+	c := &CoursesBatchCall{}
+	res := getEnrolledCoursesByTimelineClassificationResponse{}
+	c.call = b.add(
+		"core_course_get_enrolled_courses_by_timeline_classification",
+		map[string]interface{}{"classification": classification},
+		func(data []byte) error {
+			if err := mapResponseBodyToStruct(data, &res); err != nil {
+				return err
+			}
+			c.courses = mapToCourseList(res.Courses)
+			return nil
+		},
+	)
+	return c
+}
+
+// QuizzesBatchCall is the handle of a call returning quizzes
+type QuizzesBatchCall struct {
+	call    *batchCall
+	quizzes []*Quiz
+}
+
+// Result returns the quizzes or the error of the call
+func (q *QuizzesBatchCall) Result() ([]*Quiz, error) {
+	return q.quizzes, q.call.err
+}
+
+// GetQuizzesByCourse queues QuizAPI.GetQuizzesByCourse
+func (b *Batch) GetQuizzesByCourse(courseID int) *QuizzesBatchCall {
+	q := &QuizzesBatchCall{}
+	res := getQuizzesByCourseResponse{}
+	q.call = b.add(
+		"mod_quiz_get_quizzes_by_courses",
+		map[string]interface{}{"courseids": []int{courseID}},
+		func(data []byte) error {
+			if err := mapResponseBodyToStruct(data, &res); err != nil {
+				return err
+			}
+			q.quizzes = mapToQuizList(res.Quizzes)
+			return nil
+		},
+	)
+	return q
+}
+
+// GradesTableBatchCall is the handle of a call returning grade tables
+type GradesTableBatchCall struct {
+	call   *batchCall
+	tables []*GradeTable
+}
+
+// Result returns the grade tables or the error of the call
+func (g *GradesTableBatchCall) Result() ([]*GradeTable, error) {
+	return g.tables, g.call.err
+}
+
+// GetGradesTable queues GradeAPI.GetGradesTable
+func (b *Batch) GetGradesTable(userID int, courseID int) *GradesTableBatchCall {
+	g := &GradesTableBatchCall{}
+	res := getGradesTableResponse{}
+	decode := decodeInto(&res, func() Warnings { return res.Warnings })
+	g.call = b.add(
+		"gradereport_user_get_grades_table",
+		map[string]interface{}{"userid": userID, "courseid": courseID},
+		func(data []byte) error {
+			if err := decode(data); err != nil {
+				return err
+			}
+			tables, err := mapGetGradesTableResponse(&res)
+			g.tables = tables
+			return err
+		},
+	)
+	return g
+}
+
+// CourseGradesBatchCall is the handle of a call returning course grades
+type CourseGradesBatchCall struct {
+	call   *batchCall
+	grades []*CourseGrade
+}
+
+// Result returns the course grades or the error of the call
+func (c *CourseGradesBatchCall) Result() ([]*CourseGrade, error) {
+	return c.grades, c.call.err
+}
+
+// GetCourseGrades queues GradeAPI.GetCourseGrades
+func (b *Batch) GetCourseGrades(userID int) *CourseGradesBatchCall {
+	c := &CourseGradesBatchCall{}
+	res := getCourseGradesResponse{}
+	args := map[string]interface{}{}
+	if userID != 0 {
+		args["userid"] = userID
+	}
+	decode := decodeInto(&res, func() Warnings { return res.Warnings })
+	c.call = b.add(
+		"gradereport_overview_get_course_grades",
+		args,
+		func(data []byte) error {
+			if err := decode(data); err != nil {
+				return err
+			}
+			grades, err := mapToCourseGradeList(res.Grades)
+			c.grades = grades
+			return err
+		},
+	)
+	return c
+}
@@ -0,0 +1,100 @@
+package moodle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestBatch_Do(t *testing.T) {
+	t.Parallel()
+
+	var gotQuery url.Values
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		fmt.Fprintln(w, `{"responses":[
+{"error":false,"data":"{\"courses\":[{\"id\":1111,\"fullname\":\"MATH 1111\",\"shortname\":\"MATH 1111\"}],\"nextoffset\":1}"},
+{"error":false,"data":"{\"quizzes\":[{\"id\":1,\"course\":1111,\"name\":\"Quiz 1\"}]}"},
+{"error":false,"data":"{\"grades\":[{\"courseid\":1111,\"grade\":\"80.00\",\"rawgrade\":\"80.00000\"}],\"warnings\":[{\"item\":\"user\",\"itemid\":2,\"warningcode\":\"1\",\"message\":\"test warning\"}]}"},
+{"error":true,"exception":"{\"exception\":\"required_capability_exception\",\"errorcode\":\"nopermissions\",\"message\":\"Sorry, but you do not currently have permissions to do that\"}"}
+]}`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test")
+
+	batch := c.NewBatch()
+	coursesCall := batch.GetEnrolledCoursesByTimelineClassification(CourseClassificationInProgress)
+	quizzesCall := batch.GetQuizzesByCourse(1111)
+	courseGradesCall := batch.GetCourseGrades(0)
+	gradesTableCall := batch.GetGradesTable(0, 1111)
+	rawCall := batch.Call("core_course_get_contents", map[string]interface{}{"courseid": 1111}, nil)
+
+	if _, err := coursesCall.Result(); !errors.Is(err, ErrBatchCallNotExecuted) {
+		t.Errorf("Result() before Do() error = %v, want %v", err, ErrBatchCallNotExecuted)
+	}
+	if err := batch.Do(context.Background()); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if gotQuery.Get("wsfunction") != "tool_mobile_call_external_functions" ||
+		gotQuery.Get("requests[1][function]") != "mod_quiz_get_quizzes_by_courses" ||
+		gotQuery.Get("requests[1][arguments]") != `{"courseids":[1111]}` ||
+		gotQuery.Get("requests[4][arguments]") != `{"courseid":1111}` {
+		t.Errorf("Do() query = %v", gotQuery)
+	}
+
+	courses, err := coursesCall.Result()
+	if err != nil {
+		t.Errorf("courses Result() error = %v", err)
+	}
+	if len(courses) != 1 || courses[0].ID != 1111 {
+		t.Errorf("courses Result() = %v", courses)
+	}
+	quizzes, err := quizzesCall.Result()
+	if err != nil {
+		t.Errorf("quizzes Result() error = %v", err)
+	}
+	if len(quizzes) != 1 || quizzes[0].Name != "Quiz 1" {
+		t.Errorf("quizzes Result() = %v", quizzes)
+	}
+	if _, err := courseGradesCall.Result(); !errors.As(err, &Warnings{}) {
+		t.Errorf("course grades Result() error = %v, want warnings", err)
+	}
+	if _, err := gradesTableCall.Result(); Code(err) != "nopermissions" {
+		t.Errorf("grades table Result() error = %v, want nopermissions", err)
+	}
+	if err := rawCall.Err(); !errors.Is(err, ErrBatchCallNotExecuted) {
+		t.Errorf("raw call Err() = %v, want %v", err, ErrBatchCallNotExecuted)
+	}
+
+	if err := batch.Do(context.Background()); err == nil {
+		t.Errorf("second Do() error = nil")
+	}
+}
+
+func TestBatch_Do_ErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"exception":"dml_missing_record_exception","errorcode":"invalidrecord","message":"Can't find data record in database table external_functions."}`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test")
+
+	batch := c.NewBatch()
+	for i := 0; i < 3; i++ {
+		batch.Call("core_course_get_contents", map[string]interface{}{"courseid": strconv.Itoa(i)}, nil)
+	}
+	if err := batch.Do(context.Background()); Code(err) != "invalidrecord" {
+		t.Errorf("Do() error = %v, want invalidrecord", err)
+	}
+}
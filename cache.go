@@ -0,0 +1,362 @@
+package moodle
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTLs are the TTLs of the functions returning data which rarely changes
+var DefaultCacheTTLs = map[string]time.Duration{
+	"core_webservice_get_site_info":                               time.Hour,
+	"core_enrol_get_users_courses":                                10 * time.Minute,
+	"core_course_get_enrolled_courses_by_timeline_classification": 10 * time.Minute,
+	"core_course_get_courses_by_field":                            10 * time.Minute,
+	"core_course_get_contents":                                    10 * time.Minute,
+	"mod_quiz_get_quizzes_by_courses":                             10 * time.Minute,
+}
+
+// DefaultCacheInvalidations are the cached functions invalidated by the mutating functions
+var DefaultCacheInvalidations = map[string][]string{
+	"mod_quiz_start_attempt": {
+		"mod_quiz_get_user_attempts",
+		"mod_quiz_get_attempt_access_information",
+	},
+	"mod_quiz_process_attempt": {
+		"mod_quiz_get_user_attempts",
+		"mod_quiz_get_attempt_access_information",
+		"mod_quiz_get_attempt_review",
+		"gradereport_user_get_grade_items",
+		"gradereport_user_get_grades_table",
+		"gradereport_overview_get_course_grades",
+	},
+	"enrol_self_enrol_user": {
+		"core_enrol_get_users_courses",
+		"core_course_get_enrolled_courses_by_timeline_classification",
+	},
+	"core_completion_update_activity_completion_status_manually": {
+		"core_completion_get_activities_completion_status",
+		"core_completion_get_course_completion_status",
+	},
+	"core_completion_mark_course_self_completed": {
+		"core_completion_get_course_completion_status",
+	},
+	"core_course_create_categories": {
+		"core_course_get_categories",
+	},
+	"core_course_update_categories": {
+		"core_course_get_categories",
+		"core_course_get_courses_by_field",
+	},
+	"core_course_create_courses": {
+		"core_course_get_courses_by_field",
+		"core_course_get_categories",
+	},
+	"core_course_update_courses": {
+		"core_course_get_courses_by_field",
+		"core_enrol_get_users_courses",
+		"core_course_get_enrolled_courses_by_timeline_classification",
+	},
+	"core_course_delete_courses": {
+		"core_course_get_courses_by_field",
+		"core_course_get_categories",
+		"core_course_get_contents",
+		"core_enrol_get_users_courses",
+		"core_course_get_enrolled_courses_by_timeline_classification",
+	},
+	"core_course_duplicate_course": {
+		"core_course_get_courses_by_field",
+		"core_course_get_categories",
+		"core_enrol_get_users_courses",
+		"core_course_get_enrolled_courses_by_timeline_classification",
+	},
+	"core_course_import_course": {
+		"core_course_get_contents",
+	},
+	"enrol_manual_enrol_users": {
+		"core_enrol_get_users_courses",
+		"core_enrol_get_enrolled_users",
+		"core_course_get_enrolled_courses_by_timeline_classification",
+	},
+	"enrol_manual_unenrol_users": {
+		"core_enrol_get_users_courses",
+		"core_enrol_get_enrolled_users",
+		"core_course_get_enrolled_courses_by_timeline_classification",
+		"core_group_get_course_user_groups",
+	},
+	"core_group_create_groups": {
+		"core_group_get_course_groups",
+		"core_group_get_activity_allowed_groups",
+	},
+	"core_group_add_group_members": {
+		"core_group_get_course_user_groups",
+		"core_group_get_activity_allowed_groups",
+		"core_enrol_get_enrolled_users",
+	},
+	"core_group_delete_group_members": {
+		"core_group_get_course_user_groups",
+		"core_group_get_activity_allowed_groups",
+		"core_enrol_get_enrolled_users",
+	},
+}
+
+// CacheEntry is a cached response
+type CacheEntry struct {
+	Response  *CallResponse
+	StoredAt  time.Time
+	ExpiresAt time.Time
+}
+
+// CacheBackend stores the cached responses.
+// The keys start with the hash of the token followed by the wsfunction,
+// so that the entries can be deleted per token or wsfunction with DeletePrefix.
+type CacheBackend interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	DeletePrefix(prefix string)
+}
+
+// CacheOptions represents options of Cache
+type CacheOptions struct {
+	// Backend stores the responses, an in-memory LRU of 1000 entries is used if it's nil
+	Backend CacheBackend
+	// TTLs are the TTLs per wsfunction, DefaultCacheTTLs is used if it's nil.
+	// The functions not in TTLs are not cached.
+	TTLs map[string]time.Duration
+	// Invalidations are the cached functions invalidated after the calls of the mutating functions,
+	// DefaultCacheInvalidations is used if it's nil
+	Invalidations map[string][]string
+	// StaleWhileRevalidate is the duration for which an expired entry is still returned
+	// while it's refreshed in the background
+	StaleWhileRevalidate time.Duration
+	// Now returns the current time, time.Now is used if it's nil
+	Now func() time.Time
+}
+
+// Cache caches the responses of the functions per token.
+// The responses are never shared between different tokens, and only successful responses are cached.
+type Cache struct {
+	backend              CacheBackend
+	ttls                 map[string]time.Duration
+	invalidations        map[string][]string
+	staleWhileRevalidate time.Duration
+	now                  func() time.Time
+
+	refreshingMu sync.Mutex
+	refreshing   map[string]bool
+}
+
+// NewCache creates a cache to be set to the client with WithCache
+func NewCache(opts CacheOptions) *Cache {
+	c := &Cache{
+		backend:              opts.Backend,
+		ttls:                 opts.TTLs,
+		invalidations:        opts.Invalidations,
+		staleWhileRevalidate: opts.StaleWhileRevalidate,
+		now:                  opts.Now,
+		refreshing:           map[string]bool{},
+	}
+	if c.backend == nil {
+		c.backend = NewLRUCacheBackend(1000)
+	}
+	if c.ttls == nil {
+		c.ttls = DefaultCacheTTLs
+	}
+	if c.invalidations == nil {
+		c.invalidations = DefaultCacheInvalidations
+	}
+	if c.now == nil {
+		c.now = time.Now
+	}
+	return c
+}
+
+type cacheRefreshKey struct{}
+
+// ContextWithCacheRefresh returns a context to bypass the cached response and refresh it with the response
+func ContextWithCacheRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheRefreshKey{}, true)
+}
+
+func isCacheRefresh(ctx context.Context) bool {
+	refresh, _ := ctx.Value(cacheRefreshKey{}).(bool)
+	return refresh
+}
+
+// Invalidate deletes the cached responses of the functions for the token, or all the responses for the token if no function is given
+func (c *Cache) Invalidate(token string, functions ...string) {
+	if len(functions) == 0 {
+		c.backend.DeletePrefix(cacheTokenPrefix(token))
+		return
+	}
+	for _, function := range functions {
+		c.backend.DeletePrefix(cacheFunctionPrefix(token, function))
+	}
+}
+
+// Middleware returns the middleware to serve the cached responses
+func (c *Cache) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *CallRequest) (*CallResponse, error) {
+			token := req.hiddenParams.Get("wstoken")
+			ttl, cacheable := c.ttls[req.Function]
+			if token == "" || req.Function == "" || !cacheable {
+				res, err := next(ctx, req)
+				if err == nil && isSuccessfulResponse(res) {
+					if functions, ok := c.invalidations[req.Function]; ok {
+						c.Invalidate(token, functions...)
+					}
+				}
+				return res, err
+			}
+
+			key := cacheFunctionPrefix(token, req.Function) + req.Params.Encode()
+			if !isCacheRefresh(ctx) {
+				if entry, ok := c.backend.Get(key); ok {
+					now := c.now()
+					if now.Before(entry.ExpiresAt) {
+						return copyResponse(entry.Response), nil
+					}
+					if now.Before(entry.ExpiresAt.Add(c.staleWhileRevalidate)) {
+						c.refreshInBackground(ctx, next, req, key, ttl)
+						return copyResponse(entry.Response), nil
+					}
+				}
+			}
+			return c.fetch(ctx, next, req, key, ttl)
+		}
+	}
+}
+
+func (c *Cache) fetch(ctx context.Context, next Handler, req *CallRequest, key string, ttl time.Duration) (*CallResponse, error) {
+	res, err := next(ctx, req)
+	if err != nil || !isSuccessfulResponse(res) {
+		return res, err
+	}
+	now := c.now()
+	c.backend.Set(key, &CacheEntry{Response: copyResponse(res), StoredAt: now, ExpiresAt: now.Add(ttl)})
+	return res, nil
+}
+
+// copyResponse returns a deep copy of the response, so that the callers modifying the response don't break the cache
+func copyResponse(res *CallResponse) *CallResponse {
+	copied := *res
+	copied.Header = res.Header.Clone()
+	copied.Body = append([]byte(nil), res.Body...)
+	return &copied
+}
+
+// refreshInBackground refreshes the entry unless it's being refreshed already
+func (c *Cache) refreshInBackground(ctx context.Context, next Handler, req *CallRequest, key string, ttl time.Duration) {
+	c.refreshingMu.Lock()
+	if c.refreshing[key] {
+		c.refreshingMu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.refreshingMu.Unlock()
+
+	// the request is copied since the caller's request may be modified after returning
+	reqCopy := *req
+	reqCopy.Header = req.Header.Clone()
+	go func() {
+		defer func() {
+			c.refreshingMu.Lock()
+			delete(c.refreshing, key)
+			c.refreshingMu.Unlock()
+		}()
+		// the refresh shouldn't be canceled when the caller's request is done
+		_, _ = c.fetch(detachedContext{ctx}, next, &reqCopy, key, ttl)
+	}()
+}
+
+func isSuccessfulResponse(res *CallResponse) bool {
+	return res.StatusCode == http.StatusOK && parseAPIError(res.Body) == nil
+}
+
+// cacheTokenPrefix returns the prefix of the keys for the token, the token is hashed not to be stored in the backend
+func cacheTokenPrefix(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:]) + "\n"
+}
+
+func cacheFunctionPrefix(token, function string) string {
+	return cacheTokenPrefix(token) + function + "\n"
+}
+
+// detachedContext keeps the values of the parent without its deadline and cancellation
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
+
+// lruCacheBackend is an in-memory CacheBackend evicting the least recently used entries
+type lruCacheBackend struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type lruItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewLRUCacheBackend creates an in-memory CacheBackend holding up to size entries
+func NewLRUCacheBackend(size int) CacheBackend {
+	return &lruCacheBackend{
+		size:    size,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+func (l *lruCacheBackend) Get(key string) (*CacheEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	elem, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	l.order.MoveToFront(elem)
+	return elem.Value.(*lruItem).entry, true
+}
+
+func (l *lruCacheBackend) Set(key string, entry *CacheEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if elem, ok := l.entries[key]; ok {
+		elem.Value.(*lruItem).entry = entry
+		l.order.MoveToFront(elem)
+		return
+	}
+	l.entries[key] = l.order.PushFront(&lruItem{key: key, entry: entry})
+	for l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruItem).key)
+	}
+}
+
+func (l *lruCacheBackend) DeletePrefix(prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, elem := range l.entries {
+		if strings.HasPrefix(key, prefix) {
+			l.order.Remove(elem)
+			delete(l.entries, key)
+		}
+	}
+}
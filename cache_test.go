@@ -0,0 +1,310 @@
+package moodle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Add(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// countingServer counts the calls per token and wsfunction
+type countingServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	calls  map[string]int
+	status int
+}
+
+func newCountingServer() *countingServer {
+	s := &countingServer{calls: map[string]int{}, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		s.mu.Lock()
+		s.calls[query.Get("wstoken")+":"+query.Get("wsfunction")]++
+		count := s.calls[query.Get("wstoken")+":"+query.Get("wsfunction")]
+		status := s.status
+		s.mu.Unlock()
+		w.WriteHeader(status)
+		switch query.Get("wsfunction") {
+		case "core_webservice_get_site_info":
+			fmt.Fprintf(w, `{"sitename":"site %d","username":"%s"}`, count, query.Get("wstoken"))
+		case "mod_quiz_get_user_attempts":
+			fmt.Fprintln(w, `{"attempts":[]}`)
+		case "mod_quiz_start_attempt":
+			fmt.Fprintln(w, `{"attempt":{"id":1}}`)
+		default:
+			fmt.Fprintln(w, `{"exception":"moodle_exception","errorcode":"invalidrecord","message":"not found"}`)
+		}
+	}))
+	return s
+}
+
+func (s *countingServer) count(token, function string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[token+":"+function]
+}
+
+func (s *countingServer) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func newCachedClient(t *testing.T, s *countingServer, token string, cache *Cache) *Client {
+	t.Helper()
+	serviceURL, _ := url.Parse(s.URL)
+	client, err := NewClient(context.Background(), serviceURL, token, WithCache(cache))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	s := newCountingServer()
+	defer s.Close()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cache := NewCache(CacheOptions{Now: clock.Now})
+	client := newCachedClient(t, s, "token1", cache)
+	otherClient := newCachedClient(t, s, "token2", cache)
+	ctx := context.Background()
+
+	siteName := func(client *Client, ctx context.Context) string {
+		siteInfo, err := client.SiteAPI.GetSiteInfo(ctx)
+		if err != nil {
+			t.Fatalf("GetSiteInfo() error = %v", err)
+		}
+		return siteInfo.SiteName
+	}
+
+	if got := siteName(client, ctx); got != "site 1" {
+		t.Errorf("GetSiteInfo().SiteName = %v, want = site 1", got)
+	}
+	if got := siteName(client, ctx); got != "site 1" {
+		t.Errorf("GetSiteInfo().SiteName of cached = %v, want = site 1", got)
+	}
+	if got := s.count("token1", "core_webservice_get_site_info"); got != 1 {
+		t.Errorf("calls = %v, want = 1", got)
+	}
+
+	// the cache is not shared between tokens
+	if got := siteName(otherClient, ctx); got != "site 1" {
+		t.Errorf("GetSiteInfo().SiteName with other token = %v, want = site 1", got)
+	}
+	if got := s.count("token2", "core_webservice_get_site_info"); got != 1 {
+		t.Errorf("calls with other token = %v, want = 1", got)
+	}
+
+	// refreshed explicitly
+	if got := siteName(client, ContextWithCacheRefresh(ctx)); got != "site 2" {
+		t.Errorf("GetSiteInfo().SiteName with refresh = %v, want = site 2", got)
+	}
+
+	// explicit invalidation
+	cache.Invalidate("token1")
+	if got := siteName(client, ctx); got != "site 3" {
+		t.Errorf("GetSiteInfo().SiteName after invalidation = %v, want = site 3", got)
+	}
+	if got := siteName(otherClient, ctx); got != "site 1" {
+		t.Errorf("GetSiteInfo().SiteName with other token after invalidation = %v, want = site 1", got)
+	}
+
+	// expired
+	clock.Add(time.Hour)
+	if got := siteName(client, ctx); got != "site 4" {
+		t.Errorf("GetSiteInfo().SiteName after expiry = %v, want = site 4", got)
+	}
+}
+
+func TestCache_responseCopied(t *testing.T) {
+	t.Parallel()
+
+	s := newCountingServer()
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	// the middleware outside the cache breaks the responses it gets
+	breaking := func(next Handler) Handler {
+		return func(ctx context.Context, req *CallRequest) (*CallResponse, error) {
+			res, err := next(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			body := append([]byte(nil), res.Body...)
+			for i := range res.Body {
+				res.Body[i] = ' '
+			}
+			res.Header.Set("X-Broken", "1")
+			return &CallResponse{StatusCode: res.StatusCode, Header: http.Header{}, Body: body}, nil
+		}
+	}
+	client, err := NewClient(context.Background(), serviceURL, "token1", WithMiddleware(breaking), WithCache(NewCache(CacheOptions{})))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		siteInfo, err := client.SiteAPI.GetSiteInfo(context.Background())
+		if err != nil {
+			t.Fatalf("GetSiteInfo() error = %v", err)
+		}
+		if siteInfo.SiteName != "site 1" {
+			t.Errorf("GetSiteInfo().SiteName of call %d = %v, want = site 1", i, siteInfo.SiteName)
+		}
+	}
+	if got := s.count("token1", "core_webservice_get_site_info"); got != 1 {
+		t.Errorf("calls = %v, want = 1", got)
+	}
+}
+
+func TestCache_invalidation(t *testing.T) {
+	t.Parallel()
+
+	s := newCountingServer()
+	defer s.Close()
+	cache := NewCache(CacheOptions{TTLs: map[string]time.Duration{"mod_quiz_get_user_attempts": time.Hour}})
+	client := newCachedClient(t, s, "token", cache)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.QuizAPI.GetUserAttempts(ctx, 1); err != nil {
+			t.Fatalf("GetUserAttempts() error = %v", err)
+		}
+	}
+	if _, err := client.QuizAPI.StartAttempt(ctx, 1); err != nil {
+		t.Fatalf("StartAttempt() error = %v", err)
+	}
+	if _, err := client.QuizAPI.GetUserAttempts(ctx, 1); err != nil {
+		t.Fatalf("GetUserAttempts() error = %v", err)
+	}
+	if got := s.count("token", "mod_quiz_get_user_attempts"); got != 2 {
+		t.Errorf("calls = %v, want = 2", got)
+	}
+}
+
+func TestCache_errorsNotCached(t *testing.T) {
+	t.Parallel()
+
+	s := newCountingServer()
+	defer s.Close()
+	cache := NewCache(CacheOptions{TTLs: map[string]time.Duration{
+		"core_webservice_get_site_info": time.Hour,
+		"core_course_get_contents":      time.Hour,
+	}})
+	client := newCachedClient(t, s, "token", cache)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.CourseAPI.GetContents(ctx, 1); err == nil {
+			t.Fatalf("GetContents() error = nil, want APIError")
+		}
+	}
+	if got := s.count("token", "core_course_get_contents"); got != 2 {
+		t.Errorf("calls with moodle exception = %v, want = 2", got)
+	}
+
+	s.setStatus(http.StatusServiceUnavailable)
+	_, _ = client.SiteAPI.GetSiteInfo(ctx)
+	s.setStatus(http.StatusOK)
+	_, _ = client.SiteAPI.GetSiteInfo(ctx)
+	if got := s.count("token", "core_webservice_get_site_info"); got != 2 {
+		t.Errorf("calls with 503 = %v, want = 2", got)
+	}
+}
+
+func TestCache_staleWhileRevalidate(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var mu sync.Mutex
+	calls := 0
+	refreshed := make(chan struct{}, 1)
+	next := func(ctx context.Context, req *CallRequest) (*CallResponse, error) {
+		mu.Lock()
+		calls++
+		call := calls
+		mu.Unlock()
+		body := fmt.Sprintf(`{"call":%d}`, call)
+		if call > 1 {
+			defer func() { refreshed <- struct{}{} }()
+		}
+		return &CallResponse{StatusCode: http.StatusOK, Body: []byte(body)}, nil
+	}
+	cache := NewCache(CacheOptions{
+		TTLs:                 map[string]time.Duration{"core_course_get_contents": time.Minute},
+		StaleWhileRevalidate: time.Minute,
+		Now:                  clock.Now,
+	})
+	handler := cache.Middleware()(next)
+	newRequest := func() *CallRequest {
+		return &CallRequest{
+			Function:     "core_course_get_contents",
+			Params:       url.Values{"courseid": {"1"}},
+			Header:       http.Header{},
+			hiddenParams: url.Values{"wstoken": {"token"}},
+		}
+	}
+	body := func() string {
+		res, err := handler(context.Background(), newRequest())
+		if err != nil {
+			t.Fatalf("handler() error = %v", err)
+		}
+		return string(res.Body)
+	}
+
+	var got []string
+	got = append(got, body())
+	clock.Add(90 * time.Second)
+	// the stale entry is returned while it's refreshed
+	got = append(got, body())
+	<-refreshed
+	got = append(got, body())
+	if diff := cmp.Diff(got, []string{`{"call":1}`, `{"call":1}`, `{"call":2}`}); diff != "" {
+		t.Errorf("bodies (-got, +want)\n%s", diff)
+	}
+}
+
+func TestLRUCacheBackend(t *testing.T) {
+	t.Parallel()
+
+	backend := NewLRUCacheBackend(2)
+	backend.Set("a\nx", &CacheEntry{})
+	backend.Set("b\nx", &CacheEntry{})
+	backend.Get("a\nx")
+	backend.Set("a\ny", &CacheEntry{})
+
+	if _, ok := backend.Get("b\nx"); ok {
+		t.Errorf("Get(b) ok = true, want evicted")
+	}
+	backend.DeletePrefix("a\n")
+	for _, key := range []string{"a\nx", "a\ny"} {
+		if _, ok := backend.Get(key); ok {
+			t.Errorf("Get(%q) ok = true, want deleted", key)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package moodle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Call calls the web service function with the params and decodes the response into out,
+// so that functions not wrapped by the APIs can be used with the same auth and error handling.
+//
+// params is one of nil, map[string]string, url.Values (or map[string][]string) and map[string]interface{}.
+// The keys with multiple values in url.Values are flattened into moodle's array params same as slices.
+// Nested maps and slices in map[string]interface{} are flattened into moodle's array params
+// (e.g. {"courseids": []int{1, 2}} to courseids[0]=1&courseids[1]=2), and bool is sent as 1 or 0.
+//
+// The params are sent in the form body of a POST request, which moodle accepts for all the functions,
+// so that the secrets and large params are not put in the url.
+//
+// An error response is returned as *APIError. If the response has non-empty warnings,
+// out is still decoded and the warnings are returned as Warnings.
+// out can be nil to discard the response.
+func (c *Client) Call(ctx context.Context, wsfunction string, params interface{}, out interface{}) error {
+	raw, err := c.CallRaw(ctx, wsfunction, params)
+	if raw == nil {
+		return err
+	}
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return fmt.Errorf("%v, body: %s", err, raw)
+		}
+	}
+	return err
+}
+
+// CallRaw calls the web service function with the params same as Call, and returns the response json as it is.
+// The response is returned along with Warnings if it has non-empty warnings.
+func (c *Client) CallRaw(ctx context.Context, wsfunction string, params interface{}) (json.RawMessage, error) {
+	queryParams, err := mapToQueryParams(params)
+	if err != nil {
+		return nil, err
+	}
+	queryParams["wsfunction"] = wsfunction
+
+	var raw json.RawMessage
+	if err := c.apiClient.postMoodleFunction(ctx, &raw, queryParams); err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Warnings Warnings `json:"warnings"`
+	}{}
+	// the response may not be an object
+	if err := json.Unmarshal(raw, &res); err == nil && len(res.Warnings) > 0 {
+		return raw, res.Warnings
+	}
+	return raw, nil
+}
+
+func mapToQueryParams(params interface{}) (map[string]string, error) {
+	queryParams := make(map[string]string)
+	switch p := params.(type) {
+	case nil:
+	case map[string]string:
+		for k, v := range p {
+			queryParams[k] = v
+		}
+	case url.Values:
+		flattenValues(queryParams, p)
+	case map[string][]string:
+		flattenValues(queryParams, p)
+	case map[string]interface{}:
+		for k, v := range p {
+			if err := flattenParam(queryParams, k, reflect.ValueOf(v)); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported params type %T", params)
+	}
+	return queryParams, nil
+}
+
+// flattenValues sets the values to params, the key with multiple values or the key like "ids[]" is flattened
+// into moodle's array params (e.g. ids[0]=1&ids[1]=2)
+func flattenValues(params map[string]string, values map[string][]string) {
+	for k, vs := range values {
+		key := strings.TrimSuffix(k, "[]")
+		if len(vs) == 1 && key == k {
+			params[k] = vs[0]
+			continue
+		}
+		for i, v := range vs {
+			params[fmt.Sprintf("%s[%d]", key, i)] = v
+		}
+	}
+}
+
+// flattenParam sets the value to params with the key, nested values are set with keys like key[0][name]
+func flattenParam(params map[string]string, key string, v reflect.Value) error {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return flattenParam(params, key, v.Elem())
+	case reflect.String:
+		params[key] = v.String()
+	case reflect.Bool:
+		params[key] = mapBoolToBitStr(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		params[key] = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		params[key] = strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		params[key] = strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := flattenParam(params, fmt.Sprintf("%s[%d]", key, i), v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s of param %s", v.Type().Key(), key)
+		}
+		for _, k := range v.MapKeys() {
+			if err := flattenParam(params, fmt.Sprintf("%s[%s]", key, k.String()), v.MapIndex(k)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %s of param %s", v.Type(), key)
+	}
+	return nil
+}
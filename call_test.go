@@ -0,0 +1,162 @@
+package moodle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClient_Call(t *testing.T) {
+	t.Parallel()
+
+	type out struct {
+		Courses []struct {
+			ID int `json:"id"`
+		} `json:"courses"`
+	}
+	tests := []struct {
+		name        string
+		params      interface{}
+		response    string
+		wantQuery   map[string]string
+		want        *out
+		wantErrCode string
+		wantErr     bool
+	}{
+		{
+			name:      "Successful response with map[string]string",
+			params:    map[string]string{"field": "id", "value": "1111"},
+			response:  `{"courses":[{"id":1111}],"warnings":[]}`,
+			wantQuery: map[string]string{"wsfunction": "core_course_get_courses_by_field", "field": "id", "value": "1111"},
+			want: &out{Courses: []struct {
+				ID int `json:"id"`
+			}{{ID: 1111}}},
+		},
+		{
+			name: "Nested params",
+			params: map[string]interface{}{
+				"options": []map[string]interface{}{{"name": "onlyactive", "value": true}},
+				"ids":     []int{1, 2},
+				"limit":   10,
+			},
+			response: `{"courses":[],"warnings":[]}`,
+			wantQuery: map[string]string{
+				"options[0][name]":  "onlyactive",
+				"options[0][value]": "1",
+				"ids[0]":            "1",
+				"ids[1]":            "2",
+				"limit":             "10",
+			},
+			want: &out{Courses: []struct {
+				ID int `json:"id"`
+			}{}},
+		},
+		{
+			name:     "url.Values with multiple values",
+			params:   url.Values{"courseids": {"1", "2"}, "field": {"id"}, "ids[]": {"3"}},
+			response: `{"courses":[],"warnings":[]}`,
+			wantQuery: map[string]string{
+				"courseids[0]": "1",
+				"courseids[1]": "2",
+				"field":        "id",
+				"ids[0]":       "3",
+			},
+			want: &out{Courses: []struct {
+				ID int `json:"id"`
+			}{}},
+		},
+		{
+			name:      "map[string][]string",
+			params:    map[string][]string{"courseids": {"1", "2"}},
+			response:  `{"courses":[],"warnings":[]}`,
+			wantQuery: map[string]string{"courseids[0]": "1", "courseids[1]": "2"},
+			want: &out{Courses: []struct {
+				ID int `json:"id"`
+			}{}},
+		},
+		{
+			name:     "Warning response",
+			params:   url.Values{"field": {"id"}},
+			response: `{"courses":[{"id":1111}],"warnings":[{"item":"course","itemid":2222,"warningcode":"1","message":"test warning"}]}`,
+			want: &out{Courses: []struct {
+				ID int `json:"id"`
+			}{{ID: 1111}}},
+			wantErr: true,
+		},
+		{
+			name:        "Error response",
+			response:    `{"exception":"webservice_access_exception","errorcode":"accessexception","message":"Access control exception"}`,
+			wantErrCode: "accessexception",
+			wantErr:     true,
+		},
+		{
+			name:     "Unsupported params",
+			params:   []string{"id"},
+			response: `{}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotQuery url.Values
+			h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					t.Errorf("Call() method = %s, want %s", r.Method, http.MethodPost)
+				}
+				_ = r.ParseForm()
+				gotQuery = r.Form
+				fmt.Fprintln(w, tt.response)
+			})
+			s := httptest.NewServer(h)
+			defer s.Close()
+			serviceURL, _ := url.Parse(s.URL)
+			c, _ := NewClient(context.Background(), serviceURL, "test")
+
+			var got *out
+			err := c.Call(context.Background(), "core_course_get_courses_by_field", tt.params, &got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Call() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrCode != "" && Code(err) != tt.wantErrCode {
+				t.Errorf("Call() error code = %s, want %s", Code(err), tt.wantErrCode)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("Call() (-got, +want)\n%s", diff)
+			}
+			for k, v := range tt.wantQuery {
+				if gotQuery.Get(k) != v {
+					t.Errorf("Call() query %s = %q, want %q", k, gotQuery.Get(k), v)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_CallRaw(t *testing.T) {
+	t.Parallel()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":4,"name":"2021 Spring"}]`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test")
+
+	got, err := c.CallRaw(context.Background(), "core_course_get_categories", nil)
+	if err != nil {
+		t.Fatalf("CallRaw() error = %v", err)
+	}
+	if diff := cmp.Diff(got, json.RawMessage(`[{"id":4,"name":"2021 Spring"}]`)); diff != "" {
+		t.Errorf("CallRaw() (-got, +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,221 @@
+package moodle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const siteInfoFunction = "core_webservice_get_site_info"
+
+// ErrFunctionNotAvailable is returned when the web service function is not available for the token.
+// The returned error is *FunctionNotAvailableError, which can be checked with errors.Is.
+var ErrFunctionNotAvailable = errors.New("function is not available")
+
+// FunctionNotAvailableError represents an error of calling a web service function not available for the token
+type FunctionNotAvailableError struct {
+	Function string
+}
+
+func (f *FunctionNotAvailableError) Error() string {
+	return fmt.Sprintf("web service function %s is not available for the token", f.Function)
+}
+
+// Is makes errors.Is(err, ErrFunctionNotAvailable) true
+func (f *FunctionNotAvailableError) Is(target error) bool {
+	return target == ErrFunctionNotAvailable
+}
+
+// Capabilities represents the web service functions available for the token and the version of the site
+type Capabilities struct {
+	// Release is the human readable version, e.g. "3.11.2+ (Build: 20210806)"
+	Release string
+	// Version is the version number, e.g. "2021051702.04"
+	Version   string
+	functions map[string]string
+}
+
+// NewCapabilities creates capabilities from the site info
+func NewCapabilities(siteInfo *SiteInfo) *Capabilities {
+	functions := make(map[string]string, len(siteInfo.Functions))
+	for _, f := range siteInfo.Functions {
+		functions[f.Name] = f.Version
+	}
+	return &Capabilities{
+		Release:   siteInfo.Release,
+		Version:   siteInfo.Version,
+		functions: functions,
+	}
+}
+
+// HasFunction returns true if the function is available for the token
+func (c *Capabilities) HasFunction(name string) bool {
+	_, ok := c.functions[name]
+	return ok
+}
+
+// Functions returns the names of the available functions in alphabetical order
+func (c *Capabilities) Functions() []string {
+	functions := make([]string, 0, len(c.functions))
+	for name := range c.functions {
+		functions = append(functions, name)
+	}
+	sort.Strings(functions)
+	return functions
+}
+
+// Require returns *FunctionNotAvailableError for the first function not available
+func (c *Capabilities) Require(functions ...string) error {
+	for _, f := range functions {
+		if !c.HasFunction(f) {
+			return &FunctionNotAvailableError{Function: f}
+		}
+	}
+	return nil
+}
+
+// ReleaseAtLeast returns true if the release of the site is the release (e.g. "3.7") or later
+func (c *Capabilities) ReleaseAtLeast(release string) bool {
+	return CompareReleases(c.Release, release) >= 0
+}
+
+// CompareReleases compares Moodle releases (e.g. "3.9", "3.11.2+ (Build: 20210806)") by the numeric parts,
+// and returns -1 if a < b, 0 if a == b and +1 if a > b. Missing parts are treated as 0, so "3.9" equals "3.9.0".
+func CompareReleases(a, b string) int {
+	aParts, bParts := parseRelease(a), parseRelease(b)
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x = aParts[i]
+		}
+		if i < len(bParts) {
+			y = bParts[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseRelease returns the numeric parts of the leading version in the release
+func parseRelease(release string) []int {
+	fields := strings.Fields(release)
+	if len(fields) == 0 {
+		return nil
+	}
+	var parts []int
+	for _, s := range strings.Split(fields[0], ".") {
+		s = strings.TrimRightFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// capabilitiesCache holds the capabilities loaded once per client
+type capabilitiesCache struct {
+	mu           sync.Mutex
+	capabilities *Capabilities
+	// loading is the load in flight, which the concurrent callers wait for instead of loading again
+	loading *capabilitiesLoad
+}
+
+// capabilitiesLoad is a load of the capabilities, done is closed when capabilities or err is set
+type capabilitiesLoad struct {
+	done         chan struct{}
+	capabilities *Capabilities
+	err          error
+}
+
+func (c *capabilitiesCache) get() *Capabilities {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.capabilities
+}
+
+// Capabilities returns the capabilities of the token, which are loaded from the site info at the first call and cached.
+// Once they are loaded, calling a function not available fails without a request with ErrFunctionNotAvailable.
+// The concurrent calls share a single load of the site info, and the lock is not held while it's requested.
+func (c *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	cache := &c.capabilitiesCache
+	for {
+		cache.mu.Lock()
+		if cache.capabilities != nil {
+			cache.mu.Unlock()
+			return cache.capabilities, nil
+		}
+		if load := cache.loading; load != nil {
+			cache.mu.Unlock()
+			select {
+			case <-load.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			// the load canceled by the context of another caller is retried with this context
+			if load.err != nil && (errors.Is(load.err, context.Canceled) || errors.Is(load.err, context.DeadlineExceeded)) && ctx.Err() == nil {
+				continue
+			}
+			return load.capabilities, load.err
+		}
+		load := &capabilitiesLoad{done: make(chan struct{})}
+		cache.loading = load
+		cache.mu.Unlock()
+
+		siteInfo, err := c.SiteAPI.GetSiteInfo(ctx)
+		if err == nil {
+			load.capabilities = NewCapabilities(siteInfo)
+		}
+		load.err = err
+
+		cache.mu.Lock()
+		// the load is discarded if the capabilities are refreshed while loading
+		if cache.loading == load {
+			cache.loading = nil
+			if err == nil {
+				cache.capabilities = load.capabilities
+			}
+		}
+		cache.mu.Unlock()
+		close(load.done)
+		return load.capabilities, load.err
+	}
+}
+
+// RefreshCapabilities reloads the capabilities, e.g. after functions are added to the service
+func (c *Client) RefreshCapabilities(ctx context.Context) (*Capabilities, error) {
+	c.capabilitiesCache.mu.Lock()
+	c.capabilitiesCache.capabilities = nil
+	c.capabilitiesCache.loading = nil
+	c.capabilitiesCache.mu.Unlock()
+	return c.Capabilities(ctx)
+}
+
+// checkFunction is called before every function call to fail fast if the function is not available.
+// The capabilities are loaded automatically only if the function check is enabled by WithFunctionCheck.
+func (c *Client) checkFunction(ctx context.Context, wsfunction string) error {
+	if wsfunction == "" || wsfunction == siteInfoFunction {
+		return nil
+	}
+	capabilities := c.capabilitiesCache.get()
+	if capabilities == nil {
+		if !c.opts.FunctionCheck {
+			return nil
+		}
+		var err error
+		if capabilities, err = c.Capabilities(ctx); err != nil {
+			return err
+		}
+	}
+	return capabilities.Require(wsfunction)
+}
@@ -0,0 +1,185 @@
+package moodle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompareReleases(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want int
+	}{
+		{name: "Equal", a: "3.9", b: "3.9", want: 0},
+		{name: "Missing part is zero", a: "3.9", b: "3.9.0", want: 0},
+		{name: "Minor is compared numerically", a: "3.11", b: "3.9", want: 1},
+		{name: "Patch", a: "3.9.1", b: "3.9.2", want: -1},
+		{name: "Release with build", a: "3.11.2+ (Build: 20210806)", b: "3.11.2", want: 0},
+		{name: "Major", a: "4.0", b: "3.11.2", want: 1},
+		{name: "Empty release", a: "", b: "3.9", want: -1},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := CompareReleases(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareReleases(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	t.Parallel()
+
+	c := NewCapabilities(&SiteInfo{
+		Release: "3.11.2+ (Build: 20210806)",
+		Version: "2021051702.04",
+		Functions: []*SiteFunctionVersion{
+			{Name: "mod_quiz_get_quizzes_by_courses", Version: "2021051700"},
+			{Name: "core_course_get_contents", Version: "2021051700"},
+		},
+	})
+	if !c.HasFunction("core_course_get_contents") {
+		t.Errorf("HasFunction() = false, want true")
+	}
+	if c.HasFunction("core_user_create_users") {
+		t.Errorf("HasFunction() = true, want false")
+	}
+	if diff := cmp.Diff(c.Functions(), []string{"core_course_get_contents", "mod_quiz_get_quizzes_by_courses"}); diff != "" {
+		t.Errorf("Functions() (-got, +want)\n%s", diff)
+	}
+	if !c.ReleaseAtLeast("3.9") || c.ReleaseAtLeast("4.0") {
+		t.Errorf("ReleaseAtLeast() is wrong for release %s", c.Release)
+	}
+
+	err := c.Require("core_course_get_contents", "core_user_create_users")
+	if !errors.Is(err, ErrFunctionNotAvailable) {
+		t.Fatalf("Require() error = %v, want ErrFunctionNotAvailable", err)
+	}
+	var notAvailableErr *FunctionNotAvailableError
+	if !errors.As(err, &notAvailableErr) || notAvailableErr.Function != "core_user_create_users" {
+		t.Errorf("Require() error = %v, want function core_user_create_users", err)
+	}
+}
+
+func TestWithFunctionCheck(t *testing.T) {
+	t.Parallel()
+
+	var siteInfoCalls, quizCalls int32
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("wsfunction") {
+		case "core_webservice_get_site_info":
+			atomic.AddInt32(&siteInfoCalls, 1)
+			fmt.Fprintln(w, `{"release":"3.11.2","version":"2021051702","functions":[{"name":"mod_quiz_get_quizzes_by_courses","version":"2021051700"}]}`)
+		case "mod_quiz_get_quizzes_by_courses":
+			atomic.AddInt32(&quizCalls, 1)
+			fmt.Fprintln(w, `{"quizzes":[],"warnings":[]}`)
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Query().Get("wsfunction"))
+		}
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test", WithFunctionCheck())
+
+	if _, err := c.QuizAPI.GetQuizzesByCourse(context.Background(), 1111); err != nil {
+		t.Fatalf("GetQuizzesByCourse() error = %v", err)
+	}
+	_, err := c.CourseAPI.GetContents(context.Background(), 1111)
+	if !errors.Is(err, ErrFunctionNotAvailable) {
+		t.Errorf("GetContents() error = %v, want ErrFunctionNotAvailable", err)
+	}
+	if atomic.LoadInt32(&siteInfoCalls) != 1 || atomic.LoadInt32(&quizCalls) != 1 {
+		t.Errorf("site info calls = %d, quiz calls = %d, want 1 and 1", atomic.LoadInt32(&siteInfoCalls), atomic.LoadInt32(&quizCalls))
+	}
+}
+
+func TestClient_Capabilities_withoutFunctionCheck(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprintln(w, `{"exception":"webservice_access_exception","errorcode":"accessexception","message":"Access control exception"}`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test")
+
+	// Without WithFunctionCheck, the call is sent to the server as is.
+	_, err := c.CourseAPI.GetContents(context.Background(), 1111)
+	if Code(err) != "accessexception" {
+		t.Errorf("GetContents() error = %v, want accessexception", err)
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("calls = %d, want 1", atomic.LoadInt32(&calls))
+	}
+	if _, err := c.Capabilities(context.Background()); err == nil {
+		t.Errorf("Capabilities() error = nil, want error")
+	}
+}
+
+func TestClient_Capabilities_concurrent(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	release := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		fmt.Fprintln(w, `{"release":"3.11.2","version":"2021051702","functions":[]}`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test")
+
+	loaded := make(chan error)
+	go func() {
+		_, err := c.Capabilities(context.Background())
+		loaded <- err
+	}()
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the waiter gives up with its context while the site info is loaded
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Capabilities(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Capabilities() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	waited := make(chan error)
+	go func() {
+		_, err := c.Capabilities(context.Background())
+		waited <- err
+	}()
+	close(release)
+	if err := <-loaded; err != nil {
+		t.Errorf("Capabilities() error = %v", err)
+	}
+	if err := <-waited; err != nil {
+		t.Errorf("Capabilities() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("site info calls = %d, want 1", got)
+	}
+}
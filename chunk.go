@@ -0,0 +1,46 @@
+package moodle
+
+import (
+	"context"
+	"errors"
+)
+
+// callInChunks calls call with the ranges of the chunks splitting n items into batchSize.
+// When a chunk fails with *APIError, call is called for each item in the chunk to find out which items failed,
+// since moodle rolls back the whole request on an error, and fail is called with the index of each failed item.
+// Other errors like network failures are not retried per item not to multiply the failing requests, all the items in the chunk fail with the error.
+// Error is returned only when ctx is done.
+func callInChunks(ctx context.Context, n, batchSize int, call func(start, end int) error, fail func(i int, err error)) error {
+	for start := 0; start < n; start += batchSize {
+		end := start + batchSize
+		if end > n {
+			end = n
+		}
+
+		err := call(start, end)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			continue
+		}
+		var apiErr *APIError
+		if end-start == 1 || !errors.As(err, &apiErr) {
+			for i := start; i < end; i++ {
+				fail(i, err)
+			}
+			continue
+		}
+
+		for i := start; i < end; i++ {
+			err := call(i, i+1)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				fail(i, err)
+			}
+		}
+	}
+	return nil
+}
@@ -10,12 +10,17 @@ type Client struct {
 	opts      *ClientOptions
 	apiClient *apiClient
 
-	AuthAPI   AuthAPI
-	SiteAPI   SiteAPI
-	UserAPI   UserAPI
-	CourseAPI CourseAPI
-	QuizAPI   QuizAPI
-	GradeAPI  GradeAPI
+	capabilitiesCache capabilitiesCache
+
+	AuthAPI       AuthAPI
+	SiteAPI       SiteAPI
+	UserAPI       UserAPI
+	CourseAPI     CourseAPI
+	QuizAPI       QuizAPI
+	GradeAPI      GradeAPI
+	EnrolAPI      EnrolAPI
+	GroupAPI      GroupAPI
+	CompletionAPI CompletionAPI
 }
 
 // NewClient creates a new Moodle client.
@@ -41,18 +46,60 @@ func newClient(serviceURL *url.URL, opt ...ClientOption) *Client {
 	for _, o := range opt {
 		o.apply(opts)
 	}
-	apiClient := newAPIClient(opts.HttpClient, serviceURL, opts.AuthToken, opts.Debug)
+	// the logging middleware is the innermost to log each request sent, e.g. retries
+	middlewares := opts.Middlewares
+	logger, logBodyLimit := opts.Logger, opts.LogBodyLimit
+	if logger == nil && opts.Debug {
+		logger, logBodyLimit = stdLogger{}, -1
+	}
+	if logger != nil {
+		middlewares = append(middlewares[:len(middlewares):len(middlewares)], loggingMiddleware(logger, logBodyLimit))
+	}
+	httpClient := wrapHTTPClient(opts.HttpClient, opts.TransportMiddlewares)
+	apiClient := newAPIClient(httpClient, serviceURL, opts.AuthToken, middlewares)
 
-	return &Client{
-		opts:      opts,
-		apiClient: apiClient,
-		AuthAPI:   newAuthAPI(apiClient),
-		SiteAPI:   newSiteAPI(apiClient),
-		UserAPI:   newUserAPI(apiClient),
-		CourseAPI: newCourseAPI(apiClient),
-		QuizAPI:   newQuizAPI(apiClient),
-		GradeAPI:  newGradeAPI(apiClient),
+	c := &Client{
+		opts:          opts,
+		apiClient:     apiClient,
+		AuthAPI:       newAuthAPI(apiClient),
+		SiteAPI:       newSiteAPI(apiClient),
+		UserAPI:       newUserAPI(apiClient),
+		CourseAPI:     newCourseAPI(apiClient),
+		QuizAPI:       newQuizAPI(apiClient),
+		GradeAPI:      newGradeAPI(apiClient),
+		EnrolAPI:      newEnrolAPI(apiClient),
+		GroupAPI:      newGroupAPI(apiClient),
+		CompletionAPI: newCompletionAPI(apiClient),
+	}
+	if opts.AuthAPI != nil {
+		c.AuthAPI = opts.AuthAPI
+	}
+	if opts.SiteAPI != nil {
+		c.SiteAPI = opts.SiteAPI
+	}
+	if opts.UserAPI != nil {
+		c.UserAPI = opts.UserAPI
+	}
+	if opts.CourseAPI != nil {
+		c.CourseAPI = opts.CourseAPI
+	}
+	if opts.QuizAPI != nil {
+		c.QuizAPI = opts.QuizAPI
+	}
+	if opts.GradeAPI != nil {
+		c.GradeAPI = opts.GradeAPI
+	}
+	if opts.EnrolAPI != nil {
+		c.EnrolAPI = opts.EnrolAPI
+	}
+	if opts.GroupAPI != nil {
+		c.GroupAPI = opts.GroupAPI
+	}
+	if opts.CompletionAPI != nil {
+		c.CompletionAPI = opts.CompletionAPI
 	}
+	apiClient.checkFunction = c.checkFunction
+	return c
 }
 
 func (c *Client) AuthToken() string {
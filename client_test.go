@@ -44,18 +44,37 @@ func TestNewClient(t *testing.T) {
 	if got.GradeAPI == nil {
 		t.Errorf("NewClientWithLogin(), got.GradeAPI = nil")
 	}
+	if got.EnrolAPI == nil {
+		t.Errorf("NewClientWithLogin(), got.EnrolAPI = nil")
+	}
+	if got.GroupAPI == nil {
+		t.Errorf("NewClientWithLogin(), got.GroupAPI = nil")
+	}
+	if got.CompletionAPI == nil {
+		t.Errorf("NewClientWithLogin(), got.CompletionAPI = nil")
+	}
 }
 
 func TestNewClientWithLogin(t *testing.T) {
 	t.Parallel()
 
 	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the credentials are sent in the form body not to be left in the access logs
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want %s", r.Method, http.MethodPost)
+		}
+		if r.URL.Query().Get("password") != "" {
+			t.Errorf("password is sent in the url %s", r.URL)
+		}
+		if got := r.PostFormValue("password"); got != "P@ssw0rd" {
+			t.Errorf("password = %q, want %q", got, "P@ssw0rd")
+		}
 		fmt.Fprintln(w, `{"token":"test", "privatetoken": "private"}`)
 	})
 	s := httptest.NewServer(h)
 	serviceURL, _ := url.Parse(s.URL)
 
-	got, err := NewClientWithLogin(context.Background(), serviceURL, "", "")
+	got, err := NewClientWithLogin(context.Background(), serviceURL, "user", "P@ssw0rd")
 	if err != nil {
 		t.Fatalf("NewClientWithLogin() error = %v", err)
 	}
@@ -85,4 +104,45 @@ func TestNewClientWithLogin(t *testing.T) {
 	if got.GradeAPI == nil {
 		t.Errorf("NewClientWithLogin(), got.GradeAPI = nil")
 	}
+	if got.EnrolAPI == nil {
+		t.Errorf("NewClientWithLogin(), got.EnrolAPI = nil")
+	}
+	if got.GroupAPI == nil {
+		t.Errorf("NewClientWithLogin(), got.GroupAPI = nil")
+	}
+	if got.CompletionAPI == nil {
+		t.Errorf("NewClientWithLogin(), got.CompletionAPI = nil")
+	}
+}
+
+type stubSiteAPI struct{}
+
+func (stubSiteAPI) GetSiteInfo(ctx context.Context) (*SiteInfo, error) {
+	return &SiteInfo{SiteName: "stub"}, nil
+}
+
+func TestNewClient_withCustomAPI(t *testing.T) {
+	t.Parallel()
+
+	serviceURL, _ := url.Parse("https://test.edu")
+	siteAPI := stubSiteAPI{}
+	got, err := NewClient(context.Background(), serviceURL, "test", WithSiteAPI(siteAPI))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if got.SiteAPI != siteAPI {
+		t.Errorf("NewClient(), got.SiteAPI = %v, want = %v", got.SiteAPI, siteAPI)
+	}
+	if _, ok := got.CourseAPI.(*courseAPI); !ok {
+		t.Errorf("NewClient(), got.CourseAPI = %T, want = *courseAPI", got.CourseAPI)
+	}
+
+	// client level helpers use the custom implementation
+	capabilities, err := got.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Capabilities() error = %v", err)
+	}
+	if len(capabilities.Functions()) != 0 {
+		t.Errorf("Capabilities().Functions() = %v, want empty", capabilities.Functions())
+	}
 }
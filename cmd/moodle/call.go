@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/k-yomo/moodle"
+)
+
+func runCall(ctx context.Context, args []string) error {
+	fs, g := newFlagSet("call")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: moodle call <wsfunction> [key=value...]")
+	}
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := make(map[string]string)
+	for _, param := range fs.Args()[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid parameter %q, must be key=value", param)
+		}
+		params[kv[0]] = kv[1]
+	}
+
+	raw, err := client.CallRaw(ctx, fs.Arg(0), params)
+	var warnings moodle.Warnings
+	if errors.As(err, &warnings) {
+		fmt.Fprintln(os.Stderr, "Warning:", warnings)
+	} else if err != nil {
+		return err
+	}
+
+	out := &bytes.Buffer{}
+	if err := json.Indent(out, raw, "", "  "); err != nil {
+		return fmt.Errorf("invalid json response: %s", raw)
+	}
+	out.WriteString("\n")
+	_, err = out.WriteTo(stdout)
+	return err
+}
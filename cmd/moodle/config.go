@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const defaultProfileName = "default"
+
+// config represents the config file, which contains tokens and is readable only by the owner
+type config struct {
+	CurrentProfile string              `json:"current_profile"`
+	Profiles       map[string]*profile `json:"profiles"`
+}
+
+// profile represents a site and the token to access it
+type profile struct {
+	URL      string `json:"url"`
+	Username string `json:"username,omitempty"`
+	Token    string `json:"token"`
+}
+
+// defaultConfigPath returns $MOODLE_CONFIG or moodle/config.json in the user config directory
+func defaultConfigPath() string {
+	if path := os.Getenv("MOODLE_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "moodle", "config.json")
+}
+
+// loadConfig reads the config file, empty config is returned if it doesn't exist
+func loadConfig(path string) (*config, error) {
+	cfg := &config{Profiles: make(map[string]*profile)}
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]*profile)
+	}
+	return cfg, nil
+}
+
+// save writes the config file atomically with permission 0600
+func (c *config) save(path string) error {
+	if path == "" {
+		return errors.New("config path is not determined, set --config or MOODLE_CONFIG")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".config-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// profileName returns the name of the profile to use, the flag takes precedence over the current profile
+func (c *config) profileName(flagValue string) string {
+	switch {
+	case flagValue != "":
+		return flagValue
+	case c.CurrentProfile != "":
+		return c.CurrentProfile
+	default:
+		return defaultProfileName
+	}
+}
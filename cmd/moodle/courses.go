@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/k-yomo/moodle"
+)
+
+func runCoursesList(ctx context.Context, args []string) error {
+	fs, g := newFlagSet("courses list")
+	classification := fs.String("classification", string(moodle.CourseClassificationInProgress), "all, past, inprogress or future")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	courses, err := client.CourseAPI.GetEnrolledCoursesByTimelineClassification(ctx, moodle.CourseClassification(*classification))
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"id", "shortname", "fullname", "category", "progress"}}
+	for _, course := range courses {
+		progress := ""
+		if course.HasProgress {
+			progress = strconv.Itoa(course.Progress) + "%"
+		}
+		t.append(strconv.Itoa(course.ID), course.ShortName, course.FullName, course.CourseCategory, progress)
+	}
+	return writeOutput(stdout, g.output, courses, t)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/k-yomo/moodle/coursedownload"
+)
+
+func runFilesDownload(ctx context.Context, args []string) error {
+	fs, g := newFlagSet("files download")
+	courseID := fs.Int("course", 0, "ID of the course")
+	dir := fs.String("dir", ".", "directory to download the files to")
+	overwrite := fs.Bool("overwrite", false, "download files even if they are unchanged")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *courseID == 0 {
+		return errors.New("--course is required")
+	}
+	opts := []coursedownload.Option{coursedownload.WithCourseIDs(*courseID)}
+	if *overwrite {
+		opts = append(opts, coursedownload.WithOverwrite())
+	}
+	return downloadFiles(ctx, g, *dir, opts...)
+}
+
+func runFilesMirror(ctx context.Context, args []string) error {
+	fs, g := newFlagSet("files mirror")
+	courseIDs := fs.String("courses", "", "comma separated IDs of the courses, all the enrolled courses by default")
+	dir := fs.String("dir", ".", "directory to mirror the files to")
+	concurrency := fs.Int("concurrency", 4, "number of files downloaded concurrently")
+	overwrite := fs.Bool("overwrite", false, "download files even if they are unchanged")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	opts := []coursedownload.Option{coursedownload.WithConcurrency(*concurrency)}
+	if *courseIDs != "" {
+		var ids []int
+		for _, s := range strings.Split(*courseIDs, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return fmt.Errorf("invalid course ID %q", s)
+			}
+			ids = append(ids, id)
+		}
+		opts = append(opts, coursedownload.WithCourseIDs(ids...))
+	}
+	if *overwrite {
+		opts = append(opts, coursedownload.WithOverwrite())
+	}
+	return downloadFiles(ctx, g, *dir, opts...)
+}
+
+// downloadFiles downloads the files with coursedownload and writes the results
+func downloadFiles(ctx context.Context, g *globalFlags, dir string, opts ...coursedownload.Option) error {
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	type downloadedFile struct {
+		Path   string `json:"path"`
+		Size   int64  `json:"size"`
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
+	results, downloadErr := coursedownload.New(client, dir, opts...).Download(ctx)
+	files := make([]*downloadedFile, 0, len(results))
+	t := &table{header: []string{"path", "size", "status"}}
+	for _, result := range results {
+		file := &downloadedFile{Path: filepath.Join(dir, result.Path), Size: result.Size, Status: string(result.Status)}
+		if result.Err != nil {
+			file.Error = result.Err.Error()
+		}
+		files = append(files, file)
+		t.append(file.Path, strconv.FormatInt(file.Size, 10), file.Status)
+	}
+	if err := writeOutput(stdout, g.output, files, t); err != nil {
+		return err
+	}
+	return downloadErr
+}
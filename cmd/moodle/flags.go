@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/k-yomo/moodle"
+)
+
+// globalFlags holds the flags shared by the commands
+type globalFlags struct {
+	configPath string
+	profile    string
+	url        string
+	token      string
+	output     string
+}
+
+func newFlagSet(name string) (*flag.FlagSet, *globalFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	g := &globalFlags{}
+	fs.StringVar(&g.configPath, "config", defaultConfigPath(), "path to the config file ($MOODLE_CONFIG)")
+	fs.StringVar(&g.profile, "profile", os.Getenv("MOODLE_PROFILE"), "profile to use ($MOODLE_PROFILE)")
+	fs.StringVar(&g.url, "url", os.Getenv("MOODLE_URL"), "URL of the site, overrides the profile ($MOODLE_URL)")
+	fs.StringVar(&g.token, "token", os.Getenv("MOODLE_TOKEN"), "web service token, overrides the profile ($MOODLE_TOKEN)")
+	fs.StringVar(&g.output, "output", outputTable, "output format: table, json or csv")
+	return fs, g
+}
+
+func (g *globalFlags) validate() error {
+	switch g.output {
+	case outputTable, outputJSON, outputCSV:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", g.output)
+	}
+}
+
+// site returns the url and the token of the site, the url and the token flags take precedence over the profile
+func (g *globalFlags) site() (*url.URL, string, error) {
+	if err := g.validate(); err != nil {
+		return nil, "", err
+	}
+	siteURL, token := g.url, g.token
+	if siteURL == "" || token == "" {
+		cfg, err := loadConfig(g.configPath)
+		if err != nil {
+			return nil, "", err
+		}
+		name := cfg.profileName(g.profile)
+		p, ok := cfg.Profiles[name]
+		if !ok {
+			return nil, "", fmt.Errorf("profile %q is not found, run \"moodle login\" first", name)
+		}
+		if siteURL == "" {
+			siteURL = p.URL
+		}
+		if token == "" {
+			token = p.Token
+		}
+	}
+	if siteURL == "" || token == "" {
+		return nil, "", errors.New("url and token are required")
+	}
+
+	serviceURL, err := url.Parse(siteURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid url: %w", err)
+	}
+	return serviceURL, token, nil
+}
+
+func (g *globalFlags) newClient(ctx context.Context) (*moodle.Client, error) {
+	serviceURL, token, err := g.site()
+	if err != nil {
+		return nil, err
+	}
+	return moodle.NewClient(ctx, serviceURL, token)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/k-yomo/moodle"
+)
+
+func runGradesShow(ctx context.Context, args []string) error {
+	fs, g := newFlagSet("grades show")
+	courseID := fs.Int("course", 0, "ID of the course")
+	userID := fs.Int("user", 0, "ID of the user, all the users you can see if 0")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *courseID == 0 {
+		return errors.New("--course is required")
+	}
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	tables, err := client.GradeAPI.GetGradesTable(ctx, *userID, *courseID)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"user", "item", "grade", "range", "percentage", "letter", "feedback"}}
+	for _, gradeTable := range tables {
+		if gradeTable.Category != nil {
+			appendGradeTableCategory(t, gradeTable.UserFullname, gradeTable.Category, 0)
+		}
+	}
+	return writeOutput(stdout, g.output, tables, t)
+}
+
+// appendGradeTableCategory appends the items of the category indented by the depth, followed by the category total
+func appendGradeTableCategory(t *table, user string, category *moodle.GradeTableCategory, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, item := range category.Items {
+		t.append(user, indent+item.ItemName, item.GradeFormatted, item.RangeFormatted, formatPercentage(item.Percentage), item.LetterGrade, item.Feedback)
+	}
+	for _, subCategory := range category.Categories {
+		appendGradeTableCategory(t, user, subCategory, depth+1)
+	}
+	if total := category.Total; total != nil {
+		t.append(user, indent+total.ItemName, total.GradeFormatted, total.RangeFormatted, formatPercentage(total.Percentage), total.LetterGrade, total.Feedback)
+	}
+}
+
+func formatPercentage(p *float64) string {
+	if p == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*p, 'f', 2, 64) + "%"
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/k-yomo/moodle"
+	"golang.org/x/term"
+)
+
+func runLogin(ctx context.Context, args []string) error {
+	fs, g := newFlagSet("login")
+	username := fs.String("username", "", "username to log in with, the password is prompted")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin instead of prompting")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(g.configPath)
+	if err != nil {
+		return err
+	}
+	name := cfg.profileName(g.profile)
+	p := cfg.Profiles[name]
+	if p == nil {
+		p = &profile{}
+	}
+	if g.url != "" {
+		p.URL = g.url
+	}
+	if *username != "" {
+		p.Username = *username
+	}
+	if p.URL == "" {
+		return errors.New("--url is required for a new profile")
+	}
+	serviceURL, err := url.Parse(p.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	token := g.token
+	if token == "" {
+		if p.Username == "" {
+			return errors.New("--username or --token is required")
+		}
+		password, err := readPassword(*passwordStdin)
+		if err != nil {
+			return err
+		}
+		client, err := moodle.NewClient(ctx, serviceURL, "")
+		if err != nil {
+			return err
+		}
+		res, err := client.AuthAPI.Login(ctx, p.Username, password)
+		if err != nil {
+			return err
+		}
+		token = res.Token
+	}
+
+	client, err := moodle.NewClient(ctx, serviceURL, token)
+	if err != nil {
+		return err
+	}
+	siteInfo, err := client.SiteAPI.GetSiteInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("verify token: %w", err)
+	}
+
+	p.Token = token
+	p.Username = siteInfo.Username
+	cfg.Profiles[name] = p
+	if cfg.CurrentProfile == "" {
+		cfg.CurrentProfile = name
+	}
+	if err := cfg.save(g.configPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Logged in to %s as %s (profile %q)\n", siteInfo.SiteName, siteInfo.Fullname, name)
+	return nil
+}
+
+func readPassword(fromStdin bool) (string, error) {
+	if fromStdin || !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("read password: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	return string(password), nil
+}
@@ -0,0 +1,79 @@
+// Command moodle is a command-line tool to operate a Moodle site through the web service API.
+//
+// Sites are configured as profiles in the config file by the login command, so that several sites can be used
+// by switching the profile with --profile flag or MOODLE_PROFILE environment variable.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// command represents a subcommand, name consists of one or two words (e.g. "login", "courses list")
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+// stdout is the writer the commands print the results to, which is replaced in tests
+var stdout io.Writer = os.Stdout
+
+var commands = []*command{
+	{name: "login", summary: "Log in to a site and store the token in the profile", run: runLogin},
+	{name: "site info", summary: "Show the site information and the current user", run: runSiteInfo},
+	{name: "courses list", summary: "List the enrolled courses", run: runCoursesList},
+	{name: "quizzes list", summary: "List the quizzes of a course", run: runQuizzesList},
+	{name: "grades show", summary: "Show the grades table of a course", run: runGradesShow},
+	{name: "files download", summary: "Download the files of a course", run: runFilesDownload},
+	{name: "files mirror", summary: "Mirror the files of the enrolled courses", run: runFilesMirror},
+	{name: "call", summary: "Call a web service function with key=value parameters", run: runCall},
+	{name: "sync plan", summary: "Show the changes to reconcile the site with a spec", run: runSyncPlan},
+	{name: "sync apply", summary: "Apply the changes to reconcile the site with a spec", run: runSyncApply},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return errors.New("command is required")
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stdout)
+		return nil
+	}
+
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd.run(ctx, args[len(words):])
+		}
+	}
+	printUsage(os.Stderr)
+	return fmt.Errorf("unknown command %q", strings.Join(args, " "))
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: moodle <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "moodle <command> -h" for the flags of the command.`)
+}
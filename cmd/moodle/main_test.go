@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k-yomo/moodle/coursedownload"
+)
+
+// runWithOutput runs the command and returns the output, tests using it must not be parallel
+func runWithOutput(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	stdout = buf
+	defer func() { stdout = os.Stdout }()
+	err := run(context.Background(), args)
+	return buf.String(), err
+}
+
+func newTestSite(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.URL.Path == "/webservice/pluginfile.php/88/mod_resource/content/1/slides.pdf" {
+			fmt.Fprint(w, "%PDF")
+			return
+		}
+		switch r.Form.Get("wsfunction") {
+		case "core_course_get_enrolled_courses_by_timeline_classification":
+			fmt.Fprint(w, `{"courses":[{"id":1111,"fullname":"MATH 1111 Introduction to Math","shortname":"MATH 1111","coursecategory":"Current Term","progress":32,"hasprogress":true}]}`)
+		case "core_course_get_contents":
+			fmt.Fprintf(w, `[{"id":10,"name":"Week 1","section":1,"modules":[{"id":555555,"name":"Slides","modname":"resource","contents":[{"type":"file","filename":"slides.pdf","filepath":"/","filesize":4,"fileurl":"%s/webservice/pluginfile.php/88/mod_resource/content/1/slides.pdf?forcedownload=1"}]}]}]`, s.URL)
+		case "core_webservice_get_site_info":
+			fmt.Fprint(w, `{"sitename":"Test Site","username":"alice","fullname":"Alice Smith","userid":2}`)
+		default:
+			fmt.Fprint(w, `{"exception":"dml_missing_record_exception","errorcode":"invalidrecord","message":"Can't find data record in database table external_functions."}`)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestRun_CoursesList(t *testing.T) {
+	s := newTestSite(t)
+
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "table",
+			output: "table",
+			want:   "ID    SHORTNAME  FULLNAME                        CATEGORY      PROGRESS\n1111  MATH 1111  MATH 1111 Introduction to Math  Current Term  32%\n",
+		},
+		{
+			name:   "csv",
+			output: "csv",
+			want:   "id,shortname,fullname,category,progress\n1111,MATH 1111,MATH 1111 Introduction to Math,Current Term,32%\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runWithOutput(t, "courses", "list", "--url", s.URL, "--token", "test", "--output", tt.output)
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("run() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRun_FilesDownload(t *testing.T) {
+	s := newTestSite(t)
+	dir := t.TempDir()
+
+	for _, wantStatus := range []string{"downloaded", "skipped"} {
+		got, err := runWithOutput(t, "files", "download", "--url", s.URL, "--token", "test", "--course", "1111", "--dir", dir, "--output", "csv")
+		if err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+		path := filepath.Join(dir, "MATH 1111", "01 Week 1", "Slides", "slides.pdf")
+		if want := fmt.Sprintf("path,size,status\n%s,4,%s\n", path, wantStatus); got != want {
+			t.Errorf("run() = %q, want %q", got, want)
+		}
+		if data, _ := ioutil.ReadFile(path); string(data) != "%PDF" {
+			t.Errorf("downloaded file = %q, want %q", data, "%PDF")
+		}
+	}
+	// the files are recorded in the manifest as the files mirrored
+	manifest, err := coursedownload.LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+	if _, ok := manifest.Files["MATH 1111/01 Week 1/Slides/slides.pdf"]; !ok {
+		t.Errorf("manifest.Files = %v, want the downloaded file", manifest.Files)
+	}
+}
+
+func TestRun_FilesMirror(t *testing.T) {
+	s := newTestSite(t)
+	dir := t.TempDir()
+
+	for _, wantStatus := range []string{"downloaded", "skipped"} {
+		got, err := runWithOutput(t, "files", "mirror", "--url", s.URL, "--token", "test", "--dir", dir, "--output", "csv")
+		if err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+		path := filepath.Join(dir, "MATH 1111", "01 Week 1", "Slides", "slides.pdf")
+		if want := fmt.Sprintf("path,size,status\n%s,4,%s\n", path, wantStatus); got != want {
+			t.Errorf("run() = %q, want %q", got, want)
+		}
+		if data, _ := ioutil.ReadFile(path); string(data) != "%PDF" {
+			t.Errorf("downloaded file = %q, want %q", data, "%PDF")
+		}
+	}
+}
+
+func TestRun_Call(t *testing.T) {
+	s := newTestSite(t)
+
+	got, err := runWithOutput(t, "call", "--url", s.URL, "--token", "test", "core_webservice_get_site_info")
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	want := "{\n  \"sitename\": \"Test Site\",\n  \"username\": \"alice\",\n  \"fullname\": \"Alice Smith\",\n  \"userid\": 2\n}\n"
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("run() (-got, +want)\n%s", diff)
+	}
+
+	if _, err := runWithOutput(t, "call", "--url", s.URL, "--token", "test", "unknown_function"); err == nil {
+		t.Errorf("run() error = nil for unknown function")
+	}
+}
+
+func TestRun_LoginWithToken(t *testing.T) {
+	s := newTestSite(t)
+	configPath := filepath.Join(t.TempDir(), "moodle", "config.json")
+
+	if _, err := runWithOutput(t, "login", "--config", configPath, "--profile", "school", "--url", s.URL, "--token", "test"); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	info, err := os.Stat(configPath)
+	if err != nil {
+		t.Fatalf("config is not saved: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("config permission = %o, want %o", info.Mode().Perm(), 0600)
+	}
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	want := &config{
+		CurrentProfile: "school",
+		Profiles:       map[string]*profile{"school": {URL: s.URL, Username: "alice", Token: "test"}},
+	}
+	if diff := cmp.Diff(cfg, want); diff != "" {
+		t.Errorf("loadConfig() (-got, +want)\n%s", diff)
+	}
+
+	// the profile is used without url and token flags
+	os.Unsetenv("MOODLE_URL")
+	os.Unsetenv("MOODLE_TOKEN")
+	got, err := runWithOutput(t, "site", "info", "--config", configPath, "--output", "csv")
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !bytes.Contains([]byte(got), []byte("site,Test Site\n")) {
+		t.Errorf("run() = %q, want site info", got)
+	}
+}
+
+func TestRun_SyncPlanAutoApprove(t *testing.T) {
+	// plan never applies the changes, so --auto-approve is rejected
+	_, err := runWithOutput(t, "sync", "plan", "--auto-approve", "-f", "spec.yaml")
+	if err == nil || !strings.Contains(err.Error(), "auto-approve") {
+		t.Errorf("run() error = %v, want undefined flag error", err)
+	}
+}
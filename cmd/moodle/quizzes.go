@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+func runQuizzesList(ctx context.Context, args []string) error {
+	fs, g := newFlagSet("quizzes list")
+	courseID := fs.Int("course", 0, "ID of the course")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *courseID == 0 {
+		return errors.New("--course is required")
+	}
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	quizzes, err := client.QuizAPI.GetQuizzesByCourse(ctx, *courseID)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"id", "name", "open", "close", "time limit", "attempts"}}
+	for _, quiz := range quizzes {
+		timeLimit := ""
+		if quiz.TimeLimit > 0 {
+			timeLimit = (time.Duration(quiz.TimeLimit) * time.Second).String()
+		}
+		attempts := "unlimited"
+		if quiz.Attempts > 0 {
+			attempts = strconv.Itoa(quiz.Attempts)
+		}
+		t.append(strconv.Itoa(quiz.ID), quiz.Name, formatTime(quiz.TimeOpen), formatTime(quiz.TimeClose), timeLimit, attempts)
+	}
+	return writeOutput(stdout, g.output, quizzes, t)
+}
+
+// formatTime formats the time in local time zone, empty if it's not set
+func formatTime(t time.Time) string {
+	if t.Unix() <= 0 {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"strconv"
+)
+
+func runSiteInfo(ctx context.Context, args []string) error {
+	fs, g := newFlagSet("site info")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	siteInfo, err := client.SiteAPI.GetSiteInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"field", "value"}}
+	t.append("site", siteInfo.SiteName)
+	t.append("url", siteInfo.SiteURL)
+	t.append("release", siteInfo.Release)
+	t.append("user id", strconv.Itoa(siteInfo.UserID))
+	t.append("username", siteInfo.Username)
+	t.append("fullname", siteInfo.Fullname)
+	t.append("lang", siteInfo.Lang)
+	t.append("functions", strconv.Itoa(len(siteInfo.Functions)))
+	return writeOutput(stdout, g.output, siteInfo, t)
+}
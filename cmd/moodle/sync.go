@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/k-yomo/moodle/coursesync"
+)
+
+func runSyncPlan(ctx context.Context, args []string) error {
+	return runSync(ctx, "sync plan", args, false)
+}
+
+func runSyncApply(ctx context.Context, args []string) error {
+	return runSync(ctx, "sync apply", args, true)
+}
+
+func runSync(ctx context.Context, name string, args []string, apply bool) error {
+	fs, g := newFlagSet(name)
+	specPath := fs.String("f", "", "path to the spec file (.yaml, .yml or .json)")
+	// plan never applies the changes, so the flag is only for apply
+	autoApprove := new(bool)
+	if apply {
+		autoApprove = fs.Bool("auto-approve", false, "apply without confirmation")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *specPath == "" {
+		return errors.New("-f is required")
+	}
+
+	spec, err := coursesync.LoadSpec(*specPath)
+	if err != nil {
+		return err
+	}
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	plan, err := coursesync.NewPlan(ctx, client, spec)
+	if err != nil {
+		return err
+	}
+	if _, err := plan.WriteTo(stdout); err != nil {
+		return err
+	}
+	if !apply || plan.IsEmpty() {
+		return nil
+	}
+
+	if !*autoApprove {
+		fmt.Fprint(stdout, "\nDo you want to apply these changes? Only 'yes' will be accepted: ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != "yes" {
+			fmt.Fprintln(stdout, "Apply cancelled.")
+			return nil
+		}
+	}
+	if err := plan.Apply(ctx, client); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Apply complete! %d changes applied.\n", len(plan.Changes))
+	return nil
+}
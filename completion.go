@@ -0,0 +1,67 @@
+package moodle
+
+import (
+	"time"
+)
+
+// CompletionState represents the completion state of an activity
+type CompletionState int
+
+const (
+	CompletionStateIncomplete   CompletionState = 0
+	CompletionStateComplete     CompletionState = 1
+	CompletionStateCompletePass CompletionState = 2
+	CompletionStateCompleteFail CompletionState = 3
+)
+
+// CompletionTracking represents how the completion of an activity is tracked
+type CompletionTracking int
+
+const (
+	CompletionTrackingNone      CompletionTracking = 0
+	CompletionTrackingManual    CompletionTracking = 1
+	CompletionTrackingAutomatic CompletionTracking = 2
+)
+
+type ActivityCompletionStatus struct {
+	CmID          int
+	ModName       string
+	Instance      int
+	State         CompletionState
+	TimeCompleted *time.Time
+	Tracking      CompletionTracking
+	// OverrideBy is the ID of the user who overrode the state, nil if not overridden
+	OverrideBy    *int
+	ValueUsed     bool
+	HasCompletion bool
+	IsAutomatic   bool
+	UserVisible   bool
+}
+
+// IsComplete returns true if the activity is completed (and passed if it requires passing grade)
+func (a *ActivityCompletionStatus) IsComplete() bool {
+	return a.State == CompletionStateComplete || a.State == CompletionStateCompletePass
+}
+
+type CourseCompletionStatus struct {
+	Completed bool
+	// Aggregation is the method to aggregate criteria, 1 for all and 2 for any
+	Aggregation int
+	Criteria    []*CourseCompletionCriterion
+}
+
+type CourseCompletionCriterion struct {
+	Type          int
+	Title         string
+	Status        string
+	Complete      bool
+	TimeCompleted *time.Time
+	Details       *CourseCompletionCriterionDetails
+}
+
+type CourseCompletionCriterionDetails struct {
+	Type        string
+	Criteria    string
+	Requirement string
+	Status      string
+}
@@ -0,0 +1,185 @@
+package moodle
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+type CompletionAPI interface {
+	GetActivitiesCompletionStatus(ctx context.Context, courseID int, userID int) ([]*ActivityCompletionStatus, error)
+	GetCourseCompletionStatus(ctx context.Context, courseID int, userID int) (*CourseCompletionStatus, error)
+	UpdateActivityCompletionStatusManually(ctx context.Context, cmID int, completed bool) error
+	MarkCourseSelfCompleted(ctx context.Context, courseID int) error
+}
+
+type completionAPI struct {
+	*apiClient
+}
+
+func newCompletionAPI(apiClient *apiClient) *completionAPI {
+	return &completionAPI{apiClient}
+}
+
+type activityCompletionStatusResponse struct {
+	CmID              int    `json:"cmid"`
+	ModName           string `json:"modname"`
+	Instance          int    `json:"instance"`
+	State             int    `json:"state"`
+	TimeCompletedUnix int64  `json:"timecompleted"`
+	Tracking          int    `json:"tracking"`
+	OverrideBy        *int   `json:"overrideby"`
+	ValueUsed         bool   `json:"valueused"`
+	HasCompletion     bool   `json:"hascompletion"`
+	IsAutomatic       bool   `json:"isautomatic"`
+	UserVisible       bool   `json:"uservisible"`
+}
+
+type getActivitiesCompletionStatusResponse struct {
+	Statuses []*activityCompletionStatusResponse `json:"statuses"`
+	Warnings Warnings                            `json:"warnings"`
+}
+
+func (c *completionAPI) GetActivitiesCompletionStatus(ctx context.Context, courseID int, userID int) ([]*ActivityCompletionStatus, error) {
+	res := getActivitiesCompletionStatusResponse{}
+	err := c.callMoodleFunction(ctx, &res, map[string]string{
+		"wsfunction": "core_completion_get_activities_completion_status",
+		"courseid":   strconv.Itoa(courseID),
+		"userid":     strconv.Itoa(userID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+	return mapToActivityCompletionStatusList(res.Statuses), nil
+}
+
+type getCourseCompletionStatusResponse struct {
+	CompletionStatus *struct {
+		Completed   bool `json:"completed"`
+		Aggregation int  `json:"aggregation"`
+		Completions []*struct {
+			Type              int    `json:"type"`
+			Title             string `json:"title"`
+			Status            string `json:"status"`
+			Complete          bool   `json:"complete"`
+			TimeCompletedUnix *int64 `json:"timecompleted"`
+			Details           *struct {
+				Type        string `json:"type"`
+				Criteria    string `json:"criteria"`
+				Requirement string `json:"requirement"`
+				Status      string `json:"status"`
+			} `json:"details"`
+		} `json:"completions"`
+	} `json:"completionstatus"`
+	Warnings Warnings `json:"warnings"`
+}
+
+func (c *completionAPI) GetCourseCompletionStatus(ctx context.Context, courseID int, userID int) (*CourseCompletionStatus, error) {
+	res := getCourseCompletionStatusResponse{}
+	err := c.callMoodleFunction(ctx, &res, map[string]string{
+		"wsfunction": "core_completion_get_course_completion_status",
+		"courseid":   strconv.Itoa(courseID),
+		"userid":     strconv.Itoa(userID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+	if res.CompletionStatus == nil {
+		return nil, fmt.Errorf("completion status of course %d is not returned", courseID)
+	}
+
+	criteria := make([]*CourseCompletionCriterion, 0, len(res.CompletionStatus.Completions))
+	for _, completion := range res.CompletionStatus.Completions {
+		criterion := &CourseCompletionCriterion{
+			Type:     completion.Type,
+			Title:    completion.Title,
+			Status:   completion.Status,
+			Complete: completion.Complete,
+		}
+		if completion.TimeCompletedUnix != nil {
+			criterion.TimeCompleted = mapUnixToTimePtr(*completion.TimeCompletedUnix)
+		}
+		if completion.Details != nil {
+			criterion.Details = &CourseCompletionCriterionDetails{
+				Type:        completion.Details.Type,
+				Criteria:    completion.Details.Criteria,
+				Requirement: completion.Details.Requirement,
+				Status:      completion.Details.Status,
+			}
+		}
+		criteria = append(criteria, criterion)
+	}
+	return &CourseCompletionStatus{
+		Completed:   res.CompletionStatus.Completed,
+		Aggregation: res.CompletionStatus.Aggregation,
+		Criteria:    criteria,
+	}, nil
+}
+
+type completionStatusResponse struct {
+	Status   bool     `json:"status"`
+	Warnings Warnings `json:"warnings"`
+}
+
+func (c *completionAPI) UpdateActivityCompletionStatusManually(ctx context.Context, cmID int, completed bool) error {
+	res := completionStatusResponse{}
+	err := c.postMoodleFunction(ctx, &res, map[string]string{
+		"wsfunction": "core_completion_update_activity_completion_status_manually",
+		"cmid":       strconv.Itoa(cmID),
+		"completed":  mapBoolToBitStr(completed),
+	})
+	if err != nil {
+		return err
+	}
+	if len(res.Warnings) > 0 {
+		return res.Warnings
+	}
+	if !res.Status {
+		return fmt.Errorf("failed to update completion status of course module %d", cmID)
+	}
+	return nil
+}
+
+func (c *completionAPI) MarkCourseSelfCompleted(ctx context.Context, courseID int) error {
+	res := completionStatusResponse{}
+	err := c.postMoodleFunction(ctx, &res, map[string]string{
+		"wsfunction": "core_completion_mark_course_self_completed",
+		"courseid":   strconv.Itoa(courseID),
+	})
+	if err != nil {
+		return err
+	}
+	if len(res.Warnings) > 0 {
+		return res.Warnings
+	}
+	if !res.Status {
+		return fmt.Errorf("failed to mark course %d as self completed", courseID)
+	}
+	return nil
+}
+
+func mapToActivityCompletionStatusList(statusResList []*activityCompletionStatusResponse) []*ActivityCompletionStatus {
+	statuses := make([]*ActivityCompletionStatus, 0, len(statusResList))
+	for _, statusRes := range statusResList {
+		statuses = append(statuses, &ActivityCompletionStatus{
+			CmID:          statusRes.CmID,
+			ModName:       statusRes.ModName,
+			Instance:      statusRes.Instance,
+			State:         CompletionState(statusRes.State),
+			TimeCompleted: mapUnixToTimePtr(statusRes.TimeCompletedUnix),
+			Tracking:      CompletionTracking(statusRes.Tracking),
+			OverrideBy:    statusRes.OverrideBy,
+			ValueUsed:     statusRes.ValueUsed,
+			HasCompletion: statusRes.HasCompletion,
+			IsAutomatic:   statusRes.IsAutomatic,
+			UserVisible:   statusRes.UserVisible,
+		})
+	}
+	return statuses
+}
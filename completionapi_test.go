@@ -0,0 +1,269 @@
+package moodle
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_completionAPI_GetActivitiesCompletionStatus(t *testing.T) {
+	t.Parallel()
+
+	timeCompleted := time.Unix(1609459200, 0)
+	overrideBy := 2
+
+	tests := []struct {
+		name     string
+		response string
+		want     []*ActivityCompletionStatus
+		wantErr  bool
+	}{
+		{
+			name: "Successful response",
+			response: `{"statuses":[
+{"cmid":555555,"modname":"quiz","instance":123,"state":2,"timecompleted":1609459200,"tracking":2,"overrideby":null,"valueused":true,"hascompletion":true,"isautomatic":true,"istrackeduser":true,"uservisible":true},
+{"cmid":555556,"modname":"page","instance":124,"state":1,"timecompleted":1609459200,"tracking":1,"overrideby":2,"valueused":false,"hascompletion":true,"isautomatic":false,"istrackeduser":true,"uservisible":true},
+{"cmid":555557,"modname":"forum","instance":125,"state":0,"timecompleted":0,"tracking":1,"overrideby":null,"valueused":false,"hascompletion":true,"isautomatic":false,"istrackeduser":true,"uservisible":true}
+],"warnings":[]}`,
+			want: []*ActivityCompletionStatus{
+				{
+					CmID:          555555,
+					ModName:       "quiz",
+					Instance:      123,
+					State:         CompletionStateCompletePass,
+					TimeCompleted: &timeCompleted,
+					Tracking:      CompletionTrackingAutomatic,
+					ValueUsed:     true,
+					HasCompletion: true,
+					IsAutomatic:   true,
+					UserVisible:   true,
+				},
+				{
+					CmID:          555556,
+					ModName:       "page",
+					Instance:      124,
+					State:         CompletionStateComplete,
+					TimeCompleted: &timeCompleted,
+					Tracking:      CompletionTrackingManual,
+					OverrideBy:    &overrideBy,
+					HasCompletion: true,
+					UserVisible:   true,
+				},
+				{
+					CmID:          555557,
+					ModName:       "forum",
+					Instance:      125,
+					State:         CompletionStateIncomplete,
+					Tracking:      CompletionTrackingManual,
+					HasCompletion: true,
+					UserVisible:   true,
+				},
+			},
+		},
+		{
+			name:     "Warning response",
+			response: `{"statuses":[],"warnings":[{"item":"course","itemid":1111,"warningcode":"1","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"completionnotenabled","message":"Completion is not enabled"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := mockCompletionAPI(t, tt.response)
+			got, err := c.GetActivitiesCompletionStatus(context.Background(), 1111, 123456)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetActivitiesCompletionStatus() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetActivitiesCompletionStatus() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_completionAPI_GetCourseCompletionStatus(t *testing.T) {
+	t.Parallel()
+
+	timeCompleted := time.Unix(1609459200, 0)
+
+	tests := []struct {
+		name     string
+		response string
+		want     *CourseCompletionStatus
+		wantErr  bool
+	}{
+		{
+			name: "Successful response",
+			response: `{"completionstatus":{"completed":false,"aggregation":1,"completions":[
+{"type":4,"title":"Activity completion","status":"Yes","complete":true,"timecompleted":1609459200,"details":{"type":"Activity completion","criteria":"Quiz 1","requirement":"Marking yourself complete","status":""}},
+{"type":1,"title":"Self completion","status":"No","complete":false,"timecompleted":null,"details":{"type":"Self completion","criteria":"","requirement":"","status":""}}
+]},"warnings":[]}`,
+			want: &CourseCompletionStatus{
+				Completed:   false,
+				Aggregation: 1,
+				Criteria: []*CourseCompletionCriterion{
+					{
+						Type:          4,
+						Title:         "Activity completion",
+						Status:        "Yes",
+						Complete:      true,
+						TimeCompleted: &timeCompleted,
+						Details: &CourseCompletionCriterionDetails{
+							Type:        "Activity completion",
+							Criteria:    "Quiz 1",
+							Requirement: "Marking yourself complete",
+						},
+					},
+					{
+						Type:    1,
+						Title:   "Self completion",
+						Status:  "No",
+						Details: &CourseCompletionCriterionDetails{Type: "Self completion"},
+					},
+				},
+			},
+		},
+		{
+			name:     "Warning response",
+			response: `{"completionstatus":{"completed":false,"aggregation":1,"completions":[]},"warnings":[{"item":"course","itemid":1111,"warningcode":"1","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"nocriteriaset","message":"No criteria set for this course"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := mockCompletionAPI(t, tt.response)
+			got, err := c.GetCourseCompletionStatus(context.Background(), 1111, 123456)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetCourseCompletionStatus() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetCourseCompletionStatus() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_completionAPI_UpdateActivityCompletionStatusManually(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"status":true,"warnings":[]}`,
+		},
+		{
+			name:     "Failed response",
+			response: `{"status":false,"warnings":[]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Warning response",
+			response: `{"status":false,"warnings":[{"item":"cmid","itemid":555555,"warningcode":"1","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"cannotmanualctrack","message":"Activity does not have manual tracking"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := mockCompletionAPI(t, tt.response)
+			if err := c.UpdateActivityCompletionStatusManually(context.Background(), 555555, true); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateActivityCompletionStatusManually() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_completionAPI_MarkCourseSelfCompleted(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"status":true,"warnings":[]}`,
+		},
+		{
+			name:     "Warning response",
+			response: `{"status":false,"warnings":[{"item":"course","itemid":1111,"warningcode":"1","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"noselfcompletioncriteria","message":"No self completion criteria"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := mockCompletionAPI(t, tt.response)
+			if err := c.MarkCourseSelfCompleted(context.Background(), 1111); (err != nil) != tt.wantErr {
+				t.Errorf("MarkCourseSelfCompleted() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mockCompletionAPI(t *testing.T, response string) *completionAPI {
+	t.Helper()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, response)
+	})
+	s := httptest.NewServer(h)
+	apiURL, _ := url.Parse(s.URL)
+	return &completionAPI{
+		&apiClient{
+			httpClient: http.DefaultClient,
+			apiURL:     apiURL,
+		},
+	}
+}
@@ -7,15 +7,29 @@ import (
 type CourseClassification string
 
 const (
+	CourseClassificationAll        CourseClassification = "all"
 	CourseClassificationPast       CourseClassification = "past"
 	CourseClassificationInProgress CourseClassification = "inprogress"
 	CourseClassificationFuture     CourseClassification = "future"
 )
 
+// CourseField represents a field to search courses by
+type CourseField string
+
+const (
+	CourseFieldID         CourseField = "id"
+	CourseFieldIDs        CourseField = "ids"
+	CourseFieldShortName  CourseField = "shortname"
+	CourseFieldIDNumber   CourseField = "idnumber"
+	CourseFieldCategoryID CourseField = "category"
+)
+
 type Course struct {
 	ID              int
 	FullName        string
 	ShortName       string
+	IDNumber        string
+	CategoryID      int
 	Summary         string
 	SummaryFormat   int
 	StartDate       time.Time
@@ -31,3 +45,159 @@ type Course struct {
 	ShowShortName   bool
 	CourseCategory  string
 }
+
+// CreateCourseParams represents a course to be created.
+// Empty string, zero and nil fields are left to the site default.
+type CreateCourseParams struct {
+	FullName   string
+	ShortName  string
+	CategoryID int
+	IDNumber   string
+	Summary    string
+	// SummaryFormat is 1 for HTML, 0 for MOODLE, 2 for PLAIN and 4 for MARKDOWN
+	SummaryFormat    *int
+	Format           string
+	StartDate        *time.Time
+	EndDate          *time.Time
+	NumSections      *int
+	Visible          *bool
+	EnableCompletion *bool
+	Lang             string
+	// FormatOptions are the options of the course format (e.g. coursedisplay)
+	FormatOptions []*CourseOption
+}
+
+// CreatedCourse represents the course created by CreateCourses or DuplicateCourse
+type CreatedCourse struct {
+	ID        int
+	ShortName string
+}
+
+// UpdateCourseParams represents a course to be updated.
+// Empty string, zero and nil fields are left unchanged.
+type UpdateCourseParams struct {
+	ID               int
+	FullName         string
+	ShortName        string
+	CategoryID       int
+	IDNumber         string
+	Summary          string
+	SummaryFormat    *int
+	Format           string
+	StartDate        *time.Time
+	EndDate          *time.Time
+	Visible          *bool
+	EnableCompletion *bool
+	Lang             string
+	FormatOptions    []*CourseOption
+}
+
+// CourseOption represents a name value pair of course format or backup options
+type CourseOption struct {
+	Name  string
+	Value string
+}
+
+// DuplicateCourseParams represents the parameters to duplicate a course
+type DuplicateCourseParams struct {
+	CourseID   int
+	FullName   string
+	ShortName  string
+	CategoryID int
+	// Visible is omitted if it's nil, and the new course is visible by default
+	Visible *bool
+	// Options are backup options (e.g. activities, blocks, users), see core_course_duplicate_course for the list
+	Options []*CourseOption
+}
+
+// ImportCourseParams represents the parameters to import contents from a course to another
+type ImportCourseParams struct {
+	ImportFrom int
+	ImportTo   int
+	// DeleteContent deletes the contents of the course to import to before importing
+	DeleteContent bool
+	// Options are backup options (e.g. activities, blocks, filters)
+	Options []*CourseOption
+}
+
+// CourseCategory represents a course category, only ID and Name are set in the result of CreateCategories
+type CourseCategory struct {
+	ID          int
+	Name        string
+	IDNumber    string
+	Description string
+	// Parent is the ID of the parent category, 0 for the top level
+	Parent      int
+	CourseCount int
+	Visible     bool
+	Depth       int
+	// Path is the IDs of the ancestors and the category joined by "/" (e.g. /1/4)
+	Path string
+}
+
+// CategorySearchCriterion represents a criterion to search course categories.
+// Key is one of id, ids, name, parent, idnumber, visible and theme.
+type CategorySearchCriterion struct {
+	Key   string
+	Value string
+}
+
+// CreateCategoryParams represents a course category to be created
+type CreateCategoryParams struct {
+	Name string
+	// Parent is the ID of the parent category, 0 for the top level
+	Parent      int
+	IDNumber    string
+	Description string
+}
+
+// UpdateCategoryParams represents a course category to be updated.
+// Empty string and nil fields are left unchanged.
+type UpdateCategoryParams struct {
+	ID          int
+	Name        string
+	Parent      *int
+	IDNumber    string
+	Description string
+}
+
+// CourseSection represents a section of the course contents
+type CourseSection struct {
+	ID      int
+	Name    string
+	Visible bool
+	Summary string
+	// Section is the position of the section in the course, 0 for the general section
+	Section     int
+	UserVisible bool
+	Modules     []*CourseModule
+}
+
+// CourseModule represents an activity or a resource in a section
+type CourseModule struct {
+	ID          int
+	URL         string
+	Name        string
+	Instance    int
+	ContextID   int
+	Visible     bool
+	UserVisible bool
+	ModName     string
+	ModPlural   string
+	Contents    []*CourseModuleContent
+}
+
+// CourseModuleContent represents a file or an url of a module
+type CourseModuleContent struct {
+	// Type is one of file, url and content
+	Type     string
+	FileName string
+	FilePath string
+	FileSize int64
+	// FileURL is the url to download the file with the token, see Client.DownloadFile
+	FileURL      string
+	TimeCreated  *time.Time
+	TimeModified *time.Time
+	MimeType     string
+	Author       string
+}
@@ -2,11 +2,26 @@ package moodle
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"time"
 )
 
 type CourseAPI interface {
 	GetEnrolledCoursesByTimelineClassification(ctx context.Context, classification CourseClassification) ([]*Course, error)
+	// GetCoursesByField returns courses matching the field, all the courses are returned if field is empty
+	GetCoursesByField(ctx context.Context, field CourseField, value string) ([]*Course, error)
+	// GetContents returns the sections of the course with the modules in them
+	GetContents(ctx context.Context, courseID int) ([]*CourseSection, error)
+	// GetCategories returns categories matching all the criteria, including their sub categories if addSubCategories is true
+	GetCategories(ctx context.Context, criteria []*CategorySearchCriterion, addSubCategories bool) ([]*CourseCategory, error)
+	CreateCourses(ctx context.Context, courses []*CreateCourseParams) ([]*CreatedCourse, error)
+	UpdateCourses(ctx context.Context, courses []*UpdateCourseParams) error
+	DuplicateCourse(ctx context.Context, params *DuplicateCourseParams) (*CreatedCourse, error)
+	ImportCourse(ctx context.Context, params *ImportCourseParams) error
+	DeleteCourses(ctx context.Context, courseIDs []int) error
+	CreateCategories(ctx context.Context, categories []*CreateCategoryParams) ([]*CourseCategory, error)
+	UpdateCategories(ctx context.Context, categories []*UpdateCategoryParams) error
 }
 
 type courseAPI struct {
@@ -21,6 +36,7 @@ type courseResponse struct {
 	ID              int    `json:"id"`
 	FullName        string `json:"fullname"`
 	ShortName       string `json:"shortname"`
+	IDNumber        string `json:"idnumber"`
 	Summary         string `json:",omitempty"`
 	SummaryFormat   int    `json:"summaryformat"`
 	StartDateUnix   int64  `json:"startdate"`
@@ -54,6 +70,404 @@ func (c *courseAPI) GetEnrolledCoursesByTimelineClassification(ctx context.Conte
 	return mapToCourseList(res.Courses), nil
 }
 
+type getCoursesByFieldResponse struct {
+	Courses []*struct {
+		ID            int    `json:"id"`
+		FullName      string `json:"fullname"`
+		DisplayName   string `json:"displayname"`
+		ShortName     string `json:"shortname"`
+		IDNumber      string `json:"idnumber"`
+		CategoryID    int    `json:"categoryid"`
+		CategoryName  string `json:"categoryname"`
+		Summary       string `json:"summary"`
+		SummaryFormat int    `json:"summaryformat"`
+		StartDateUnix int64  `json:"startdate"`
+		EndDateUnix   int64  `json:"enddate"`
+		Visible       int    `json:"visible"`
+	} `json:"courses"`
+	Warnings Warnings `json:"warnings"`
+}
+
+func (c *courseAPI) GetCoursesByField(ctx context.Context, field CourseField, value string) ([]*Course, error) {
+	params := map[string]string{
+		"wsfunction": "core_course_get_courses_by_field",
+	}
+	if field != "" {
+		params["field"] = string(field)
+		params["value"] = value
+	}
+
+	res := getCoursesByFieldResponse{}
+	if err := c.callMoodleFunction(ctx, &res, params); err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+	courses := make([]*Course, 0, len(res.Courses))
+	for _, courseRes := range res.Courses {
+		courses = append(courses, &Course{
+			ID:              courseRes.ID,
+			FullName:        courseRes.FullName,
+			ShortName:       courseRes.ShortName,
+			IDNumber:        courseRes.IDNumber,
+			CategoryID:      courseRes.CategoryID,
+			Summary:         courseRes.Summary,
+			SummaryFormat:   courseRes.SummaryFormat,
+			StartDate:       time.Unix(courseRes.StartDateUnix, 0),
+			EndDate:         time.Unix(courseRes.EndDateUnix, 0),
+			Visible:         mapBitToBool(courseRes.Visible),
+			FullNameDisplay: courseRes.DisplayName,
+			CourseCategory:  courseRes.CategoryName,
+		})
+	}
+	return courses, nil
+}
+
+type courseSectionResponse struct {
+	ID          int                     `json:"id"`
+	Name        string                  `json:"name"`
+	Visible     int                     `json:"visible"`
+	Summary     string                  `json:"summary"`
+	Section     int                     `json:"section"`
+	UserVisible bool                    `json:"uservisible"`
+	Modules     []*courseModuleResponse `json:"modules"`
+}
+
+type courseModuleResponse struct {
+	ID          int    `json:"id"`
+	URL         string `json:"url"`
+	Name        string `json:"name"`
+	Instance    int    `json:"instance"`
+	ContextID   int    `json:"contextid"`
+	Visible     int    `json:"visible"`
+	UserVisible bool   `json:"uservisible"`
+	ModName     string `json:"modname"`
+	ModPlural   string `json:"modplural"`
+	Contents    []*struct {
+		Type             string `json:"type"`
+		FileName         string `json:"filename"`
+		FilePath         string `json:"filepath"`
+		FileSize         int64  `json:"filesize"`
+		FileURL          string `json:"fileurl"`
+		TimeCreatedUnix  int64  `json:"timecreated"`
+		TimeModifiedUnix int64  `json:"timemodified"`
+		MimeType         string `json:"mimetype"`
+		Author           string `json:"author"`
+	} `json:"contents"`
+}
+
+func (c *courseAPI) GetContents(ctx context.Context, courseID int) ([]*CourseSection, error) {
+	var res []*courseSectionResponse
+	err := c.callMoodleFunction(ctx, &res, map[string]string{
+		"wsfunction": "core_course_get_contents",
+		"courseid":   strconv.Itoa(courseID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapToCourseSectionList(res), nil
+}
+
+type categoryResponse struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	IDNumber    string `json:"idnumber"`
+	Description string `json:"description"`
+	Parent      int    `json:"parent"`
+	CourseCount int    `json:"coursecount"`
+	Visible     int    `json:"visible"`
+	Depth       int    `json:"depth"`
+	Path        string `json:"path"`
+}
+
+func (c *courseAPI) GetCategories(ctx context.Context, criteria []*CategorySearchCriterion, addSubCategories bool) ([]*CourseCategory, error) {
+	params := map[string]string{
+		"wsfunction":       "core_course_get_categories",
+		"addsubcategories": mapBoolToBitStr(addSubCategories),
+	}
+	for i, criterion := range criteria {
+		params[fmt.Sprintf("criteria[%d][key]", i)] = criterion.Key
+		params[fmt.Sprintf("criteria[%d][value]", i)] = criterion.Value
+	}
+
+	var res []*categoryResponse
+	if err := c.callMoodleFunction(ctx, &res, params); err != nil {
+		return nil, err
+	}
+	categories := make([]*CourseCategory, 0, len(res))
+	for _, categoryRes := range res {
+		categories = append(categories, &CourseCategory{
+			ID:          categoryRes.ID,
+			Name:        categoryRes.Name,
+			IDNumber:    categoryRes.IDNumber,
+			Description: categoryRes.Description,
+			Parent:      categoryRes.Parent,
+			CourseCount: categoryRes.CourseCount,
+			Visible:     mapBitToBool(categoryRes.Visible),
+			Depth:       categoryRes.Depth,
+			Path:        categoryRes.Path,
+		})
+	}
+	return categories, nil
+}
+
+type createdCourseResponse struct {
+	ID        int    `json:"id"`
+	ShortName string `json:"shortname"`
+}
+
+func (c *courseAPI) CreateCourses(ctx context.Context, courses []*CreateCourseParams) ([]*CreatedCourse, error) {
+	params := map[string]string{
+		"wsfunction": "core_course_create_courses",
+	}
+	for i, course := range courses {
+		key := func(field string) string { return fmt.Sprintf("courses[%d][%s]", i, field) }
+		params[key("fullname")] = course.FullName
+		params[key("shortname")] = course.ShortName
+		params[key("categoryid")] = strconv.Itoa(course.CategoryID)
+		setIfNotEmpty(params, key("idnumber"), course.IDNumber)
+		setIfNotEmpty(params, key("summary"), course.Summary)
+		setIfNotEmpty(params, key("format"), course.Format)
+		setIfNotEmpty(params, key("lang"), course.Lang)
+		if course.SummaryFormat != nil {
+			params[key("summaryformat")] = strconv.Itoa(*course.SummaryFormat)
+		}
+		if course.StartDate != nil {
+			params[key("startdate")] = strconv.FormatInt(course.StartDate.Unix(), 10)
+		}
+		if course.EndDate != nil {
+			params[key("enddate")] = strconv.FormatInt(course.EndDate.Unix(), 10)
+		}
+		if course.NumSections != nil {
+			params[key("numsections")] = strconv.Itoa(*course.NumSections)
+		}
+		if course.Visible != nil {
+			params[key("visible")] = mapBoolToBitStr(*course.Visible)
+		}
+		if course.EnableCompletion != nil {
+			params[key("enablecompletion")] = mapBoolToBitStr(*course.EnableCompletion)
+		}
+		setCourseOptionParams(params, key("courseformatoptions"), course.FormatOptions)
+	}
+
+	var res []*createdCourseResponse
+	if err := c.postMoodleFunction(ctx, &res, params); err != nil {
+		return nil, err
+	}
+	createdCourses := make([]*CreatedCourse, 0, len(res))
+	for _, courseRes := range res {
+		createdCourses = append(createdCourses, &CreatedCourse{ID: courseRes.ID, ShortName: courseRes.ShortName})
+	}
+	return createdCourses, nil
+}
+
+type updateCoursesResponse struct {
+	Warnings Warnings `json:"warnings"`
+}
+
+func (c *courseAPI) UpdateCourses(ctx context.Context, courses []*UpdateCourseParams) error {
+	params := map[string]string{
+		"wsfunction": "core_course_update_courses",
+	}
+	for i, course := range courses {
+		key := func(field string) string { return fmt.Sprintf("courses[%d][%s]", i, field) }
+		params[key("id")] = strconv.Itoa(course.ID)
+		setIfNotEmpty(params, key("fullname"), course.FullName)
+		setIfNotEmpty(params, key("shortname"), course.ShortName)
+		setIfNotEmpty(params, key("idnumber"), course.IDNumber)
+		setIfNotEmpty(params, key("summary"), course.Summary)
+		setIfNotEmpty(params, key("format"), course.Format)
+		setIfNotEmpty(params, key("lang"), course.Lang)
+		if course.CategoryID != 0 {
+			params[key("categoryid")] = strconv.Itoa(course.CategoryID)
+		}
+		if course.SummaryFormat != nil {
+			params[key("summaryformat")] = strconv.Itoa(*course.SummaryFormat)
+		}
+		if course.StartDate != nil {
+			params[key("startdate")] = strconv.FormatInt(course.StartDate.Unix(), 10)
+		}
+		if course.EndDate != nil {
+			params[key("enddate")] = strconv.FormatInt(course.EndDate.Unix(), 10)
+		}
+		if course.Visible != nil {
+			params[key("visible")] = mapBoolToBitStr(*course.Visible)
+		}
+		if course.EnableCompletion != nil {
+			params[key("enablecompletion")] = mapBoolToBitStr(*course.EnableCompletion)
+		}
+		setCourseOptionParams(params, key("courseformatoptions"), course.FormatOptions)
+	}
+
+	res := updateCoursesResponse{}
+	if err := c.postMoodleFunction(ctx, &res, params); err != nil {
+		return err
+	}
+	if len(res.Warnings) > 0 {
+		return res.Warnings
+	}
+	return nil
+}
+
+func (c *courseAPI) DuplicateCourse(ctx context.Context, params *DuplicateCourseParams) (*CreatedCourse, error) {
+	queryParams := map[string]string{
+		"wsfunction": "core_course_duplicate_course",
+		"courseid":   strconv.Itoa(params.CourseID),
+		"fullname":   params.FullName,
+		"shortname":  params.ShortName,
+		"categoryid": strconv.Itoa(params.CategoryID),
+	}
+	if params.Visible != nil {
+		queryParams["visible"] = mapBoolToBitStr(*params.Visible)
+	}
+	setCourseOptionParams(queryParams, "options", params.Options)
+
+	res := createdCourseResponse{}
+	if err := c.postMoodleFunction(ctx, &res, queryParams); err != nil {
+		return nil, err
+	}
+	return &CreatedCourse{ID: res.ID, ShortName: res.ShortName}, nil
+}
+
+func (c *courseAPI) ImportCourse(ctx context.Context, params *ImportCourseParams) error {
+	queryParams := map[string]string{
+		"wsfunction":    "core_course_import_course",
+		"importfrom":    strconv.Itoa(params.ImportFrom),
+		"importto":      strconv.Itoa(params.ImportTo),
+		"deletecontent": mapBoolToBitStr(params.DeleteContent),
+	}
+	setCourseOptionParams(queryParams, "options", params.Options)
+
+	var res interface{}
+	return c.postMoodleFunction(ctx, &res, queryParams)
+}
+
+type deleteCoursesResponse struct {
+	Warnings Warnings `json:"warnings"`
+}
+
+func (c *courseAPI) DeleteCourses(ctx context.Context, courseIDs []int) error {
+	params := map[string]string{
+		"wsfunction": "core_course_delete_courses",
+	}
+	for i, courseID := range courseIDs {
+		params[fmt.Sprintf("courseids[%d]", i)] = strconv.Itoa(courseID)
+	}
+
+	res := deleteCoursesResponse{}
+	if err := c.postMoodleFunction(ctx, &res, params); err != nil {
+		return err
+	}
+	if len(res.Warnings) > 0 {
+		return res.Warnings
+	}
+	return nil
+}
+
+type courseCategoryResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (c *courseAPI) CreateCategories(ctx context.Context, categories []*CreateCategoryParams) ([]*CourseCategory, error) {
+	params := map[string]string{
+		"wsfunction": "core_course_create_categories",
+	}
+	for i, category := range categories {
+		key := func(field string) string { return fmt.Sprintf("categories[%d][%s]", i, field) }
+		params[key("name")] = category.Name
+		params[key("parent")] = strconv.Itoa(category.Parent)
+		setIfNotEmpty(params, key("idnumber"), category.IDNumber)
+		setIfNotEmpty(params, key("description"), category.Description)
+	}
+
+	var res []*courseCategoryResponse
+	if err := c.postMoodleFunction(ctx, &res, params); err != nil {
+		return nil, err
+	}
+	createdCategories := make([]*CourseCategory, 0, len(res))
+	for _, categoryRes := range res {
+		createdCategories = append(createdCategories, &CourseCategory{ID: categoryRes.ID, Name: categoryRes.Name})
+	}
+	return createdCategories, nil
+}
+
+func (c *courseAPI) UpdateCategories(ctx context.Context, categories []*UpdateCategoryParams) error {
+	params := map[string]string{
+		"wsfunction": "core_course_update_categories",
+	}
+	for i, category := range categories {
+		key := func(field string) string { return fmt.Sprintf("categories[%d][%s]", i, field) }
+		params[key("id")] = strconv.Itoa(category.ID)
+		setIfNotEmpty(params, key("name"), category.Name)
+		setIfNotEmpty(params, key("idnumber"), category.IDNumber)
+		setIfNotEmpty(params, key("description"), category.Description)
+		if category.Parent != nil {
+			params[key("parent")] = strconv.Itoa(*category.Parent)
+		}
+	}
+
+	var res interface{}
+	return c.postMoodleFunction(ctx, &res, params)
+}
+
+func setCourseOptionParams(params map[string]string, key string, options []*CourseOption) {
+	for i, option := range options {
+		params[fmt.Sprintf("%s[%d][name]", key, i)] = option.Name
+		params[fmt.Sprintf("%s[%d][value]", key, i)] = option.Value
+	}
+}
+
+func mapToCourseSectionList(sectionResList []*courseSectionResponse) []*CourseSection {
+	sections := make([]*CourseSection, 0, len(sectionResList))
+	for _, sectionRes := range sectionResList {
+		modules := make([]*CourseModule, 0, len(sectionRes.Modules))
+		for _, moduleRes := range sectionRes.Modules {
+			modules = append(modules, mapToCourseModule(moduleRes))
+		}
+		sections = append(sections, &CourseSection{
+			ID:          sectionRes.ID,
+			Name:        sectionRes.Name,
+			Visible:     mapBitToBool(sectionRes.Visible),
+			Summary:     sectionRes.Summary,
+			Section:     sectionRes.Section,
+			UserVisible: sectionRes.UserVisible,
+			Modules:     modules,
+		})
+	}
+	return sections
+}
+
+func mapToCourseModule(moduleRes *courseModuleResponse) *CourseModule {
+	contents := make([]*CourseModuleContent, 0, len(moduleRes.Contents))
+	for _, contentRes := range moduleRes.Contents {
+		contents = append(contents, &CourseModuleContent{
+			Type:         contentRes.Type,
+			FileName:     contentRes.FileName,
+			FilePath:     contentRes.FilePath,
+			FileSize:     contentRes.FileSize,
+			FileURL:      contentRes.FileURL,
+			TimeCreated:  mapUnixToTimePtr(contentRes.TimeCreatedUnix),
+			TimeModified: mapUnixToTimePtr(contentRes.TimeModifiedUnix),
+			MimeType:     contentRes.MimeType,
+			Author:       contentRes.Author,
+		})
+	}
+	return &CourseModule{
+		ID:          moduleRes.ID,
+		URL:         moduleRes.URL,
+		Name:        moduleRes.Name,
+		Instance:    moduleRes.Instance,
+		ContextID:   moduleRes.ContextID,
+		Visible:     mapBitToBool(moduleRes.Visible),
+		UserVisible: moduleRes.UserVisible,
+		ModName:     moduleRes.ModName,
+		ModPlural:   moduleRes.ModPlural,
+		Contents:    contents,
+	}
+}
+
 func mapToCourseList(courseResList []*courseResponse) []*Course {
 	courses := make([]*Course, 0, len(courseResList))
 	for _, courseRes := range courseResList {
@@ -67,6 +481,7 @@ func mapToCourse(courseRes *courseResponse) *Course {
 		ID:              courseRes.ID,
 		FullName:        courseRes.FullName,
 		ShortName:       courseRes.ShortName,
+		IDNumber:        courseRes.IDNumber,
 		Summary:         courseRes.Summary,
 		SummaryFormat:   courseRes.SummaryFormat,
 		StartDate:       time.Unix(courseRes.StartDateUnix, 0),
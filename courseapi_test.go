@@ -112,3 +112,502 @@ func Test_courseAPI_GetEnrolledCoursesByTimelineClassification(t *testing.T) {
 		})
 	}
 }
+
+func Test_courseAPI_GetCoursesByField(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		want     []*Course
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"courses":[{"id":2222,"fullname":"MATH 1111 Introduction to Math","displayname":"MATH 1111 Introduction to Math","shortname":"MATH 1111 2021S","categoryid":4,"categoryname":"2021 Spring","idnumber":"","summary":"","summaryformat":1,"format":"topics","startdate":1617235200,"enddate":1625097600,"visible":0}],"warnings":[]}`,
+			want: []*Course{
+				{
+					ID:              2222,
+					FullName:        "MATH 1111 Introduction to Math",
+					ShortName:       "MATH 1111 2021S",
+					CategoryID:      4,
+					SummaryFormat:   1,
+					StartDate:       time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+					EndDate:         time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC),
+					FullNameDisplay: "MATH 1111 Introduction to Math",
+					CourseCategory:  "2021 Spring",
+				},
+			},
+		},
+		{
+			name:     "Warning response",
+			response: `{"courses":[],"warnings":[{"item":"course","itemid":2222,"warningcode":"1","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := mockCourseAPI(t, tt.response)
+			got, err := c.GetCoursesByField(context.Background(), CourseFieldShortName, "MATH 1111 2021S")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetCoursesByField() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetCoursesByField() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_courseAPI_GetContents(t *testing.T) {
+	t.Parallel()
+
+	timeModified := time.Unix(1609459200, 0)
+
+	tests := []struct {
+		name     string
+		response string
+		want     []*CourseSection
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `[{"id":10,"name":"Week 1","visible":1,"summary":"","summaryformat":1,"section":1,"hiddenbynumsections":0,"uservisible":true,"modules":[{"id":555555,"url":"https://test.edu/mod/resource/view.php?id=555555","name":"Slides","instance":77,"contextid":88,"visible":1,"uservisible":true,"modicon":"","modname":"resource","modplural":"Files","indent":0,"contents":[{"type":"file","filename":"slides.pdf","filepath":"/","filesize":1024,"fileurl":"https://test.edu/webservice/pluginfile.php/88/mod_resource/content/1/slides.pdf?forcedownload=1","timecreated":1609459200,"timemodified":1609459200,"sortorder":1,"mimetype":"application/pdf","isexternalfile":false,"userid":2,"author":"Teacher","license":"allrightsreserved"}]}]}]`,
+			want: []*CourseSection{
+				{
+					ID:          10,
+					Name:        "Week 1",
+					Visible:     true,
+					Section:     1,
+					UserVisible: true,
+					Modules: []*CourseModule{
+						{
+							ID:          555555,
+							URL:         "https://test.edu/mod/resource/view.php?id=555555",
+							Name:        "Slides",
+							Instance:    77,
+							ContextID:   88,
+							Visible:     true,
+							UserVisible: true,
+							ModName:     "resource",
+							ModPlural:   "Files",
+							Contents: []*CourseModuleContent{
+								{
+									Type:         "file",
+									FileName:     "slides.pdf",
+									FilePath:     "/",
+									FileSize:     1024,
+									FileURL:      "https://test.edu/webservice/pluginfile.php/88/mod_resource/content/1/slides.pdf?forcedownload=1",
+									TimeCreated:  &timeModified,
+									TimeModified: &timeModified,
+									MimeType:     "application/pdf",
+									Author:       "Teacher",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"require_login_exception","errorcode":"requireloginerror","message":"Course or activity not accessible."}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := mockCourseAPI(t, tt.response)
+			got, err := c.GetContents(context.Background(), 1111)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetContents() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetContents() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_courseAPI_GetCategories(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		want     []*CourseCategory
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `[{"id":4,"name":"2021 Spring","idnumber":"2021S","description":"","descriptionformat":1,"parent":1,"sortorder":20000,"coursecount":3,"visible":1,"visibleold":1,"timemodified":1609459200,"depth":2,"path":"/1/4","theme":""}]`,
+			want: []*CourseCategory{
+				{ID: 4, Name: "2021 Spring", IDNumber: "2021S", Parent: 1, CourseCount: 3, Visible: true, Depth: 2, Path: "/1/4"},
+			},
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"invalid_parameter_exception","errorcode":"invalidparameter","message":"Invalid parameter value detected"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := mockCourseAPI(t, tt.response)
+			got, err := c.GetCategories(context.Background(), []*CategorySearchCriterion{{Key: "idnumber", Value: "2021S"}}, false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetCategories() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetCategories() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_courseAPI_CreateCourses(t *testing.T) {
+	t.Parallel()
+
+	var gotQuery url.Values
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		gotQuery = r.Form
+		fmt.Fprintln(w, `[{"id":2222,"shortname":"MATH 1111 2021S"}]`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	apiURL, _ := url.Parse(s.URL)
+	c := &courseAPI{&apiClient{httpClient: http.DefaultClient, apiURL: apiURL}}
+
+	visible := false
+	startDate := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	got, err := c.CreateCourses(context.Background(), []*CreateCourseParams{
+		{
+			FullName:      "MATH 1111 Introduction to Math",
+			ShortName:     "MATH 1111 2021S",
+			CategoryID:    3,
+			StartDate:     &startDate,
+			Visible:       &visible,
+			FormatOptions: []*CourseOption{{Name: "coursedisplay", Value: "1"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateCourses() error = %v", err)
+	}
+	if diff := cmp.Diff(got, []*CreatedCourse{{ID: 2222, ShortName: "MATH 1111 2021S"}}); diff != "" {
+		t.Errorf("CreateCourses() (-got, +want)\n%s", diff)
+	}
+	wantQuery := map[string]string{
+		"courses[0][shortname]":                     "MATH 1111 2021S",
+		"courses[0][categoryid]":                    "3",
+		"courses[0][startdate]":                     "1617235200",
+		"courses[0][visible]":                       "0",
+		"courses[0][courseformatoptions][0][name]":  "coursedisplay",
+		"courses[0][courseformatoptions][0][value]": "1",
+	}
+	for k, v := range wantQuery {
+		if gotQuery.Get(k) != v {
+			t.Errorf("CreateCourses() query %s = %q, want %q", k, gotQuery.Get(k), v)
+		}
+	}
+	if _, ok := gotQuery["courses[0][enddate]"]; ok {
+		t.Errorf("CreateCourses() sent unset enddate")
+	}
+}
+
+func Test_courseAPI_UpdateCourses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"warnings":[]}`,
+		},
+		{
+			name:     "Warning response",
+			response: `{"warnings":[{"item":"course","itemid":2222,"warningcode":"errorcourseupdate","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"invalid_parameter_exception","errorcode":"invalidparameter","message":"Invalid parameter value detected"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := mockCourseAPI(t, tt.response)
+			if err := c.UpdateCourses(context.Background(), []*UpdateCourseParams{{ID: 2222, FullName: "MATH 1111"}}); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateCourses() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_courseAPI_DuplicateCourse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		want     *CreatedCourse
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"id":2222,"shortname":"MATH 1111 2021S"}`,
+			want:     &CreatedCourse{ID: 2222, ShortName: "MATH 1111 2021S"},
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"shortnametaken","message":"Short name is already used for another course"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := mockCourseAPI(t, tt.response)
+			got, err := c.DuplicateCourse(context.Background(), &DuplicateCourseParams{
+				CourseID:   1111,
+				FullName:   "MATH 1111 Introduction to Math",
+				ShortName:  "MATH 1111 2021S",
+				CategoryID: 3,
+				Options:    []*CourseOption{{Name: "users", Value: "0"}},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DuplicateCourse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("DuplicateCourse() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_courseAPI_DuplicateCourse_visible(t *testing.T) {
+	t.Parallel()
+
+	hidden := false
+	tests := []struct {
+		name        string
+		visible     *bool
+		wantVisible []string
+	}{
+		{name: "Not set", visible: nil, wantVisible: nil},
+		{name: "Hidden", visible: &hidden, wantVisible: []string{"0"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotQuery url.Values
+			h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				gotQuery = r.Form
+				fmt.Fprintln(w, `{"id":2222,"shortname":"MATH 1111 2021S"}`)
+			})
+			s := httptest.NewServer(h)
+			defer s.Close()
+			apiURL, _ := url.Parse(s.URL)
+			c := &courseAPI{&apiClient{httpClient: http.DefaultClient, apiURL: apiURL}}
+
+			if _, err := c.DuplicateCourse(context.Background(), &DuplicateCourseParams{CourseID: 1111, Visible: tt.visible}); err != nil {
+				t.Fatalf("DuplicateCourse() error = %v", err)
+			}
+			if diff := cmp.Diff(gotQuery["visible"], tt.wantVisible); diff != "" {
+				t.Errorf("DuplicateCourse() visible (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_courseAPI_ImportCourse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: "null",
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"nopermissions","message":"Sorry, but you do not currently have permissions to do that"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := mockCourseAPI(t, tt.response)
+			if err := c.ImportCourse(context.Background(), &ImportCourseParams{ImportFrom: 1111, ImportTo: 2222}); (err != nil) != tt.wantErr {
+				t.Errorf("ImportCourse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_courseAPI_DeleteCourses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"warnings":[]}`,
+		},
+		{
+			name:     "Warning response",
+			response: `{"warnings":[{"item":"course","itemid":2222,"warningcode":"unknowncourseidnumber","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := mockCourseAPI(t, tt.response)
+			if err := c.DeleteCourses(context.Background(), []int{2222}); (err != nil) != tt.wantErr {
+				t.Errorf("DeleteCourses() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_courseAPI_CreateCategories(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		want     []*CourseCategory
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `[{"id":4,"name":"2021 Spring"}]`,
+			want:     []*CourseCategory{{ID: 4, Name: "2021 Spring"}},
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"categoryidnumbertaken","message":"ID number is already used for another category"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := mockCourseAPI(t, tt.response)
+			got, err := c.CreateCategories(context.Background(), []*CreateCategoryParams{{Name: "2021 Spring", IDNumber: "2021S"}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateCategories() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("CreateCategories() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_courseAPI_UpdateCategories(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: "null",
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"unknowncategory","message":"Unknown category"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := mockCourseAPI(t, tt.response)
+			if err := c.UpdateCategories(context.Background(), []*UpdateCategoryParams{{ID: 4, Name: "2021 Spring"}}); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateCategories() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mockCourseAPI(t *testing.T, response string) *courseAPI {
+	t.Helper()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, response)
+	})
+	s := httptest.NewServer(h)
+	apiURL, _ := url.Parse(s.URL)
+	return &courseAPI{
+		&apiClient{
+			httpClient: http.DefaultClient,
+			apiURL:     apiURL,
+		},
+	}
+}
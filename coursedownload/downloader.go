@@ -0,0 +1,271 @@
+// Package coursedownload mirrors the files of the courses to disk in the "Course/Section/Module/" layout.
+//
+// The files are downloaded concurrently through the authenticated pluginfile endpoint, and recorded in a manifest
+// in the directory. The unchanged files are skipped by comparing their time modified and size,
+// so that an interrupted download can be resumed by running it again.
+//
+//	downloader := coursedownload.New(client, "./moodle", coursedownload.WithConcurrency(8))
+//	results, err := downloader.Download(ctx)
+package coursedownload
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/k-yomo/moodle"
+)
+
+// DefaultModNames are the module types whose files are downloaded by default
+var DefaultModNames = []string{"resource", "folder", "page"}
+
+// Status represents the result of a file
+type Status string
+
+const (
+	StatusDownloaded Status = "downloaded"
+	StatusSkipped    Status = "skipped"
+	StatusFailed     Status = "failed"
+)
+
+// File is a file of a course module
+type File struct {
+	CourseID int
+	ModuleID int
+	// Path is the path relative to the download directory, e.g. "MATH1111/01 Week 1/Slides/slides.pdf"
+	Path         string
+	URL          string
+	Size         int64
+	TimeModified *time.Time
+}
+
+// Result is the result of a file, Err is set if the status is StatusFailed
+type Result struct {
+	*File
+	Status Status
+	Err    error
+}
+
+// Downloader downloads the files of the courses
+type Downloader struct {
+	client      *moodle.Client
+	dir         string
+	courseIDs   []int
+	concurrency int
+	overwrite   bool
+	modNames    map[string]bool
+}
+
+// New creates a downloader to the directory
+func New(client *moodle.Client, dir string, opt ...Option) *Downloader {
+	opts := newDefaultDownloaderOptions()
+	for _, o := range opt {
+		o.apply(opts)
+	}
+	d := &Downloader{
+		client:      client,
+		dir:         dir,
+		courseIDs:   opts.courseIDs,
+		concurrency: opts.concurrency,
+		overwrite:   opts.overwrite,
+	}
+	if d.concurrency < 1 {
+		d.concurrency = 1
+	}
+	if len(opts.modNames) > 0 {
+		d.modNames = map[string]bool{}
+		for _, modName := range opts.modNames {
+			d.modNames[modName] = true
+		}
+	}
+	return d
+}
+
+// Files returns the files of the courses to be downloaded
+func (d *Downloader) Files(ctx context.Context) ([]*File, error) {
+	courses, err := d.courses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var files []*File
+	for _, course := range courses {
+		sections, err := d.client.CourseAPI.GetContents(ctx, course.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get contents of course %d: %w", course.ID, err)
+		}
+		courseDir := course.ShortName
+		if courseDir == "" {
+			courseDir = course.FullName
+		}
+		courseDir = SanitizeFileName(courseDir)
+		for _, section := range sections {
+			for _, module := range section.Modules {
+				if d.modNames != nil && !d.modNames[module.ModName] {
+					continue
+				}
+				for _, content := range module.Contents {
+					if content.Type != "file" {
+						continue
+					}
+					files = append(files, &File{
+						CourseID:     course.ID,
+						ModuleID:     module.ID,
+						Path:         filepath.Join(courseDir, ContentPath(section, module, content)),
+						URL:          content.FileURL,
+						Size:         content.FileSize,
+						TimeModified: content.TimeModified,
+					})
+				}
+			}
+		}
+	}
+	return files, nil
+}
+
+func (d *Downloader) courses(ctx context.Context) ([]*moodle.Course, error) {
+	courses, err := d.client.CourseAPI.GetEnrolledCoursesByTimelineClassification(ctx, moodle.CourseClassificationAll)
+	if err != nil {
+		return nil, fmt.Errorf("get courses: %w", err)
+	}
+	if len(d.courseIDs) == 0 {
+		return courses, nil
+	}
+	coursesByID := map[int]*moodle.Course{}
+	for _, course := range courses {
+		coursesByID[course.ID] = course
+	}
+	filtered := make([]*moodle.Course, 0, len(d.courseIDs))
+	for _, courseID := range d.courseIDs {
+		course, ok := coursesByID[courseID]
+		if !ok {
+			return nil, fmt.Errorf("course %d is not enrolled", courseID)
+		}
+		filtered = append(filtered, course)
+	}
+	return filtered, nil
+}
+
+// Download downloads the changed files with the worker pool and returns the results in the order of Files.
+// A failed file doesn't stop the others, and an error is returned after all the files are processed.
+func (d *Downloader) Download(ctx context.Context) ([]*Result, error) {
+	manifest, err := LoadManifest(d.dir)
+	if err != nil {
+		return nil, fmt.Errorf("load manifest: %w", err)
+	}
+	files, err := d.Files(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	results := make([]*Result, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < d.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = d.download(ctx, files[i], manifest, &mu)
+			}
+		}()
+	}
+loop:
+	for i := range files {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := manifest.save(d.dir); err != nil {
+		return nil, fmt.Errorf("save manifest: %w", err)
+	}
+	var processed []*Result
+	var failed []*Result
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		processed = append(processed, result)
+		if result.Status == StatusFailed {
+			failed = append(failed, result)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return processed, err
+	}
+	if len(failed) > 0 {
+		return processed, fmt.Errorf("failed to download %d of %d files, %s: %w", len(failed), len(files), failed[0].Path, failed[0].Err)
+	}
+	return processed, nil
+}
+
+// download downloads the file unless it's unchanged, and records it in the manifest.
+// The manifest is saved after each download so that the downloads can be resumed.
+func (d *Downloader) download(ctx context.Context, file *File, manifest *Manifest, mu *sync.Mutex) *Result {
+	key := filepath.ToSlash(file.Path)
+	path := filepath.Join(d.dir, file.Path)
+	mu.Lock()
+	entry := manifest.Files[key]
+	mu.Unlock()
+
+	if !d.overwrite && isUnchanged(path, file, entry) {
+		if entry == nil {
+			mu.Lock()
+			manifest.Files[key] = newManifestEntry(file)
+			mu.Unlock()
+		}
+		return &Result{File: file, Status: StatusSkipped}
+	}
+
+	if err := DownloadToFile(ctx, d.client, file.URL, path); err != nil {
+		return &Result{File: file, Status: StatusFailed, Err: err}
+	}
+	// the modification time is set to detect changes even without the manifest
+	if file.TimeModified != nil {
+		if err := os.Chtimes(path, *file.TimeModified, *file.TimeModified); err != nil {
+			return &Result{File: file, Status: StatusFailed, Err: err}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	manifest.Files[key] = newManifestEntry(file)
+	if err := manifest.save(d.dir); err != nil {
+		return &Result{File: file, Status: StatusFailed, Err: fmt.Errorf("save manifest: %w", err)}
+	}
+	return &Result{File: file, Status: StatusDownloaded}
+}
+
+func newManifestEntry(file *File) *ManifestEntry {
+	return &ManifestEntry{
+		CourseID:     file.CourseID,
+		ModuleID:     file.ModuleID,
+		Size:         file.Size,
+		TimeModified: file.TimeModified,
+		DownloadedAt: time.Now(),
+	}
+}
+
+// isUnchanged reports whether the file on disk has the same size and time modified as the file on the site.
+// The time modified is compared with the manifest entry or the modification time of the file on disk.
+func isUnchanged(path string, file *File, entry *ManifestEntry) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != file.Size {
+		return false
+	}
+	if file.TimeModified == nil {
+		return true
+	}
+	if entry != nil && entry.Size == file.Size && entry.TimeModified != nil && entry.TimeModified.Equal(*file.TimeModified) {
+		return true
+	}
+	return info.ModTime().Equal(*file.TimeModified)
+}
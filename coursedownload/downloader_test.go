@@ -0,0 +1,214 @@
+package coursedownload
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k-yomo/moodle"
+)
+
+// testSite serves the course contents and the files, whose time modified can be changed
+type testSite struct {
+	*httptest.Server
+	mu           sync.Mutex
+	timeModified int64
+	failing      bool
+	downloads    []string
+}
+
+func newTestSite(t *testing.T) *testSite {
+	t.Helper()
+	site := &testSite{timeModified: 1600000000}
+	site.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		site.mu.Lock()
+		defer site.mu.Unlock()
+		if filepath.Dir(r.URL.Path) != "/webservice/rest" {
+			if r.URL.Query().Get("token") != "test" {
+				fmt.Fprint(w, `{"error":"Invalid token","errorcode":"invalidtoken"}`)
+				return
+			}
+			if site.failing && filepath.Base(r.URL.Path) == "notes.txt" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			site.downloads = append(site.downloads, filepath.Base(r.URL.Path))
+			fmt.Fprint(w, filepath.Base(r.URL.Path))
+			return
+		}
+		switch r.URL.Query().Get("wsfunction") {
+		case "core_course_get_enrolled_courses_by_timeline_classification":
+			fmt.Fprint(w, `{"courses":[{"id":1111,"fullname":"Math","shortname":"MATH 1111"},{"id":2222,"fullname":"Art","shortname":"ART/2222"}]}`)
+		case "core_course_get_contents":
+			if r.URL.Query().Get("courseid") == "2222" {
+				fmt.Fprint(w, `[{"id":20,"name":"General","section":0,"modules":[{"id":3,"name":"News","modname":"forum","contents":[]}]}]`)
+				return
+			}
+			fmt.Fprintf(w, `[{"id":10,"name":"Week 1: Intro","section":1,"modules":[
+{"id":1,"name":"Slides","modname":"resource","contents":[{"type":"file","filename":"slides.pdf","filepath":"/","filesize":10,"fileurl":"%[1]s/webservice/pluginfile.php/1/mod_resource/content/1/slides.pdf","timemodified":%[2]d}]},
+{"id":2,"name":"Materials","modname":"folder","contents":[
+	{"type":"file","filename":"notes.txt","filepath":"/week1/","filesize":9,"fileurl":"%[1]s/webservice/pluginfile.php/2/mod_folder/content/0/week1/notes.txt","timemodified":1600000000},
+	{"type":"url","filename":"Site","fileurl":"https://example.com"}
+]},
+{"id":4,"name":"Assignment","modname":"assign","contents":[{"type":"file","filename":"brief.pdf","filepath":"/","filesize":9,"fileurl":"%[1]s/webservice/pluginfile.php/4/brief.pdf","timemodified":1600000000}]}
+]}]`, site.URL, site.timeModified)
+		default:
+			fmt.Fprint(w, `{"exception":"moodle_exception","errorcode":"invalidrecord","message":"not found"}`)
+		}
+	}))
+	t.Cleanup(site.Close)
+	return site
+}
+
+func (s *testSite) takeDownloads() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	downloads := s.downloads
+	s.downloads = nil
+	sort.Strings(downloads)
+	return downloads
+}
+
+func (s *testSite) update(fn func(s *testSite)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s)
+}
+
+func statuses(results []*Result) map[string]Status {
+	m := map[string]Status{}
+	for _, result := range results {
+		m[filepath.ToSlash(result.Path)] = result.Status
+	}
+	return m
+}
+
+func TestDownloader_Download(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	site := newTestSite(t)
+	serviceURL, _ := url.Parse(site.URL)
+	client, err := moodle.NewClient(ctx, serviceURL, "test")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	dir := t.TempDir()
+	downloader := New(client, dir, WithConcurrency(3))
+
+	// the failed file doesn't stop the others
+	site.update(func(s *testSite) { s.failing = true })
+	results, err := downloader.Download(ctx)
+	if err == nil {
+		t.Errorf("Download() error = nil, want error of the failed file")
+	}
+	want := map[string]Status{
+		"MATH 1111/01 Week 1_ Intro/Slides/slides.pdf":         StatusDownloaded,
+		"MATH 1111/01 Week 1_ Intro/Materials/week1/notes.txt": StatusFailed,
+	}
+	if diff := cmp.Diff(statuses(results), want); diff != "" {
+		t.Errorf("Download() statuses (-got, +want)\n%s", diff)
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, "MATH 1111", "01 Week 1_ Intro", "Slides", "slides.pdf")); string(data) != "slides.pdf" {
+		t.Errorf("downloaded file = %q, want %q", data, "slides.pdf")
+	}
+	site.takeDownloads()
+
+	// resumed
+	site.update(func(s *testSite) { s.failing = false })
+	results, err = downloader.Download(ctx)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	want = map[string]Status{
+		"MATH 1111/01 Week 1_ Intro/Slides/slides.pdf":         StatusSkipped,
+		"MATH 1111/01 Week 1_ Intro/Materials/week1/notes.txt": StatusDownloaded,
+	}
+	if diff := cmp.Diff(statuses(results), want); diff != "" {
+		t.Errorf("Download() statuses after resume (-got, +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(site.takeDownloads(), []string{"notes.txt"}); diff != "" {
+		t.Errorf("downloads after resume (-got, +want)\n%s", diff)
+	}
+
+	// the file modified on the site is downloaded again
+	site.update(func(s *testSite) { s.timeModified++ })
+	results, err = downloader.Download(ctx)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if got := statuses(results)["MATH 1111/01 Week 1_ Intro/Slides/slides.pdf"]; got != StatusDownloaded {
+		t.Errorf("Download() status of modified file = %v, want %v", got, StatusDownloaded)
+	}
+	if diff := cmp.Diff(site.takeDownloads(), []string{"slides.pdf"}); diff != "" {
+		t.Errorf("downloads of modified file (-got, +want)\n%s", diff)
+	}
+
+	manifest, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+	if len(manifest.Files) != 2 {
+		t.Errorf("manifest files = %v, want 2 files", manifest.Files)
+	}
+	entry := manifest.Files["MATH 1111/01 Week 1_ Intro/Slides/slides.pdf"]
+	if entry == nil || entry.Size != 10 || entry.TimeModified.Unix() != 1600000001 {
+		t.Errorf("manifest entry = %+v, want size 10 and time modified 1600000001", entry)
+	}
+
+	// the modification time on disk is used without the manifest
+	if err := os.Remove(filepath.Join(dir, ManifestFileName)); err != nil {
+		t.Fatalf("remove manifest error = %v", err)
+	}
+	results, err = New(client, dir, WithCourseIDs(1111)).Download(ctx)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	for path, status := range statuses(results) {
+		if status != StatusSkipped {
+			t.Errorf("Download() status of %s = %v, want %v", path, status, StatusSkipped)
+		}
+	}
+}
+
+func TestDownloader_Files(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	site := newTestSite(t)
+	serviceURL, _ := url.Parse(site.URL)
+	client, err := moodle.NewClient(ctx, serviceURL, "test")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	files, err := New(client, t.TempDir(), WithModNames()).Files(ctx)
+	if err != nil {
+		t.Fatalf("Files() error = %v", err)
+	}
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, filepath.ToSlash(file.Path))
+	}
+	want := []string{
+		"MATH 1111/01 Week 1_ Intro/Slides/slides.pdf",
+		"MATH 1111/01 Week 1_ Intro/Materials/week1/notes.txt",
+		"MATH 1111/01 Week 1_ Intro/Assignment/brief.pdf",
+	}
+	if diff := cmp.Diff(paths, want); diff != "" {
+		t.Errorf("Files() paths (-got, +want)\n%s", diff)
+	}
+
+	if _, err := New(client, t.TempDir(), WithCourseIDs(3333)).Files(ctx); err == nil {
+		t.Errorf("Files() error = nil for the course not enrolled")
+	}
+}
@@ -0,0 +1,72 @@
+package coursedownload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k-yomo/moodle"
+)
+
+// FileDownloader is implemented by moodle.Client
+type FileDownloader interface {
+	DownloadFile(ctx context.Context, fileURL string, w io.Writer) (int64, error)
+}
+
+// DownloadToFile downloads the file to a temporary file and renames it, so that a partial file is not left
+func DownloadToFile(ctx context.Context, client FileDownloader, fileURL, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := client.DownloadFile(ctx, fileURL, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ContentPath returns the relative path of the content in the "Section/Module/" layout,
+// e.g. "01 Week 1/Slides/slides.pdf". The file path of the content in the module is kept.
+// The module ID is appended to the module directory if another module in the section has the same name, e.g. "Slides (12)".
+func ContentPath(section *moodle.CourseSection, module *moodle.CourseModule, content *moodle.CourseModuleContent) string {
+	sectionDir := fmt.Sprintf("%02d %s", section.Section, SanitizeFileName(section.Name))
+	return filepath.Join(sectionDir, moduleDirName(section, module), filepath.FromSlash(filepath.Clean("/"+content.FilePath)), SanitizeFileName(content.FileName))
+}
+
+// moduleDirName returns the sanitized name of the module, which is compared case-insensitively for case-insensitive file systems
+func moduleDirName(section *moodle.CourseSection, module *moodle.CourseModule) string {
+	name := SanitizeFileName(module.Name)
+	for _, other := range section.Modules {
+		if other.ID != module.ID && strings.EqualFold(SanitizeFileName(other.Name), name) {
+			return fmt.Sprintf("%s (%d)", name, module.ID)
+		}
+	}
+	return name
+}
+
+// SanitizeFileName replaces the characters not allowed in file names on common platforms
+func SanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
@@ -0,0 +1,50 @@
+package coursedownload
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/k-yomo/moodle"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Week 1: Intro/Overview", want: "Week 1_ Intro_Overview"},
+		{name: "..", want: "_"},
+		{name: " slides.pdf ", want: "slides.pdf"},
+	}
+	for _, tt := range tests {
+		if got := SanitizeFileName(tt.name); got != tt.want {
+			t.Errorf("SanitizeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestContentPath(t *testing.T) {
+	t.Parallel()
+
+	slides := &moodle.CourseModule{ID: 1, Name: "Slides"}
+	duplicatedSlides := &moodle.CourseModule{ID: 2, Name: "slides"}
+	notes := &moodle.CourseModule{ID: 3, Name: "Notes"}
+	section := &moodle.CourseSection{Section: 1, Name: "Week 1", Modules: []*moodle.CourseModule{slides, duplicatedSlides, notes}}
+	content := &moodle.CourseModuleContent{FilePath: "/", FileName: "slides.pdf"}
+
+	tests := []struct {
+		module *moodle.CourseModule
+		want   string
+	}{
+		{module: slides, want: "01 Week 1/Slides (1)/slides.pdf"},
+		{module: duplicatedSlides, want: "01 Week 1/slides (2)/slides.pdf"},
+		{module: notes, want: "01 Week 1/Notes/slides.pdf"},
+	}
+	for _, tt := range tests {
+		if got := filepath.ToSlash(ContentPath(section, tt.module, content)); got != tt.want {
+			t.Errorf("ContentPath() of module %d = %q, want %q", tt.module.ID, got, tt.want)
+		}
+	}
+}
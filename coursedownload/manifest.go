@@ -0,0 +1,71 @@
+package coursedownload
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestFileName is the name of the manifest written in the download directory
+const ManifestFileName = ".moodle-manifest.json"
+
+// Manifest records the downloaded files to skip unchanged files and resume interrupted downloads
+type Manifest struct {
+	// Files are the downloaded files by the slash separated path relative to the download directory
+	Files map[string]*ManifestEntry `json:"files"`
+}
+
+// ManifestEntry is a downloaded file
+type ManifestEntry struct {
+	CourseID     int        `json:"courseId"`
+	ModuleID     int        `json:"moduleId"`
+	Size         int64      `json:"size"`
+	TimeModified *time.Time `json:"timeModified,omitempty"`
+	DownloadedAt time.Time  `json:"downloadedAt"`
+}
+
+// LoadManifest loads the manifest in the directory, an empty manifest is returned if it doesn't exist
+func LoadManifest(dir string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, ManifestFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Manifest{Files: map[string]*ManifestEntry{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Files == nil {
+		manifest.Files = map[string]*ManifestEntry{}
+	}
+	return &manifest, nil
+}
+
+// save writes the manifest to a temporary file and renames it not to leave a broken file
+func (m *Manifest) save(dir string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, ManifestFileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(dir, ManifestFileName))
+}
@@ -0,0 +1,63 @@
+package coursedownload
+
+type downloaderOptions struct {
+	courseIDs   []int
+	concurrency int
+	overwrite   bool
+	modNames    []string
+}
+
+func newDefaultDownloaderOptions() *downloaderOptions {
+	return &downloaderOptions{
+		concurrency: 4,
+		modNames:    DefaultModNames,
+	}
+}
+
+// Option is a option to change downloader configuration.
+type Option interface {
+	apply(*downloaderOptions)
+}
+
+type optionFunc struct {
+	f func(opts *downloaderOptions)
+}
+
+func (o *optionFunc) apply(opts *downloaderOptions) {
+	o.f(opts)
+}
+
+func newOptionFunc(f func(opts *downloaderOptions)) *optionFunc {
+	return &optionFunc{
+		f: f,
+	}
+}
+
+// WithCourseIDs limits the courses to download, all the enrolled courses are downloaded by default
+func WithCourseIDs(courseIDs ...int) Option {
+	return newOptionFunc(func(opts *downloaderOptions) {
+		opts.courseIDs = courseIDs
+	})
+}
+
+// WithConcurrency sets the number of files downloaded concurrently, 4 by default
+func WithConcurrency(concurrency int) Option {
+	return newOptionFunc(func(opts *downloaderOptions) {
+		opts.concurrency = concurrency
+	})
+}
+
+// WithOverwrite downloads all the files even if they are unchanged
+func WithOverwrite() Option {
+	return newOptionFunc(func(opts *downloaderOptions) {
+		opts.overwrite = true
+	})
+}
+
+// WithModNames sets the module types whose files are downloaded (e.g. "resource", "assign"),
+// DefaultModNames by default. The files of all the modules are downloaded if no name is given.
+func WithModNames(modNames ...string) Option {
+	return newOptionFunc(func(opts *downloaderOptions) {
+		opts.modNames = modNames
+	})
+}
@@ -0,0 +1,603 @@
+package coursesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k-yomo/moodle"
+)
+
+// Action represents the action of a change
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+)
+
+// ResourceType represents the type of the resource a change is applied to
+type ResourceType string
+
+const (
+	ResourceTypeCategory    ResourceType = "category"
+	ResourceTypeCourse      ResourceType = "course"
+	ResourceTypeGroup       ResourceType = "group"
+	ResourceTypeEnrolment   ResourceType = "enrolment"
+	ResourceTypeGroupMember ResourceType = "group member"
+)
+
+// Change represents a change to be applied to the site
+type Change struct {
+	Action       Action
+	ResourceType ResourceType
+	// Address identifies the resource, e.g. "MATH1111" for a course and "MATH1111/Team A/alice" for a group member
+	Address string
+	Diffs   []*FieldDiff
+
+	apply func(ctx context.Context, client *moodle.Client, s *state) error
+}
+
+// FieldDiff represents a field changed by a change, Old is empty for creation
+type FieldDiff struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// String returns the change in the format of "+ course "MATH1111"" followed by the field diffs
+func (c *Change) String() string {
+	symbol := "+"
+	if c.Action == ActionUpdate {
+		symbol = "~"
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s %s %q", symbol, c.ResourceType, c.Address)
+	for _, diff := range c.Diffs {
+		if c.Action == ActionCreate {
+			fmt.Fprintf(b, "\n    %s: %q", diff.Field, diff.New)
+		} else {
+			fmt.Fprintf(b, "\n    %s: %q -> %q", diff.Field, diff.Old, diff.New)
+		}
+	}
+	return b.String()
+}
+
+// Plan represents the changes to reconcile the site with the spec.
+// A plan should be applied only once, compute a new plan to apply the spec again.
+type Plan struct {
+	Changes []*Change
+
+	state *state
+}
+
+// state holds the IDs of the resources, which are filled as the resources are created
+type state struct {
+	categoryIDs map[string]int
+	courseIDs   map[string]int
+	groupIDs    map[string]int
+	userIDs     map[string]int
+}
+
+// IsEmpty returns true if the site matches the spec
+func (p *Plan) IsEmpty() bool {
+	return len(p.Changes) == 0
+}
+
+// Summary returns the numbers of the changes, e.g. "Plan: 2 to create, 1 to update."
+func (p *Plan) Summary() string {
+	if p.IsEmpty() {
+		return "No changes. The site matches the spec."
+	}
+	var toCreate, toUpdate int
+	for _, change := range p.Changes {
+		switch change.Action {
+		case ActionCreate:
+			toCreate++
+		case ActionUpdate:
+			toUpdate++
+		}
+	}
+	return fmt.Sprintf("Plan: %d to create, %d to update.", toCreate, toUpdate)
+}
+
+// WriteTo writes the changes and the summary in human readable format
+func (p *Plan) WriteTo(w io.Writer) (int64, error) {
+	b := &strings.Builder{}
+	for _, change := range p.Changes {
+		b.WriteString(change.String())
+		b.WriteString("\n")
+	}
+	if !p.IsEmpty() {
+		b.WriteString("\n")
+	}
+	b.WriteString(p.Summary())
+	b.WriteString("\n")
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Apply applies the changes in order, and stops at the first failure.
+// The changes applied before the failure are kept, so computing a plan again resumes from the failed change.
+func (p *Plan) Apply(ctx context.Context, client *moodle.Client) error {
+	for _, change := range p.Changes {
+		if err := change.apply(ctx, client, p.state); err != nil {
+			return fmt.Errorf("%s %s %q: %w", change.Action, change.ResourceType, change.Address, err)
+		}
+	}
+	return nil
+}
+
+// NewPlan computes the changes to reconcile the site with the spec.
+// Users in the enrolments must exist on the site, and the parent categories not in the spec must exist on the site.
+func NewPlan(ctx context.Context, client *moodle.Client, spec *Spec) (*Plan, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	loc, _ := spec.location()
+	p := &planner{
+		client: client,
+		spec:   spec,
+		loc:    loc,
+		plan: &Plan{
+			state: &state{
+				categoryIDs: make(map[string]int),
+				courseIDs:   make(map[string]int),
+				groupIDs:    make(map[string]int),
+				userIDs:     make(map[string]int),
+			},
+		},
+		plannedCategories: make(map[string]bool),
+	}
+
+	if err := p.resolveUsers(ctx); err != nil {
+		return nil, err
+	}
+	for _, category := range spec.Categories {
+		if err := p.planCategory(ctx, category); err != nil {
+			return nil, err
+		}
+	}
+	for _, course := range spec.Courses {
+		if err := p.planCourse(ctx, course); err != nil {
+			return nil, err
+		}
+	}
+	return p.plan, nil
+}
+
+type planner struct {
+	client *moodle.Client
+	spec   *Spec
+	loc    *time.Location
+	plan   *Plan
+	// plannedCategories are the ID numbers of the categories to be created
+	plannedCategories map[string]bool
+}
+
+func (p *planner) addChange(change *Change) {
+	p.plan.Changes = append(p.plan.Changes, change)
+}
+
+// userLookupBatchSize is the number of usernames looked up in one request
+const userLookupBatchSize = 50
+
+func (p *planner) resolveUsers(ctx context.Context) error {
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, course := range p.spec.Courses {
+		for _, enrolment := range course.Enrolments {
+			if !seen[enrolment.Username] {
+				seen[enrolment.Username] = true
+				usernames = append(usernames, enrolment.Username)
+			}
+		}
+	}
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	// the usernames are looked up in chunks, so that large rosters fit in the requests
+	for start := 0; start < len(usernames); start += userLookupBatchSize {
+		end := start + userLookupBatchSize
+		if end > len(usernames) {
+			end = len(usernames)
+		}
+		users, err := p.client.UserAPI.GetUsersByField(ctx, moodle.UserFieldUsername, usernames[start:end])
+		if err != nil {
+			return fmt.Errorf("get users: %w", err)
+		}
+		for _, user := range users {
+			p.plan.state.userIDs[user.Username] = user.ID
+		}
+	}
+	var unknown []string
+	for _, username := range usernames {
+		if _, ok := p.plan.state.userIDs[username]; !ok {
+			unknown = append(unknown, username)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("users not found: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// findCategory returns the category with the ID number on the site, nil if it doesn't exist
+func (p *planner) findCategory(ctx context.Context, idNumber string) (*moodle.CourseCategory, error) {
+	categories, err := p.client.CourseAPI.GetCategories(ctx, []*moodle.CategorySearchCriterion{{Key: "idnumber", Value: idNumber}}, false)
+	if err != nil {
+		return nil, fmt.Errorf("get category %q: %w", idNumber, err)
+	}
+	if len(categories) == 0 {
+		return nil, nil
+	}
+	return categories[0], nil
+}
+
+// resolveCategory makes sure the category exists on the site or is planned to be created
+func (p *planner) resolveCategory(ctx context.Context, idNumber string) error {
+	if idNumber == "" || p.plannedCategories[idNumber] {
+		return nil
+	}
+	if _, ok := p.plan.state.categoryIDs[idNumber]; ok {
+		return nil
+	}
+	category, err := p.findCategory(ctx, idNumber)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		return fmt.Errorf("category %q is neither in the spec before the reference nor on the site", idNumber)
+	}
+	p.plan.state.categoryIDs[idNumber] = category.ID
+	return nil
+}
+
+func (p *planner) planCategory(ctx context.Context, spec *CategorySpec) error {
+	if err := p.resolveCategory(ctx, spec.Parent); err != nil {
+		return err
+	}
+	existing, err := p.findCategory(ctx, spec.IDNumber)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		p.plannedCategories[spec.IDNumber] = true
+		p.addChange(&Change{
+			Action:       ActionCreate,
+			ResourceType: ResourceTypeCategory,
+			Address:      spec.IDNumber,
+			Diffs:        creationDiffs("name", spec.Name, "parent", spec.Parent, "description", spec.Description),
+			apply: func(ctx context.Context, client *moodle.Client, s *state) error {
+				categories, err := client.CourseAPI.CreateCategories(ctx, []*moodle.CreateCategoryParams{
+					{
+						Name:        spec.Name,
+						Parent:      s.categoryIDs[spec.Parent],
+						IDNumber:    spec.IDNumber,
+						Description: spec.Description,
+					},
+				})
+				if err != nil {
+					return err
+				}
+				if len(categories) == 0 {
+					return errors.New("no category is returned")
+				}
+				s.categoryIDs[spec.IDNumber] = categories[0].ID
+				return nil
+			},
+		})
+		return nil
+	}
+
+	p.plan.state.categoryIDs[spec.IDNumber] = existing.ID
+	var diffs []*FieldDiff
+	if existing.Name != spec.Name {
+		diffs = append(diffs, &FieldDiff{Field: "name", Old: existing.Name, New: spec.Name})
+	}
+	parentID, parentKnown := p.plan.state.categoryIDs[spec.Parent]
+	if spec.Parent == "" {
+		parentID, parentKnown = 0, true
+	}
+	if !parentKnown || existing.Parent != parentID {
+		diffs = append(diffs, &FieldDiff{Field: "parent", Old: strconv.Itoa(existing.Parent), New: spec.Parent})
+	}
+	if spec.Description != "" && existing.Description != spec.Description {
+		diffs = append(diffs, &FieldDiff{Field: "description", Old: existing.Description, New: spec.Description})
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+	p.addChange(&Change{
+		Action:       ActionUpdate,
+		ResourceType: ResourceTypeCategory,
+		Address:      spec.IDNumber,
+		Diffs:        diffs,
+		apply: func(ctx context.Context, client *moodle.Client, s *state) error {
+			parent := s.categoryIDs[spec.Parent]
+			return client.CourseAPI.UpdateCategories(ctx, []*moodle.UpdateCategoryParams{
+				{
+					ID:          existing.ID,
+					Name:        spec.Name,
+					Parent:      &parent,
+					Description: spec.Description,
+				},
+			})
+		},
+	})
+	return nil
+}
+
+func (p *planner) planCourse(ctx context.Context, spec *CourseSpec) error {
+	if err := p.resolveCategory(ctx, spec.Category); err != nil {
+		return err
+	}
+	startDate, _ := parseDate(spec.StartDate, p.loc)
+	endDate, _ := parseDate(spec.EndDate, p.loc)
+
+	courses, err := p.client.CourseAPI.GetCoursesByField(ctx, moodle.CourseFieldShortName, spec.ShortName)
+	if err != nil {
+		return fmt.Errorf("get course %q: %w", spec.ShortName, err)
+	}
+
+	if len(courses) == 0 {
+		diffs := creationDiffs(
+			"fullname", spec.FullName,
+			"category", spec.Category,
+			"idnumber", spec.IDNumber,
+			"startdate", spec.StartDate,
+			"enddate", spec.EndDate,
+		)
+		if spec.Visible != nil {
+			diffs = append(diffs, &FieldDiff{Field: "visible", New: strconv.FormatBool(*spec.Visible)})
+		}
+		p.addChange(&Change{
+			Action:       ActionCreate,
+			ResourceType: ResourceTypeCourse,
+			Address:      spec.ShortName,
+			Diffs:        diffs,
+			apply: func(ctx context.Context, client *moodle.Client, s *state) error {
+				courses, err := client.CourseAPI.CreateCourses(ctx, []*moodle.CreateCourseParams{
+					{
+						FullName:   spec.FullName,
+						ShortName:  spec.ShortName,
+						CategoryID: s.categoryIDs[spec.Category],
+						IDNumber:   spec.IDNumber,
+						StartDate:  startDate,
+						EndDate:    endDate,
+						Visible:    spec.Visible,
+					},
+				})
+				if err != nil {
+					return err
+				}
+				if len(courses) == 0 {
+					return errors.New("no course is returned")
+				}
+				s.courseIDs[spec.ShortName] = courses[0].ID
+				return nil
+			},
+		})
+		for _, group := range spec.Groups {
+			p.planGroup(spec, group, nil)
+		}
+		for _, enrolment := range spec.Enrolments {
+			p.planEnrolment(spec, enrolment, nil, nil)
+		}
+		return nil
+	}
+
+	existing := courses[0]
+	p.plan.state.courseIDs[spec.ShortName] = existing.ID
+	p.planCourseUpdate(spec, existing, startDate, endDate)
+
+	groups, err := p.client.GroupAPI.GetCourseGroups(ctx, existing.ID)
+	if err != nil {
+		return fmt.Errorf("get groups of course %q: %w", spec.ShortName, err)
+	}
+	groupsByName := make(map[string]*moodle.Group)
+	for _, group := range groups {
+		groupsByName[group.Name] = group
+	}
+	for _, group := range spec.Groups {
+		p.planGroup(spec, group, groupsByName[group.Name])
+	}
+
+	if len(spec.Enrolments) == 0 {
+		return nil
+	}
+	enrolledUsers, err := p.client.EnrolAPI.GetEnrolledUsers(ctx, existing.ID, nil)
+	if err != nil {
+		return fmt.Errorf("get enrolled users of course %q: %w", spec.ShortName, err)
+	}
+	enrolledUsersByID := make(map[int]*moodle.EnrolledUser)
+	for _, user := range enrolledUsers {
+		enrolledUsersByID[user.ID] = user
+	}
+	for _, enrolment := range spec.Enrolments {
+		p.planEnrolment(spec, enrolment, enrolledUsersByID[p.plan.state.userIDs[enrolment.Username]], groupsByName)
+	}
+	return nil
+}
+
+func (p *planner) planCourseUpdate(spec *CourseSpec, existing *moodle.Course, startDate, endDate *time.Time) {
+	params := &moodle.UpdateCourseParams{ID: existing.ID}
+	var diffs []*FieldDiff
+	if existing.FullName != spec.FullName {
+		params.FullName = spec.FullName
+		diffs = append(diffs, &FieldDiff{Field: "fullname", Old: existing.FullName, New: spec.FullName})
+	}
+	categoryID, categoryKnown := p.plan.state.categoryIDs[spec.Category]
+	if !categoryKnown || existing.CategoryID != categoryID {
+		diffs = append(diffs, &FieldDiff{Field: "category", Old: strconv.Itoa(existing.CategoryID), New: spec.Category})
+	}
+	if spec.IDNumber != "" && existing.IDNumber != spec.IDNumber {
+		params.IDNumber = spec.IDNumber
+		diffs = append(diffs, &FieldDiff{Field: "idnumber", Old: existing.IDNumber, New: spec.IDNumber})
+	}
+	if startDate != nil && !existing.StartDate.Equal(*startDate) {
+		params.StartDate = startDate
+		diffs = append(diffs, &FieldDiff{Field: "startdate", Old: p.formatDate(existing.StartDate), New: spec.StartDate})
+	}
+	if endDate != nil && !existing.EndDate.Equal(*endDate) {
+		params.EndDate = endDate
+		diffs = append(diffs, &FieldDiff{Field: "enddate", Old: p.formatDate(existing.EndDate), New: spec.EndDate})
+	}
+	if spec.Visible != nil && existing.Visible != *spec.Visible {
+		params.Visible = spec.Visible
+		diffs = append(diffs, &FieldDiff{Field: "visible", Old: strconv.FormatBool(existing.Visible), New: strconv.FormatBool(*spec.Visible)})
+	}
+	if len(diffs) == 0 {
+		return
+	}
+
+	p.addChange(&Change{
+		Action:       ActionUpdate,
+		ResourceType: ResourceTypeCourse,
+		Address:      spec.ShortName,
+		Diffs:        diffs,
+		apply: func(ctx context.Context, client *moodle.Client, s *state) error {
+			params.CategoryID = s.categoryIDs[spec.Category]
+			return client.CourseAPI.UpdateCourses(ctx, []*moodle.UpdateCourseParams{params})
+		},
+	})
+}
+
+// planGroup plans the creation of the group if existing is nil
+func (p *planner) planGroup(course *CourseSpec, spec *GroupSpec, existing *moodle.Group) {
+	key := groupKey(course.ShortName, spec.Name)
+	if existing != nil {
+		p.plan.state.groupIDs[key] = existing.ID
+		return
+	}
+	p.addChange(&Change{
+		Action:       ActionCreate,
+		ResourceType: ResourceTypeGroup,
+		Address:      key,
+		Diffs:        creationDiffs("idnumber", spec.IDNumber, "description", spec.Description),
+		apply: func(ctx context.Context, client *moodle.Client, s *state) error {
+			groups, err := client.GroupAPI.CreateGroups(ctx, []*moodle.CreateGroupParams{
+				{
+					CourseID:    s.courseIDs[course.ShortName],
+					Name:        spec.Name,
+					IDNumber:    spec.IDNumber,
+					Description: spec.Description,
+				},
+			})
+			if err != nil {
+				return err
+			}
+			if len(groups) == 0 {
+				return errors.New("no group is returned")
+			}
+			s.groupIDs[key] = groups[0].ID
+			return nil
+		},
+	})
+}
+
+// planEnrolment plans the enrolment and the group memberships of the user.
+// existing is nil if the user is not enrolled, and existingGroups is nil if the course is to be created.
+// The role is assigned to the enrolled user without the role, and the other roles of the user are kept.
+func (p *planner) planEnrolment(course *CourseSpec, spec *EnrolmentSpec, existing *moodle.EnrolledUser, existingGroups map[string]*moodle.Group) {
+	roleID, _ := p.spec.roleID(spec.Role)
+	role := spec.Role
+	if role == "" {
+		role = defaultRole
+	}
+
+	hasRole := false
+	var existingRoles []string
+	memberOf := make(map[int]bool)
+	if existing != nil {
+		for _, r := range existing.Roles {
+			hasRole = hasRole || r.RoleID == roleID
+			if r.ShortName != "" {
+				existingRoles = append(existingRoles, r.ShortName)
+			} else {
+				existingRoles = append(existingRoles, strconv.Itoa(r.RoleID))
+			}
+		}
+		for _, group := range existing.Groups {
+			memberOf[group.ID] = true
+		}
+	}
+
+	address := course.ShortName + "/" + spec.Username
+	if !hasRole {
+		change := &Change{
+			Action:       ActionCreate,
+			ResourceType: ResourceTypeEnrolment,
+			Address:      address,
+			Diffs:        creationDiffs("role", role),
+			apply: func(ctx context.Context, client *moodle.Client, s *state) error {
+				// enrolling the enrolled user assigns the role to the existing enrolment
+				return client.EnrolAPI.ManualEnrolUsers(ctx, []*moodle.ManualEnrolment{
+					{
+						RoleID:   roleID,
+						UserID:   s.userIDs[spec.Username],
+						CourseID: s.courseIDs[course.ShortName],
+					},
+				})
+			},
+		}
+		if existing != nil {
+			change.Action = ActionUpdate
+			change.Diffs = []*FieldDiff{{Field: "role", Old: strings.Join(existingRoles, ","), New: role}}
+		}
+		p.addChange(change)
+	}
+
+	for _, groupName := range spec.Groups {
+		if group, ok := existingGroups[groupName]; ok && memberOf[group.ID] {
+			continue
+		}
+		key := groupKey(course.ShortName, groupName)
+		p.addChange(&Change{
+			Action:       ActionCreate,
+			ResourceType: ResourceTypeGroupMember,
+			Address:      key + "/" + spec.Username,
+			apply: func(ctx context.Context, client *moodle.Client, s *state) error {
+				return client.GroupAPI.AddGroupMembers(ctx, []*moodle.GroupMember{
+					{
+						GroupID: s.groupIDs[key],
+						UserID:  s.userIDs[spec.Username],
+					},
+				})
+			},
+		})
+	}
+}
+
+// formatDate formats the time as YYYY-MM-DD if it's midnight in the location of the spec, empty if it's not set
+func (p *planner) formatDate(t time.Time) string {
+	if t.Unix() <= 0 {
+		return ""
+	}
+	t = t.In(p.loc)
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+func groupKey(courseShortName, groupName string) string {
+	return courseShortName + "/" + groupName
+}
+
+// creationDiffs builds the diffs from pairs of field and value, skipping empty values
+func creationDiffs(fieldValues ...string) []*FieldDiff {
+	var diffs []*FieldDiff
+	for i := 0; i+1 < len(fieldValues); i += 2 {
+		if fieldValues[i+1] != "" {
+			diffs = append(diffs, &FieldDiff{Field: fieldValues[i], New: fieldValues[i+1]})
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,464 @@
+package coursesync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k-yomo/moodle"
+)
+
+// fakeSite is an in-memory site implementing the APIs used by the planner
+type fakeSite struct {
+	moodle.CourseAPI
+	moodle.GroupAPI
+	moodle.EnrolAPI
+	moodle.UserAPI
+
+	nextID        int
+	categories    []*moodle.CourseCategory
+	courses       []*moodle.Course
+	groups        map[int][]*moodle.Group
+	enrolledUsers map[int][]*moodle.EnrolledUser
+	users         []*moodle.User
+	// userLookups are the numbers of the usernames looked up in each request
+	userLookups []int
+}
+
+func newFakeSite() *fakeSite {
+	return &fakeSite{
+		nextID:        100,
+		groups:        make(map[int][]*moodle.Group),
+		enrolledUsers: make(map[int][]*moodle.EnrolledUser),
+	}
+}
+
+func (f *fakeSite) client() *moodle.Client {
+	return &moodle.Client{CourseAPI: f, GroupAPI: f, EnrolAPI: f, UserAPI: f}
+}
+
+func (f *fakeSite) newID() int {
+	f.nextID++
+	return f.nextID
+}
+
+func (f *fakeSite) GetCategories(_ context.Context, criteria []*moodle.CategorySearchCriterion, _ bool) ([]*moodle.CourseCategory, error) {
+	var categories []*moodle.CourseCategory
+	for _, category := range f.categories {
+		if category.IDNumber == criteria[0].Value {
+			categories = append(categories, category)
+		}
+	}
+	return categories, nil
+}
+
+func (f *fakeSite) CreateCategories(_ context.Context, params []*moodle.CreateCategoryParams) ([]*moodle.CourseCategory, error) {
+	category := &moodle.CourseCategory{ID: f.newID(), Name: params[0].Name, IDNumber: params[0].IDNumber, Parent: params[0].Parent}
+	f.categories = append(f.categories, category)
+	return []*moodle.CourseCategory{category}, nil
+}
+
+func (f *fakeSite) UpdateCategories(_ context.Context, params []*moodle.UpdateCategoryParams) error {
+	for _, category := range f.categories {
+		if category.ID == params[0].ID {
+			category.Name = params[0].Name
+			category.Parent = *params[0].Parent
+		}
+	}
+	return nil
+}
+
+func (f *fakeSite) GetCoursesByField(_ context.Context, _ moodle.CourseField, value string) ([]*moodle.Course, error) {
+	var courses []*moodle.Course
+	for _, course := range f.courses {
+		if course.ShortName == value {
+			courses = append(courses, course)
+		}
+	}
+	return courses, nil
+}
+
+func (f *fakeSite) CreateCourses(_ context.Context, params []*moodle.CreateCourseParams) ([]*moodle.CreatedCourse, error) {
+	course := &moodle.Course{
+		ID:         f.newID(),
+		ShortName:  params[0].ShortName,
+		FullName:   params[0].FullName,
+		CategoryID: params[0].CategoryID,
+		IDNumber:   params[0].IDNumber,
+		StartDate:  *params[0].StartDate,
+		EndDate:    time.Unix(0, 0),
+		Visible:    params[0].Visible == nil || *params[0].Visible,
+	}
+	f.courses = append(f.courses, course)
+	return []*moodle.CreatedCourse{{ID: course.ID, ShortName: course.ShortName}}, nil
+}
+
+func (f *fakeSite) UpdateCourses(_ context.Context, params []*moodle.UpdateCourseParams) error {
+	for _, course := range f.courses {
+		if course.ID != params[0].ID {
+			continue
+		}
+		if params[0].FullName != "" {
+			course.FullName = params[0].FullName
+		}
+		if params[0].Visible != nil {
+			course.Visible = *params[0].Visible
+		}
+		course.CategoryID = params[0].CategoryID
+	}
+	return nil
+}
+
+func (f *fakeSite) GetCourseGroups(_ context.Context, courseID int) ([]*moodle.Group, error) {
+	return f.groups[courseID], nil
+}
+
+func (f *fakeSite) CreateGroups(_ context.Context, params []*moodle.CreateGroupParams) ([]*moodle.Group, error) {
+	group := &moodle.Group{ID: f.newID(), CourseID: params[0].CourseID, Name: params[0].Name}
+	f.groups[group.CourseID] = append(f.groups[group.CourseID], group)
+	return []*moodle.Group{group}, nil
+}
+
+func (f *fakeSite) AddGroupMembers(_ context.Context, members []*moodle.GroupMember) error {
+	for courseID, groups := range f.groups {
+		for _, group := range groups {
+			if group.ID != members[0].GroupID {
+				continue
+			}
+			for _, user := range f.enrolledUsers[courseID] {
+				if user.ID == members[0].UserID {
+					user.Groups = append(user.Groups, &moodle.EnrolledUserGroup{ID: group.ID, Name: group.Name})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeSite) GetEnrolledUsers(_ context.Context, courseID int, _ *moodle.GetEnrolledUsersOptions) ([]*moodle.EnrolledUser, error) {
+	return f.enrolledUsers[courseID], nil
+}
+
+func (f *fakeSite) ManualEnrolUsers(_ context.Context, enrolments []*moodle.ManualEnrolment) error {
+	enrolment := enrolments[0]
+	role := &moodle.EnrolledUserRole{RoleID: enrolment.RoleID}
+	for _, user := range f.enrolledUsers[enrolment.CourseID] {
+		if user.ID == enrolment.UserID {
+			user.Roles = append(user.Roles, role)
+			return nil
+		}
+	}
+	f.enrolledUsers[enrolment.CourseID] = append(f.enrolledUsers[enrolment.CourseID], &moodle.EnrolledUser{
+		ID:    enrolment.UserID,
+		Roles: []*moodle.EnrolledUserRole{role},
+	})
+	return nil
+}
+
+func (f *fakeSite) GetUsersByField(_ context.Context, _ moodle.UserField, values []string) ([]*moodle.User, error) {
+	f.userLookups = append(f.userLookups, len(values))
+	var users []*moodle.User
+	for _, user := range f.users {
+		for _, value := range values {
+			if user.Username == value {
+				users = append(users, user)
+			}
+		}
+	}
+	return users, nil
+}
+
+func testSpec() *Spec {
+	return &Spec{
+		Categories: []*CategorySpec{
+			{IDNumber: "2021", Name: "2021"},
+			{IDNumber: "2021S", Name: "2021 Spring", Parent: "2021"},
+		},
+		Courses: []*CourseSpec{
+			{
+				ShortName: "MATH1111-2021S",
+				FullName:  "MATH 1111 Introduction to Math",
+				Category:  "2021S",
+				StartDate: "2021-04-01",
+				Groups:    []*GroupSpec{{Name: "Team A"}},
+				Enrolments: []*EnrolmentSpec{
+					{Username: "alice", Groups: []string{"Team A"}},
+					{Username: "bob", Role: "editingteacher"},
+				},
+			},
+		},
+	}
+}
+
+func TestNewPlan_CreateAndApply(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	site := newFakeSite()
+	site.users = []*moodle.User{{ID: 1, Username: "alice"}, {ID: 2, Username: "bob"}}
+	spec := testSpec()
+
+	plan, err := NewPlan(ctx, site.client(), spec)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	var got []string
+	for _, change := range plan.Changes {
+		got = append(got, string(change.Action)+" "+string(change.ResourceType)+" "+change.Address)
+	}
+	want := []string{
+		"create category 2021",
+		"create category 2021S",
+		"create course MATH1111-2021S",
+		"create group MATH1111-2021S/Team A",
+		"create enrolment MATH1111-2021S/alice",
+		"create group member MATH1111-2021S/Team A/alice",
+		"create enrolment MATH1111-2021S/bob",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("NewPlan() changes (-got, +want)\n%s", diff)
+	}
+	if got, want := plan.Summary(), "Plan: 7 to create, 0 to update."; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+
+	if err := plan.Apply(ctx, site.client()); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if site.categories[1].Parent != site.categories[0].ID {
+		t.Errorf("Apply() parent of 2021S = %d, want %d", site.categories[1].Parent, site.categories[0].ID)
+	}
+	if site.courses[0].CategoryID != site.categories[1].ID {
+		t.Errorf("Apply() category of course = %d, want %d", site.courses[0].CategoryID, site.categories[1].ID)
+	}
+
+	// applying the spec again doesn't change anything
+	plan, err = NewPlan(ctx, site.client(), spec)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if !plan.IsEmpty() {
+		b := &bytes.Buffer{}
+		plan.WriteTo(b)
+		t.Errorf("NewPlan() after Apply() is not empty\n%s", b)
+	}
+}
+
+func TestNewPlan_Update(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	site := newFakeSite()
+	site.users = []*moodle.User{{ID: 1, Username: "alice"}, {ID: 2, Username: "bob"}}
+	site.categories = []*moodle.CourseCategory{
+		{ID: 10, IDNumber: "2021", Name: "2021"},
+		{ID: 11, IDNumber: "2021S", Name: "Spring", Parent: 10},
+	}
+	site.courses = []*moodle.Course{
+		{ID: 20, ShortName: "MATH1111-2021S", FullName: "MATH 1111", CategoryID: 11, StartDate: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC), Visible: true},
+	}
+	site.groups[20] = []*moodle.Group{{ID: 30, CourseID: 20, Name: "Team A"}}
+	site.enrolledUsers[20] = []*moodle.EnrolledUser{
+		{ID: 1, Roles: []*moodle.EnrolledUserRole{{RoleID: 5}}, Groups: []*moodle.EnrolledUserGroup{{ID: 30, Name: "Team A"}}},
+		{ID: 2, Roles: []*moodle.EnrolledUserRole{{RoleID: 5, ShortName: "student"}}},
+	}
+
+	plan, err := NewPlan(ctx, site.client(), testSpec())
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	b := &bytes.Buffer{}
+	if _, err := plan.WriteTo(b); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	want := `~ category "2021S"
+    name: "Spring" -> "2021 Spring"
+~ course "MATH1111-2021S"
+    fullname: "MATH 1111" -> "MATH 1111 Introduction to Math"
+~ enrolment "MATH1111-2021S/bob"
+    role: "student" -> "editingteacher"
+
+Plan: 0 to create, 3 to update.
+`
+	if diff := cmp.Diff(b.String(), want); diff != "" {
+		t.Errorf("WriteTo() (-got, +want)\n%s", diff)
+	}
+
+	if err := plan.Apply(ctx, site.client()); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if site.courses[0].CategoryID != 11 || site.categories[1].Parent != 10 {
+		t.Errorf("Apply() moved course or category, course category = %d, category parent = %d", site.courses[0].CategoryID, site.categories[1].Parent)
+	}
+	if roles := site.enrolledUsers[20][1].Roles; len(roles) != 2 || roles[1].RoleID != 3 {
+		t.Errorf("Apply() roles of bob = %v, want student and editingteacher", roles)
+	}
+}
+
+// emptySite returns no resource for the creations
+type emptySite struct {
+	*fakeSite
+}
+
+func (e *emptySite) CreateCategories(context.Context, []*moodle.CreateCategoryParams) ([]*moodle.CourseCategory, error) {
+	return nil, nil
+}
+
+func (e *emptySite) CreateCourses(context.Context, []*moodle.CreateCourseParams) ([]*moodle.CreatedCourse, error) {
+	return nil, nil
+}
+
+func (e *emptySite) CreateGroups(context.Context, []*moodle.CreateGroupParams) ([]*moodle.Group, error) {
+	return nil, nil
+}
+
+func TestPlan_Apply_EmptyResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		spec    *Spec
+		wantErr string
+	}{
+		{
+			name:    "Category",
+			spec:    &Spec{Categories: []*CategorySpec{{IDNumber: "2021S", Name: "2021 Spring", Parent: "2021"}}},
+			wantErr: `create category "2021S": no category is returned`,
+		},
+		{
+			name:    "Course",
+			spec:    &Spec{Courses: []*CourseSpec{{ShortName: "ART1111", FullName: "Art", Category: "2021"}}},
+			wantErr: `create course "ART1111": no course is returned`,
+		},
+		{
+			name:    "Group",
+			spec:    &Spec{Courses: []*CourseSpec{{ShortName: "MATH1111", FullName: "Math", Category: "2021", Groups: []*GroupSpec{{Name: "Team A"}}}}},
+			wantErr: `create group "MATH1111/Team A": no group is returned`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			site := &emptySite{fakeSite: newFakeSite()}
+			site.categories = []*moodle.CourseCategory{{ID: 10, IDNumber: "2021", Name: "2021"}}
+			site.courses = []*moodle.Course{{ID: 20, ShortName: "MATH1111", FullName: "Math", CategoryID: 10}}
+			client := &moodle.Client{CourseAPI: site, GroupAPI: site, EnrolAPI: site, UserAPI: site}
+			plan, err := NewPlan(ctx, client, tt.spec)
+			if err != nil {
+				t.Fatalf("NewPlan() error = %v", err)
+			}
+			if err := plan.Apply(ctx, client); err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Apply() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewPlan_UnknownUser(t *testing.T) {
+	t.Parallel()
+
+	site := newFakeSite()
+	site.users = []*moodle.User{{ID: 1, Username: "alice"}}
+
+	_, err := NewPlan(context.Background(), site.client(), testSpec())
+	if err == nil || !strings.Contains(err.Error(), "users not found: bob") {
+		t.Errorf("NewPlan() error = %v, want users not found error", err)
+	}
+}
+
+func TestNewPlan_LargeRoster(t *testing.T) {
+	t.Parallel()
+
+	site := newFakeSite()
+	spec := testSpec()
+	course := spec.Courses[0]
+	course.Groups = nil
+	course.Enrolments = nil
+	for i := 0; i < 120; i++ {
+		username := fmt.Sprintf("student%d", i)
+		site.users = append(site.users, &moodle.User{ID: i + 1, Username: username})
+		course.Enrolments = append(course.Enrolments, &EnrolmentSpec{Username: username})
+	}
+
+	plan, err := NewPlan(context.Background(), site.client(), spec)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if diff := cmp.Diff(site.userLookups, []int{50, 50, 20}); diff != "" {
+		t.Errorf("NewPlan() user lookups (-got, +want)\n%s", diff)
+	}
+	if got, want := plan.Summary(), "Plan: 123 to create, 0 to update."; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestNewPlan_CachedClient(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var courses []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Form.Get("wsfunction") {
+		case "core_course_get_categories":
+			fmt.Fprint(w, `[{"id":10,"name":"2021 Spring","idnumber":"2021S","parent":0,"visible":1}]`)
+		case "core_course_get_courses_by_field":
+			fmt.Fprintf(w, `{"courses":[%s],"warnings":[]}`, strings.Join(courses, ","))
+		case "core_course_create_courses":
+			courses = append(courses, fmt.Sprintf(`{"id":100,"shortname":%q,"fullname":%q,"categoryid":%s,"startdate":%s,"enddate":0,"visible":1}`,
+				r.Form.Get("courses[0][shortname]"), r.Form.Get("courses[0][fullname]"), r.Form.Get("courses[0][categoryid]"), r.Form.Get("courses[0][startdate]")))
+			fmt.Fprintf(w, `[{"id":100,"shortname":%q}]`, r.Form.Get("courses[0][shortname]"))
+		case "core_group_get_course_groups":
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Errorf("unexpected call to %s", r.Form.Get("wsfunction"))
+		}
+	}))
+	defer s.Close()
+
+	ctx := context.Background()
+	serviceURL, _ := url.Parse(s.URL)
+	client, err := moodle.NewClient(ctx, serviceURL, "test", moodle.WithCache(moodle.NewCache(moodle.CacheOptions{})))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	spec := &Spec{
+		Courses: []*CourseSpec{
+			{ShortName: "MATH1111-2021S", FullName: "MATH 1111 Introduction to Math", Category: "2021S", StartDate: "2021-04-01"},
+		},
+	}
+
+	plan, err := NewPlan(ctx, client, spec)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if err := plan.Apply(ctx, client); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	// the cached courses are invalidated by the creation, so that the created course is found
+	plan, err = NewPlan(ctx, client, spec)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if !plan.IsEmpty() {
+		b := &bytes.Buffer{}
+		plan.WriteTo(b)
+		t.Errorf("NewPlan() after Apply() is not empty\n%s", b)
+	}
+}
@@ -0,0 +1,246 @@
+// Package coursesync reconciles the course shells of a Moodle site with a declarative spec.
+// The spec describes categories, courses, groups and enrolments, and a plan is computed by diffing it
+// against the live site, so that it can be reviewed before being applied.
+package coursesync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRoleIDs maps the short names of the default roles of Moodle to their IDs
+var DefaultRoleIDs = map[string]int{
+	"manager":        1,
+	"coursecreator":  2,
+	"editingteacher": 3,
+	"teacher":        4,
+	"student":        5,
+	"guest":          6,
+}
+
+const defaultRole = "student"
+
+// Format represents the format of a spec file
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// Spec represents the desired state of the course shells.
+// Resources which are not in the spec are left as they are, so nothing is deleted by applying a spec.
+type Spec struct {
+	// Timezone is the location to interpret the dates in, UTC is used if it's empty
+	Timezone string `json:"timezone" yaml:"timezone"`
+	// Roles maps role short names to role IDs in addition to DefaultRoleIDs
+	Roles      map[string]int  `json:"roles" yaml:"roles"`
+	Categories []*CategorySpec `json:"categories" yaml:"categories"`
+	Courses    []*CourseSpec   `json:"courses" yaml:"courses"`
+}
+
+// CategorySpec represents a course category identified by its ID number
+type CategorySpec struct {
+	IDNumber string `json:"idnumber" yaml:"idnumber"`
+	Name     string `json:"name" yaml:"name"`
+	// Parent is the ID number of the parent category, empty for the top level
+	Parent      string `json:"parent" yaml:"parent"`
+	Description string `json:"description" yaml:"description"`
+}
+
+// CourseSpec represents a course identified by its short name.
+// Empty and nil fields except FullName and Category are not compared with the site.
+type CourseSpec struct {
+	ShortName string `json:"shortname" yaml:"shortname"`
+	FullName  string `json:"fullname" yaml:"fullname"`
+	// Category is the ID number of the category
+	Category string `json:"category" yaml:"category"`
+	IDNumber string `json:"idnumber" yaml:"idnumber"`
+	// StartDate and EndDate are in YYYY-MM-DD or RFC 3339 format
+	StartDate  string           `json:"startdate" yaml:"startdate"`
+	EndDate    string           `json:"enddate" yaml:"enddate"`
+	Visible    *bool            `json:"visible" yaml:"visible"`
+	Groups     []*GroupSpec     `json:"groups" yaml:"groups"`
+	Enrolments []*EnrolmentSpec `json:"enrolments" yaml:"enrolments"`
+}
+
+// GroupSpec represents a group of a course identified by its name
+type GroupSpec struct {
+	Name        string `json:"name" yaml:"name"`
+	IDNumber    string `json:"idnumber" yaml:"idnumber"`
+	Description string `json:"description" yaml:"description"`
+}
+
+// EnrolmentSpec represents a user enrolled in a course with the role
+type EnrolmentSpec struct {
+	Username string `json:"username" yaml:"username"`
+	// Role is the short name of the role (e.g. student, editingteacher), student is used if it's empty
+	Role string `json:"role" yaml:"role"`
+	// Groups are the names of the groups of the course the user belongs to
+	Groups []string `json:"groups" yaml:"groups"`
+}
+
+// LoadSpec reads the spec file, the format is determined by the extension (.json, .yaml or .yml)
+func LoadSpec(path string) (*Spec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	format := FormatYAML
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = FormatJSON
+	}
+	return ParseSpec(data, format)
+}
+
+// ParseSpec parses and validates the spec, unknown fields are rejected to catch typos
+func ParseSpec(data []byte, format Format) (*Spec, error) {
+	spec := &Spec{}
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(spec); err != nil {
+			return nil, fmt.Errorf("parse spec: %w", err)
+		}
+	case FormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(spec); err != nil {
+			return nil, fmt.Errorf("parse spec: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown spec format: %s", format)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// Validate checks the required fields, the uniqueness of the identifiers and the references in the spec
+func (s *Spec) Validate() error {
+	var errs []string
+	addErr := func(format string, a ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, a...))
+	}
+
+	if _, err := s.location(); err != nil {
+		addErr("invalid timezone %q", s.Timezone)
+	}
+
+	categories := make(map[string]bool)
+	for i, category := range s.Categories {
+		switch {
+		case category.IDNumber == "":
+			addErr("categories[%d]: idnumber is required", i)
+		case categories[category.IDNumber]:
+			addErr("categories[%d]: idnumber %q is duplicated", i, category.IDNumber)
+		}
+		if category.Name == "" {
+			addErr("categories[%d]: name is required", i)
+		}
+		if category.Parent != "" && category.Parent == category.IDNumber {
+			addErr("categories[%d]: category can't be the parent of itself", i)
+		}
+		categories[category.IDNumber] = true
+	}
+
+	courses := make(map[string]bool)
+	for i, course := range s.Courses {
+		switch {
+		case course.ShortName == "":
+			addErr("courses[%d]: shortname is required", i)
+		case courses[course.ShortName]:
+			addErr("courses[%d]: shortname %q is duplicated", i, course.ShortName)
+		}
+		courses[course.ShortName] = true
+		if course.FullName == "" {
+			addErr("courses[%d]: fullname is required", i)
+		}
+		if course.Category == "" {
+			addErr("courses[%d]: category is required", i)
+		}
+		for _, date := range []string{course.StartDate, course.EndDate} {
+			if _, err := parseDate(date, time.UTC); err != nil {
+				addErr("courses[%d]: invalid date %q", i, date)
+			}
+		}
+
+		groups := make(map[string]bool)
+		for j, group := range course.Groups {
+			switch {
+			case group.Name == "":
+				addErr("courses[%d].groups[%d]: name is required", i, j)
+			case groups[group.Name]:
+				addErr("courses[%d].groups[%d]: name %q is duplicated", i, j, group.Name)
+			}
+			groups[group.Name] = true
+		}
+
+		usernames := make(map[string]bool)
+		for j, enrolment := range course.Enrolments {
+			switch {
+			case enrolment.Username == "":
+				addErr("courses[%d].enrolments[%d]: username is required", i, j)
+			case usernames[enrolment.Username]:
+				addErr("courses[%d].enrolments[%d]: username %q is duplicated", i, j, enrolment.Username)
+			}
+			usernames[enrolment.Username] = true
+			if _, ok := s.roleID(enrolment.Role); !ok {
+				addErr("courses[%d].enrolments[%d]: unknown role %q", i, j, enrolment.Role)
+			}
+			for _, group := range enrolment.Groups {
+				if !groups[group] {
+					addErr("courses[%d].enrolments[%d]: group %q is not defined in the course", i, j, group)
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New("invalid spec:\n  " + strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+func (s *Spec) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+func (s *Spec) roleID(role string) (int, bool) {
+	if role == "" {
+		role = defaultRole
+	}
+	if id, ok := s.Roles[role]; ok {
+		return id, true
+	}
+	id, ok := DefaultRoleIDs[role]
+	return id, ok
+}
+
+// parseDate parses the date in YYYY-MM-DD or RFC 3339 format, nil is returned for empty string
+func parseDate(date string, loc *time.Location) (*time.Time, error) {
+	if date == "" {
+		return nil, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", date, loc); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
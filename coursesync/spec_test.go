@@ -0,0 +1,141 @@
+package coursesync
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSpec(t *testing.T) {
+	t.Parallel()
+
+	visible := false
+	want := &Spec{
+		Timezone:   "Asia/Tokyo",
+		Categories: []*CategorySpec{{IDNumber: "2021S", Name: "2021 Spring"}},
+		Courses: []*CourseSpec{
+			{
+				ShortName:  "MATH1111-2021S",
+				FullName:   "MATH 1111 Introduction to Math",
+				Category:   "2021S",
+				StartDate:  "2021-04-01",
+				Visible:    &visible,
+				Groups:     []*GroupSpec{{Name: "Team A"}},
+				Enrolments: []*EnrolmentSpec{{Username: "alice", Groups: []string{"Team A"}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		format  Format
+		want    *Spec
+		wantErr string
+	}{
+		{
+			name: "YAML",
+			data: `
+timezone: Asia/Tokyo
+categories:
+  - idnumber: 2021S
+    name: 2021 Spring
+courses:
+  - shortname: MATH1111-2021S
+    fullname: MATH 1111 Introduction to Math
+    category: 2021S
+    startdate: "2021-04-01"
+    visible: false
+    groups:
+      - name: Team A
+    enrolments:
+      - username: alice
+        groups: [Team A]
+`,
+			format: FormatYAML,
+			want:   want,
+		},
+		{
+			name:   "JSON",
+			data:   `{"timezone":"Asia/Tokyo","categories":[{"idnumber":"2021S","name":"2021 Spring"}],"courses":[{"shortname":"MATH1111-2021S","fullname":"MATH 1111 Introduction to Math","category":"2021S","startdate":"2021-04-01","visible":false,"groups":[{"name":"Team A"}],"enrolments":[{"username":"alice","groups":["Team A"]}]}]}`,
+			format: FormatJSON,
+			want:   want,
+		},
+		{
+			name:    "Unknown field",
+			data:    `courses: [{shortname: MATH1111, fulname: typo}]`,
+			format:  FormatYAML,
+			wantErr: "field fulname not found",
+		},
+		{
+			name: "Invalid references",
+			data: `
+courses:
+  - shortname: MATH1111
+    fullname: MATH 1111
+    category: 2021S
+    startdate: 2021/04/01
+    enrolments:
+      - username: alice
+        role: professor
+        groups: [Team B]
+  - shortname: MATH1111
+    fullname: MATH 1111
+    category: 2021S
+`,
+			format:  FormatYAML,
+			wantErr: `courses[0]: invalid date "2021/04/01"`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseSpec([]byte(tt.data), tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseSpec() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSpec() error = %v", err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("ParseSpec() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpec_Validate(t *testing.T) {
+	t.Parallel()
+
+	spec := &Spec{
+		Categories: []*CategorySpec{{IDNumber: "2021S"}, {IDNumber: "2021S", Name: "2021 Spring"}},
+		Courses: []*CourseSpec{
+			{
+				ShortName:  "MATH1111",
+				Category:   "2021S",
+				Enrolments: []*EnrolmentSpec{{Username: "alice", Role: "professor", Groups: []string{"Team B"}}},
+			},
+		},
+	}
+	err := spec.Validate()
+	if err == nil {
+		t.Fatal("Validate() error = nil")
+	}
+	for _, want := range []string{
+		"categories[0]: name is required",
+		`categories[1]: idnumber "2021S" is duplicated`,
+		"courses[0]: fullname is required",
+		`courses[0].enrolments[0]: unknown role "professor"`,
+		`courses[0].enrolments[0]: group "Team B" is not defined in the course`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want error containing %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package moodle
+
+import (
+	"time"
+)
+
+type EnrolledUser struct {
+	ID               int
+	Username         string
+	Firstname        string
+	Lastname         string
+	Fullname         string
+	Email            string
+	IDNumber         string
+	FirstAccess      *time.Time
+	LastAccess       *time.Time
+	LastCourseAccess *time.Time
+	ProfileImageURL  string
+	Groups           []*EnrolledUserGroup
+	Roles            []*EnrolledUserRole
+	EnrolledCourses  []*EnrolledUserCourse
+}
+
+type EnrolledUserGroup struct {
+	ID          int
+	Name        string
+	Description string
+}
+
+type EnrolledUserRole struct {
+	RoleID    int
+	Name      string
+	ShortName string
+	SortOrder int
+}
+
+type EnrolledUserCourse struct {
+	ID        int
+	FullName  string
+	ShortName string
+}
+
+// GetEnrolledUsersOptions represents options to filter and page enrolled users
+type GetEnrolledUsersOptions struct {
+	// WithCapability returns only users with this capability
+	WithCapability string
+	GroupID        int
+	// OnlyActive returns only users with active enrolment
+	OnlyActive bool
+	// UserFields limits the returned user fields, id is always returned
+	UserFields  []string
+	LimitFrom   int
+	LimitNumber int
+	// SortBy is one of id, firstname, lastname and siteorder
+	SortBy string
+	// SortDirection is ASC or DESC
+	SortDirection string
+}
+
+// UserCourse represents a course the user is enrolled in
+type UserCourse struct {
+	ID                int
+	ShortName         string
+	FullName          string
+	DisplayName       string
+	EnrolledUserCount *int
+	IDNumber          string
+	Visible           bool
+	Summary           string
+	SummaryFormat     int
+	Format            string
+	ShowGrades        bool
+	Lang              string
+	EnableCompletion  bool
+	Category          int
+	Progress          *float64
+	Completed         *bool
+	StartDate         time.Time
+	EndDate           time.Time
+	LastAccess        *time.Time
+	IsFavourite       bool
+	Hidden            bool
+}
+
+// EnrolmentMethod represents an enrolment instance of a course
+type EnrolmentMethod struct {
+	ID       int
+	CourseID int
+	// Type is the enrolment plugin name (e.g. self, guest, manual)
+	Type   string
+	Name   string
+	Status string
+	// WSFunction is the web service function to get the instance information
+	WSFunction string
+}
+
+// GuestEnrolmentInstance represents a guest enrolment instance of a course
+type GuestEnrolmentInstance struct {
+	ID               int
+	CourseID         int
+	Type             string
+	Name             string
+	Status           bool
+	PasswordRequired bool
+}
+
+// ManualEnrolment represents an enrolment or unenrolment of a user by the manual enrolment plugin
+type ManualEnrolment struct {
+	RoleID   int
+	UserID   int
+	CourseID int
+	// TimeStart and TimeEnd are used only for enrolment
+	TimeStart *time.Time
+	TimeEnd   *time.Time
+	Suspend   bool
+}
@@ -0,0 +1,361 @@
+package moodle
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type EnrolAPI interface {
+	GetEnrolledUsers(ctx context.Context, courseID int, opts *GetEnrolledUsersOptions) ([]*EnrolledUser, error)
+	GetUsersCourses(ctx context.Context, userID int) ([]*UserCourse, error)
+	GetCourseEnrolmentMethods(ctx context.Context, courseID int) ([]*EnrolmentMethod, error)
+	SelfEnrolUser(ctx context.Context, courseID int, password string, instanceID int) error
+	GetGuestInstanceInfo(ctx context.Context, instanceID int) (*GuestEnrolmentInstance, error)
+	ManualEnrolUsers(ctx context.Context, enrolments []*ManualEnrolment) error
+	ManualUnenrolUsers(ctx context.Context, enrolments []*ManualEnrolment) error
+}
+
+type enrolAPI struct {
+	*apiClient
+}
+
+func newEnrolAPI(apiClient *apiClient) *enrolAPI {
+	return &enrolAPI{apiClient}
+}
+
+type enrolledUserResponse struct {
+	ID                   int    `json:"id"`
+	Username             string `json:"username"`
+	Firstname            string `json:"firstname"`
+	Lastname             string `json:"lastname"`
+	Fullname             string `json:"fullname"`
+	Email                string `json:"email"`
+	IDNumber             string `json:"idnumber"`
+	FirstAccessUnix      int64  `json:"firstaccess"`
+	LastAccessUnix       int64  `json:"lastaccess"`
+	LastCourseAccessUnix int64  `json:"lastcourseaccess"`
+	ProfileImageURL      string `json:"profileimageurl"`
+	Groups               []*struct {
+		ID          int    `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	} `json:"groups"`
+	Roles []*struct {
+		RoleID    int    `json:"roleid"`
+		Name      string `json:"name"`
+		ShortName string `json:"shortname"`
+		SortOrder int    `json:"sortorder"`
+	} `json:"roles"`
+	EnrolledCourses []*struct {
+		ID        int    `json:"id"`
+		FullName  string `json:"fullname"`
+		ShortName string `json:"shortname"`
+	} `json:"enrolledcourses"`
+}
+
+func (e *enrolAPI) GetEnrolledUsers(ctx context.Context, courseID int, opts *GetEnrolledUsersOptions) ([]*EnrolledUser, error) {
+	var res []*enrolledUserResponse
+	err := e.callMoodleFunction(
+		ctx,
+		&res,
+		map[string]string{
+			"wsfunction": "core_enrol_get_enrolled_users",
+			"courseid":   strconv.Itoa(courseID),
+		},
+		mapGetEnrolledUsersOptionsToQueryParams(opts),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return mapToEnrolledUserList(res), nil
+}
+
+type userCourseResponse struct {
+	ID                int      `json:"id"`
+	ShortName         string   `json:"shortname"`
+	FullName          string   `json:"fullname"`
+	DisplayName       string   `json:"displayname"`
+	EnrolledUserCount *int     `json:"enrolledusercount,omitempty"`
+	IDNumber          string   `json:"idnumber"`
+	Visible           int      `json:"visible"`
+	Summary           string   `json:"summary"`
+	SummaryFormat     int      `json:"summaryformat"`
+	Format            string   `json:"format"`
+	ShowGrades        bool     `json:"showgrades"`
+	Lang              string   `json:"lang"`
+	EnableCompletion  bool     `json:"enablecompletion"`
+	Category          int      `json:"category"`
+	Progress          *float64 `json:"progress,omitempty"`
+	Completed         *bool    `json:"completed,omitempty"`
+	StartDateUnix     int64    `json:"startdate"`
+	EndDateUnix       int64    `json:"enddate"`
+	LastAccessUnix    *int64   `json:"lastaccess,omitempty"`
+	IsFavourite       bool     `json:"isfavourite"`
+	Hidden            bool     `json:"hidden"`
+}
+
+func (e *enrolAPI) GetUsersCourses(ctx context.Context, userID int) ([]*UserCourse, error) {
+	var res []*userCourseResponse
+	err := e.callMoodleFunction(ctx, &res, map[string]string{
+		"wsfunction":      "core_enrol_get_users_courses",
+		"userid":          strconv.Itoa(userID),
+		"returnusercount": "1",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapToUserCourseList(res), nil
+}
+
+type enrolmentMethodResponse struct {
+	ID         int    `json:"id"`
+	CourseID   int    `json:"courseid"`
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	WSFunction string `json:"wsfunction"`
+}
+
+func (e *enrolAPI) GetCourseEnrolmentMethods(ctx context.Context, courseID int) ([]*EnrolmentMethod, error) {
+	var res []*enrolmentMethodResponse
+	err := e.callMoodleFunction(ctx, &res, map[string]string{
+		"wsfunction": "core_enrol_get_course_enrolment_methods",
+		"courseid":   strconv.Itoa(courseID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	methods := make([]*EnrolmentMethod, 0, len(res))
+	for _, m := range res {
+		methods = append(methods, &EnrolmentMethod{
+			ID:         m.ID,
+			CourseID:   m.CourseID,
+			Type:       m.Type,
+			Name:       m.Name,
+			Status:     m.Status,
+			WSFunction: m.WSFunction,
+		})
+	}
+	return methods, nil
+}
+
+type selfEnrolUserResponse struct {
+	Status   bool     `json:"status"`
+	Warnings Warnings `json:"warnings"`
+}
+
+// SelfEnrolUser enrols the current user in the course by self enrolment.
+// password is the enrolment key, and instanceID can be 0 to use the first self enrolment instance.
+func (e *enrolAPI) SelfEnrolUser(ctx context.Context, courseID int, password string, instanceID int) error {
+	params := map[string]string{
+		"wsfunction": "enrol_self_enrol_user",
+		"courseid":   strconv.Itoa(courseID),
+	}
+	if password != "" {
+		params["password"] = password
+	}
+	if instanceID != 0 {
+		params["instanceid"] = strconv.Itoa(instanceID)
+	}
+	res := selfEnrolUserResponse{}
+	if err := e.postMoodleFunction(ctx, &res, params); err != nil {
+		return err
+	}
+	if len(res.Warnings) > 0 {
+		return res.Warnings
+	}
+	if !res.Status {
+		return fmt.Errorf("failed to self enrol in course %d", courseID)
+	}
+	return nil
+}
+
+type getGuestInstanceInfoResponse struct {
+	InstanceInfo *struct {
+		ID               int    `json:"id"`
+		CourseID         int    `json:"courseid"`
+		Type             string `json:"type"`
+		Name             string `json:"name"`
+		Status           bool   `json:"status"`
+		PasswordRequired bool   `json:"passwordrequired"`
+	} `json:"instanceinfo"`
+	Warnings Warnings `json:"warnings"`
+}
+
+func (e *enrolAPI) GetGuestInstanceInfo(ctx context.Context, instanceID int) (*GuestEnrolmentInstance, error) {
+	res := getGuestInstanceInfoResponse{}
+	err := e.callMoodleFunction(ctx, &res, map[string]string{
+		"wsfunction": "enrol_guest_get_instance_info",
+		"instanceid": strconv.Itoa(instanceID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+	if res.InstanceInfo == nil {
+		return nil, fmt.Errorf("guest enrolment instance %d is not found", instanceID)
+	}
+	return &GuestEnrolmentInstance{
+		ID:               res.InstanceInfo.ID,
+		CourseID:         res.InstanceInfo.CourseID,
+		Type:             res.InstanceInfo.Type,
+		Name:             res.InstanceInfo.Name,
+		Status:           res.InstanceInfo.Status,
+		PasswordRequired: res.InstanceInfo.PasswordRequired,
+	}, nil
+}
+
+func (e *enrolAPI) ManualEnrolUsers(ctx context.Context, enrolments []*ManualEnrolment) error {
+	params := map[string]string{
+		"wsfunction": "enrol_manual_enrol_users",
+	}
+	for i, enrolment := range enrolments {
+		params[fmt.Sprintf("enrolments[%d][roleid]", i)] = strconv.Itoa(enrolment.RoleID)
+		params[fmt.Sprintf("enrolments[%d][userid]", i)] = strconv.Itoa(enrolment.UserID)
+		params[fmt.Sprintf("enrolments[%d][courseid]", i)] = strconv.Itoa(enrolment.CourseID)
+		if enrolment.TimeStart != nil {
+			params[fmt.Sprintf("enrolments[%d][timestart]", i)] = strconv.FormatInt(enrolment.TimeStart.Unix(), 10)
+		}
+		if enrolment.TimeEnd != nil {
+			params[fmt.Sprintf("enrolments[%d][timeend]", i)] = strconv.FormatInt(enrolment.TimeEnd.Unix(), 10)
+		}
+		if enrolment.Suspend {
+			params[fmt.Sprintf("enrolments[%d][suspend]", i)] = mapBoolToBitStr(enrolment.Suspend)
+		}
+	}
+	// the response is null on success
+	var res interface{}
+	return e.postMoodleFunction(ctx, &res, params)
+}
+
+func (e *enrolAPI) ManualUnenrolUsers(ctx context.Context, enrolments []*ManualEnrolment) error {
+	params := map[string]string{
+		"wsfunction": "enrol_manual_unenrol_users",
+	}
+	for i, enrolment := range enrolments {
+		params[fmt.Sprintf("enrolments[%d][userid]", i)] = strconv.Itoa(enrolment.UserID)
+		params[fmt.Sprintf("enrolments[%d][courseid]", i)] = strconv.Itoa(enrolment.CourseID)
+		if enrolment.RoleID != 0 {
+			params[fmt.Sprintf("enrolments[%d][roleid]", i)] = strconv.Itoa(enrolment.RoleID)
+		}
+	}
+	// the response is null on success
+	var res interface{}
+	return e.postMoodleFunction(ctx, &res, params)
+}
+
+func mapGetEnrolledUsersOptionsToQueryParams(opts *GetEnrolledUsersOptions) map[string]string {
+	params := make(map[string]string)
+	if opts == nil {
+		return params
+	}
+	i := 0
+	addOption := func(name, value string) {
+		params[fmt.Sprintf("options[%d][name]", i)] = name
+		params[fmt.Sprintf("options[%d][value]", i)] = value
+		i++
+	}
+	if opts.WithCapability != "" {
+		addOption("withcapability", opts.WithCapability)
+	}
+	if opts.GroupID != 0 {
+		addOption("groupid", strconv.Itoa(opts.GroupID))
+	}
+	if opts.OnlyActive {
+		addOption("onlyactive", mapBoolToBitStr(opts.OnlyActive))
+	}
+	if len(opts.UserFields) > 0 {
+		addOption("userfields", strings.Join(opts.UserFields, ","))
+	}
+	if opts.LimitFrom != 0 {
+		addOption("limitfrom", strconv.Itoa(opts.LimitFrom))
+	}
+	if opts.LimitNumber != 0 {
+		addOption("limitnumber", strconv.Itoa(opts.LimitNumber))
+	}
+	if opts.SortBy != "" {
+		addOption("sortby", opts.SortBy)
+	}
+	if opts.SortDirection != "" {
+		addOption("sortdirection", opts.SortDirection)
+	}
+	return params
+}
+
+func mapToEnrolledUserList(userResList []*enrolledUserResponse) []*EnrolledUser {
+	users := make([]*EnrolledUser, 0, len(userResList))
+	for _, userRes := range userResList {
+		users = append(users, mapToEnrolledUser(userRes))
+	}
+	return users
+}
+
+func mapToEnrolledUser(userRes *enrolledUserResponse) *EnrolledUser {
+	groups := make([]*EnrolledUserGroup, 0, len(userRes.Groups))
+	for _, g := range userRes.Groups {
+		groups = append(groups, &EnrolledUserGroup{ID: g.ID, Name: g.Name, Description: g.Description})
+	}
+	roles := make([]*EnrolledUserRole, 0, len(userRes.Roles))
+	for _, r := range userRes.Roles {
+		roles = append(roles, &EnrolledUserRole{RoleID: r.RoleID, Name: r.Name, ShortName: r.ShortName, SortOrder: r.SortOrder})
+	}
+	courses := make([]*EnrolledUserCourse, 0, len(userRes.EnrolledCourses))
+	for _, c := range userRes.EnrolledCourses {
+		courses = append(courses, &EnrolledUserCourse{ID: c.ID, FullName: c.FullName, ShortName: c.ShortName})
+	}
+	return &EnrolledUser{
+		ID:               userRes.ID,
+		Username:         userRes.Username,
+		Firstname:        userRes.Firstname,
+		Lastname:         userRes.Lastname,
+		Fullname:         userRes.Fullname,
+		Email:            userRes.Email,
+		IDNumber:         userRes.IDNumber,
+		FirstAccess:      mapUnixToTimePtr(userRes.FirstAccessUnix),
+		LastAccess:       mapUnixToTimePtr(userRes.LastAccessUnix),
+		LastCourseAccess: mapUnixToTimePtr(userRes.LastCourseAccessUnix),
+		ProfileImageURL:  userRes.ProfileImageURL,
+		Groups:           groups,
+		Roles:            roles,
+		EnrolledCourses:  courses,
+	}
+}
+
+func mapToUserCourseList(courseResList []*userCourseResponse) []*UserCourse {
+	courses := make([]*UserCourse, 0, len(courseResList))
+	for _, courseRes := range courseResList {
+		var lastAccess *time.Time
+		if courseRes.LastAccessUnix != nil {
+			lastAccess = mapUnixToTimePtr(*courseRes.LastAccessUnix)
+		}
+		courses = append(courses, &UserCourse{
+			ID:                courseRes.ID,
+			ShortName:         courseRes.ShortName,
+			FullName:          courseRes.FullName,
+			DisplayName:       courseRes.DisplayName,
+			EnrolledUserCount: courseRes.EnrolledUserCount,
+			IDNumber:          courseRes.IDNumber,
+			Visible:           mapBitToBool(courseRes.Visible),
+			Summary:           courseRes.Summary,
+			SummaryFormat:     courseRes.SummaryFormat,
+			Format:            courseRes.Format,
+			ShowGrades:        courseRes.ShowGrades,
+			Lang:              courseRes.Lang,
+			EnableCompletion:  courseRes.EnableCompletion,
+			Category:          courseRes.Category,
+			Progress:          courseRes.Progress,
+			Completed:         courseRes.Completed,
+			StartDate:         time.Unix(courseRes.StartDateUnix, 0),
+			EndDate:           time.Unix(courseRes.EndDateUnix, 0),
+			LastAccess:        lastAccess,
+			IsFavourite:       courseRes.IsFavourite,
+			Hidden:            courseRes.Hidden,
+		})
+	}
+	return courses
+}
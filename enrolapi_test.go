@@ -0,0 +1,431 @@
+package moodle
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_enrolAPI_GetEnrolledUsers(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		ctx      context.Context
+		courseID int
+		opts     *GetEnrolledUsersOptions
+	}
+	tests := []struct {
+		name     string
+		args     args
+		response string
+		want     []*EnrolledUser
+		wantErr  bool
+	}{
+		{
+			name: "Successful response",
+			args: args{ctx: context.Background(), courseID: 1111, opts: &GetEnrolledUsersOptions{OnlyActive: true}},
+			response: `[
+  {
+    "id": 123456,
+    "username": "s123456",
+    "firstname": "Test",
+    "lastname": "User",
+    "fullname": "Test User",
+    "email": "test@test.edu",
+    "idnumber": "S123456",
+    "firstaccess": 1577836800,
+    "lastaccess": 1577837100,
+    "lastcourseaccess": 0,
+    "profileimageurl": "https://test.edu/pluginfile.php/1/user/icon/f1",
+    "groups": [{"id": 11, "name": "Team A", "description": "", "descriptionformat": 1}],
+    "roles": [{"roleid": 5, "name": "", "shortname": "student", "sortorder": 0}],
+    "enrolledcourses": [{"id": 1111, "fullname": "Test Course", "shortname": "TC"}]
+  }
+]`,
+			want: []*EnrolledUser{
+				{
+					ID:              123456,
+					Username:        "s123456",
+					Firstname:       "Test",
+					Lastname:        "User",
+					Fullname:        "Test User",
+					Email:           "test@test.edu",
+					IDNumber:        "S123456",
+					FirstAccess:     func() *time.Time { t := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC); return &t }(),
+					LastAccess:      func() *time.Time { t := time.Date(2020, 1, 1, 0, 5, 0, 0, time.UTC); return &t }(),
+					ProfileImageURL: "https://test.edu/pluginfile.php/1/user/icon/f1",
+					Groups:          []*EnrolledUserGroup{{ID: 11, Name: "Team A"}},
+					Roles:           []*EnrolledUserRole{{RoleID: 5, ShortName: "student"}},
+					EnrolledCourses: []*EnrolledUserCourse{{ID: 1111, FullName: "Test Course", ShortName: "TC"}},
+				},
+			},
+		},
+		{
+			name:     "Error response",
+			args:     args{ctx: context.Background(), courseID: 0000},
+			response: `{"exception":"dml_missing_record_exception","errorcode":"invalidrecord","message":"Can't find data record in database table course."}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			args:     args{ctx: context.Background(), courseID: 0000},
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := mockEnrolAPI(t, tt.response)
+			got, err := e.GetEnrolledUsers(tt.args.ctx, tt.args.courseID, tt.args.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetEnrolledUsers() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetEnrolledUsers() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_mapGetEnrolledUsersOptionsToQueryParams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts *GetEnrolledUsersOptions
+		want map[string]string
+	}{
+		{
+			name: "nil options",
+			opts: nil,
+			want: map[string]string{},
+		},
+		{
+			name: "all options",
+			opts: &GetEnrolledUsersOptions{
+				WithCapability: "moodle/course:update",
+				GroupID:        11,
+				OnlyActive:     true,
+				UserFields:     []string{"id", "email"},
+				LimitFrom:      100,
+				LimitNumber:    50,
+				SortBy:         "lastname",
+				SortDirection:  "ASC",
+			},
+			want: map[string]string{
+				"options[0][name]":  "withcapability",
+				"options[0][value]": "moodle/course:update",
+				"options[1][name]":  "groupid",
+				"options[1][value]": "11",
+				"options[2][name]":  "onlyactive",
+				"options[2][value]": "1",
+				"options[3][name]":  "userfields",
+				"options[3][value]": "id,email",
+				"options[4][name]":  "limitfrom",
+				"options[4][value]": "100",
+				"options[5][name]":  "limitnumber",
+				"options[5][value]": "50",
+				"options[6][name]":  "sortby",
+				"options[6][value]": "lastname",
+				"options[7][name]":  "sortdirection",
+				"options[7][value]": "ASC",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(mapGetEnrolledUsersOptionsToQueryParams(tt.opts), tt.want); diff != "" {
+				t.Errorf("mapGetEnrolledUsersOptionsToQueryParams() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_enrolAPI_GetUsersCourses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		want     []*UserCourse
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `[{"id":1111,"shortname":"TC","fullname":"Test Course","displayname":"Test Course","enrolledusercount":30,"idnumber":"","visible":1,"summary":"","summaryformat":1,"format":"topics","showgrades":true,"lang":"","enablecompletion":true,"category":2,"progress":50,"completed":false,"startdate":1577836800,"enddate":1590969600,"lastaccess":1577837100,"isfavourite":false,"hidden":false}]`,
+			want: []*UserCourse{
+				{
+					ID:                1111,
+					ShortName:         "TC",
+					FullName:          "Test Course",
+					DisplayName:       "Test Course",
+					EnrolledUserCount: func() *int { i := 30; return &i }(),
+					Visible:           true,
+					SummaryFormat:     1,
+					Format:            "topics",
+					ShowGrades:        true,
+					EnableCompletion:  true,
+					Category:          2,
+					Progress:          func() *float64 { f := 50.0; return &f }(),
+					Completed:         func() *bool { b := false; return &b }(),
+					StartDate:         time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+					EndDate:           time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+					LastAccess:        func() *time.Time { t := time.Date(2020, 1, 1, 0, 5, 0, 0, time.UTC); return &t }(),
+				},
+			},
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"dml_missing_record_exception","errorcode":"invaliduser","message":"Invalid user"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := mockEnrolAPI(t, tt.response)
+			got, err := e.GetUsersCourses(context.Background(), 123456)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetUsersCourses() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetUsersCourses() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_enrolAPI_GetCourseEnrolmentMethods(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		want     []*EnrolmentMethod
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `[{"id":11,"courseid":1111,"type":"self","name":"Self enrolment (Student)","status":"1","wsfunction":"enrol_self_get_instance_info"}]`,
+			want: []*EnrolmentMethod{
+				{ID: 11, CourseID: 1111, Type: "self", Name: "Self enrolment (Student)", Status: "1", WSFunction: "enrol_self_get_instance_info"},
+			},
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"dml_missing_record_exception","errorcode":"invalidrecord","message":"Can't find data record in database table course."}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := mockEnrolAPI(t, tt.response)
+			got, err := e.GetCourseEnrolmentMethods(context.Background(), 1111)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetCourseEnrolmentMethods() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetCourseEnrolmentMethods() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_enrolAPI_SelfEnrolUser(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"status":true,"warnings":[]}`,
+		},
+		{
+			name:     "Failed response",
+			response: `{"status":false,"warnings":[]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Warning response",
+			response: `{"status":false,"warnings":[{"item":"instance","itemid":11,"warningcode":"4","message":"Incorrect enrolment key, please try again"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := mockEnrolAPI(t, tt.response)
+			if err := e.SelfEnrolUser(context.Background(), 1111, "secret", 0); (err != nil) != tt.wantErr {
+				t.Errorf("SelfEnrolUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_enrolAPI_GetGuestInstanceInfo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		want     *GuestEnrolmentInstance
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"instanceinfo":{"id":12,"courseid":1111,"type":"guest","name":"Guest access","status":true,"passwordrequired":false},"warnings":[]}`,
+			want:     &GuestEnrolmentInstance{ID: 12, CourseID: 1111, Type: "guest", Name: "Guest access", Status: true},
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"dml_missing_record_exception","errorcode":"invalidrecord","message":"Can't find data record in database table enrol."}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := mockEnrolAPI(t, tt.response)
+			got, err := e.GetGuestInstanceInfo(context.Background(), 12)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetGuestInstanceInfo() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetGuestInstanceInfo() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_enrolAPI_ManualEnrolUsers(t *testing.T) {
+	t.Parallel()
+
+	var gotQuery url.Values
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		gotQuery = r.Form
+		fmt.Fprintln(w, "null")
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	apiURL, _ := url.Parse(s.URL)
+	e := &enrolAPI{&apiClient{httpClient: http.DefaultClient, apiURL: apiURL}}
+
+	timeEnd := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	err := e.ManualEnrolUsers(context.Background(), []*ManualEnrolment{
+		{RoleID: 5, UserID: 123456, CourseID: 1111, TimeEnd: &timeEnd},
+	})
+	if err != nil {
+		t.Fatalf("ManualEnrolUsers() error = %v", err)
+	}
+	want := map[string]string{
+		"wsfunction":              "enrol_manual_enrol_users",
+		"enrolments[0][roleid]":   "5",
+		"enrolments[0][userid]":   "123456",
+		"enrolments[0][courseid]": "1111",
+		"enrolments[0][timeend]":  "1590969600",
+	}
+	for k, v := range want {
+		if gotQuery.Get(k) != v {
+			t.Errorf("ManualEnrolUsers() query %s = %v, want %v", k, gotQuery.Get(k), v)
+		}
+	}
+	if gotQuery.Get("enrolments[0][timestart]") != "" {
+		t.Errorf("ManualEnrolUsers() query timestart = %v, want empty", gotQuery.Get("enrolments[0][timestart]"))
+	}
+}
+
+func Test_enrolAPI_ManualUnenrolUsers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: "null",
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"wsnoinstance","message":"Manual enrolment plugin instance doesn't exist or is disabled for the course (id = 1111)"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := mockEnrolAPI(t, tt.response)
+			err := e.ManualUnenrolUsers(context.Background(), []*ManualEnrolment{{UserID: 123456, CourseID: 1111}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ManualUnenrolUsers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mockEnrolAPI(t *testing.T, response string) *enrolAPI {
+	t.Helper()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, response)
+	})
+	s := httptest.NewServer(h)
+	apiURL, _ := url.Parse(s.URL)
+	return &enrolAPI{
+		&apiClient{
+			httpClient: http.DefaultClient,
+			apiURL:     apiURL,
+		},
+	}
+}
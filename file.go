@@ -0,0 +1,67 @@
+package moodle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/k-yomo/moodle/pkg/urlutil"
+)
+
+// DownloadFile downloads the file of the url (e.g. CourseModuleContent.FileURL) to w with the token of the client.
+// The url must be under the webservice pluginfile endpoint of the service url not to send the token to other sites.
+// The number of bytes written is returned.
+func (c *Client) DownloadFile(ctx context.Context, fileURL string, w io.Writer) (int64, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file url: %w", err)
+	}
+	if !c.isPluginFileURL(u) {
+		return 0, fmt.Errorf("invalid file url: %q is not a pluginfile url of %s", fileURL, c.apiClient.serviceURL)
+	}
+	urlutil.SetQueries(u, map[string]string{"token": c.apiClient.authToken})
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.apiClient.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download file, status: %s", resp.Status)
+	}
+	// moodle returns an error as json with 200 status, e.g. when the token is invalid
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return 0, err
+		}
+		apiError := APIError{}
+		if err := json.Unmarshal(body, &apiError); err == nil && apiError.ErrorCode != "" {
+			return 0, &apiError
+		}
+		n, err := w.Write(body)
+		return int64(n), err
+	}
+	return io.Copy(w, resp.Body)
+}
+
+// isPluginFileURL reports whether the url is under the webservice pluginfile endpoint of the service url
+func (c *Client) isPluginFileURL(u *url.URL) bool {
+	serviceURL := c.apiClient.serviceURL
+	if !strings.EqualFold(u.Scheme, serviceURL.Scheme) || !strings.EqualFold(u.Host, serviceURL.Host) {
+		return false
+	}
+	pluginFilePath := path.Join("/", serviceURL.Path, "/webservice/pluginfile.php")
+	return strings.HasPrefix(path.Clean(u.Path), pluginFilePath+"/")
+}
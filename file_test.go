@@ -0,0 +1,122 @@
+package moodle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestClient_DownloadFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		contentType string
+		response    string
+		want        string
+		wantErrCode string
+		wantErr     bool
+	}{
+		{
+			name:        "Successful response",
+			contentType: "application/pdf",
+			response:    "%PDF-1.4",
+			want:        "%PDF-1.4",
+		},
+		{
+			name:        "JSON file",
+			contentType: "application/json",
+			response:    `{"key":"value"}`,
+			want:        `{"key":"value"}`,
+		},
+		{
+			name:        "Error response",
+			contentType: "application/json",
+			response:    `{"error":"Invalid token - token not found","errorcode":"invalidtoken"}`,
+			wantErrCode: "invalidtoken",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("token") != "test" {
+					t.Errorf("DownloadFile() token = %q, want %q", r.URL.Query().Get("token"), "test")
+				}
+				w.Header().Set("Content-Type", tt.contentType)
+				fmt.Fprint(w, tt.response)
+			})
+			s := httptest.NewServer(h)
+			defer s.Close()
+			serviceURL, _ := url.Parse(s.URL)
+			c, _ := NewClient(context.Background(), serviceURL, "test")
+
+			buf := &bytes.Buffer{}
+			n, err := c.DownloadFile(context.Background(), s.URL+"/webservice/pluginfile.php/1/mod_resource/content/0/file.pdf?forcedownload=1", buf)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DownloadFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && Code(err) != tt.wantErrCode {
+				t.Errorf("DownloadFile() error code = %q, want %q", Code(err), tt.wantErrCode)
+			}
+			if buf.String() != tt.want || n != int64(len(tt.want)) {
+				t.Errorf("DownloadFile() = %q (%d bytes), want %q", buf.String(), n, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_DownloadFile_InvalidURL(t *testing.T) {
+	t.Parallel()
+
+	var called bool
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	foreign := httptest.NewServer(h)
+	defer foreign.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test")
+
+	tests := []struct {
+		name    string
+		fileURL string
+	}{
+		{
+			name:    "Foreign host",
+			fileURL: foreign.URL + "/webservice/pluginfile.php/1/mod_resource/content/0/file.pdf",
+		},
+		{
+			name:    "Different scheme",
+			fileURL: strings.Replace(s.URL, "http://", "https://", 1) + "/webservice/pluginfile.php/1/mod_resource/content/0/file.pdf",
+		},
+		{
+			name:    "Not pluginfile",
+			fileURL: s.URL + "/webservice/rest/server.php?wsfunction=core_webservice_get_site_info",
+		},
+		{
+			name:    "Escaping pluginfile",
+			fileURL: s.URL + "/webservice/pluginfile.php/../../login/index.php",
+		},
+	}
+	for _, tt := range tests {
+		n, err := c.DownloadFile(context.Background(), tt.fileURL, &bytes.Buffer{})
+		if err == nil || n != 0 {
+			t.Errorf("%s: DownloadFile() = %d, %v, want error", tt.name, n, err)
+		}
+	}
+	if called {
+		t.Errorf("DownloadFile() sent a request for an invalid url")
+	}
+}
@@ -1,6 +1,9 @@
 package moodle
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type UserGrade struct {
 	CourseID     int
@@ -13,18 +16,20 @@ type UserGrade struct {
 // GradeItem represents an grade
 // If you want to know percentage of the grade out of course total grade, see GradeTableItem
 type GradeItem struct {
-	ID                 int
-	ItemName           string
-	ItemType           string
-	ItemModule         *string
-	ItemInstance       int
-	ItemNumber         *int
-	CategoryID         *int
-	OutcomeID          *int
-	ScaleID            *int
-	Locked             *bool
-	CmID               int
-	GradeRaw           float64
+	ID           int
+	ItemName     string
+	ItemType     string
+	ItemModule   *string
+	ItemInstance int
+	ItemNumber   *int
+	CategoryID   *int
+	OutcomeID    *int
+	ScaleID      *int
+	Locked       *bool
+	CmID         int
+	GradeRaw     float64
+	// WeightRaw is the weight of the item in its parent category (0 to 1), nil if the weight is not shown
+	WeightRaw          *float64
 	GradeDateSubmitted *time.Time
 	GradeDateGraded    *time.Time
 	GradeHiddenByDate  bool
@@ -46,7 +51,12 @@ type GradeTable struct {
 	UserID       int
 	UserFullname string
 	MaxDepth     int
-	ItemGroups   []*GradeTableItemGroup
+	// ItemGroups is a flattened list of graded items grouped by the closest category.
+	//
+	// Deprecated: Use Category to get the hierarchy of categories.
+	ItemGroups []*GradeTableItemGroup
+	// Category is the root category of the course, which contains nested categories and items
+	Category *GradeTableCategory
 }
 
 // GradeTableItemGroup represents a group of grade items
@@ -55,15 +65,171 @@ type GradeTableItemGroup struct {
 	Items []*GradeTableItem
 }
 
+// GradeTableCategory represents a grade category in a grade table
+type GradeTableCategory struct {
+	Name string
+	// Depth is the depth of the category in the grade table, the root category is 1
+	Depth int
+	// RowSpan is the number of rows the category spans in the grade table
+	RowSpan    int
+	Categories []*GradeTableCategory
+	Items      []*GradeTableItem
+	// Total is the category total (or course total for the root category), nil if it's not shown
+	Total *GradeTableItem
+}
+
+// GradeDisplayType represents how a grade is displayed
+type GradeDisplayType string
+
+const (
+	GradeDisplayTypeReal       GradeDisplayType = "real"
+	GradeDisplayTypePercentage GradeDisplayType = "percentage"
+	GradeDisplayTypeLetter     GradeDisplayType = "letter"
+	GradeDisplayTypeScale      GradeDisplayType = "scale"
+)
+
 type GradeTableItem struct {
-	ItemName                  string
-	ItemNameRawHTML           string
-	ItemURL                   *string
-	IsGraded                  bool
-	Grade                     float64
+	ItemName        string
+	ItemNameRawHTML string
+	ItemURL         *string
+	IsGraded        bool
+	// Grade is the numeric value of the grade, which is set only for real and percentage display types
+	Grade float64
+	// GradeFormatted is the grade as displayed, e.g. "85.00", "85.00 %", "B+", "Pass"
+	GradeFormatted   string
+	GradeDisplayType GradeDisplayType
+	// GradeRangeMin and GradeRangeMax are set only when the range is numeric
 	GradeRangeMin             float64
 	GradeRangeMax             float64
+	RangeFormatted            string
+	Weight                    *float64
+	Percentage                *float64
+	LetterGrade               string
 	Feedback                  string
 	FeedBackRawHTML           string
 	ContributionToCourseTotal float64
 }
+
+// CourseGrade represents the course total grade of a user in a course
+type CourseGrade struct {
+	CourseID int
+	Grade    string
+	RawGrade *float64
+	Rank     *int
+}
+
+// GradeReportUser represents a user who can be searched in the grade report
+type GradeReportUser struct {
+	ID                   int
+	Fullname             string
+	Email                string
+	ProfileImageURL      string
+	ProfileImageURLSmall string
+}
+
+// GradeReportAccessInformation represents what the current user can see in the user grade report
+type GradeReportAccessInformation struct {
+	CanViewUserReport bool
+	CanViewMyGrades   bool
+	CanViewAllGrades  bool
+}
+
+// CourseGradeItem represents a grade item in the gradebook of a course
+type CourseGradeItem struct {
+	// ID is the ID of the grade item, which is the same as GradeItem.ID
+	ID       int
+	ItemName string
+	// Category is the name of the grade category the item belongs to
+	Category string
+}
+
+// GradeUpdateStatus represents the result of core_grades_update_grades
+type GradeUpdateStatus int
+
+const (
+	GradeUpdateStatusOK         GradeUpdateStatus = 0
+	GradeUpdateStatusFailed     GradeUpdateStatus = 1
+	GradeUpdateStatusMultiple   GradeUpdateStatus = 2
+	GradeUpdateStatusItemLocked GradeUpdateStatus = 4
+)
+
+func (g GradeUpdateStatus) String() string {
+	switch g {
+	case GradeUpdateStatusOK:
+		return "ok"
+	case GradeUpdateStatusFailed:
+		return "failed"
+	case GradeUpdateStatusMultiple:
+		return "multiple"
+	case GradeUpdateStatusItemLocked:
+		return "item locked"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(g))
+	}
+}
+
+// GradeUpdateStatusError is returned when grades are not updated successfully
+type GradeUpdateStatusError struct {
+	Status GradeUpdateStatus
+}
+
+func (g *GradeUpdateStatusError) Error() string {
+	return fmt.Sprintf("failed to update grades, status: %s", g.Status)
+}
+
+// UpdateGradesParams represents grades to be written to an activity grade item
+type UpdateGradesParams struct {
+	// Source is a free text to identify the system updating grades
+	Source   string
+	CourseID int
+	// Component is a component name of the activity (e.g. mod_assign)
+	Component string
+	// ActivityID is the instance ID of the activity
+	ActivityID int
+	ItemNumber int
+	Grades     []*GradeUpdate
+}
+
+type GradeUpdate struct {
+	StudentID int
+	// Grade is nil when only feedback is updated
+	Grade    *float64
+	Feedback string
+}
+
+// GradingPanelItem identifies a grade item and the user graded in the grading panel
+type GradingPanelItem struct {
+	// Component is a component name of the activity (e.g. mod_forum)
+	Component    string
+	ContextID    int
+	ItemName     string
+	GradedUserID int
+}
+
+// GradingPanelPointGrade represents a point grade in the grading panel
+type GradingPanelPointGrade struct {
+	HasGrade     bool
+	Grade        *float64
+	UserGrade    string
+	MaxGrade     int
+	GradedBy     string
+	TimeCreated  time.Time
+	TimeModified time.Time
+}
+
+// GradingPanelScaleGrade represents a scale grade in the grading panel
+type GradingPanelScaleGrade struct {
+	HasGrade     bool
+	Options      []*GradingPanelScaleOption
+	UserGrade    string
+	MaxGrade     int
+	GradedBy     string
+	TimeCreated  time.Time
+	TimeModified time.Time
+}
+
+type GradingPanelScaleOption struct {
+	Value    int
+	Title    string
+	Selected bool
+}
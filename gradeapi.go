@@ -1,11 +1,12 @@
 package moodle
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"github.com/PuerkitoBio/goquery"
-	"regexp"
+	"fmt"
+	"github.com/k-yomo/moodle/pkg/urlutil"
+	"net/url"
+	"path"
 	"strconv"
 	"time"
 )
@@ -13,6 +14,18 @@ import (
 type GradeAPI interface {
 	GetGradeItems(ctx context.Context, userID int, courseID int) ([]*UserGrade, error)
 	GetGradesTable(ctx context.Context, userID int, courseID int) ([]*GradeTable, error)
+	// GetCourseGrades returns the course total grades of all the courses the user is enrolled in.
+	// If userID is 0, the grades of the current user are returned.
+	GetCourseGrades(ctx context.Context, userID int) ([]*CourseGrade, error)
+	GetEnrolledUsersForSearchWidget(ctx context.Context, courseID int, groupID int) ([]*GradeReportUser, error)
+	GetUserReportAccessInformation(ctx context.Context, courseID int) (*GradeReportAccessInformation, error)
+	// GetCourseGradeItems returns the grade items of the course with their categories (Moodle 4.2 or later)
+	GetCourseGradeItems(ctx context.Context, courseID int) ([]*CourseGradeItem, error)
+	UpdateGrades(ctx context.Context, params *UpdateGradesParams) error
+	FetchGradingPanelPointGrade(ctx context.Context, item *GradingPanelItem) (*GradingPanelPointGrade, error)
+	StoreGradingPanelPointGrade(ctx context.Context, item *GradingPanelItem, grade float64, notifyUser bool) (*GradingPanelPointGrade, error)
+	FetchGradingPanelScaleGrade(ctx context.Context, item *GradingPanelItem) (*GradingPanelScaleGrade, error)
+	StoreGradingPanelScaleGrade(ctx context.Context, item *GradingPanelItem, grade int, notifyUser bool) (*GradingPanelScaleGrade, error)
 }
 
 type gradeAPI struct {
@@ -32,31 +45,32 @@ type userGradeResponse struct {
 }
 
 type gradeItemResponse struct {
-	ID                     int     `json:"id"`
-	ItemName               string  `json:"itemname"`
-	ItemType               string  `json:"itemtype"`
-	ItemModule             *string `json:"itemmodule"`
-	ItemInstance           int     `json:"iteminstance"`
-	ItemNumber             *int    `json:"itemnumber"`
-	CategoryID             *int    `json:"categoryid"`
-	OutcomeID              *int    `json:"outcomeid"`
-	ScaleID                *int    `json:"scaleid"`
-	Locked                 *bool   `json:"locked"`
-	CmID                   int     `json:"cmid"`
-	GradeRaw               float64 `json:"graderaw"`
-	GradeDateSubmittedUnix *int64  `json:"gradedatesubmitted"`
-	GradeDateGradedUnix    int64   `json:"gradedategraded"`
-	GradeHiddenByDate      bool    `json:"gradehiddenbydate"`
-	GradeNeedsUpdate       bool    `json:"gradeneedsupdate"`
-	GradeIsHidden          bool    `json:"gradeishidden"`
-	GradeIsLocked          *bool   `json:"gradeislocked"`
-	GradeIsOverridden      *bool   `json:"gradeisoverridden"`
-	GradeFormatted         string  `json:"gradeformatted"`
-	GradeMin               int     `json:"grademin"`
-	GradeMax               int     `json:"grademax"`
-	RangeFormatted         string  `json:"rangeformatted"`
-	Feedback               string  `json:"feedback"`
-	FeedbackFormat         int     `json:"feedbackformat"`
+	ID                     int      `json:"id"`
+	ItemName               string   `json:"itemname"`
+	ItemType               string   `json:"itemtype"`
+	ItemModule             *string  `json:"itemmodule"`
+	ItemInstance           int      `json:"iteminstance"`
+	ItemNumber             *int     `json:"itemnumber"`
+	CategoryID             *int     `json:"categoryid"`
+	OutcomeID              *int     `json:"outcomeid"`
+	ScaleID                *int     `json:"scaleid"`
+	Locked                 *bool    `json:"locked"`
+	CmID                   int      `json:"cmid"`
+	GradeRaw               float64  `json:"graderaw"`
+	WeightRaw              *float64 `json:"weightraw,omitempty"`
+	GradeDateSubmittedUnix *int64   `json:"gradedatesubmitted"`
+	GradeDateGradedUnix    int64    `json:"gradedategraded"`
+	GradeHiddenByDate      bool     `json:"gradehiddenbydate"`
+	GradeNeedsUpdate       bool     `json:"gradeneedsupdate"`
+	GradeIsHidden          bool     `json:"gradeishidden"`
+	GradeIsLocked          *bool    `json:"gradeislocked"`
+	GradeIsOverridden      *bool    `json:"gradeisoverridden"`
+	GradeFormatted         string   `json:"gradeformatted"`
+	GradeMin               int      `json:"grademin"`
+	GradeMax               int      `json:"grademax"`
+	RangeFormatted         string   `json:"rangeformatted"`
+	Feedback               string   `json:"feedback"`
+	FeedbackFormat         int      `json:"feedbackformat"`
 }
 
 type getGradeItemsResponse struct {
@@ -105,26 +119,19 @@ type tableDataItemResponse struct {
 		Class   string `json:"class"`
 		RowSpan int    `json:"rowspan"`
 	} `json:"leader,omitempty"`
-	Grade *struct {
-		Class   string `json:"class"`
-		Content string `json:"content"`
-		Headers string `json:"headers"`
-	} `json:"grade,omitempty"`
-	Range *struct {
-		Class   string `json:"class"`
-		Content string `json:"content"`
-		Headers string `json:"headers"`
-	} `json:"range"`
-	Feedback *struct {
-		Class   string `json:"class"`
-		Content string `json:"content"`
-		Headers string `json:"headers"`
-	} `json:"feedback"`
-	ContributionToCourseTotal *struct {
-		Class   string `json:"class"`
-		Content string `json:"content"`
-		Headers string `json:"headers"`
-	} `json:"contributiontocoursetotal"`
+	Grade                     *tableDataCellResponse `json:"grade,omitempty"`
+	Weight                    *tableDataCellResponse `json:"weight,omitempty"`
+	Range                     *tableDataCellResponse `json:"range"`
+	Percentage                *tableDataCellResponse `json:"percentage,omitempty"`
+	LetterGrade               *tableDataCellResponse `json:"lettergrade,omitempty"`
+	Feedback                  *tableDataCellResponse `json:"feedback"`
+	ContributionToCourseTotal *tableDataCellResponse `json:"contributiontocoursetotal"`
+}
+
+type tableDataCellResponse struct {
+	Class   string `json:"class"`
+	Content string `json:"content"`
+	Headers string `json:"headers"`
 }
 
 func (g *gradeAPI) GetGradesTable(ctx context.Context, userID int, courseID int) ([]*GradeTable, error) {
@@ -144,7 +151,11 @@ func (g *gradeAPI) GetGradesTable(ctx context.Context, userID int, courseID int)
 	if len(res.Warnings) > 0 {
 		return nil, res.Warnings
 	}
+	return mapGetGradesTableResponse(&res)
+}
 
+// mapGetGradesTableResponse decodes the table data, which contains empty arrays for empty rows, and maps the tables
+func mapGetGradesTableResponse(res *getGradesTableResponse) ([]*GradeTable, error) {
 	for i, t := range res.Tables {
 		for _, td := range t.TableDataRaw {
 			switch td.(type) {
@@ -164,7 +175,282 @@ func (g *gradeAPI) GetGradesTable(ctx context.Context, userID int, courseID int)
 		}
 	}
 
-	return mapToGradeTableList(&res)
+	return mapToGradeTableList(res)
+}
+
+type courseGradeResponse struct {
+	CourseID int     `json:"courseid"`
+	Grade    string  `json:"grade"`
+	RawGrade *string `json:"rawgrade"`
+	Rank     *int    `json:"rank,omitempty"`
+}
+
+type getCourseGradesResponse struct {
+	Grades   []*courseGradeResponse `json:"grades"`
+	Warnings Warnings               `json:"warnings"`
+}
+
+func (g *gradeAPI) GetCourseGrades(ctx context.Context, userID int) ([]*CourseGrade, error) {
+	params := map[string]string{
+		"wsfunction": "gradereport_overview_get_course_grades",
+	}
+	if userID != 0 {
+		params["userid"] = strconv.Itoa(userID)
+	}
+	res := getCourseGradesResponse{}
+	if err := g.callMoodleFunction(ctx, &res, params); err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+
+	return mapToCourseGradeList(res.Grades)
+}
+
+type gradeReportUserResponse struct {
+	ID                   int    `json:"id"`
+	Fullname             string `json:"fullname"`
+	Email                string `json:"email"`
+	ProfileImageURL      string `json:"profileimageurl"`
+	ProfileImageURLSmall string `json:"profileimageurlsmall"`
+}
+
+type getEnrolledUsersForSearchWidgetResponse struct {
+	Users    []*gradeReportUserResponse `json:"users"`
+	Warnings Warnings                   `json:"warnings"`
+}
+
+func (g *gradeAPI) GetEnrolledUsersForSearchWidget(ctx context.Context, courseID int, groupID int) ([]*GradeReportUser, error) {
+	actionBaseURL := urlutil.Copy(g.serviceURL)
+	actionBaseURL.Path = path.Join(actionBaseURL.Path, "/grade/report/user/index.php")
+	params := map[string]string{
+		"wsfunction":    "core_grades_get_enrolled_users_for_search_widget",
+		"courseid":      strconv.Itoa(courseID),
+		"actionbaseurl": actionBaseURL.String(),
+	}
+	if groupID != 0 {
+		params["groupid"] = strconv.Itoa(groupID)
+	}
+	res := getEnrolledUsersForSearchWidgetResponse{}
+	if err := g.callMoodleFunction(ctx, &res, params); err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+
+	return mapToGradeReportUserList(res.Users), nil
+}
+
+type getUserReportAccessInformationResponse struct {
+	CanViewUserReport bool     `json:"canviewusergradereport"`
+	CanViewMyGrades   bool     `json:"canviewmygrades"`
+	CanViewAllGrades  bool     `json:"canviewallgrades"`
+	Warnings          Warnings `json:"warnings"`
+}
+
+func (g *gradeAPI) GetUserReportAccessInformation(ctx context.Context, courseID int) (*GradeReportAccessInformation, error) {
+	res := getUserReportAccessInformationResponse{}
+	err := g.callMoodleFunction(ctx, &res, map[string]string{
+		"wsfunction": "gradereport_user_get_access_information",
+		"courseid":   strconv.Itoa(courseID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+
+	return &GradeReportAccessInformation{
+		CanViewUserReport: res.CanViewUserReport,
+		CanViewMyGrades:   res.CanViewMyGrades,
+		CanViewAllGrades:  res.CanViewAllGrades,
+	}, nil
+}
+
+type getCourseGradeItemsResponse struct {
+	GradeItems []*struct {
+		ID       int    `json:"id"`
+		ItemName string `json:"itemname"`
+		Category string `json:"category"`
+	} `json:"gradeItems"`
+	Warnings Warnings `json:"warnings"`
+}
+
+func (g *gradeAPI) GetCourseGradeItems(ctx context.Context, courseID int) ([]*CourseGradeItem, error) {
+	res := getCourseGradeItemsResponse{}
+	err := g.callMoodleFunction(ctx, &res, map[string]string{
+		"wsfunction": "core_grades_get_gradeitems",
+		"courseid":   strconv.Itoa(courseID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+
+	gradeItems := make([]*CourseGradeItem, 0, len(res.GradeItems))
+	for _, gradeItemRes := range res.GradeItems {
+		gradeItems = append(gradeItems, &CourseGradeItem{
+			ID:       gradeItemRes.ID,
+			ItemName: gradeItemRes.ItemName,
+			Category: gradeItemRes.Category,
+		})
+	}
+	return gradeItems, nil
+}
+
+func (g *gradeAPI) UpdateGrades(ctx context.Context, params *UpdateGradesParams) error {
+	queryParams := map[string]string{
+		"wsfunction": "core_grades_update_grades",
+		"source":     params.Source,
+		"courseid":   strconv.Itoa(params.CourseID),
+		"component":  params.Component,
+		"activityid": strconv.Itoa(params.ActivityID),
+		"itemnumber": strconv.Itoa(params.ItemNumber),
+	}
+	for i, grade := range params.Grades {
+		queryParams[fmt.Sprintf("grades[%d][studentid]", i)] = strconv.Itoa(grade.StudentID)
+		if grade.Grade != nil {
+			queryParams[fmt.Sprintf("grades[%d][grade]", i)] = strconv.FormatFloat(*grade.Grade, 'f', -1, 64)
+		}
+		if grade.Feedback != "" {
+			queryParams[fmt.Sprintf("grades[%d][str_feedback]", i)] = grade.Feedback
+		}
+	}
+
+	var status GradeUpdateStatus
+	if err := g.postMoodleFunction(ctx, &status, queryParams); err != nil {
+		return err
+	}
+	if status != GradeUpdateStatusOK {
+		return &GradeUpdateStatusError{Status: status}
+	}
+	return nil
+}
+
+type gradingPanelPointGradeResponse struct {
+	Grade            *float64 `json:"grade"`
+	UserGrade        string   `json:"usergrade"`
+	MaxGrade         int      `json:"maxgrade"`
+	GradedBy         string   `json:"gradedby"`
+	TimeCreatedUnix  int64    `json:"timecreated"`
+	TimeModifiedUnix int64    `json:"timemodified"`
+}
+
+type gradingPanelPointResponse struct {
+	HasGrade bool                            `json:"hasgrade"`
+	Grade    *gradingPanelPointGradeResponse `json:"grade"`
+	Warnings Warnings                        `json:"warnings"`
+}
+
+func (g *gradeAPI) FetchGradingPanelPointGrade(ctx context.Context, item *GradingPanelItem) (*GradingPanelPointGrade, error) {
+	res := gradingPanelPointResponse{}
+	err := g.callMoodleFunction(
+		ctx,
+		&res,
+		map[string]string{"wsfunction": "core_grades_grader_gradingpanel_point_fetch"},
+		mapGradingPanelItemToQueryParams(item),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+	return mapToGradingPanelPointGrade(&res), nil
+}
+
+func (g *gradeAPI) StoreGradingPanelPointGrade(ctx context.Context, item *GradingPanelItem, grade float64, notifyUser bool) (*GradingPanelPointGrade, error) {
+	formData := url.Values{"grade": {strconv.FormatFloat(grade, 'f', -1, 64)}}
+	res := gradingPanelPointResponse{}
+	err := g.postMoodleFunction(
+		ctx,
+		&res,
+		map[string]string{
+			"wsfunction": "core_grades_grader_gradingpanel_point_store",
+			"notifyuser": mapBoolToBitStr(notifyUser),
+			"formdata":   formData.Encode(),
+		},
+		mapGradingPanelItemToQueryParams(item),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+	return mapToGradingPanelPointGrade(&res), nil
+}
+
+type gradingPanelScaleGradeResponse struct {
+	Options []*struct {
+		Value    int    `json:"value"`
+		Title    string `json:"title"`
+		Selected bool   `json:"selected"`
+	} `json:"options"`
+	UserGrade        string `json:"usergrade"`
+	MaxGrade         int    `json:"maxgrade"`
+	GradedBy         string `json:"gradedby"`
+	TimeCreatedUnix  int64  `json:"timecreated"`
+	TimeModifiedUnix int64  `json:"timemodified"`
+}
+
+type gradingPanelScaleResponse struct {
+	HasGrade bool                            `json:"hasgrade"`
+	Grade    *gradingPanelScaleGradeResponse `json:"grade"`
+	Warnings Warnings                        `json:"warnings"`
+}
+
+func (g *gradeAPI) FetchGradingPanelScaleGrade(ctx context.Context, item *GradingPanelItem) (*GradingPanelScaleGrade, error) {
+	res := gradingPanelScaleResponse{}
+	err := g.callMoodleFunction(
+		ctx,
+		&res,
+		map[string]string{"wsfunction": "core_grades_grader_gradingpanel_scale_fetch"},
+		mapGradingPanelItemToQueryParams(item),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+	return mapToGradingPanelScaleGrade(&res), nil
+}
+
+func (g *gradeAPI) StoreGradingPanelScaleGrade(ctx context.Context, item *GradingPanelItem, grade int, notifyUser bool) (*GradingPanelScaleGrade, error) {
+	formData := url.Values{"grade": {strconv.Itoa(grade)}}
+	res := gradingPanelScaleResponse{}
+	err := g.postMoodleFunction(
+		ctx,
+		&res,
+		map[string]string{
+			"wsfunction": "core_grades_grader_gradingpanel_scale_store",
+			"notifyuser": mapBoolToBitStr(notifyUser),
+			"formdata":   formData.Encode(),
+		},
+		mapGradingPanelItemToQueryParams(item),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+	return mapToGradingPanelScaleGrade(&res), nil
+}
+
+func mapGradingPanelItemToQueryParams(item *GradingPanelItem) map[string]string {
+	return map[string]string{
+		"component":    item.Component,
+		"contextid":    strconv.Itoa(item.ContextID),
+		"itemname":     item.ItemName,
+		"gradeduserid": strconv.Itoa(item.GradedUserID),
+	}
 }
 
 func mapToUserGradeList(userGradeResList []*userGradeResponse) []*UserGrade {
@@ -216,6 +502,7 @@ func mapToGradeItem(gradeItemRes *gradeItemResponse) *GradeItem {
 		Locked:             gradeItemRes.Locked,
 		CmID:               gradeItemRes.CmID,
 		GradeRaw:           gradeItemRes.GradeRaw,
+		WeightRaw:          gradeItemRes.WeightRaw,
 		GradeDateSubmitted: gradeDateSubmitted,
 		GradeDateGraded:    gradeDateGraded,
 		GradeHiddenByDate:  gradeItemRes.GradeHiddenByDate,
@@ -232,118 +519,86 @@ func mapToGradeItem(gradeItemRes *gradeItemResponse) *GradeItem {
 	}
 }
 
-func mapToGradeTableList(res *getGradesTableResponse) ([]*GradeTable, error) {
-	gradeTables := make([]*GradeTable, 0, len(res.Tables))
-	for _, table := range res.Tables {
-		tableItemGroups, err := mapToGradeTableItemGroupList(table.TableData)
+func mapToCourseGradeList(courseGradeResList []*courseGradeResponse) ([]*CourseGrade, error) {
+	courseGrades := make([]*CourseGrade, 0, len(courseGradeResList))
+	for _, courseGradeRes := range courseGradeResList {
+		courseGrade, err := mapToCourseGrade(courseGradeRes)
 		if err != nil {
 			return nil, err
 		}
-		gradeTables = append(gradeTables, &GradeTable{
-			CourseID:     table.CourseID,
-			UserID:       table.UserID,
-			UserFullname: table.UserFullName,
-			MaxDepth:     table.MaxDepth,
-			ItemGroups:   tableItemGroups,
-		})
+		courseGrades = append(courseGrades, courseGrade)
 	}
-	return gradeTables, nil
+	return courseGrades, nil
 }
 
-func mapToGradeTableItemGroupList(tableDataItemResList []*tableDataItemResponse) ([]*GradeTableItemGroup, error) {
-	gradeTableItemGroups := make([]*GradeTableItemGroup, 0)
-
-	var groupName string
-	gradeTableItems := make([]*GradeTableItem, 0)
-	for _, gradeItemRes := range tableDataItemResList {
-		isLabelItem := gradeItemRes.Grade == nil
-		if isLabelItem {
-			if len(gradeTableItems) > 0 {
-				gradeTableItemGroups = append(gradeTableItemGroups, &GradeTableItemGroup{
-					Name:  groupName,
-					Items: gradeTableItems,
-				})
-				gradeTableItems = []*GradeTableItem{}
-			}
-			itemNameDoc, err := goquery.NewDocumentFromReader(bytes.NewReader([]byte(gradeItemRes.ItemName.Content)))
-			if err != nil {
-				return nil, err
-			}
-			groupName = itemNameDoc.Text()
-		} else {
-			// Exclude non graded item
-			if gradeItemRes.ContributionToCourseTotal == nil || gradeItemRes.ContributionToCourseTotal.Content == "-" {
-				continue
-			}
-			gradeTableItem, err := mapToGradeTableItem(gradeItemRes)
-			if err != nil {
-				return nil, err
-			}
-			gradeTableItems = append(gradeTableItems, gradeTableItem)
+func mapToCourseGrade(courseGradeRes *courseGradeResponse) (*CourseGrade, error) {
+	var rawGrade *float64
+	// raw grade is null or empty when the course is not graded yet
+	if courseGradeRes.RawGrade != nil && *courseGradeRes.RawGrade != "" {
+		f, err := strconv.ParseFloat(*courseGradeRes.RawGrade, 64)
+		if err != nil {
+			return nil, err
 		}
+		rawGrade = &f
 	}
-	gradeTableItemGroups = append(gradeTableItemGroups, &GradeTableItemGroup{
-		Name:  groupName,
-		Items: gradeTableItems,
-	})
-	return gradeTableItemGroups, nil
+	return &CourseGrade{
+		CourseID: courseGradeRes.CourseID,
+		Grade:    courseGradeRes.Grade,
+		RawGrade: rawGrade,
+		Rank:     courseGradeRes.Rank,
+	}, nil
 }
 
-var floatValueRegex = regexp.MustCompile("([0-9]*[.])?[0-9]+")
-
-func mapToGradeTableItem(tableDataItemRes *tableDataItemResponse) (*GradeTableItem, error) {
-	itemNameDoc, err := goquery.NewDocumentFromReader(bytes.NewReader([]byte(tableDataItemRes.ItemName.Content)))
-	if err != nil {
-		return nil, err
+func mapToGradeReportUserList(userResList []*gradeReportUserResponse) []*GradeReportUser {
+	users := make([]*GradeReportUser, 0, len(userResList))
+	for _, userRes := range userResList {
+		users = append(users, &GradeReportUser{
+			ID:                   userRes.ID,
+			Fullname:             userRes.Fullname,
+			Email:                userRes.Email,
+			ProfileImageURL:      userRes.ProfileImageURL,
+			ProfileImageURLSmall: userRes.ProfileImageURLSmall,
+		})
 	}
+	return users
+}
 
-	var itemURL *string
-	if href, itemURLExist := itemNameDoc.Find("a").Attr("href"); itemURLExist {
-		itemURL = &href
+func mapToGradingPanelPointGrade(res *gradingPanelPointResponse) *GradingPanelPointGrade {
+	gradeRes := res.Grade
+	if gradeRes == nil {
+		return &GradingPanelPointGrade{HasGrade: res.HasGrade}
 	}
-
-	isGraded := tableDataItemRes.Grade.Content != "-"
-	var grade float64
-	if isGraded {
-		grade, err = strconv.ParseFloat(tableDataItemRes.Grade.Content, 64)
-		if err != nil {
-			return nil, err
-		}
+	return &GradingPanelPointGrade{
+		HasGrade:     res.HasGrade,
+		Grade:        gradeRes.Grade,
+		UserGrade:    gradeRes.UserGrade,
+		MaxGrade:     gradeRes.MaxGrade,
+		GradedBy:     gradeRes.GradedBy,
+		TimeCreated:  time.Unix(gradeRes.TimeCreatedUnix, 0),
+		TimeModified: time.Unix(gradeRes.TimeModifiedUnix, 0),
 	}
+}
 
-	// the content is something like "0-100"
-	gradeRangeMatch := floatValueRegex.FindAllString(tableDataItemRes.Range.Content, 2)
-	gradeRangeMin, err := strconv.ParseFloat(gradeRangeMatch[0], 64)
-	if err != nil {
-		return nil, err
+func mapToGradingPanelScaleGrade(res *gradingPanelScaleResponse) *GradingPanelScaleGrade {
+	gradeRes := res.Grade
+	if gradeRes == nil {
+		return &GradingPanelScaleGrade{HasGrade: res.HasGrade}
 	}
-	gradeRangeMax, err := strconv.ParseFloat(gradeRangeMatch[1], 64)
-	if err != nil {
-		return nil, err
-	}
-
-	feedbackDoc, err := goquery.NewDocumentFromReader(bytes.NewReader([]byte(tableDataItemRes.Feedback.Content)))
-	if err != nil {
-		return nil, err
+	options := make([]*GradingPanelScaleOption, 0, len(gradeRes.Options))
+	for _, o := range gradeRes.Options {
+		options = append(options, &GradingPanelScaleOption{
+			Value:    o.Value,
+			Title:    o.Title,
+			Selected: o.Selected,
+		})
 	}
-
-	// the content is something like "10.00 %"
-	contributionMatch := floatValueRegex.FindStringSubmatch(tableDataItemRes.ContributionToCourseTotal.Content)
-	contributionToCourseTotal, err := strconv.ParseFloat(contributionMatch[0], 64)
-	if err != nil {
-		return nil, err
+	return &GradingPanelScaleGrade{
+		HasGrade:     res.HasGrade,
+		Options:      options,
+		UserGrade:    gradeRes.UserGrade,
+		MaxGrade:     gradeRes.MaxGrade,
+		GradedBy:     gradeRes.GradedBy,
+		TimeCreated:  time.Unix(gradeRes.TimeCreatedUnix, 0),
+		TimeModified: time.Unix(gradeRes.TimeModifiedUnix, 0),
 	}
-
-	return &GradeTableItem{
-		ItemName:                  itemNameDoc.Text(),
-		ItemNameRawHTML:           tableDataItemRes.ItemName.Content,
-		ItemURL:                   itemURL,
-		IsGraded:                  isGraded,
-		Grade:                     grade,
-		GradeRangeMin:             gradeRangeMin,
-		GradeRangeMax:             gradeRangeMax,
-		Feedback:                  feedbackDoc.Text(),
-		FeedBackRawHTML:           tableDataItemRes.Feedback.Content,
-		ContributionToCourseTotal: contributionToCourseTotal,
-	}, nil
 }
@@ -264,7 +264,10 @@ func Test_gradeAPI_GetGradesTable(t *testing.T) {
 									ItemURL:                   func() *string { s := "https://test.edu/mod/workshop/view.php?id=111111"; return &s }(),
 									IsGraded:                  true,
 									Grade:                     92,
+									GradeFormatted:            "92.00",
+									GradeDisplayType:          GradeDisplayTypeReal,
 									GradeRangeMax:             100,
+									RangeFormatted:            "0–100",
 									Feedback:                  "\u00a0",
 									FeedBackRawHTML:           "&nbsp;",
 									ContributionToCourseTotal: 2.7,
@@ -272,6 +275,46 @@ func Test_gradeAPI_GetGradesTable(t *testing.T) {
 							},
 						},
 					},
+					Category: &GradeTableCategory{
+						Name:    "Test Course",
+						Depth:   1,
+						RowSpan: 52,
+						Categories: []*GradeTableCategory{
+							{
+								Name:    "Assignments",
+								Depth:   2,
+								RowSpan: 14,
+								Items: []*GradeTableItem{
+									{
+										ItemName:                  "Assignment 1",
+										ItemNameRawHTML:           `<a title="Assignment 1" class="gradeitemheader" href="https://test.edu/mod/workshop/view.php?id=111111"><img class="icon itemicon" src="https://test.edu/theme/image.php/lambda/workshop/1620139498/icon" alt="Workshop" />Assignment 1</a>`,
+										ItemURL:                   func() *string { s := "https://test.edu/mod/workshop/view.php?id=111111"; return &s }(),
+										IsGraded:                  true,
+										Grade:                     92,
+										GradeFormatted:            "92.00",
+										GradeDisplayType:          GradeDisplayTypeReal,
+										GradeRangeMax:             100,
+										RangeFormatted:            "0–100",
+										Feedback:                  "\u00a0",
+										FeedBackRawHTML:           "&nbsp;",
+										ContributionToCourseTotal: 2.7,
+									},
+								},
+							},
+						},
+						Total: &GradeTableItem{
+							ItemName:         "Course total",
+							ItemNameRawHTML:  `<span class="gradeitemheader" title="Course total" tabindex="0"><img class="icon icon itemicon" alt="Weighted mean of grades" title="Weighted mean of grades" src="https://test.edu/theme/image.php/lambda/core/1620139498/i/agg_mean" />Course total</span><div class="gradeitemdescription">Weighted mean of grades. Include empty grades.</div><div class="gradeitemdescriptionfiller"></div>`,
+							IsGraded:         true,
+							Grade:            30.79,
+							GradeFormatted:   "30.79",
+							GradeDisplayType: GradeDisplayTypeReal,
+							GradeRangeMax:    100,
+							RangeFormatted:   "0–100",
+							Feedback:         "\u00a0",
+							FeedBackRawHTML:  "&nbsp;",
+						},
+					},
 				},
 			},
 		},
@@ -309,6 +352,534 @@ func Test_gradeAPI_GetGradesTable(t *testing.T) {
 	}
 }
 
+func Test_gradeAPI_GetCourseGrades(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userID int
+	}
+	tests := []struct {
+		name     string
+		args     args
+		response string
+		want     []*CourseGrade
+		wantErr  bool
+	}{
+		{
+			name: "Successful response",
+			args: args{ctx: context.Background()},
+			response: `{
+  "grades": [
+    {"courseid": 1111, "grade": "85.50", "rawgrade": "85.50000", "rank": 3},
+    {"courseid": 2222, "grade": "-", "rawgrade": null}
+  ],
+  "warnings": []
+}`,
+			want: []*CourseGrade{
+				{
+					CourseID: 1111,
+					Grade:    "85.50",
+					RawGrade: func() *float64 { f := 85.5; return &f }(),
+					Rank:     func() *int { i := 3; return &i }(),
+				},
+				{
+					CourseID: 2222,
+					Grade:    "-",
+				},
+			},
+		},
+		{
+			name:     "Invalid raw grade response",
+			args:     args{ctx: context.Background(), userID: 1111},
+			response: `{"grades":[{"courseid":1111,"grade":"A","rawgrade":"A"}],"warnings":[]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Warning response",
+			args:     args{ctx: context.Background(), userID: 1111},
+			response: `{"grades":[],"warnings":[{"item":"user","itemid":1111,"warningcode":"1","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Error response",
+			args:     args{ctx: context.Background(), userID: 0000},
+			response: `{"exception":"required_capability_exception","errorcode":"nopermissions","message":"Sorry, but you do not currently have permissions to do that."}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			args:     args{ctx: context.Background()},
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGradeAPI(t, tt.response)
+			got, err := g.GetCourseGrades(tt.args.ctx, tt.args.userID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetCourseGrades() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetCourseGrades() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_gradeAPI_GetEnrolledUsersForSearchWidget(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		courseID int
+		groupID  int
+	}
+	tests := []struct {
+		name     string
+		args     args
+		response string
+		want     []*GradeReportUser
+		wantErr  bool
+	}{
+		{
+			name: "Successful response",
+			args: args{ctx: context.Background(), courseID: 1111},
+			response: `{
+  "users": [
+    {
+      "id": 123456,
+      "fullname": "Test User",
+      "email": "test@test.edu",
+      "profileimageurl": "https://test.edu/pluginfile.php/1/user/icon/f1",
+      "profileimageurlsmall": "https://test.edu/pluginfile.php/1/user/icon/f2"
+    }
+  ],
+  "warnings": []
+}`,
+			want: []*GradeReportUser{
+				{
+					ID:                   123456,
+					Fullname:             "Test User",
+					Email:                "test@test.edu",
+					ProfileImageURL:      "https://test.edu/pluginfile.php/1/user/icon/f1",
+					ProfileImageURLSmall: "https://test.edu/pluginfile.php/1/user/icon/f2",
+				},
+			},
+		},
+		{
+			name:     "Warning response",
+			args:     args{ctx: context.Background(), courseID: 1111, groupID: 2222},
+			response: `{"users":[],"warnings":[{"item":"group","itemid":2222,"warningcode":"1","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Error response",
+			args:     args{ctx: context.Background(), courseID: 0000},
+			response: `{"exception":"dml_missing_record_exception","errorcode":"invalidrecord","message":"Can't find data record in database table course."}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			args:     args{ctx: context.Background(), courseID: 0000},
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGradeAPI(t, tt.response)
+			got, err := g.GetEnrolledUsersForSearchWidget(tt.args.ctx, tt.args.courseID, tt.args.groupID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetEnrolledUsersForSearchWidget() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetEnrolledUsersForSearchWidget() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_gradeAPI_GetUserReportAccessInformation(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		courseID int
+	}
+	tests := []struct {
+		name     string
+		args     args
+		response string
+		want     *GradeReportAccessInformation
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			args:     args{ctx: context.Background(), courseID: 1111},
+			response: `{"canviewusergradereport":true,"canviewmygrades":true,"canviewallgrades":false,"warnings":[]}`,
+			want: &GradeReportAccessInformation{
+				CanViewUserReport: true,
+				CanViewMyGrades:   true,
+				CanViewAllGrades:  false,
+			},
+		},
+		{
+			name:     "Error response",
+			args:     args{ctx: context.Background(), courseID: 0000},
+			response: `{"exception":"dml_missing_record_exception","errorcode":"invalidrecord","message":"Can't find data record in database table course."}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			args:     args{ctx: context.Background(), courseID: 0000},
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGradeAPI(t, tt.response)
+			got, err := g.GetUserReportAccessInformation(tt.args.ctx, tt.args.courseID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetUserReportAccessInformation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetUserReportAccessInformation() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_gradeAPI_GetCourseGradeItems(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		courseID int
+	}
+	tests := []struct {
+		name     string
+		args     args
+		response string
+		want     []*CourseGradeItem
+		wantErr  bool
+	}{
+		{
+			name: "Successful response",
+			args: args{ctx: context.Background(), courseID: 1111},
+			response: `{"gradeItems":[
+{"id":11,"itemname":"Lab 1","category":"Labs"},
+{"id":12,"itemname":"Labs total","category":"Coursework"},
+{"id":13,"itemname":"Course total","category":"MATH1111"}
+],"warnings":[]}`,
+			want: []*CourseGradeItem{
+				{ID: 11, ItemName: "Lab 1", Category: "Labs"},
+				{ID: 12, ItemName: "Labs total", Category: "Coursework"},
+				{ID: 13, ItemName: "Course total", Category: "MATH1111"},
+			},
+		},
+		{
+			name:     "Error response",
+			args:     args{ctx: context.Background(), courseID: 0000},
+			response: `{"exception":"dml_missing_record_exception","errorcode":"invalidrecord","message":"Can't find data record in database table course."}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			args:     args{ctx: context.Background(), courseID: 0000},
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGradeAPI(t, tt.response)
+			got, err := g.GetCourseGradeItems(tt.args.ctx, tt.args.courseID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetCourseGradeItems() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetCourseGradeItems() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_gradeAPI_UpdateGrades(t *testing.T) {
+	grade := 85.5
+	params := &UpdateGradesParams{
+		Source:     "test",
+		CourseID:   1111,
+		Component:  "mod_assign",
+		ActivityID: 2222,
+		Grades: []*GradeUpdate{
+			{StudentID: 123456, Grade: &grade, Feedback: "Good job"},
+		},
+	}
+	tests := []struct {
+		name     string
+		params   *UpdateGradesParams
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			params:   params,
+			response: "0",
+		},
+		{
+			name:     "Failed status response",
+			params:   params,
+			response: "4",
+			wantErr:  true,
+		},
+		{
+			name:     "Error response",
+			params:   params,
+			response: `{"exception":"invalid_parameter_exception","errorcode":"invalidparameter","message":"Invalid parameter value detected"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			params:   params,
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGradeAPI(t, tt.response)
+			if err := g.UpdateGrades(context.Background(), tt.params); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateGrades() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_gradeAPI_FetchGradingPanelPointGrade(t *testing.T) {
+	item := &GradingPanelItem{Component: "mod_forum", ContextID: 1111, ItemName: "forum", GradedUserID: 123456}
+	tests := []struct {
+		name     string
+		response string
+		want     *GradingPanelPointGrade
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"templatename":"core_grades/grades/grader/gradingpanel/point","hasgrade":true,"grade":{"grade":8,"usergrade":"8.00","maxgrade":10,"gradedby":"Teacher","timecreated":1577836800,"timemodified":1577837100},"warnings":[]}`,
+			want: &GradingPanelPointGrade{
+				HasGrade:     true,
+				Grade:        func() *float64 { f := 8.0; return &f }(),
+				UserGrade:    "8.00",
+				MaxGrade:     10,
+				GradedBy:     "Teacher",
+				TimeCreated:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				TimeModified: time.Date(2020, 1, 1, 0, 5, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "Warning response",
+			response: `{"warnings":[{"item":"grade","itemid":1111,"warningcode":"1","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGradeAPI(t, tt.response)
+			got, err := g.FetchGradingPanelPointGrade(context.Background(), item)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FetchGradingPanelPointGrade() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("FetchGradingPanelPointGrade() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_gradeAPI_StoreGradingPanelPointGrade(t *testing.T) {
+	item := &GradingPanelItem{Component: "mod_forum", ContextID: 1111, ItemName: "forum", GradedUserID: 123456}
+	tests := []struct {
+		name     string
+		response string
+		want     *GradingPanelPointGrade
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"templatename":"core_grades/grades/grader/gradingpanel/point","hasgrade":true,"grade":{"grade":8.5,"usergrade":"8.50","maxgrade":10,"gradedby":"Teacher","timecreated":1577836800,"timemodified":1577837100},"warnings":[]}`,
+			want: &GradingPanelPointGrade{
+				HasGrade:     true,
+				Grade:        func() *float64 { f := 8.5; return &f }(),
+				UserGrade:    "8.50",
+				MaxGrade:     10,
+				GradedBy:     "Teacher",
+				TimeCreated:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				TimeModified: time.Date(2020, 1, 1, 0, 5, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"nopermissions","message":"Sorry, but you do not currently have permissions to do that."}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Warning response",
+			response: `{"warnings":[{"item":"grade","itemid":1111,"warningcode":"1","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGradeAPI(t, tt.response)
+			got, err := g.StoreGradingPanelPointGrade(context.Background(), item, 8.5, true)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("StoreGradingPanelPointGrade() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("StoreGradingPanelPointGrade() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_gradeAPI_FetchGradingPanelScaleGrade(t *testing.T) {
+	item := &GradingPanelItem{Component: "mod_forum", ContextID: 1111, ItemName: "forum", GradedUserID: 123456}
+	tests := []struct {
+		name     string
+		response string
+		want     *GradingPanelScaleGrade
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"templatename":"core_grades/grades/grader/gradingpanel/scale","hasgrade":true,"grade":{"options":[{"value":1,"title":"Fail","selected":true},{"value":2,"title":"Pass","selected":false}],"usergrade":"Fail","maxgrade":2,"gradedby":"Teacher","timecreated":1577836800,"timemodified":1577837100},"warnings":[]}`,
+			want: &GradingPanelScaleGrade{
+				HasGrade: true,
+				Options: []*GradingPanelScaleOption{
+					{Value: 1, Title: "Fail", Selected: true},
+					{Value: 2, Title: "Pass"},
+				},
+				UserGrade:    "Fail",
+				MaxGrade:     2,
+				GradedBy:     "Teacher",
+				TimeCreated:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				TimeModified: time.Date(2020, 1, 1, 0, 5, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "Warning response",
+			response: `{"warnings":[{"item":"grade","itemid":1111,"warningcode":"1","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGradeAPI(t, tt.response)
+			got, err := g.FetchGradingPanelScaleGrade(context.Background(), item)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FetchGradingPanelScaleGrade() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("FetchGradingPanelScaleGrade() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_gradeAPI_StoreGradingPanelScaleGrade(t *testing.T) {
+	item := &GradingPanelItem{Component: "mod_forum", ContextID: 1111, ItemName: "forum", GradedUserID: 123456}
+	tests := []struct {
+		name     string
+		response string
+		want     *GradingPanelScaleGrade
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"templatename":"core_grades/grades/grader/gradingpanel/scale","hasgrade":true,"grade":{"options":[{"value":1,"title":"Fail","selected":false},{"value":2,"title":"Pass","selected":true}],"usergrade":"Pass","maxgrade":2,"gradedby":"Teacher","timecreated":1577836800,"timemodified":1577837100},"warnings":[]}`,
+			want: &GradingPanelScaleGrade{
+				HasGrade: true,
+				Options: []*GradingPanelScaleOption{
+					{Value: 1, Title: "Fail"},
+					{Value: 2, Title: "Pass", Selected: true},
+				},
+				UserGrade:    "Pass",
+				MaxGrade:     2,
+				GradedBy:     "Teacher",
+				TimeCreated:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				TimeModified: time.Date(2020, 1, 1, 0, 5, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"nopermissions","message":"Sorry, but you do not currently have permissions to do that."}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGradeAPI(t, tt.response)
+			got, err := g.StoreGradingPanelScaleGrade(context.Background(), item, 2, false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("StoreGradingPanelScaleGrade() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("StoreGradingPanelScaleGrade() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
 func mockGradeAPI(t *testing.T, response string) *gradeAPI {
 	t.Helper()
 
@@ -320,6 +891,7 @@ func mockGradeAPI(t *testing.T, response string) *gradeAPI {
 	return &gradeAPI{
 		&apiClient{
 			httpClient: http.DefaultClient,
+			serviceURL: apiURL,
 			apiURL:     apiURL,
 		},
 	}
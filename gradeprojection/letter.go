@@ -0,0 +1,42 @@
+package gradeprojection
+
+import (
+	"sort"
+)
+
+// LetterBoundary represents the minimum percentage (0 to 100) to get the letter
+type LetterBoundary struct {
+	Letter string
+	Min    float64
+}
+
+// DefaultLetterBoundaries are the default grade letters of Moodle.
+// Moodle doesn't expose the letter settings via web service, so configure them if the course overrides the defaults.
+var DefaultLetterBoundaries = []*LetterBoundary{
+	{Letter: "A", Min: 93},
+	{Letter: "A-", Min: 90},
+	{Letter: "B+", Min: 87},
+	{Letter: "B", Min: 83},
+	{Letter: "B-", Min: 80},
+	{Letter: "C+", Min: 77},
+	{Letter: "C", Min: 73},
+	{Letter: "C-", Min: 70},
+	{Letter: "D+", Min: 67},
+	{Letter: "D", Min: 60},
+	{Letter: "F", Min: 0},
+}
+
+// Letter returns the letter for the percentage. "" is returned if no boundary matches.
+func Letter(percentage float64, boundaries []*LetterBoundary) string {
+	sorted := make([]*LetterBoundary, len(boundaries))
+	copy(sorted, boundaries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Min > sorted[j].Min
+	})
+	for _, b := range sorted {
+		if percentage >= b.Min {
+			return b.Letter
+		}
+	}
+	return ""
+}
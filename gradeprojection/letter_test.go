@@ -0,0 +1,37 @@
+package gradeprojection
+
+import (
+	"testing"
+)
+
+func TestLetter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		percentage float64
+		boundaries []*LetterBoundary
+		want       string
+	}{
+		{name: "exact boundary", percentage: 93, boundaries: DefaultLetterBoundaries, want: "A"},
+		{name: "between boundaries", percentage: 85.5, boundaries: DefaultLetterBoundaries, want: "B"},
+		{name: "lowest letter", percentage: 10, boundaries: DefaultLetterBoundaries, want: "F"},
+		{
+			name:       "unsorted boundaries",
+			percentage: 75,
+			boundaries: []*LetterBoundary{{Letter: "Fail", Min: 0}, {Letter: "Pass", Min: 50}, {Letter: "Merit", Min: 80}},
+			want:       "Pass",
+		},
+		{name: "no boundary matches", percentage: 10, boundaries: []*LetterBoundary{{Letter: "Pass", Min: 50}}, want: ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Letter(tt.percentage, tt.boundaries); got != tt.want {
+				t.Errorf("Letter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Package gradeprojection calculates the current standing and the projected course total of a student
+// from the weighted grade items of a course.
+//
+// FetchItems fetches the items of a student with the categories of the grade items from core_grades_get_gradeitems,
+// so that the categories nested at any depth are weighted. The items can also be built from the grade reports
+// with ItemsFromGradeItems, or ItemsFromGradeTable which estimates the weights hidden in the table.
+// The letter boundaries are given by the caller since no web service function returns the letter settings,
+// DefaultLetterBoundaries are the defaults of Moodle for the courses not overriding them.
+// The projection assumes the course total is the weighted mean of the items, e.g. extra credits are not supported.
+package gradeprojection
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrUnknownLetter is returned when the letter is not found in the letter boundaries
+var ErrUnknownLetter = errors.New("unknown letter")
+
+// Item represents a grade item contributing to the course total
+type Item struct {
+	Name string
+	// Weight is the weight of the item in the course total (0 to 1)
+	Weight float64
+	Min    float64
+	Max    float64
+	Graded bool
+	// Grade is the grade of the item in its range, which is used only when Graded is true
+	Grade float64
+}
+
+// normalizedGrade returns the grade normalized to 0 to 1
+func (i *Item) normalizedGrade() float64 {
+	if i.Max <= i.Min {
+		return 0
+	}
+	n := (i.Grade - i.Min) / (i.Max - i.Min)
+	return math.Max(0, math.Min(1, n))
+}
+
+// Projection represents the current standing and the projected course total in percentage (0 to 100)
+type Projection struct {
+	// Current is the weighted mean of the graded items only
+	Current float64
+	// Worst is the course total when all the remaining items get the minimum grade
+	Worst float64
+	// Best is the course total when all the remaining items get the maximum grade
+	Best float64
+	// GradedWeight and RemainingWeight are the share of the graded and ungraded items in the course total (0 to 1)
+	GradedWeight    float64
+	RemainingWeight float64
+}
+
+// Project calculates the current standing and the best and worst case course totals.
+// Weights are normalized by their sum, so that items excluded from the list (e.g. hidden items) don't affect the result.
+func Project(items []*Item) *Projection {
+	earned, gradedWeight, remainingWeight := sumWeights(items)
+	totalWeight := gradedWeight + remainingWeight
+	projection := &Projection{}
+	if totalWeight <= 0 {
+		return projection
+	}
+	if gradedWeight > 0 {
+		projection.Current = earned / gradedWeight * 100
+	}
+	projection.Worst = earned / totalWeight * 100
+	projection.Best = (earned + remainingWeight) / totalWeight * 100
+	projection.GradedWeight = gradedWeight / totalWeight
+	projection.RemainingWeight = remainingWeight / totalWeight
+	return projection
+}
+
+// Requirement represents the grades required on the ungraded items to reach a target course total
+type Requirement struct {
+	// Target is the target course total in percentage (0 to 100)
+	Target float64
+	// Percentage is the percentage (0 to 100) required on every ungraded item.
+	// It's 0 if the target is already secured, and more than 100 if it's not achievable.
+	Percentage float64
+	// Achievable is false if the target can't be reached even with the maximum grades
+	Achievable bool
+	// Scores are the minimum grades required on each ungraded item in its range
+	Scores []*ItemScore
+}
+
+type ItemScore struct {
+	Item  *Item
+	Score float64
+}
+
+// Required calculates the minimum percentage required uniformly on the ungraded items to reach the target course total.
+func Required(items []*Item, target float64) *Requirement {
+	earned, gradedWeight, remainingWeight := sumWeights(items)
+	totalWeight := gradedWeight + remainingWeight
+
+	requirement := &Requirement{Target: target}
+	if totalWeight <= 0 {
+		requirement.Achievable = target <= 0
+		return requirement
+	}
+	needed := target/100*totalWeight - earned
+	switch {
+	case needed <= 0:
+		requirement.Achievable = true
+	case remainingWeight <= 0:
+		requirement.Percentage = math.Inf(1)
+	default:
+		requirement.Percentage = needed / remainingWeight * 100
+		requirement.Achievable = requirement.Percentage <= 100
+	}
+
+	for _, item := range items {
+		if item.Graded || item.Weight <= 0 {
+			continue
+		}
+		score := item.Min + math.Min(requirement.Percentage, 100)/100*(item.Max-item.Min)
+		requirement.Scores = append(requirement.Scores, &ItemScore{Item: item, Score: score})
+	}
+	return requirement
+}
+
+// RequiredForLetter calculates the minimum percentage required on the ungraded items to get the letter.
+func RequiredForLetter(items []*Item, letter string, boundaries []*LetterBoundary) (*Requirement, error) {
+	for _, b := range boundaries {
+		if b.Letter == letter {
+			return Required(items, b.Min), nil
+		}
+	}
+	return nil, ErrUnknownLetter
+}
+
+func sumWeights(items []*Item) (earned, gradedWeight, remainingWeight float64) {
+	for _, item := range items {
+		if item.Weight <= 0 {
+			continue
+		}
+		if item.Graded {
+			earned += item.Weight * item.normalizedGrade()
+			gradedWeight += item.Weight
+		} else {
+			remainingWeight += item.Weight
+		}
+	}
+	return earned, gradedWeight, remainingWeight
+}
@@ -0,0 +1,150 @@
+package gradeprojection
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"math"
+	"testing"
+)
+
+func testItems() []*Item {
+	return []*Item{
+		{Name: "Assignment 1", Weight: 0.2, Min: 0, Max: 100, Graded: true, Grade: 90},
+		{Name: "Assignment 2", Weight: 0.2, Min: 0, Max: 10, Graded: true, Grade: 7},
+		{Name: "Final exam", Weight: 0.6, Min: 0, Max: 50},
+	}
+}
+
+func TestProject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		items []*Item
+		want  *Projection
+	}{
+		{
+			name:  "partially graded",
+			items: testItems(),
+			want: &Projection{
+				Current:         80,
+				Worst:           32,
+				Best:            92,
+				GradedWeight:    0.4,
+				RemainingWeight: 0.6,
+			},
+		},
+		{
+			name: "weights are normalized",
+			items: []*Item{
+				{Weight: 0.1, Max: 100, Graded: true, Grade: 50},
+				{Weight: 0.1, Max: 100},
+			},
+			want: &Projection{
+				Current:         50,
+				Worst:           25,
+				Best:            75,
+				GradedWeight:    0.5,
+				RemainingWeight: 0.5,
+			},
+		},
+		{
+			name:  "no items",
+			items: nil,
+			want:  &Projection{},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := Project(tt.items)
+			if diff := cmp.Diff(got, tt.want, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Project() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRequired(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		items  []*Item
+		target float64
+		want   *Requirement
+	}{
+		{
+			name:   "achievable target",
+			items:  testItems(),
+			target: 80,
+			want: &Requirement{
+				Target:     80,
+				Percentage: 80,
+				Achievable: true,
+				Scores:     []*ItemScore{{Item: testItems()[2], Score: 40}},
+			},
+		},
+		{
+			name:   "already secured target",
+			items:  testItems(),
+			target: 30,
+			want: &Requirement{
+				Target:     30,
+				Percentage: 0,
+				Achievable: true,
+				Scores:     []*ItemScore{{Item: testItems()[2], Score: 0}},
+			},
+		},
+		{
+			name:   "not achievable target",
+			items:  testItems(),
+			target: 95,
+			want: &Requirement{
+				Target:     95,
+				Percentage: 105,
+				Achievable: false,
+				Scores:     []*ItemScore{{Item: testItems()[2], Score: 50}},
+			},
+		},
+		{
+			name:   "no remaining items",
+			items:  testItems()[:2],
+			target: 90,
+			want: &Requirement{
+				Target:     90,
+				Percentage: math.Inf(1),
+				Achievable: false,
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := Required(tt.items, tt.target)
+			if diff := cmp.Diff(got, tt.want, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Required() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRequiredForLetter(t *testing.T) {
+	t.Parallel()
+
+	got, err := RequiredForLetter(testItems(), "B-", DefaultLetterBoundaries)
+	if err != nil {
+		t.Fatalf("RequiredForLetter() error = %v", err)
+	}
+	if math.Abs(got.Percentage-80) > 1e-9 || !got.Achievable {
+		t.Errorf("RequiredForLetter() = %v, want percentage 80", got)
+	}
+
+	if _, err := RequiredForLetter(testItems(), "Z", DefaultLetterBoundaries); err != ErrUnknownLetter {
+		t.Errorf("RequiredForLetter() error = %v, want %v", err, ErrUnknownLetter)
+	}
+}
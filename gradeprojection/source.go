@@ -0,0 +1,244 @@
+package gradeprojection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/k-yomo/moodle"
+)
+
+const (
+	gradeItemTypeCourse   = "course"
+	gradeItemTypeCategory = "category"
+)
+
+// ItemsFromGradeTable builds items from the grade table.
+// The weight of an item is taken from the weight column multiplied by the weights of its parent categories.
+// If the weight column is hidden, it's derived from the contribution to course total of the graded item.
+// The share of the course total not covered by the known weights is split among the items with unknown weight
+// (e.g. ungraded items without the weight column, or items of a category without the total row) in proportion to their ranges.
+func ItemsFromGradeTable(table *moodle.GradeTable) []*Item {
+	if table.Category == nil {
+		return nil
+	}
+	var knownWeight float64
+	var unknownItems []*Item
+	items := itemsFromGradeTableCategory(table.Category, 1, true)
+	for _, item := range items {
+		if item.weightKnown {
+			knownWeight += item.Weight
+		} else {
+			unknownItems = append(unknownItems, item.Item)
+		}
+	}
+	distributeWeight(unknownItems, 1-knownWeight)
+
+	result := make([]*Item, 0, len(items))
+	for _, item := range items {
+		result = append(result, item.Item)
+	}
+	return result
+}
+
+// weightedItem is an item whose weight may be unknown from the grade table
+type weightedItem struct {
+	*Item
+	weightKnown bool
+}
+
+// itemsFromGradeTableCategory builds the items in the category, categoryWeightKnown is false if the category total is not shown
+func itemsFromGradeTableCategory(category *moodle.GradeTableCategory, categoryWeight float64, categoryWeightKnown bool) []*weightedItem {
+	var items []*weightedItem
+	for _, gradeTableItem := range category.Items {
+		items = append(items, itemFromGradeTableItem(gradeTableItem, categoryWeight, categoryWeightKnown))
+	}
+	for _, subCategory := range category.Categories {
+		if subCategory.Total == nil {
+			items = append(items, itemsFromGradeTableCategory(subCategory, 0, false)...)
+			continue
+		}
+		total := itemFromGradeTableItem(subCategory.Total, categoryWeight, categoryWeightKnown)
+		items = append(items, itemsFromGradeTableCategory(subCategory, total.Weight, total.weightKnown)...)
+	}
+	return items
+}
+
+// distributeWeight splits the weight among the items in proportion to their ranges, or equally if they have no range
+func distributeWeight(items []*Item, weight float64) {
+	if len(items) == 0 || weight <= 0 {
+		return
+	}
+	var totalRange float64
+	for _, item := range items {
+		totalRange += math.Max(0, item.Max-item.Min)
+	}
+	for _, item := range items {
+		if totalRange > 0 {
+			item.Weight = weight * math.Max(0, item.Max-item.Min) / totalRange
+		} else {
+			item.Weight = weight / float64(len(items))
+		}
+	}
+}
+
+func itemFromGradeTableItem(tableItem *moodle.GradeTableItem, categoryWeight float64, categoryWeightKnown bool) *weightedItem {
+	item := &Item{
+		Name:   tableItem.ItemName,
+		Min:    tableItem.GradeRangeMin,
+		Max:    tableItem.GradeRangeMax,
+		Graded: tableItem.IsGraded,
+		Grade:  tableItem.Grade,
+	}
+	isNumeric := true
+	switch {
+	case tableItem.GradeDisplayType == moodle.GradeDisplayTypePercentage:
+		item.Min, item.Max = 0, 100
+	case tableItem.GradeDisplayType == moodle.GradeDisplayTypeLetter || tableItem.GradeDisplayType == moodle.GradeDisplayTypeScale:
+		isNumeric = tableItem.Percentage != nil
+		if isNumeric {
+			item.Min, item.Max, item.Grade = 0, 100, *tableItem.Percentage
+		}
+	}
+
+	contribution := tableItem.ContributionToCourseTotal / 100
+	weightKnown := true
+	switch {
+	case tableItem.Weight != nil && categoryWeightKnown:
+		item.Weight = *tableItem.Weight / 100 * categoryWeight
+	case item.Graded && isNumeric && item.normalizedGrade() > 0:
+		item.Weight = contribution / item.normalizedGrade()
+	default:
+		weightKnown = false
+	}
+	// the grade of letter or scale item without percentage column is derived from its contribution
+	if item.Graded && !isNumeric && item.Weight > 0 {
+		item.Min, item.Max, item.Grade = 0, 100, contribution/item.Weight*100
+	}
+	return &weightedItem{Item: item, weightKnown: weightKnown}
+}
+
+// FetchItems fetches the grade items of the user in the course and builds the items with ItemsFromGradeItems.
+// The categories of the grade items are fetched from core_grades_get_gradeitems where it's available,
+// and the items are built only from the grade items of the user on the sites without it.
+func FetchItems(ctx context.Context, client *moodle.Client, userID, courseID int) ([]*Item, error) {
+	userGrades, err := client.GradeAPI.GetGradeItems(ctx, userID, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("get grade items: %w", err)
+	}
+	if len(userGrades) == 0 {
+		return nil, fmt.Errorf("grade items of user %d are not found in course %d", userID, courseID)
+	}
+	userGrade := userGrades[0]
+	for _, g := range userGrades {
+		if g.UserID == userID {
+			userGrade = g
+		}
+	}
+
+	courseGradeItems, err := client.GradeAPI.GetCourseGradeItems(ctx, courseID)
+	var apiErr *moodle.APIError
+	if err != nil && !errors.As(err, &apiErr) && !errors.Is(err, moodle.ErrFunctionNotAvailable) {
+		return nil, fmt.Errorf("get course grade items: %w", err)
+	}
+	return ItemsFromGradeItems(userGrade, courseGradeItems), nil
+}
+
+// ItemsFromUserGrade builds items from the grade items of the user.
+// The weight of an item is taken from its weight in the parent category multiplied by the weight of the category.
+// Since the parent of a category is not returned, categories are assumed to be directly under the course,
+// use ItemsFromGradeItems to weight the categories nested deeper.
+func ItemsFromUserGrade(userGrade *moodle.UserGrade) []*Item {
+	return ItemsFromGradeItems(userGrade, nil)
+}
+
+// ItemsFromGradeItems builds items from the grade items of the user and the grade items of the course
+// returned by GradeAPI.GetCourseGradeItems, which have the names of their categories.
+// The weight of an item is taken from its weight in the parent category multiplied by the weights of all the
+// ancestor categories. The category of a category is resolved by its name with the items in the category,
+// and a category whose parent can't be resolved is assumed to be directly under the course.
+func ItemsFromGradeItems(userGrade *moodle.UserGrade, courseGradeItems []*moodle.CourseGradeItem) []*Item {
+	categoryNamesByItemID := make(map[int]string)
+	for _, courseGradeItem := range courseGradeItems {
+		categoryNamesByItemID[courseGradeItem.ID] = courseGradeItem.Category
+	}
+
+	tree := &categoryTree{
+		weights: make(map[int]float64),
+		parents: make(map[int]int),
+	}
+	categoryIDsByName := make(map[string]int)
+	for _, gradeItem := range userGrade.GradeItems {
+		switch gradeItem.ItemType {
+		case gradeItemTypeCourse:
+			id := gradeItem.ItemInstance
+			tree.courseCategoryID = &id
+		case gradeItemTypeCategory:
+			if gradeItem.WeightRaw != nil {
+				tree.weights[gradeItem.ItemInstance] = *gradeItem.WeightRaw
+			}
+		default:
+			if name, ok := categoryNamesByItemID[gradeItem.ID]; ok && gradeItem.CategoryID != nil {
+				categoryIDsByName[name] = *gradeItem.CategoryID
+			}
+		}
+	}
+	for _, gradeItem := range userGrade.GradeItems {
+		if gradeItem.ItemType != gradeItemTypeCategory {
+			continue
+		}
+		parentID, ok := categoryIDsByName[categoryNamesByItemID[gradeItem.ID]]
+		if ok && parentID != gradeItem.ItemInstance {
+			tree.parents[gradeItem.ItemInstance] = parentID
+		}
+	}
+
+	var items []*Item
+	for _, gradeItem := range userGrade.GradeItems {
+		if gradeItem.ItemType == gradeItemTypeCourse || gradeItem.ItemType == gradeItemTypeCategory {
+			continue
+		}
+		var weight float64
+		if gradeItem.WeightRaw != nil {
+			weight = *gradeItem.WeightRaw
+			if gradeItem.CategoryID != nil {
+				weight *= tree.weight(*gradeItem.CategoryID)
+			}
+		}
+		items = append(items, &Item{
+			Name:   gradeItem.ItemName,
+			Weight: weight,
+			Min:    float64(gradeItem.GradeMin),
+			Max:    float64(gradeItem.GradeMax),
+			Graded: gradeItem.GradeFormatted != "" && gradeItem.GradeFormatted != "-",
+			Grade:  gradeItem.GradeRaw,
+		})
+	}
+	return items
+}
+
+// categoryTree holds the weights of the categories in their parents and the parents of the categories
+type categoryTree struct {
+	courseCategoryID *int
+	weights          map[int]float64
+	parents          map[int]int
+}
+
+// weight returns the weight of the category in the course total.
+// The categories whose weights are unknown are regarded as weighted 1 in their parents.
+func (t *categoryTree) weight(categoryID int) float64 {
+	weight := 1.0
+	// visited guards against the cycles of the categories resolved by the duplicated names
+	visited := make(map[int]bool)
+	for id, ok := categoryID, true; ok && !visited[id]; id, ok = t.parents[id] {
+		visited[id] = true
+		if t.courseCategoryID != nil && id == *t.courseCategoryID {
+			break
+		}
+		if categoryWeight, known := t.weights[id]; known {
+			weight *= categoryWeight
+		}
+	}
+	return weight
+}
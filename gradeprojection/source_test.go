@@ -0,0 +1,253 @@
+package gradeprojection
+
+import (
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/k-yomo/moodle"
+	"github.com/k-yomo/moodle/moodlemock"
+	"math"
+	"testing"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestItemsFromGradeTable(t *testing.T) {
+	t.Parallel()
+
+	table := &moodle.GradeTable{
+		Category: &moodle.GradeTableCategory{
+			Name:  "Course",
+			Depth: 1,
+			Items: []*moodle.GradeTableItem{
+				{ItemName: "Final exam", GradeRangeMax: 100, Weight: floatPtr(50)},
+			},
+			Categories: []*moodle.GradeTableCategory{
+				{
+					Name:  "Assignments",
+					Depth: 2,
+					Items: []*moodle.GradeTableItem{
+						{ItemName: "Assignment 1", IsGraded: true, Grade: 80, GradeDisplayType: moodle.GradeDisplayTypeReal, GradeRangeMax: 100, Weight: floatPtr(50), ContributionToCourseTotal: 20},
+						{ItemName: "Assignment 2", IsGraded: true, GradeDisplayType: moodle.GradeDisplayTypeLetter, GradeRangeMax: 100, Weight: floatPtr(50), Percentage: floatPtr(90), ContributionToCourseTotal: 22.5},
+					},
+					Total: &moodle.GradeTableItem{ItemName: "Assignments total", IsGraded: true, Grade: 85, GradeRangeMax: 100, Weight: floatPtr(50)},
+				},
+			},
+		},
+	}
+	want := []*Item{
+		{Name: "Final exam", Weight: 0.5, Max: 100},
+		{Name: "Assignment 1", Weight: 0.25, Max: 100, Graded: true, Grade: 80},
+		{Name: "Assignment 2", Weight: 0.25, Max: 100, Graded: true, Grade: 90},
+	}
+	if diff := cmp.Diff(ItemsFromGradeTable(table), want, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("ItemsFromGradeTable() (-got, +want)\n%s", diff)
+	}
+}
+
+func TestItemsFromGradeTable_withoutWeightColumn(t *testing.T) {
+	t.Parallel()
+
+	table := &moodle.GradeTable{
+		Category: &moodle.GradeTableCategory{
+			Items: []*moodle.GradeTableItem{
+				{ItemName: "Quiz", IsGraded: true, Grade: 8, GradeDisplayType: moodle.GradeDisplayTypeReal, GradeRangeMax: 10, ContributionToCourseTotal: 16},
+				{ItemName: "Essay", GradeRangeMax: 100},
+				{ItemName: "Report", GradeRangeMax: 300},
+			},
+		},
+	}
+	// the remaining weight is split among the ungraded items by their ranges
+	want := []*Item{
+		{Name: "Quiz", Weight: 0.2, Max: 10, Graded: true, Grade: 8},
+		{Name: "Essay", Weight: 0.2, Max: 100},
+		{Name: "Report", Weight: 0.6, Max: 300},
+	}
+	items := ItemsFromGradeTable(table)
+	if diff := cmp.Diff(items, want, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("ItemsFromGradeTable() (-got, +want)\n%s", diff)
+	}
+	if projection := Project(items); projection.Best <= projection.Worst {
+		t.Errorf("Project() best = %v, worst = %v, want best above worst", projection.Best, projection.Worst)
+	}
+}
+
+func TestItemsFromGradeTable_categoryWithoutTotal(t *testing.T) {
+	t.Parallel()
+
+	table := &moodle.GradeTable{
+		Category: &moodle.GradeTableCategory{
+			Items: []*moodle.GradeTableItem{
+				{ItemName: "Final exam", GradeRangeMax: 100, Weight: floatPtr(60)},
+			},
+			Categories: []*moodle.GradeTableCategory{
+				{
+					Name: "Assignments",
+					Items: []*moodle.GradeTableItem{
+						{ItemName: "Assignment 1", IsGraded: true, Grade: 50, GradeDisplayType: moodle.GradeDisplayTypeReal, GradeRangeMax: 100, Weight: floatPtr(50), ContributionToCourseTotal: 10},
+						{ItemName: "Assignment 2", GradeRangeMax: 100, Weight: floatPtr(50)},
+					},
+				},
+			},
+		},
+	}
+	// the items of the category are kept, and the ungraded item shares the rest of the course total
+	want := []*Item{
+		{Name: "Final exam", Weight: 0.6, Max: 100},
+		{Name: "Assignment 1", Weight: 0.2, Max: 100, Graded: true, Grade: 50},
+		{Name: "Assignment 2", Weight: 0.2, Max: 100},
+	}
+	if diff := cmp.Diff(ItemsFromGradeTable(table), want, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("ItemsFromGradeTable() (-got, +want)\n%s", diff)
+	}
+}
+
+func TestItemsFromUserGrade(t *testing.T) {
+	t.Parallel()
+
+	userGrade := &moodle.UserGrade{
+		GradeItems: []*moodle.GradeItem{
+			{ItemName: "Quiz", ItemType: "mod", CategoryID: intPtr(2), WeightRaw: floatPtr(0.5), GradeRaw: 6, GradeFormatted: "6.00", GradeMax: 10},
+			{ItemName: "Essay", ItemType: "mod", CategoryID: intPtr(2), WeightRaw: floatPtr(0.5), GradeFormatted: "-", GradeMax: 100},
+			{ItemName: "Assignments", ItemType: "category", ItemInstance: 2, WeightRaw: floatPtr(0.4), GradeFormatted: "30.00", GradeMax: 100},
+			{ItemName: "Final exam", ItemType: "manual", CategoryID: intPtr(1), WeightRaw: floatPtr(0.6), GradeFormatted: "-", GradeMax: 100},
+			{ItemName: "Course total", ItemType: "course", ItemInstance: 1, GradeFormatted: "12.00", GradeMax: 100},
+		},
+	}
+	want := []*Item{
+		{Name: "Quiz", Weight: 0.2, Max: 10, Graded: true, Grade: 6},
+		{Name: "Essay", Weight: 0.2, Max: 100},
+		{Name: "Final exam", Weight: 0.6, Max: 100},
+	}
+	if diff := cmp.Diff(ItemsFromUserGrade(userGrade), want, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("ItemsFromUserGrade() (-got, +want)\n%s", diff)
+	}
+}
+
+// nestedUserGrade has the categories nested as MATH1111 > Coursework (50%) > Labs (40%)
+func nestedUserGrade() *moodle.UserGrade {
+	return &moodle.UserGrade{
+		UserID: 10,
+		GradeItems: []*moodle.GradeItem{
+			{ID: 1, ItemName: "Lab 1", ItemType: "mod", CategoryID: intPtr(3), WeightRaw: floatPtr(1), GradeRaw: 8, GradeFormatted: "8.00", GradeMax: 10},
+			{ID: 2, ItemName: "Labs total", ItemType: "category", ItemInstance: 3, WeightRaw: floatPtr(0.4), GradeFormatted: "80.00", GradeMax: 100},
+			{ID: 3, ItemName: "Essay", ItemType: "mod", CategoryID: intPtr(2), WeightRaw: floatPtr(0.6), GradeFormatted: "-", GradeMax: 100},
+			{ID: 4, ItemName: "Coursework total", ItemType: "category", ItemInstance: 2, WeightRaw: floatPtr(0.5), GradeFormatted: "32.00", GradeMax: 100},
+			{ID: 5, ItemName: "Final exam", ItemType: "manual", CategoryID: intPtr(1), WeightRaw: floatPtr(0.5), GradeFormatted: "-", GradeMax: 100},
+			{ID: 6, ItemName: "Course total", ItemType: "course", ItemInstance: 1, GradeFormatted: "16.00", GradeMax: 100},
+		},
+	}
+}
+
+func nestedCourseGradeItems() []*moodle.CourseGradeItem {
+	return []*moodle.CourseGradeItem{
+		{ID: 1, ItemName: "Lab 1", Category: "Labs"},
+		{ID: 2, ItemName: "Labs total", Category: "Coursework"},
+		{ID: 3, ItemName: "Essay", Category: "Coursework"},
+		{ID: 4, ItemName: "Coursework total", Category: "MATH1111"},
+		{ID: 5, ItemName: "Final exam", Category: "MATH1111"},
+		{ID: 6, ItemName: "Course total", Category: "MATH1111"},
+	}
+}
+
+func TestItemsFromGradeItems(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		courseGradeItems []*moodle.CourseGradeItem
+		want             []*Item
+	}{
+		{
+			name:             "nested categories",
+			courseGradeItems: nestedCourseGradeItems(),
+			want: []*Item{
+				{Name: "Lab 1", Weight: 0.2, Max: 10, Graded: true, Grade: 8},
+				{Name: "Essay", Weight: 0.3, Max: 100},
+				{Name: "Final exam", Weight: 0.5, Max: 100},
+			},
+		},
+		{
+			name: "category of category is itself",
+			courseGradeItems: []*moodle.CourseGradeItem{
+				{ID: 1, ItemName: "Lab 1", Category: "Labs"},
+				{ID: 2, ItemName: "Labs total", Category: "Labs"},
+			},
+			// Labs is assumed to be directly under the course
+			want: []*Item{
+				{Name: "Lab 1", Weight: 0.4, Max: 10, Graded: true, Grade: 8},
+				{Name: "Essay", Weight: 0.3, Max: 100},
+				{Name: "Final exam", Weight: 0.5, Max: 100},
+			},
+		},
+		{
+			name: "without course grade items",
+			want: []*Item{
+				{Name: "Lab 1", Weight: 0.4, Max: 10, Graded: true, Grade: 8},
+				{Name: "Essay", Weight: 0.3, Max: 100},
+				{Name: "Final exam", Weight: 0.5, Max: 100},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := ItemsFromGradeItems(nestedUserGrade(), tt.courseGradeItems)
+			if diff := cmp.Diff(got, tt.want, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("ItemsFromGradeItems() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFetchItems(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                string
+		courseGradeItemsErr error
+		wantLabWeight       float64
+		wantErr             bool
+	}{
+		{name: "nested categories are weighted", wantLabWeight: 0.2},
+		{name: "function not available on the site", courseGradeItemsErr: &moodle.APIError{ErrorCode: "invalidrecord"}, wantLabWeight: 0.4},
+		{name: "network error", courseGradeItemsErr: errors.New("connection refused"), wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, mocks := moodlemock.NewClient()
+			mocks.GradeAPI.GetGradeItemsFunc = func(ctx context.Context, userID int, courseID int) ([]*moodle.UserGrade, error) {
+				return []*moodle.UserGrade{nestedUserGrade()}, nil
+			}
+			mocks.GradeAPI.GetCourseGradeItemsFunc = func(ctx context.Context, courseID int) ([]*moodle.CourseGradeItem, error) {
+				if tt.courseGradeItemsErr != nil {
+					return nil, tt.courseGradeItemsErr
+				}
+				return nestedCourseGradeItems(), nil
+			}
+
+			items, err := FetchItems(context.Background(), client, 10, 1111)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchItems() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if math.Abs(items[0].Weight-tt.wantLabWeight) > 1e-9 {
+				t.Errorf("FetchItems() weight of Lab 1 = %v, want %v", items[0].Weight, tt.wantLabWeight)
+			}
+		})
+	}
+}
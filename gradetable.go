@@ -0,0 +1,362 @@
+package moodle
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func mapToGradeTableList(res *getGradesTableResponse) ([]*GradeTable, error) {
+	gradeTables := make([]*GradeTable, 0, len(res.Tables))
+	for _, table := range res.Tables {
+		tableItemGroups, err := mapToGradeTableItemGroupList(table.TableData)
+		if err != nil {
+			return nil, err
+		}
+		category, err := mapToGradeTableCategory(table.TableData, table.MaxDepth)
+		if err != nil {
+			return nil, err
+		}
+		gradeTables = append(gradeTables, &GradeTable{
+			CourseID:     table.CourseID,
+			UserID:       table.UserID,
+			UserFullname: table.UserFullName,
+			MaxDepth:     table.MaxDepth,
+			ItemGroups:   tableItemGroups,
+			Category:     category,
+		})
+	}
+	return gradeTables, nil
+}
+
+func mapToGradeTableItemGroupList(tableDataItemResList []*tableDataItemResponse) ([]*GradeTableItemGroup, error) {
+	gradeTableItemGroups := make([]*GradeTableItemGroup, 0)
+
+	var groupName string
+	gradeTableItems := make([]*GradeTableItem, 0)
+	for _, gradeItemRes := range tableDataItemResList {
+		if gradeItemRes.ItemName == nil {
+			continue
+		}
+		isLabelItem := gradeItemRes.Grade == nil
+		if isLabelItem {
+			if len(gradeTableItems) > 0 {
+				gradeTableItemGroups = append(gradeTableItemGroups, &GradeTableItemGroup{
+					Name:  groupName,
+					Items: gradeTableItems,
+				})
+				gradeTableItems = []*GradeTableItem{}
+			}
+			itemNameDoc, err := goquery.NewDocumentFromReader(strings.NewReader(gradeItemRes.ItemName.Content))
+			if err != nil {
+				return nil, err
+			}
+			groupName = itemNameDoc.Text()
+		} else {
+			// Exclude non graded item
+			if gradeItemRes.ContributionToCourseTotal == nil || gradeItemRes.ContributionToCourseTotal.Content == "-" {
+				continue
+			}
+			gradeTableItem, err := mapToGradeTableItem(gradeItemRes)
+			if err != nil {
+				return nil, err
+			}
+			gradeTableItems = append(gradeTableItems, gradeTableItem)
+		}
+	}
+	gradeTableItemGroups = append(gradeTableItemGroups, &GradeTableItemGroup{
+		Name:  groupName,
+		Items: gradeTableItems,
+	})
+	return gradeTableItemGroups, nil
+}
+
+var levelClassRegex = regexp.MustCompile(`\blevel([0-9]+)\b`)
+
+// mapToGradeTableCategory builds the category tree from the rows of the table.
+// Each row has "levelN" class which represents the depth in the tree (bounded by maxDepth),
+// and a category total row has the same level as the category header row.
+func mapToGradeTableCategory(tableDataItemResList []*tableDataItemResponse, maxDepth int) (*GradeTableCategory, error) {
+	var root *GradeTableCategory
+	var stack []*GradeTableCategory
+	ensureRoot := func() {
+		if root == nil {
+			root = &GradeTableCategory{Depth: 1}
+			stack = []*GradeTableCategory{root}
+		}
+	}
+
+	for _, row := range tableDataItemResList {
+		if row.ItemName == nil {
+			continue
+		}
+		level := gradeTableRowLevel(row.ItemName.Class, maxDepth)
+
+		isCategoryRow := row.Grade == nil
+		if isCategoryRow {
+			name, err := htmlToText(row.ItemName.Content)
+			if err != nil {
+				return nil, err
+			}
+			category := &GradeTableCategory{Name: name}
+			if row.Leader != nil {
+				category.RowSpan = row.Leader.RowSpan
+			}
+			if root == nil {
+				category.Depth = 1
+				if level > 0 {
+					category.Depth = level
+				}
+				root = category
+				stack = []*GradeTableCategory{root}
+				continue
+			}
+			if level == 0 {
+				level = stack[len(stack)-1].Depth + 1
+			}
+			for len(stack) > 1 && stack[len(stack)-1].Depth >= level {
+				stack = stack[:len(stack)-1]
+			}
+			category.Depth = level
+			parent := stack[len(stack)-1]
+			parent.Categories = append(parent.Categories, category)
+			stack = append(stack, category)
+			continue
+		}
+
+		ensureRoot()
+		item, err := mapToGradeTableItem(row)
+		if err != nil {
+			return nil, err
+		}
+		if level == 0 {
+			stack[len(stack)-1].Items = append(stack[len(stack)-1].Items, item)
+			continue
+		}
+
+		isTotalRow := strings.Contains(row.ItemName.Class, "baggt") || strings.Contains(row.ItemName.Class, "baggb")
+		if isTotalRow {
+			for len(stack) > 1 && stack[len(stack)-1].Depth > level {
+				stack = stack[:len(stack)-1]
+			}
+			if category := stack[len(stack)-1]; category.Depth == level {
+				category.Total = item
+				if len(stack) > 1 {
+					stack = stack[:len(stack)-1]
+				}
+				continue
+			}
+		}
+		for len(stack) > 1 && stack[len(stack)-1].Depth >= level {
+			stack = stack[:len(stack)-1]
+		}
+		stack[len(stack)-1].Items = append(stack[len(stack)-1].Items, item)
+	}
+
+	ensureRoot()
+	return root, nil
+}
+
+// gradeTableRowLevel returns the level of the row, 0 is returned when the level is unknown.
+func gradeTableRowLevel(class string, maxDepth int) int {
+	match := levelClassRegex.FindStringSubmatch(class)
+	if len(match) < 2 {
+		return 0
+	}
+	level, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0
+	}
+	if maxDepth > 0 && level > maxDepth {
+		return maxDepth
+	}
+	return level
+}
+
+func mapToGradeTableItem(tableDataItemRes *tableDataItemResponse) (*GradeTableItem, error) {
+	itemNameDoc, err := goquery.NewDocumentFromReader(strings.NewReader(tableDataItemRes.ItemName.Content))
+	if err != nil {
+		return nil, err
+	}
+	itemName := itemNameDoc.Find(".gradeitemheader").Text()
+	if itemName == "" {
+		itemName = itemNameDoc.Text()
+	}
+
+	var itemURL *string
+	if href, itemURLExist := itemNameDoc.Find("a").Attr("href"); itemURLExist {
+		itemURL = &href
+	}
+
+	rangeFormatted, err := cellText(tableDataItemRes.Range)
+	if err != nil {
+		return nil, err
+	}
+	gradeRangeMin, gradeRangeMax, isRangeNumeric := parseGradeRange(rangeFormatted)
+
+	gradeFormatted, err := cellText(tableDataItemRes.Grade)
+	if err != nil {
+		return nil, err
+	}
+	isGraded := gradeFormatted != "" && gradeFormatted != "-"
+	var grade float64
+	var gradeDisplayType GradeDisplayType
+	if isGraded {
+		grade, gradeDisplayType = parseGrade(gradeFormatted, isRangeNumeric)
+	}
+
+	weight, err := cellFloat(tableDataItemRes.Weight)
+	if err != nil {
+		return nil, err
+	}
+	percentage, err := cellFloat(tableDataItemRes.Percentage)
+	if err != nil {
+		return nil, err
+	}
+	letterGrade, err := cellText(tableDataItemRes.LetterGrade)
+	if err != nil {
+		return nil, err
+	}
+	if letterGrade == "-" {
+		letterGrade = ""
+	}
+
+	var feedback, feedbackRawHTML string
+	if tableDataItemRes.Feedback != nil {
+		feedbackDoc, err := goquery.NewDocumentFromReader(strings.NewReader(tableDataItemRes.Feedback.Content))
+		if err != nil {
+			return nil, err
+		}
+		feedback = feedbackDoc.Text()
+		feedbackRawHTML = tableDataItemRes.Feedback.Content
+	}
+
+	// the content is something like "10.00 %", "10,00 %" or "-"
+	contribution, err := cellFloat(tableDataItemRes.ContributionToCourseTotal)
+	if err != nil {
+		return nil, err
+	}
+	var contributionToCourseTotal float64
+	if contribution != nil {
+		contributionToCourseTotal = *contribution
+	}
+
+	return &GradeTableItem{
+		ItemName:                  itemName,
+		ItemNameRawHTML:           tableDataItemRes.ItemName.Content,
+		ItemURL:                   itemURL,
+		IsGraded:                  isGraded,
+		Grade:                     grade,
+		GradeFormatted:            gradeFormatted,
+		GradeDisplayType:          gradeDisplayType,
+		GradeRangeMin:             gradeRangeMin,
+		GradeRangeMax:             gradeRangeMax,
+		RangeFormatted:            rangeFormatted,
+		Weight:                    weight,
+		Percentage:                percentage,
+		LetterGrade:               letterGrade,
+		Feedback:                  feedback,
+		FeedBackRawHTML:           feedbackRawHTML,
+		ContributionToCourseTotal: contributionToCourseTotal,
+	}, nil
+}
+
+// parseGrade parses the displayed grade.
+// A non numeric grade is regarded as a letter grade if the range is numeric, otherwise as a scale item.
+func parseGrade(gradeFormatted string, isRangeNumeric bool) (float64, GradeDisplayType) {
+	if grade, ok := parseLocalizedFloat(gradeFormatted); ok {
+		if strings.Contains(gradeFormatted, "%") {
+			return grade, GradeDisplayTypePercentage
+		}
+		return grade, GradeDisplayTypeReal
+	}
+	if isRangeNumeric {
+		return 0, GradeDisplayTypeLetter
+	}
+	return 0, GradeDisplayTypeScale
+}
+
+// gradeRangeRegex matches the range like "0–100", "-10-10" or "-10 – -5", the bounds may be negative
+var gradeRangeRegex = regexp.MustCompile(`^\s*(-?[0-9]+(?:[.,][0-9]+)*)\s*[-–]\s*(-?[0-9]+(?:[.,][0-9]+)*)`)
+
+// parseGradeRange parses the range like "0–100" or "0,00-100,00", both en dash and hyphen are accepted.
+// false is returned when the range is empty or not numeric (e.g. "Fail–Pass").
+func parseGradeRange(rangeFormatted string) (float64, float64, bool) {
+	matches := gradeRangeRegex.FindStringSubmatch(rangeFormatted)
+	if matches == nil {
+		return 0, 0, false
+	}
+	min, ok := parseLocalizedFloat(matches[1])
+	if !ok {
+		return 0, 0, false
+	}
+	max, ok := parseLocalizedFloat(matches[2])
+	if !ok {
+		return 0, 0, false
+	}
+	return min, max, true
+}
+
+var localizedFloatRegex = regexp.MustCompile(`^-?[0-9]+([.,][0-9]+)*`)
+
+// parseLocalizedFloat parses the number at the beginning of s, accepting both "." and "," as decimal separator.
+// When both are used, the last one is regarded as decimal separator and the other as thousands separator.
+func parseLocalizedFloat(s string) (float64, bool) {
+	number := localizedFloatRegex.FindString(strings.TrimSpace(s))
+	if number == "" {
+		return 0, false
+	}
+	lastDot, lastComma := strings.LastIndex(number, "."), strings.LastIndex(number, ",")
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastComma > lastDot {
+			number = strings.ReplaceAll(number, ".", "")
+			number = strings.Replace(number, ",", ".", 1)
+		} else {
+			number = strings.ReplaceAll(number, ",", "")
+		}
+	case lastComma >= 0:
+		if strings.Count(number, ",") > 1 {
+			number = strings.ReplaceAll(number, ",", "")
+		} else {
+			number = strings.Replace(number, ",", ".", 1)
+		}
+	case strings.Count(number, ".") > 1:
+		number = strings.ReplaceAll(number, ".", "")
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+// cellText returns the trimmed text of the cell, "" is returned when the cell doesn't exist.
+func cellText(cell *tableDataCellResponse) (string, error) {
+	if cell == nil {
+		return "", nil
+	}
+	return htmlToText(cell.Content)
+}
+
+// cellFloat returns the number in the cell, nil is returned when the cell doesn't exist or is not numeric.
+func cellFloat(cell *tableDataCellResponse) (*float64, error) {
+	text, err := cellText(cell)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := parseLocalizedFloat(text)
+	if !ok {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+func htmlToText(html string) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.ReplaceAll(doc.Text(), "\u00a0", " ")), nil
+}
@@ -0,0 +1,154 @@
+package moodle
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"testing"
+)
+
+func Test_parseLocalizedFloat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		s      string
+		want   float64
+		wantOk bool
+	}{
+		{name: "dot decimal", s: "85.50", want: 85.5, wantOk: true},
+		{name: "comma decimal", s: "1,5", want: 1.5, wantOk: true},
+		{name: "percentage", s: "10,00 %", want: 10, wantOk: true},
+		{name: "dot thousands and comma decimal", s: "1.234,56", want: 1234.56, wantOk: true},
+		{name: "comma thousands and dot decimal", s: "1,234.56", want: 1234.56, wantOk: true},
+		{name: "comma decimal with 3 decimal places", s: "1,500", want: 1.5, wantOk: true},
+		{name: "negative", s: "-2.5", want: -2.5, wantOk: true},
+		{name: "number with letter", s: "92.00 (A-)", want: 92, wantOk: true},
+		{name: "letter", s: "A-", wantOk: false},
+		{name: "not graded", s: "-", wantOk: false},
+		{name: "empty", s: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotOk := parseLocalizedFloat(tt.s)
+			if got != tt.want || gotOk != tt.wantOk {
+				t.Errorf("parseLocalizedFloat() = (%v, %v), want (%v, %v)", got, gotOk, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_parseGradeRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		s       string
+		wantMin float64
+		wantMax float64
+		wantOk  bool
+	}{
+		{name: "en dash", s: "0–100", wantMin: 0, wantMax: 100, wantOk: true},
+		{name: "hyphen", s: "0-100", wantMin: 0, wantMax: 100, wantOk: true},
+		{name: "comma decimal", s: "0,00–2,00", wantMin: 0, wantMax: 2, wantOk: true},
+		{name: "comma decimal with 3 decimal places", s: "0,000–10,000", wantMin: 0, wantMax: 10, wantOk: true},
+		{name: "negative min with en dash", s: "-10–10", wantMin: -10, wantMax: 10, wantOk: true},
+		{name: "negative min with hyphen", s: "-10-10", wantMin: -10, wantMax: 10, wantOk: true},
+		{name: "negative bounds with spaces", s: "-10 - -5", wantMin: -10, wantMax: -5, wantOk: true},
+		{name: "scale", s: "Fail–Pass", wantOk: false},
+		{name: "empty", s: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotMin, gotMax, gotOk := parseGradeRange(tt.s)
+			if gotMin != tt.wantMin || gotMax != tt.wantMax || gotOk != tt.wantOk {
+				t.Errorf("parseGradeRange() = (%v, %v, %v), want (%v, %v, %v)", gotMin, gotMax, gotOk, tt.wantMin, tt.wantMax, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_mapToGradeTableCategory(t *testing.T) {
+	t.Parallel()
+
+	row := func(class, name, grade, gradeRange, contribution string) *tableDataItemResponse {
+		res := &tableDataItemResponse{}
+		res.ItemName = &struct {
+			ID       string `json:"id"`
+			Class    string `json:"class"`
+			ColSpan  int    `json:"colspan"`
+			Content  string `json:"content"`
+			CellType string `json:"celltype"`
+		}{Class: class, Content: name}
+		if grade != "" {
+			res.Grade = &tableDataCellResponse{Content: grade}
+			res.Range = &tableDataCellResponse{Content: gradeRange}
+			res.ContributionToCourseTotal = &tableDataCellResponse{Content: contribution}
+		}
+		return res
+	}
+	rows := []*tableDataItemResponse{
+		row("level1 column-itemname", "Course", "", "", ""),
+		row("level2 column-itemname", "Quizzes", "", "", ""),
+		row("level3 item column-itemname", "Quiz 1", "1,5", "0,00&ndash;2,00", "7,50 %"),
+		row("level3 item column-itemname", "Quiz 2", "A-", "0&ndash;100", "9.00 %"),
+		row("level2 baggt column-itemname", "Quizzes total", "85.00 %", "0&ndash;100", "16.50 %"),
+		row("level2 column-itemname", "Participation", "", "", ""),
+		row("level3 item column-itemname", "Discussion", "Pass", "Fail&ndash;Pass", "10.00 %"),
+		row("level3 item column-itemname", "Hidden", "-", "", "-"),
+		row("level2 item column-itemname", "Final", "-", "0&ndash;100", "-"),
+		row("level1 baggt column-itemname", "Course total", "26.50", "0&ndash;100", "-"),
+	}
+
+	got, err := mapToGradeTableCategory(rows, 3)
+	if err != nil {
+		t.Fatalf("mapToGradeTableCategory() error = %v", err)
+	}
+	want := &GradeTableCategory{
+		Name:  "Course",
+		Depth: 1,
+		Categories: []*GradeTableCategory{
+			{
+				Name:  "Quizzes",
+				Depth: 2,
+				Items: []*GradeTableItem{
+					{ItemName: "Quiz 1", IsGraded: true, Grade: 1.5, GradeFormatted: "1,5", GradeDisplayType: GradeDisplayTypeReal, GradeRangeMax: 2, RangeFormatted: "0,00–2,00", ContributionToCourseTotal: 7.5},
+					{ItemName: "Quiz 2", IsGraded: true, GradeFormatted: "A-", GradeDisplayType: GradeDisplayTypeLetter, GradeRangeMax: 100, RangeFormatted: "0–100", ContributionToCourseTotal: 9},
+				},
+				Total: &GradeTableItem{ItemName: "Quizzes total", IsGraded: true, Grade: 85, GradeFormatted: "85.00 %", GradeDisplayType: GradeDisplayTypePercentage, GradeRangeMax: 100, RangeFormatted: "0–100", ContributionToCourseTotal: 16.5},
+			},
+			{
+				Name:  "Participation",
+				Depth: 2,
+				Items: []*GradeTableItem{
+					{ItemName: "Discussion", IsGraded: true, GradeFormatted: "Pass", GradeDisplayType: GradeDisplayTypeScale, RangeFormatted: "Fail–Pass", ContributionToCourseTotal: 10},
+					{ItemName: "Hidden", GradeFormatted: "-"},
+				},
+			},
+		},
+		Items: []*GradeTableItem{
+			{ItemName: "Final", GradeFormatted: "-", GradeRangeMax: 100, RangeFormatted: "0–100"},
+		},
+		Total: &GradeTableItem{ItemName: "Course total", IsGraded: true, Grade: 26.5, GradeFormatted: "26.50", GradeDisplayType: GradeDisplayTypeReal, GradeRangeMax: 100, RangeFormatted: "0–100"},
+	}
+	if diff := cmp.Diff(got, want, cmpopts.IgnoreFields(GradeTableItem{}, "ItemNameRawHTML")); diff != "" {
+		t.Errorf("mapToGradeTableCategory() (-got, +want)\n%s", diff)
+	}
+}
+
+func Test_mapToGradeTableCategory_emptyTable(t *testing.T) {
+	t.Parallel()
+
+	got, err := mapToGradeTableCategory(nil, 0)
+	if err != nil {
+		t.Fatalf("mapToGradeTableCategory() error = %v", err)
+	}
+	if diff := cmp.Diff(got, &GradeTableCategory{Depth: 1}); diff != "" {
+		t.Errorf("mapToGradeTableCategory() (-got, +want)\n%s", diff)
+	}
+}
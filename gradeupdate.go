@@ -0,0 +1,55 @@
+package moodle
+
+import (
+	"context"
+)
+
+// DefaultGradeUpdateBatchSize is the number of grades sent in one request when batch size is not specified
+const DefaultGradeUpdateBatchSize = 50
+
+// GradeUpdateReport represents the result of BatchUpdateGrades
+type GradeUpdateReport struct {
+	UpdatedStudentIDs []int
+	Failures          []*GradeUpdateFailure
+}
+
+// GradeUpdateFailure represents a grade failed to be updated
+type GradeUpdateFailure struct {
+	StudentID int
+	Err       error
+}
+
+// HasFailures returns true if any grade failed to be updated
+func (g *GradeUpdateReport) HasFailures() bool {
+	return len(g.Failures) > 0
+}
+
+// BatchUpdateGrades updates grades splitting them into chunks of batchSize, so that large class lists fit in requests.
+// When a chunk fails with an error response, the grades in the chunk are updated one by one to find out which users failed.
+// Failures of each user are aggregated into the report, and error is returned only when ctx is done.
+func (c *Client) BatchUpdateGrades(ctx context.Context, params *UpdateGradesParams, batchSize int) (*GradeUpdateReport, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultGradeUpdateBatchSize
+	}
+
+	report := &GradeUpdateReport{}
+	grades := params.Grades
+	err := callInChunks(ctx, len(grades), batchSize, func(start, end int) error {
+		err := c.GradeAPI.UpdateGrades(ctx, withGradeUpdates(params, grades[start:end]))
+		if err == nil {
+			for _, grade := range grades[start:end] {
+				report.UpdatedStudentIDs = append(report.UpdatedStudentIDs, grade.StudentID)
+			}
+		}
+		return err
+	}, func(i int, err error) {
+		report.Failures = append(report.Failures, &GradeUpdateFailure{StudentID: grades[i].StudentID, Err: err})
+	})
+	return report, err
+}
+
+func withGradeUpdates(params *UpdateGradesParams, grades []*GradeUpdate) *UpdateGradesParams {
+	copied := *params
+	copied.Grades = grades
+	return &copied
+}
@@ -0,0 +1,63 @@
+package moodle
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestClient_BatchUpdateGrades(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var requestCount int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestCount++
+		mu.Unlock()
+
+		// student 3 is not enrolled in the course
+		r.ParseForm()
+		q := r.Form
+		for i := 0; q.Get(fmt.Sprintf("grades[%d][studentid]", i)) != ""; i++ {
+			if q.Get(fmt.Sprintf("grades[%d][studentid]", i)) == "3" {
+				fmt.Fprintln(w, `{"exception":"invalid_parameter_exception","errorcode":"invalidparameter","message":"Invalid parameter value detected"}`)
+				return
+			}
+		}
+		fmt.Fprintln(w, "0")
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test")
+
+	grade := 80.0
+	params := &UpdateGradesParams{Source: "test", CourseID: 1111, Component: "mod_assign", ActivityID: 2222}
+	for i := 1; i <= 5; i++ {
+		params.Grades = append(params.Grades, &GradeUpdate{StudentID: i, Grade: &grade})
+	}
+
+	got, err := c.BatchUpdateGrades(context.Background(), params, 2)
+	if err != nil {
+		t.Fatalf("BatchUpdateGrades() error = %v", err)
+	}
+	if diff := cmp.Diff(got.UpdatedStudentIDs, []int{1, 2, 4, 5}); diff != "" {
+		t.Errorf("BatchUpdateGrades() UpdatedStudentIDs (-got, +want)\n%s", diff)
+	}
+	if len(got.Failures) != 1 || got.Failures[0].StudentID != 3 || Code(got.Failures[0].Err) != "invalidparameter" {
+		t.Errorf("BatchUpdateGrades() Failures = %v, want failure of student 3", got.Failures)
+	}
+	// 3 chunks + 2 retries for the failed chunk
+	if requestCount != 5 {
+		t.Errorf("BatchUpdateGrades() request count = %d, want %d", requestCount, 5)
+	}
+	if len(params.Grades) != 5 {
+		t.Errorf("BatchUpdateGrades() modified params.Grades, len = %d", len(params.Grades))
+	}
+}
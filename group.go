@@ -0,0 +1,40 @@
+package moodle
+
+type Group struct {
+	ID                int
+	CourseID          int
+	Name              string
+	Description       string
+	DescriptionFormat int
+	EnrolmentKey      string
+	IDNumber          string
+}
+
+type Grouping struct {
+	ID                int
+	CourseID          int
+	Name              string
+	Description       string
+	DescriptionFormat int
+	IDNumber          string
+}
+
+// ActivityAllowedGroups represents the groups the user can access in an activity
+type ActivityAllowedGroups struct {
+	Groups             []*Group
+	CanAccessAllGroups bool
+}
+
+type CreateGroupParams struct {
+	CourseID          int
+	Name              string
+	Description       string
+	DescriptionFormat int
+	EnrolmentKey      string
+	IDNumber          string
+}
+
+type GroupMember struct {
+	GroupID int
+	UserID  int
+}
@@ -0,0 +1,202 @@
+package moodle
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+type GroupAPI interface {
+	GetCourseGroups(ctx context.Context, courseID int) ([]*Group, error)
+	GetCourseGroupings(ctx context.Context, courseID int) ([]*Grouping, error)
+	// GetCourseUserGroups returns the groups of the user in the course.
+	// groupingID can be 0 to return groups in all groupings.
+	GetCourseUserGroups(ctx context.Context, courseID int, userID int, groupingID int) ([]*Group, error)
+	GetActivityAllowedGroups(ctx context.Context, cmID int, userID int) (*ActivityAllowedGroups, error)
+	CreateGroups(ctx context.Context, groups []*CreateGroupParams) ([]*Group, error)
+	AddGroupMembers(ctx context.Context, members []*GroupMember) error
+	DeleteGroupMembers(ctx context.Context, members []*GroupMember) error
+}
+
+type groupAPI struct {
+	*apiClient
+}
+
+func newGroupAPI(apiClient *apiClient) *groupAPI {
+	return &groupAPI{apiClient}
+}
+
+type groupResponse struct {
+	ID                int    `json:"id"`
+	CourseID          int    `json:"courseid"`
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	DescriptionFormat int    `json:"descriptionformat"`
+	EnrolmentKey      string `json:"enrolmentkey"`
+	IDNumber          string `json:"idnumber"`
+}
+
+type groupingResponse struct {
+	ID                int    `json:"id"`
+	CourseID          int    `json:"courseid"`
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	DescriptionFormat int    `json:"descriptionformat"`
+	IDNumber          string `json:"idnumber"`
+}
+
+func (g *groupAPI) GetCourseGroups(ctx context.Context, courseID int) ([]*Group, error) {
+	var res []*groupResponse
+	err := g.callMoodleFunction(ctx, &res, map[string]string{
+		"wsfunction": "core_group_get_course_groups",
+		"courseid":   strconv.Itoa(courseID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapToGroupList(res), nil
+}
+
+func (g *groupAPI) GetCourseGroupings(ctx context.Context, courseID int) ([]*Grouping, error) {
+	var res []*groupingResponse
+	err := g.callMoodleFunction(ctx, &res, map[string]string{
+		"wsfunction": "core_group_get_course_groupings",
+		"courseid":   strconv.Itoa(courseID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	groupings := make([]*Grouping, 0, len(res))
+	for _, groupingRes := range res {
+		groupings = append(groupings, &Grouping{
+			ID:                groupingRes.ID,
+			CourseID:          groupingRes.CourseID,
+			Name:              groupingRes.Name,
+			Description:       groupingRes.Description,
+			DescriptionFormat: groupingRes.DescriptionFormat,
+			IDNumber:          groupingRes.IDNumber,
+		})
+	}
+	return groupings, nil
+}
+
+type getCourseUserGroupsResponse struct {
+	Groups   []*groupResponse `json:"groups"`
+	Warnings Warnings         `json:"warnings"`
+}
+
+func (g *groupAPI) GetCourseUserGroups(ctx context.Context, courseID int, userID int, groupingID int) ([]*Group, error) {
+	res := getCourseUserGroupsResponse{}
+	err := g.callMoodleFunction(ctx, &res, map[string]string{
+		"wsfunction": "core_group_get_course_user_groups",
+		"courseid":   strconv.Itoa(courseID),
+		"userid":     strconv.Itoa(userID),
+		"groupingid": strconv.Itoa(groupingID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+	return mapToGroupList(res.Groups), nil
+}
+
+type getActivityAllowedGroupsResponse struct {
+	Groups             []*groupResponse `json:"groups"`
+	CanAccessAllGroups bool             `json:"canaccessallgroups"`
+	Warnings           Warnings         `json:"warnings"`
+}
+
+func (g *groupAPI) GetActivityAllowedGroups(ctx context.Context, cmID int, userID int) (*ActivityAllowedGroups, error) {
+	params := map[string]string{
+		"wsfunction": "core_group_get_activity_allowed_groups",
+		"cmid":       strconv.Itoa(cmID),
+	}
+	if userID != 0 {
+		params["userid"] = strconv.Itoa(userID)
+	}
+	res := getActivityAllowedGroupsResponse{}
+	if err := g.callMoodleFunction(ctx, &res, params); err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+	return &ActivityAllowedGroups{
+		Groups:             mapToGroupList(res.Groups),
+		CanAccessAllGroups: res.CanAccessAllGroups,
+	}, nil
+}
+
+func (g *groupAPI) CreateGroups(ctx context.Context, groups []*CreateGroupParams) ([]*Group, error) {
+	params := map[string]string{
+		"wsfunction": "core_group_create_groups",
+	}
+	for i, group := range groups {
+		params[fmt.Sprintf("groups[%d][courseid]", i)] = strconv.Itoa(group.CourseID)
+		params[fmt.Sprintf("groups[%d][name]", i)] = group.Name
+		params[fmt.Sprintf("groups[%d][description]", i)] = group.Description
+		if group.DescriptionFormat != 0 {
+			params[fmt.Sprintf("groups[%d][descriptionformat]", i)] = strconv.Itoa(group.DescriptionFormat)
+		}
+		if group.EnrolmentKey != "" {
+			params[fmt.Sprintf("groups[%d][enrolmentkey]", i)] = group.EnrolmentKey
+		}
+		if group.IDNumber != "" {
+			params[fmt.Sprintf("groups[%d][idnumber]", i)] = group.IDNumber
+		}
+	}
+	var res []*groupResponse
+	if err := g.postMoodleFunction(ctx, &res, params); err != nil {
+		return nil, err
+	}
+	return mapToGroupList(res), nil
+}
+
+func (g *groupAPI) AddGroupMembers(ctx context.Context, members []*GroupMember) error {
+	// the response is null on success
+	var res interface{}
+	return g.postMoodleFunction(
+		ctx,
+		&res,
+		map[string]string{"wsfunction": "core_group_add_group_members"},
+		mapGroupMembersToQueryParams(members),
+	)
+}
+
+func (g *groupAPI) DeleteGroupMembers(ctx context.Context, members []*GroupMember) error {
+	// the response is null on success
+	var res interface{}
+	return g.postMoodleFunction(
+		ctx,
+		&res,
+		map[string]string{"wsfunction": "core_group_delete_group_members"},
+		mapGroupMembersToQueryParams(members),
+	)
+}
+
+func mapGroupMembersToQueryParams(members []*GroupMember) map[string]string {
+	params := make(map[string]string)
+	for i, member := range members {
+		params[fmt.Sprintf("members[%d][groupid]", i)] = strconv.Itoa(member.GroupID)
+		params[fmt.Sprintf("members[%d][userid]", i)] = strconv.Itoa(member.UserID)
+	}
+	return params
+}
+
+func mapToGroupList(groupResList []*groupResponse) []*Group {
+	groups := make([]*Group, 0, len(groupResList))
+	for _, groupRes := range groupResList {
+		groups = append(groups, &Group{
+			ID:                groupRes.ID,
+			CourseID:          groupRes.CourseID,
+			Name:              groupRes.Name,
+			Description:       groupRes.Description,
+			DescriptionFormat: groupRes.DescriptionFormat,
+			EnrolmentKey:      groupRes.EnrolmentKey,
+			IDNumber:          groupRes.IDNumber,
+		})
+	}
+	return groups
+}
@@ -0,0 +1,299 @@
+package moodle
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func Test_groupAPI_GetCourseGroups(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		want     []*Group
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `[{"id":11,"courseid":1111,"name":"Team A","description":"<p>Project team</p>","descriptionformat":1,"enrolmentkey":"","idnumber":"team-a"}]`,
+			want: []*Group{
+				{ID: 11, CourseID: 1111, Name: "Team A", Description: "<p>Project team</p>", DescriptionFormat: 1, IDNumber: "team-a"},
+			},
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"dml_missing_record_exception","errorcode":"invalidrecord","message":"Can't find data record in database table course."}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGroupAPI(t, tt.response)
+			got, err := g.GetCourseGroups(context.Background(), 1111)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetCourseGroups() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetCourseGroups() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_groupAPI_GetCourseGroupings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		want     []*Grouping
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `[{"id":21,"courseid":1111,"name":"Projects","description":"","descriptionformat":1,"idnumber":""}]`,
+			want: []*Grouping{
+				{ID: 21, CourseID: 1111, Name: "Projects", DescriptionFormat: 1},
+			},
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"dml_missing_record_exception","errorcode":"invalidrecord","message":"Can't find data record in database table course."}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGroupAPI(t, tt.response)
+			got, err := g.GetCourseGroupings(context.Background(), 1111)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetCourseGroupings() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetCourseGroupings() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_groupAPI_GetCourseUserGroups(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		want     []*Group
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"groups":[{"id":11,"name":"Team A","description":"","descriptionformat":1,"idnumber":"","courseid":1111}],"warnings":[]}`,
+			want: []*Group{
+				{ID: 11, CourseID: 1111, Name: "Team A", DescriptionFormat: 1},
+			},
+		},
+		{
+			name:     "Warning response",
+			response: `{"groups":[],"warnings":[{"item":"course","itemid":1111,"warningcode":"1","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGroupAPI(t, tt.response)
+			got, err := g.GetCourseUserGroups(context.Background(), 1111, 123456, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetCourseUserGroups() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetCourseUserGroups() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_groupAPI_GetActivityAllowedGroups(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		want     *ActivityAllowedGroups
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"groups":[{"id":11,"name":"Team A","description":"","descriptionformat":1,"idnumber":""}],"canaccessallgroups":true,"warnings":[]}`,
+			want: &ActivityAllowedGroups{
+				Groups:             []*Group{{ID: 11, Name: "Team A", DescriptionFormat: 1}},
+				CanAccessAllGroups: true,
+			},
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"invalidcoursemodule","message":"Invalid course module ID"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGroupAPI(t, tt.response)
+			got, err := g.GetActivityAllowedGroups(context.Background(), 555555, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetActivityAllowedGroups() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetActivityAllowedGroups() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_groupAPI_CreateGroups(t *testing.T) {
+	t.Parallel()
+
+	var gotQuery url.Values
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		gotQuery = r.Form
+		fmt.Fprintln(w, `[{"id":11,"courseid":1111,"name":"Team A","description":"","descriptionformat":1,"enrolmentkey":"","idnumber":"team-a"}]`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	apiURL, _ := url.Parse(s.URL)
+	g := &groupAPI{&apiClient{httpClient: http.DefaultClient, apiURL: apiURL}}
+
+	got, err := g.CreateGroups(context.Background(), []*CreateGroupParams{
+		{CourseID: 1111, Name: "Team A", IDNumber: "team-a"},
+	})
+	if err != nil {
+		t.Fatalf("CreateGroups() error = %v", err)
+	}
+	want := []*Group{{ID: 11, CourseID: 1111, Name: "Team A", DescriptionFormat: 1, IDNumber: "team-a"}}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("CreateGroups() (-got, +want)\n%s", diff)
+	}
+	if gotQuery.Get("groups[0][courseid]") != "1111" || gotQuery.Get("groups[0][name]") != "Team A" || gotQuery.Get("groups[0][idnumber]") != "team-a" {
+		t.Errorf("CreateGroups() query = %v", gotQuery)
+	}
+}
+
+func Test_groupAPI_AddGroupMembers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: "null",
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"userisnotmemberofcourse","message":"The user is not a member of the course"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGroupAPI(t, tt.response)
+			if err := g.AddGroupMembers(context.Background(), []*GroupMember{{GroupID: 11, UserID: 123456}}); (err != nil) != tt.wantErr {
+				t.Errorf("AddGroupMembers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_groupAPI_DeleteGroupMembers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: "null",
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"dml_missing_record_exception","errorcode":"invalidrecord","message":"Can't find data record in database table groups."}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := mockGroupAPI(t, tt.response)
+			if err := g.DeleteGroupMembers(context.Background(), []*GroupMember{{GroupID: 11, UserID: 123456}}); (err != nil) != tt.wantErr {
+				t.Errorf("DeleteGroupMembers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mockGroupAPI(t *testing.T, response string) *groupAPI {
+	t.Helper()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, response)
+	})
+	s := httptest.NewServer(h)
+	apiURL, _ := url.Parse(s.URL)
+	return &groupAPI{
+		&apiClient{
+			httpClient: http.DefaultClient,
+			apiURL:     apiURL,
+		},
+	}
+}
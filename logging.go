@@ -0,0 +1,131 @@
+package moodle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Logger is a structured logger which takes alternating keys and values as args.
+// *slog.Logger satisfies the interface.
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+	InfoContext(ctx context.Context, msg string, args ...interface{})
+	WarnContext(ctx context.Context, msg string, args ...interface{})
+	ErrorContext(ctx context.Context, msg string, args ...interface{})
+}
+
+const redactedValue = "[REDACTED]"
+
+// secretParams are redacted in logged urls, also as the last name of a nested param (e.g. "users[0][password]")
+var secretParams = map[string]bool{"wstoken": true, "token": true, "privatetoken": true, "password": true}
+
+// secretBodyFieldRegex matches the secret fields in the json body, e.g. the token in the login response
+var secretBodyFieldRegex = regexp.MustCompile(`"(token|privatetoken|password)"\s*:\s*"(?:[^"\\]|\\.)*"`)
+
+// redactURL returns the url with the secret params redacted
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	for key := range query {
+		if secretParams[paramName(key)] {
+			query.Set(key, redactedValue)
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// paramName returns the last name of the param, e.g. "password" for "users[0][password]"
+func paramName(key string) string {
+	key = strings.TrimSuffix(key, "]")
+	if i := strings.LastIndex(key, "["); i >= 0 {
+		return key[i+1:]
+	}
+	return key
+}
+
+// redactBody returns the body with the secret fields redacted and truncated to limit bytes, negative limit means no limit
+func redactBody(body []byte, limit int) string {
+	s := secretBodyFieldRegex.ReplaceAllString(string(body), fmt.Sprintf(`"$1":"%s"`, redactedValue))
+	if limit >= 0 && len(s) > limit {
+		return s[:limit] + fmt.Sprintf("...(%d bytes truncated)", len(s)-limit)
+	}
+	return s
+}
+
+// loggingMiddleware logs each request and its result.
+// Errors are logged at error level and Moodle exceptions at warn level,
+// and the response body is logged at debug level if bodyLimit is not 0 (negative means no limit).
+func loggingMiddleware(logger Logger, bodyLimit int) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *CallRequest) (*CallResponse, error) {
+			target := callTarget(req)
+			logger.DebugContext(ctx, "moodle request", append(target, "url", redactURL(req.url()))...)
+
+			start := time.Now()
+			res, err := next(ctx, req)
+			args := append(target, "duration", time.Since(start))
+			if err != nil {
+				args = append(args, "error", err.Error())
+				logger.ErrorContext(ctx, "moodle call failed", args...)
+				return res, err
+			}
+
+			args = append(args, "status", res.StatusCode, "response_size", len(res.Body))
+			if bodyLimit != 0 {
+				logger.DebugContext(ctx, "moodle response", append(target, "body", redactBody(res.Body, bodyLimit))...)
+			}
+			if apiError := parseAPIError(res.Body); apiError != nil {
+				args = append(args, "error_code", apiError.ErrorCode)
+				if apiError.Exception != nil {
+					args = append(args, "exception", *apiError.Exception)
+				}
+				logger.WarnContext(ctx, "moodle call failed", args...)
+				return res, nil
+			}
+			logger.InfoContext(ctx, "moodle call", args...)
+			return res, nil
+		}
+	}
+}
+
+// callTarget returns the wsfunction, or the path for the requests other than web service functions (e.g. login)
+func callTarget(req *CallRequest) []interface{} {
+	if req.Function != "" {
+		return []interface{}{"wsfunction", req.Function}
+	}
+	return []interface{}{"path", req.Path}
+}
+
+// stdLogger is a Logger writing to the standard log package, used by WithDebugEnabled
+type stdLogger struct{}
+
+func (stdLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	stdLog("DEBUG", msg, args)
+}
+
+func (stdLogger) InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	stdLog("INFO", msg, args)
+}
+
+func (stdLogger) WarnContext(ctx context.Context, msg string, args ...interface{}) {
+	stdLog("WARN", msg, args)
+}
+
+func (stdLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	stdLog("ERROR", msg, args)
+}
+
+func stdLog(level, msg string, args []interface{}) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", level, msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&b, " %v=%q", args[i], fmt.Sprint(args[i+1]))
+	}
+	log.Print(b.String())
+}
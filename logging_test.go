@@ -0,0 +1,193 @@
+package moodle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type logEntry struct {
+	level string
+	msg   string
+	args  map[string]interface{}
+}
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []*logEntry
+}
+
+func (r *recordingLogger) log(level, msg string, args []interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := &logEntry{level: level, msg: msg, args: map[string]interface{}{}}
+	for i := 0; i+1 < len(args); i += 2 {
+		entry.args[args[i].(string)] = args[i+1]
+	}
+	r.entries = append(r.entries, entry)
+}
+
+func (r *recordingLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	r.log("debug", msg, args)
+}
+
+func (r *recordingLogger) InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	r.log("info", msg, args)
+}
+
+func (r *recordingLogger) WarnContext(ctx context.Context, msg string, args ...interface{}) {
+	r.log("warn", msg, args)
+}
+
+func (r *recordingLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	r.log("error", msg, args)
+}
+
+func Test_redactURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "Login password",
+			url:  "https://test.edu/login/token.php?password=P%40ssw0rd&service=moodle_mobile_app&username=user",
+			want: "https://test.edu/login/token.php?password=%5BREDACTED%5D&service=moodle_mobile_app&username=user",
+		},
+		{
+			name: "Nested password and token",
+			url:  "https://test.edu/webservice/rest/server.php?users%5B0%5D%5Bpassword%5D=P%40ssw0rd&users%5B0%5D%5Busername%5D=user&wstoken=secret",
+			want: "https://test.edu/webservice/rest/server.php?users%5B0%5D%5Bpassword%5D=%5BREDACTED%5D&users%5B0%5D%5Busername%5D=user&wstoken=%5BREDACTED%5D",
+		},
+		{
+			name: "Param only containing a secret name",
+			url:  "https://test.edu/webservice/rest/server.php?options%5B0%5D%5Bname%5D=password",
+			want: "https://test.edu/webservice/rest/server.php?options%5B0%5D%5Bname%5D=password",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			u, _ := url.Parse(tt.url)
+			if got := redactURL(u); got != tt.want {
+				t.Errorf("redactURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_redactBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		body  string
+		limit int
+		want  string
+	}{
+		{
+			name:  "Login response",
+			body:  `{"token":"secret","privatetoken":"very\"secret"}`,
+			limit: -1,
+			want:  `{"token":"[REDACTED]","privatetoken":"[REDACTED]"}`,
+		},
+		{
+			name:  "Truncated",
+			body:  `{"courses":[]}`,
+			limit: 5,
+			want:  `{"cou...(9 bytes truncated)`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := redactBody([]byte(tt.body), tt.limit); got != tt.want {
+				t.Errorf("redactBody() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_logging(t *testing.T) {
+	t.Parallel()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("wsfunction") {
+		case "":
+			fmt.Fprint(w, `{"token":"secret-token","privatetoken":null}`)
+		case "core_webservice_get_site_info":
+			fmt.Fprint(w, `{"sitename":"Test Site"}`)
+		default:
+			fmt.Fprint(w, `{"exception":"webservice_access_exception","errorcode":"accessexception","message":"Access control exception"}`)
+		}
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	logger := &recordingLogger{}
+
+	c, err := NewClientWithLogin(context.Background(), serviceURL, "user", "P@ssw0rd", WithLogger(logger), WithLogBody(1024))
+	if err != nil {
+		t.Fatalf("NewClientWithLogin() error = %v", err)
+	}
+	if _, err := c.SiteAPI.GetSiteInfo(context.Background()); err != nil {
+		t.Fatalf("GetSiteInfo() error = %v", err)
+	}
+	if _, err := c.CourseAPI.GetContents(context.Background(), 1111); err == nil {
+		t.Fatalf("GetContents() error = nil, want error")
+	}
+	if _, err := c.UserAPI.CreateUsers(context.Background(), []*CreateUserParams{{Username: "new", Password: "N3wP@ssw0rd"}}); err == nil {
+		t.Fatalf("CreateUsers() error = nil, want error")
+	}
+
+	for _, entry := range logger.entries {
+		for _, v := range entry.args {
+			if s := fmt.Sprint(v); strings.Contains(s, "P@ssw0rd") || strings.Contains(s, "P%40ssw0rd") || strings.Contains(s, "secret-token") || strings.Contains(s, "N3wP") {
+				t.Errorf("%s log %q contains a secret: %s", entry.level, entry.msg, s)
+			}
+		}
+	}
+
+	var got []string
+	for _, entry := range logger.entries {
+		target := entry.args["wsfunction"]
+		if target == nil {
+			target = entry.args["path"]
+		}
+		got = append(got, fmt.Sprintf("%s %s %v", entry.level, entry.msg, target))
+	}
+	want := []string{
+		"debug moodle request /login/token.php",
+		"debug moodle response /login/token.php",
+		"info moodle call /login/token.php",
+		"debug moodle request core_webservice_get_site_info",
+		"debug moodle response core_webservice_get_site_info",
+		"info moodle call core_webservice_get_site_info",
+		"debug moodle request core_course_get_contents",
+		"debug moodle response core_course_get_contents",
+		"warn moodle call failed core_course_get_contents",
+		"debug moodle request core_user_create_users",
+		"debug moodle response core_user_create_users",
+		"warn moodle call failed core_user_create_users",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("logs (-got, +want)\n%s", diff)
+	}
+	last := logger.entries[len(logger.entries)-1]
+	if last.args["error_code"] != "accessexception" || last.args["status"] != http.StatusOK {
+		t.Errorf("failed call log args = %v", last.args)
+	}
+}
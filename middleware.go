@@ -0,0 +1,160 @@
+package moodle
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CallRequest is a request to the site passed through the middlewares
+type CallRequest struct {
+	// Method is GET, or POST for the functions changing data, whose params and token are sent in the form body
+	Method string
+	// Function is the wsfunction, empty for the requests other than web service functions (e.g. login)
+	Function string
+	// Path is the path of the endpoint, e.g. /webservice/rest/server.php
+	Path string
+	// Params are the params except wsfunction, wstoken and moodlewsrestformat, sent in the query or the form body by Method
+	Params url.Values
+	// Header is sent with the request
+	Header http.Header
+
+	endpoint *url.URL
+	// hiddenParams are the params not exposed to the middlewares (wsfunction, wstoken and moodlewsrestformat)
+	hiddenParams url.Values
+}
+
+// CallResponse is the raw response of a request
+type CallResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Handler sends the request and returns the raw response
+type Handler func(ctx context.Context, req *CallRequest) (*CallResponse, error)
+
+// Middleware wraps a handler to compose behaviors like headers, auditing, retries and caching around calls.
+// A middleware can short-circuit the call by returning a response without calling next.
+type Middleware func(next Handler) Handler
+
+var hiddenParamKeys = []string{"wsfunction", "wstoken", "moodlewsrestformat"}
+
+func newCallRequest(method string, u *url.URL) *CallRequest {
+	params := u.Query()
+	hiddenParams := url.Values{}
+	for _, k := range hiddenParamKeys {
+		if v, ok := params[k]; ok {
+			hiddenParams[k] = v
+			params.Del(k)
+		}
+	}
+	endpoint := *u
+	endpoint.RawQuery = ""
+	return &CallRequest{
+		Method:       method,
+		Function:     hiddenParams.Get("wsfunction"),
+		Path:         u.Path,
+		Params:       params,
+		Header:       http.Header{},
+		endpoint:     &endpoint,
+		hiddenParams: hiddenParams,
+	}
+}
+
+// url returns the url of the request with all the params
+func (c *CallRequest) url() *url.URL {
+	u := *c.endpoint
+	query := url.Values{}
+	for k, v := range c.Params {
+		query[k] = v
+	}
+	for k, v := range c.hiddenParams {
+		query[k] = v
+	}
+	if c.Function != "" {
+		query.Set("wsfunction", c.Function)
+	}
+	u.RawQuery = query.Encode()
+	return &u
+}
+
+// form returns the url with wsfunction and moodlewsrestformat, and the form body with the other params for POST
+func (c *CallRequest) form() (*url.URL, url.Values) {
+	u := *c.endpoint
+	query := url.Values{}
+	form := url.Values{}
+	for k, v := range c.Params {
+		form[k] = v
+	}
+	for k, v := range c.hiddenParams {
+		if k == "wstoken" {
+			form[k] = v
+		} else {
+			query[k] = v
+		}
+	}
+	if c.Function != "" {
+		query.Set("wsfunction", c.Function)
+	}
+	u.RawQuery = query.Encode()
+	return &u, form
+}
+
+// chainMiddlewares returns the handler wrapped with the middlewares, the first middleware is the outermost
+func chainMiddlewares(handler Handler, middlewares []Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// RetryOptions represents options of RetryMiddleware
+type RetryOptions struct {
+	// MaxRetries is the max number of retries after the first try
+	MaxRetries int
+	// Backoff is the wait before the first retry, which is doubled for each retry
+	Backoff time.Duration
+	// Retryable reports whether the function can be retried safely.
+	// Only functions getting data (whose name contains "_get_") are retried if it's nil.
+	Retryable func(function string) bool
+}
+
+// RetryMiddleware retries the calls failed with network errors or 5xx status.
+// The retries are marked with ContextWithRetryAttempt to be counted by instrumentation.
+func RetryMiddleware(opts RetryOptions) Middleware {
+	retryable := opts.Retryable
+	if retryable == nil {
+		retryable = func(function string) bool {
+			return strings.Contains(function, "_get_")
+		}
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *CallRequest) (*CallResponse, error) {
+			res, err := next(ctx, req)
+			if !retryable(req.Function) {
+				return res, err
+			}
+			backoff := opts.Backoff
+			for attempt := 1; attempt <= opts.MaxRetries && isRetryableResponse(res, err); attempt++ {
+				select {
+				case <-time.After(backoff):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				backoff *= 2
+				res, err = next(ContextWithRetryAttempt(ctx, attempt), req)
+			}
+			return res, err
+		}
+	}
+}
+
+func isRetryableResponse(res *CallResponse, err error) bool {
+	if err != nil {
+		return true
+	}
+	return res.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,260 @@
+package moodle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var gotHeader string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Request-ID")
+		fmt.Fprintln(w, `{"sitename":"test"}`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+
+	var mu sync.Mutex
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *CallRequest) (*CallResponse, error) {
+				mu.Lock()
+				order = append(order, name+":"+req.Function)
+				mu.Unlock()
+				return next(ctx, req)
+			}
+		}
+	}
+	addHeader := func(next Handler) Handler {
+		return func(ctx context.Context, req *CallRequest) (*CallResponse, error) {
+			req.Header.Set("X-Request-ID", "abc")
+			return next(ctx, req)
+		}
+	}
+
+	client, err := NewClient(context.Background(), serviceURL, "secret",
+		WithMiddleware(trace("outer")),
+		WithMiddleware(trace("inner")),
+		WithMiddleware(addHeader),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	siteInfo, err := client.SiteAPI.GetSiteInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetSiteInfo() error = %v", err)
+	}
+	if siteInfo.SiteName != "test" {
+		t.Errorf("GetSiteInfo().SiteName = %v, want = test", siteInfo.SiteName)
+	}
+	if diff := cmp.Diff(order, []string{"outer:core_webservice_get_site_info", "inner:core_webservice_get_site_info"}); diff != "" {
+		t.Errorf("middleware order (-got, +want)\n%s", diff)
+	}
+	if gotHeader != "abc" {
+		t.Errorf("X-Request-ID = %q, want = abc", gotHeader)
+	}
+}
+
+func TestWithMiddleware_params(t *testing.T) {
+	t.Parallel()
+
+	var gotQuery url.Values
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		fmt.Fprintln(w, `[]`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+
+	var gotParams url.Values
+	audit := func(next Handler) Handler {
+		return func(ctx context.Context, req *CallRequest) (*CallResponse, error) {
+			gotParams = req.Params
+			req.Params.Set("courseid", "2")
+			return next(ctx, req)
+		}
+	}
+	client, err := NewClient(context.Background(), serviceURL, "secret", WithMiddleware(audit))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := client.CourseAPI.GetContents(context.Background(), 1); err != nil {
+		t.Fatalf("GetContents() error = %v", err)
+	}
+
+	// the token and format are not exposed to middlewares
+	if diff := cmp.Diff(gotParams, url.Values{"courseid": {"2"}}); diff != "" {
+		t.Errorf("CallRequest.Params (-got, +want)\n%s", diff)
+	}
+	want := url.Values{
+		"courseid":           {"2"},
+		"moodlewsrestformat": {"json"},
+		"wsfunction":         {"core_course_get_contents"},
+		"wstoken":            {"secret"},
+	}
+	if diff := cmp.Diff(gotQuery, want); diff != "" {
+		t.Errorf("query (-got, +want)\n%s", diff)
+	}
+}
+
+func TestWithMiddleware_post(t *testing.T) {
+	t.Parallel()
+
+	var gotURLQuery, gotForm url.Values
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURLQuery = r.URL.Query()
+		r.ParseForm()
+		gotForm = r.PostForm
+		fmt.Fprintln(w, `null`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+
+	var gotMethod string
+	audit := func(next Handler) Handler {
+		return func(ctx context.Context, req *CallRequest) (*CallResponse, error) {
+			gotMethod = req.Method
+			return next(ctx, req)
+		}
+	}
+	client, err := NewClient(context.Background(), serviceURL, "secret", WithMiddleware(audit))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if err := client.UserAPI.DeleteUsers(context.Background(), []int{1}); err != nil {
+		t.Fatalf("DeleteUsers() error = %v", err)
+	}
+
+	// the params and the token of the functions changing data are sent in the form body
+	if gotMethod != http.MethodPost {
+		t.Errorf("CallRequest.Method = %q, want %q", gotMethod, http.MethodPost)
+	}
+	wantURLQuery := url.Values{
+		"moodlewsrestformat": {"json"},
+		"wsfunction":         {"core_user_delete_users"},
+	}
+	if diff := cmp.Diff(gotURLQuery, wantURLQuery); diff != "" {
+		t.Errorf("url query (-got, +want)\n%s", diff)
+	}
+	wantForm := url.Values{
+		"userids[0]": {"1"},
+		"wstoken":    {"secret"},
+	}
+	if diff := cmp.Diff(gotForm, wantForm); diff != "" {
+		t.Errorf("form (-got, +want)\n%s", diff)
+	}
+}
+
+func TestWithMiddleware_shortCircuit(t *testing.T) {
+	t.Parallel()
+
+	// the request fails if it's sent since the host doesn't exist
+	serviceURL, _ := url.Parse("http://moodle.invalid")
+	stub := func(next Handler) Handler {
+		return func(ctx context.Context, req *CallRequest) (*CallResponse, error) {
+			if req.Function == "core_webservice_get_site_info" {
+				return &CallResponse{StatusCode: http.StatusOK, Body: []byte(`{"sitename":"stub"}`)}, nil
+			}
+			return &CallResponse{StatusCode: http.StatusOK, Body: []byte(`{"exception":"moodle_exception","errorcode":"invalidrecord","message":"not found"}`)}, nil
+		}
+	}
+	client, err := NewClient(context.Background(), serviceURL, "secret", WithMiddleware(stub))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	siteInfo, err := client.SiteAPI.GetSiteInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetSiteInfo() error = %v", err)
+	}
+	if siteInfo.SiteName != "stub" {
+		t.Errorf("GetSiteInfo().SiteName = %v, want = stub", siteInfo.SiteName)
+	}
+	_, err = client.CourseAPI.GetContents(context.Background(), 1)
+	var apiError *APIError
+	if !errors.As(err, &apiError) || apiError.ErrorCode != "invalidrecord" {
+		t.Errorf("GetContents() error = %v, want APIError invalidrecord", err)
+	}
+}
+
+func TestRetryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		function     string
+		failures     int32
+		wantCalls    int32
+		wantErr      bool
+		wantAttempts []int
+	}{
+		{
+			name:         "Retry until success",
+			function:     "core_course_get_contents",
+			failures:     2,
+			wantCalls:    3,
+			wantAttempts: []int{0, 1, 2},
+		},
+		{
+			name:         "Give up after max retries",
+			function:     "core_course_get_contents",
+			failures:     10,
+			wantCalls:    4,
+			wantErr:      true,
+			wantAttempts: []int{0, 1, 2, 3},
+		},
+		{
+			name:         "Not retry function with side effects",
+			function:     "mod_quiz_start_attempt",
+			failures:     1,
+			wantCalls:    1,
+			wantErr:      true,
+			wantAttempts: []int{0},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int32
+			var attempts []int
+			next := func(ctx context.Context, req *CallRequest) (*CallResponse, error) {
+				attempts = append(attempts, RetryAttempt(ctx))
+				if atomic.AddInt32(&calls, 1) <= tt.failures {
+					return &CallResponse{StatusCode: http.StatusServiceUnavailable}, nil
+				}
+				return &CallResponse{StatusCode: http.StatusOK, Body: []byte(`[]`)}, nil
+			}
+			handler := RetryMiddleware(RetryOptions{MaxRetries: 3})(next)
+			res, err := handler(context.Background(), &CallRequest{Function: tt.function})
+			if err != nil {
+				t.Fatalf("handler() error = %v", err)
+			}
+			if gotErr := res.StatusCode != http.StatusOK; gotErr != tt.wantErr {
+				t.Errorf("handler() status = %v, wantErr %v", res.StatusCode, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %v, want = %v", calls, tt.wantCalls)
+			}
+			if diff := cmp.Diff(attempts, tt.wantAttempts); diff != "" {
+				t.Errorf("retry attempts (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
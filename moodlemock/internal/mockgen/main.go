@@ -0,0 +1,231 @@
+// Command mockgen generates mocks of the sub-API interfaces (e.g. CourseAPI) of the moodle package.
+//
+//	go run ./internal/mockgen -src .. -out mocks_gen.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+type method struct {
+	name    string
+	params  []*param
+	results []string
+}
+
+type param struct {
+	name string
+	typ  string
+}
+
+type api struct {
+	name    string
+	methods []*method
+}
+
+func main() {
+	src := flag.String("src", "..", "directory of the moodle package")
+	out := flag.String("out", "mocks_gen.go", "output file")
+	flag.Parse()
+
+	apis, err := parseAPIs(*src)
+	if err != nil {
+		log.Fatal(err)
+	}
+	code, err := generate(apis)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*out, code, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseAPIs returns the exported interfaces whose name ends with API in alphabetical order
+func parseAPIs(dir string) ([]*api, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	pkg, ok := pkgs["moodle"]
+	if !ok {
+		return nil, fmt.Errorf("moodle package is not found in %s", dir)
+	}
+
+	var apis []*api
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				iface, ok := typeSpec.Type.(*ast.InterfaceType)
+				if !ok || !typeSpec.Name.IsExported() || !strings.HasSuffix(typeSpec.Name.Name, "API") {
+					continue
+				}
+				a := &api{name: typeSpec.Name.Name}
+				for _, field := range iface.Methods.List {
+					a.methods = append(a.methods, parseMethod(field))
+				}
+				apis = append(apis, a)
+			}
+		}
+	}
+	sort.Slice(apis, func(i, j int) bool { return apis[i].name < apis[j].name })
+	return apis, nil
+}
+
+func parseMethod(field *ast.Field) *method {
+	funcType := field.Type.(*ast.FuncType)
+	m := &method{name: field.Names[0].Name}
+	for i, p := range funcType.Params.List {
+		typ := typeString(p.Type)
+		if len(p.Names) == 0 {
+			m.params = append(m.params, &param{name: fmt.Sprintf("arg%d", i), typ: typ})
+		}
+		for _, name := range p.Names {
+			m.params = append(m.params, &param{name: name.Name, typ: typ})
+		}
+	}
+	if funcType.Results != nil {
+		for _, r := range funcType.Results.List {
+			m.results = append(m.results, typeString(r.Type))
+		}
+	}
+	return m
+}
+
+// typeString returns the type qualified with the package, e.g. "[]*moodle.Course" for "[]*Course"
+func typeString(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if e.IsExported() {
+			return "moodle." + e.Name
+		}
+		return e.Name
+	case *ast.StarExpr:
+		return "*" + typeString(e.X)
+	case *ast.ArrayType:
+		return "[]" + typeString(e.Elt)
+	case *ast.MapType:
+		return "map[" + typeString(e.Key) + "]" + typeString(e.Value)
+	case *ast.SelectorExpr:
+		return typeString(e.X) + "." + e.Sel.Name
+	case *ast.InterfaceType:
+		return "interface{}"
+	default:
+		panic(fmt.Sprintf("unsupported type %T", expr))
+	}
+}
+
+// zeroValue returns the value returned for the type when the function is not set
+func zeroValue(typ, err string) string {
+	switch {
+	case typ == "error":
+		return err
+	case typ == "bool":
+		return "false"
+	case typ == "string":
+		return `""`
+	case strings.HasPrefix(typ, "*"), strings.HasPrefix(typ, "[]"), strings.HasPrefix(typ, "map["), typ == "interface{}":
+		return "nil"
+	case strings.HasPrefix(typ, "moodle."):
+		return typ + "{}"
+	default:
+		return "0"
+	}
+}
+
+func generate(apis []*api) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "// Code generated by mockgen. DO NOT EDIT.")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "package moodlemock")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "import (")
+	fmt.Fprintln(&b, `"context"`)
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, `"github.com/k-yomo/moodle"`)
+	fmt.Fprintln(&b, ")")
+
+	for _, a := range apis {
+		fmt.Fprintln(&b)
+		fmt.Fprintf(&b, "// %s is a mock of moodle.%s.\n", a.name, a.name)
+		fmt.Fprintf(&b, "// Each method calls the function field of the same name with Func suffix, and fails with ErrNotImplemented if it's nil.\n")
+		fmt.Fprintf(&b, "type %s struct {\n", a.name)
+		for _, m := range a.methods {
+			fmt.Fprintf(&b, "%sFunc func(%s) %s\n", m.name, m.paramList(), m.resultList())
+		}
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "recorder")
+		fmt.Fprintln(&b, "}")
+		fmt.Fprintln(&b)
+		fmt.Fprintf(&b, "var _ moodle.%s = (*%s)(nil)\n", a.name, a.name)
+
+		for _, m := range a.methods {
+			fmt.Fprintln(&b)
+			fmt.Fprintf(&b, "func (m *%s) %s(%s) %s {\n", a.name, m.name, m.paramList(), m.resultList())
+			fmt.Fprintf(&b, "m.record(%s)\n", strings.Join(append([]string{fmt.Sprintf("%q", m.name)}, m.recordedArgs()...), ", "))
+			fmt.Fprintf(&b, "if m.%sFunc == nil {\n", m.name)
+			zeros := make([]string, 0, len(m.results))
+			for _, r := range m.results {
+				zeros = append(zeros, zeroValue(r, fmt.Sprintf("notImplemented(%q, %q)", a.name, m.name)))
+			}
+			fmt.Fprintf(&b, "return %s\n", strings.Join(zeros, ", "))
+			fmt.Fprintln(&b, "}")
+			fmt.Fprintf(&b, "return m.%sFunc(%s)\n", m.name, strings.Join(m.paramNames(), ", "))
+			fmt.Fprintln(&b, "}")
+		}
+	}
+	return format.Source(b.Bytes())
+}
+
+func (m *method) paramList() string {
+	params := make([]string, 0, len(m.params))
+	for _, p := range m.params {
+		params = append(params, p.name+" "+p.typ)
+	}
+	return strings.Join(params, ", ")
+}
+
+func (m *method) paramNames() []string {
+	names := make([]string, 0, len(m.params))
+	for _, p := range m.params {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// recordedArgs returns the params except context
+func (m *method) recordedArgs() []string {
+	var args []string
+	for _, p := range m.params {
+		if p.typ != "context.Context" {
+			args = append(args, p.name)
+		}
+	}
+	return args
+}
+
+func (m *method) resultList() string {
+	if len(m.results) == 1 {
+		return m.results[0]
+	}
+	return "(" + strings.Join(m.results, ", ") + ")"
+}
@@ -0,0 +1,585 @@
+// Code generated by mockgen. DO NOT EDIT.
+
+package moodlemock
+
+import (
+	"context"
+
+	"github.com/k-yomo/moodle"
+)
+
+// AuthAPI is a mock of moodle.AuthAPI.
+// Each method calls the function field of the same name with Func suffix, and fails with ErrNotImplemented if it's nil.
+type AuthAPI struct {
+	LoginFunc func(ctx context.Context, username string, password string) (*moodle.LoginResponse, error)
+
+	recorder
+}
+
+var _ moodle.AuthAPI = (*AuthAPI)(nil)
+
+func (m *AuthAPI) Login(ctx context.Context, username string, password string) (*moodle.LoginResponse, error) {
+	m.record("Login", username, password)
+	if m.LoginFunc == nil {
+		return nil, notImplemented("AuthAPI", "Login")
+	}
+	return m.LoginFunc(ctx, username, password)
+}
+
+// CompletionAPI is a mock of moodle.CompletionAPI.
+// Each method calls the function field of the same name with Func suffix, and fails with ErrNotImplemented if it's nil.
+type CompletionAPI struct {
+	GetActivitiesCompletionStatusFunc          func(ctx context.Context, courseID int, userID int) ([]*moodle.ActivityCompletionStatus, error)
+	GetCourseCompletionStatusFunc              func(ctx context.Context, courseID int, userID int) (*moodle.CourseCompletionStatus, error)
+	UpdateActivityCompletionStatusManuallyFunc func(ctx context.Context, cmID int, completed bool) error
+	MarkCourseSelfCompletedFunc                func(ctx context.Context, courseID int) error
+
+	recorder
+}
+
+var _ moodle.CompletionAPI = (*CompletionAPI)(nil)
+
+func (m *CompletionAPI) GetActivitiesCompletionStatus(ctx context.Context, courseID int, userID int) ([]*moodle.ActivityCompletionStatus, error) {
+	m.record("GetActivitiesCompletionStatus", courseID, userID)
+	if m.GetActivitiesCompletionStatusFunc == nil {
+		return nil, notImplemented("CompletionAPI", "GetActivitiesCompletionStatus")
+	}
+	return m.GetActivitiesCompletionStatusFunc(ctx, courseID, userID)
+}
+
+func (m *CompletionAPI) GetCourseCompletionStatus(ctx context.Context, courseID int, userID int) (*moodle.CourseCompletionStatus, error) {
+	m.record("GetCourseCompletionStatus", courseID, userID)
+	if m.GetCourseCompletionStatusFunc == nil {
+		return nil, notImplemented("CompletionAPI", "GetCourseCompletionStatus")
+	}
+	return m.GetCourseCompletionStatusFunc(ctx, courseID, userID)
+}
+
+func (m *CompletionAPI) UpdateActivityCompletionStatusManually(ctx context.Context, cmID int, completed bool) error {
+	m.record("UpdateActivityCompletionStatusManually", cmID, completed)
+	if m.UpdateActivityCompletionStatusManuallyFunc == nil {
+		return notImplemented("CompletionAPI", "UpdateActivityCompletionStatusManually")
+	}
+	return m.UpdateActivityCompletionStatusManuallyFunc(ctx, cmID, completed)
+}
+
+func (m *CompletionAPI) MarkCourseSelfCompleted(ctx context.Context, courseID int) error {
+	m.record("MarkCourseSelfCompleted", courseID)
+	if m.MarkCourseSelfCompletedFunc == nil {
+		return notImplemented("CompletionAPI", "MarkCourseSelfCompleted")
+	}
+	return m.MarkCourseSelfCompletedFunc(ctx, courseID)
+}
+
+// CourseAPI is a mock of moodle.CourseAPI.
+// Each method calls the function field of the same name with Func suffix, and fails with ErrNotImplemented if it's nil.
+type CourseAPI struct {
+	GetEnrolledCoursesByTimelineClassificationFunc func(ctx context.Context, classification moodle.CourseClassification) ([]*moodle.Course, error)
+	GetCoursesByFieldFunc                          func(ctx context.Context, field moodle.CourseField, value string) ([]*moodle.Course, error)
+	GetContentsFunc                                func(ctx context.Context, courseID int) ([]*moodle.CourseSection, error)
+	GetCategoriesFunc                              func(ctx context.Context, criteria []*moodle.CategorySearchCriterion, addSubCategories bool) ([]*moodle.CourseCategory, error)
+	CreateCoursesFunc                              func(ctx context.Context, courses []*moodle.CreateCourseParams) ([]*moodle.CreatedCourse, error)
+	UpdateCoursesFunc                              func(ctx context.Context, courses []*moodle.UpdateCourseParams) error
+	DuplicateCourseFunc                            func(ctx context.Context, params *moodle.DuplicateCourseParams) (*moodle.CreatedCourse, error)
+	ImportCourseFunc                               func(ctx context.Context, params *moodle.ImportCourseParams) error
+	DeleteCoursesFunc                              func(ctx context.Context, courseIDs []int) error
+	CreateCategoriesFunc                           func(ctx context.Context, categories []*moodle.CreateCategoryParams) ([]*moodle.CourseCategory, error)
+	UpdateCategoriesFunc                           func(ctx context.Context, categories []*moodle.UpdateCategoryParams) error
+
+	recorder
+}
+
+var _ moodle.CourseAPI = (*CourseAPI)(nil)
+
+func (m *CourseAPI) GetEnrolledCoursesByTimelineClassification(ctx context.Context, classification moodle.CourseClassification) ([]*moodle.Course, error) {
+	m.record("GetEnrolledCoursesByTimelineClassification", classification)
+	if m.GetEnrolledCoursesByTimelineClassificationFunc == nil {
+		return nil, notImplemented("CourseAPI", "GetEnrolledCoursesByTimelineClassification")
+	}
+	return m.GetEnrolledCoursesByTimelineClassificationFunc(ctx, classification)
+}
+
+func (m *CourseAPI) GetCoursesByField(ctx context.Context, field moodle.CourseField, value string) ([]*moodle.Course, error) {
+	m.record("GetCoursesByField", field, value)
+	if m.GetCoursesByFieldFunc == nil {
+		return nil, notImplemented("CourseAPI", "GetCoursesByField")
+	}
+	return m.GetCoursesByFieldFunc(ctx, field, value)
+}
+
+func (m *CourseAPI) GetContents(ctx context.Context, courseID int) ([]*moodle.CourseSection, error) {
+	m.record("GetContents", courseID)
+	if m.GetContentsFunc == nil {
+		return nil, notImplemented("CourseAPI", "GetContents")
+	}
+	return m.GetContentsFunc(ctx, courseID)
+}
+
+func (m *CourseAPI) GetCategories(ctx context.Context, criteria []*moodle.CategorySearchCriterion, addSubCategories bool) ([]*moodle.CourseCategory, error) {
+	m.record("GetCategories", criteria, addSubCategories)
+	if m.GetCategoriesFunc == nil {
+		return nil, notImplemented("CourseAPI", "GetCategories")
+	}
+	return m.GetCategoriesFunc(ctx, criteria, addSubCategories)
+}
+
+func (m *CourseAPI) CreateCourses(ctx context.Context, courses []*moodle.CreateCourseParams) ([]*moodle.CreatedCourse, error) {
+	m.record("CreateCourses", courses)
+	if m.CreateCoursesFunc == nil {
+		return nil, notImplemented("CourseAPI", "CreateCourses")
+	}
+	return m.CreateCoursesFunc(ctx, courses)
+}
+
+func (m *CourseAPI) UpdateCourses(ctx context.Context, courses []*moodle.UpdateCourseParams) error {
+	m.record("UpdateCourses", courses)
+	if m.UpdateCoursesFunc == nil {
+		return notImplemented("CourseAPI", "UpdateCourses")
+	}
+	return m.UpdateCoursesFunc(ctx, courses)
+}
+
+func (m *CourseAPI) DuplicateCourse(ctx context.Context, params *moodle.DuplicateCourseParams) (*moodle.CreatedCourse, error) {
+	m.record("DuplicateCourse", params)
+	if m.DuplicateCourseFunc == nil {
+		return nil, notImplemented("CourseAPI", "DuplicateCourse")
+	}
+	return m.DuplicateCourseFunc(ctx, params)
+}
+
+func (m *CourseAPI) ImportCourse(ctx context.Context, params *moodle.ImportCourseParams) error {
+	m.record("ImportCourse", params)
+	if m.ImportCourseFunc == nil {
+		return notImplemented("CourseAPI", "ImportCourse")
+	}
+	return m.ImportCourseFunc(ctx, params)
+}
+
+func (m *CourseAPI) DeleteCourses(ctx context.Context, courseIDs []int) error {
+	m.record("DeleteCourses", courseIDs)
+	if m.DeleteCoursesFunc == nil {
+		return notImplemented("CourseAPI", "DeleteCourses")
+	}
+	return m.DeleteCoursesFunc(ctx, courseIDs)
+}
+
+func (m *CourseAPI) CreateCategories(ctx context.Context, categories []*moodle.CreateCategoryParams) ([]*moodle.CourseCategory, error) {
+	m.record("CreateCategories", categories)
+	if m.CreateCategoriesFunc == nil {
+		return nil, notImplemented("CourseAPI", "CreateCategories")
+	}
+	return m.CreateCategoriesFunc(ctx, categories)
+}
+
+func (m *CourseAPI) UpdateCategories(ctx context.Context, categories []*moodle.UpdateCategoryParams) error {
+	m.record("UpdateCategories", categories)
+	if m.UpdateCategoriesFunc == nil {
+		return notImplemented("CourseAPI", "UpdateCategories")
+	}
+	return m.UpdateCategoriesFunc(ctx, categories)
+}
+
+// EnrolAPI is a mock of moodle.EnrolAPI.
+// Each method calls the function field of the same name with Func suffix, and fails with ErrNotImplemented if it's nil.
+type EnrolAPI struct {
+	GetEnrolledUsersFunc          func(ctx context.Context, courseID int, opts *moodle.GetEnrolledUsersOptions) ([]*moodle.EnrolledUser, error)
+	GetUsersCoursesFunc           func(ctx context.Context, userID int) ([]*moodle.UserCourse, error)
+	GetCourseEnrolmentMethodsFunc func(ctx context.Context, courseID int) ([]*moodle.EnrolmentMethod, error)
+	SelfEnrolUserFunc             func(ctx context.Context, courseID int, password string, instanceID int) error
+	GetGuestInstanceInfoFunc      func(ctx context.Context, instanceID int) (*moodle.GuestEnrolmentInstance, error)
+	ManualEnrolUsersFunc          func(ctx context.Context, enrolments []*moodle.ManualEnrolment) error
+	ManualUnenrolUsersFunc        func(ctx context.Context, enrolments []*moodle.ManualEnrolment) error
+
+	recorder
+}
+
+var _ moodle.EnrolAPI = (*EnrolAPI)(nil)
+
+func (m *EnrolAPI) GetEnrolledUsers(ctx context.Context, courseID int, opts *moodle.GetEnrolledUsersOptions) ([]*moodle.EnrolledUser, error) {
+	m.record("GetEnrolledUsers", courseID, opts)
+	if m.GetEnrolledUsersFunc == nil {
+		return nil, notImplemented("EnrolAPI", "GetEnrolledUsers")
+	}
+	return m.GetEnrolledUsersFunc(ctx, courseID, opts)
+}
+
+func (m *EnrolAPI) GetUsersCourses(ctx context.Context, userID int) ([]*moodle.UserCourse, error) {
+	m.record("GetUsersCourses", userID)
+	if m.GetUsersCoursesFunc == nil {
+		return nil, notImplemented("EnrolAPI", "GetUsersCourses")
+	}
+	return m.GetUsersCoursesFunc(ctx, userID)
+}
+
+func (m *EnrolAPI) GetCourseEnrolmentMethods(ctx context.Context, courseID int) ([]*moodle.EnrolmentMethod, error) {
+	m.record("GetCourseEnrolmentMethods", courseID)
+	if m.GetCourseEnrolmentMethodsFunc == nil {
+		return nil, notImplemented("EnrolAPI", "GetCourseEnrolmentMethods")
+	}
+	return m.GetCourseEnrolmentMethodsFunc(ctx, courseID)
+}
+
+func (m *EnrolAPI) SelfEnrolUser(ctx context.Context, courseID int, password string, instanceID int) error {
+	m.record("SelfEnrolUser", courseID, password, instanceID)
+	if m.SelfEnrolUserFunc == nil {
+		return notImplemented("EnrolAPI", "SelfEnrolUser")
+	}
+	return m.SelfEnrolUserFunc(ctx, courseID, password, instanceID)
+}
+
+func (m *EnrolAPI) GetGuestInstanceInfo(ctx context.Context, instanceID int) (*moodle.GuestEnrolmentInstance, error) {
+	m.record("GetGuestInstanceInfo", instanceID)
+	if m.GetGuestInstanceInfoFunc == nil {
+		return nil, notImplemented("EnrolAPI", "GetGuestInstanceInfo")
+	}
+	return m.GetGuestInstanceInfoFunc(ctx, instanceID)
+}
+
+func (m *EnrolAPI) ManualEnrolUsers(ctx context.Context, enrolments []*moodle.ManualEnrolment) error {
+	m.record("ManualEnrolUsers", enrolments)
+	if m.ManualEnrolUsersFunc == nil {
+		return notImplemented("EnrolAPI", "ManualEnrolUsers")
+	}
+	return m.ManualEnrolUsersFunc(ctx, enrolments)
+}
+
+func (m *EnrolAPI) ManualUnenrolUsers(ctx context.Context, enrolments []*moodle.ManualEnrolment) error {
+	m.record("ManualUnenrolUsers", enrolments)
+	if m.ManualUnenrolUsersFunc == nil {
+		return notImplemented("EnrolAPI", "ManualUnenrolUsers")
+	}
+	return m.ManualUnenrolUsersFunc(ctx, enrolments)
+}
+
+// GradeAPI is a mock of moodle.GradeAPI.
+// Each method calls the function field of the same name with Func suffix, and fails with ErrNotImplemented if it's nil.
+type GradeAPI struct {
+	GetGradeItemsFunc                   func(ctx context.Context, userID int, courseID int) ([]*moodle.UserGrade, error)
+	GetGradesTableFunc                  func(ctx context.Context, userID int, courseID int) ([]*moodle.GradeTable, error)
+	GetCourseGradesFunc                 func(ctx context.Context, userID int) ([]*moodle.CourseGrade, error)
+	GetEnrolledUsersForSearchWidgetFunc func(ctx context.Context, courseID int, groupID int) ([]*moodle.GradeReportUser, error)
+	GetUserReportAccessInformationFunc  func(ctx context.Context, courseID int) (*moodle.GradeReportAccessInformation, error)
+	GetCourseGradeItemsFunc             func(ctx context.Context, courseID int) ([]*moodle.CourseGradeItem, error)
+	UpdateGradesFunc                    func(ctx context.Context, params *moodle.UpdateGradesParams) error
+	FetchGradingPanelPointGradeFunc     func(ctx context.Context, item *moodle.GradingPanelItem) (*moodle.GradingPanelPointGrade, error)
+	StoreGradingPanelPointGradeFunc     func(ctx context.Context, item *moodle.GradingPanelItem, grade float64, notifyUser bool) (*moodle.GradingPanelPointGrade, error)
+	FetchGradingPanelScaleGradeFunc     func(ctx context.Context, item *moodle.GradingPanelItem) (*moodle.GradingPanelScaleGrade, error)
+	StoreGradingPanelScaleGradeFunc     func(ctx context.Context, item *moodle.GradingPanelItem, grade int, notifyUser bool) (*moodle.GradingPanelScaleGrade, error)
+
+	recorder
+}
+
+var _ moodle.GradeAPI = (*GradeAPI)(nil)
+
+func (m *GradeAPI) GetGradeItems(ctx context.Context, userID int, courseID int) ([]*moodle.UserGrade, error) {
+	m.record("GetGradeItems", userID, courseID)
+	if m.GetGradeItemsFunc == nil {
+		return nil, notImplemented("GradeAPI", "GetGradeItems")
+	}
+	return m.GetGradeItemsFunc(ctx, userID, courseID)
+}
+
+func (m *GradeAPI) GetGradesTable(ctx context.Context, userID int, courseID int) ([]*moodle.GradeTable, error) {
+	m.record("GetGradesTable", userID, courseID)
+	if m.GetGradesTableFunc == nil {
+		return nil, notImplemented("GradeAPI", "GetGradesTable")
+	}
+	return m.GetGradesTableFunc(ctx, userID, courseID)
+}
+
+func (m *GradeAPI) GetCourseGrades(ctx context.Context, userID int) ([]*moodle.CourseGrade, error) {
+	m.record("GetCourseGrades", userID)
+	if m.GetCourseGradesFunc == nil {
+		return nil, notImplemented("GradeAPI", "GetCourseGrades")
+	}
+	return m.GetCourseGradesFunc(ctx, userID)
+}
+
+func (m *GradeAPI) GetEnrolledUsersForSearchWidget(ctx context.Context, courseID int, groupID int) ([]*moodle.GradeReportUser, error) {
+	m.record("GetEnrolledUsersForSearchWidget", courseID, groupID)
+	if m.GetEnrolledUsersForSearchWidgetFunc == nil {
+		return nil, notImplemented("GradeAPI", "GetEnrolledUsersForSearchWidget")
+	}
+	return m.GetEnrolledUsersForSearchWidgetFunc(ctx, courseID, groupID)
+}
+
+func (m *GradeAPI) GetUserReportAccessInformation(ctx context.Context, courseID int) (*moodle.GradeReportAccessInformation, error) {
+	m.record("GetUserReportAccessInformation", courseID)
+	if m.GetUserReportAccessInformationFunc == nil {
+		return nil, notImplemented("GradeAPI", "GetUserReportAccessInformation")
+	}
+	return m.GetUserReportAccessInformationFunc(ctx, courseID)
+}
+
+func (m *GradeAPI) GetCourseGradeItems(ctx context.Context, courseID int) ([]*moodle.CourseGradeItem, error) {
+	m.record("GetCourseGradeItems", courseID)
+	if m.GetCourseGradeItemsFunc == nil {
+		return nil, notImplemented("GradeAPI", "GetCourseGradeItems")
+	}
+	return m.GetCourseGradeItemsFunc(ctx, courseID)
+}
+
+func (m *GradeAPI) UpdateGrades(ctx context.Context, params *moodle.UpdateGradesParams) error {
+	m.record("UpdateGrades", params)
+	if m.UpdateGradesFunc == nil {
+		return notImplemented("GradeAPI", "UpdateGrades")
+	}
+	return m.UpdateGradesFunc(ctx, params)
+}
+
+func (m *GradeAPI) FetchGradingPanelPointGrade(ctx context.Context, item *moodle.GradingPanelItem) (*moodle.GradingPanelPointGrade, error) {
+	m.record("FetchGradingPanelPointGrade", item)
+	if m.FetchGradingPanelPointGradeFunc == nil {
+		return nil, notImplemented("GradeAPI", "FetchGradingPanelPointGrade")
+	}
+	return m.FetchGradingPanelPointGradeFunc(ctx, item)
+}
+
+func (m *GradeAPI) StoreGradingPanelPointGrade(ctx context.Context, item *moodle.GradingPanelItem, grade float64, notifyUser bool) (*moodle.GradingPanelPointGrade, error) {
+	m.record("StoreGradingPanelPointGrade", item, grade, notifyUser)
+	if m.StoreGradingPanelPointGradeFunc == nil {
+		return nil, notImplemented("GradeAPI", "StoreGradingPanelPointGrade")
+	}
+	return m.StoreGradingPanelPointGradeFunc(ctx, item, grade, notifyUser)
+}
+
+func (m *GradeAPI) FetchGradingPanelScaleGrade(ctx context.Context, item *moodle.GradingPanelItem) (*moodle.GradingPanelScaleGrade, error) {
+	m.record("FetchGradingPanelScaleGrade", item)
+	if m.FetchGradingPanelScaleGradeFunc == nil {
+		return nil, notImplemented("GradeAPI", "FetchGradingPanelScaleGrade")
+	}
+	return m.FetchGradingPanelScaleGradeFunc(ctx, item)
+}
+
+func (m *GradeAPI) StoreGradingPanelScaleGrade(ctx context.Context, item *moodle.GradingPanelItem, grade int, notifyUser bool) (*moodle.GradingPanelScaleGrade, error) {
+	m.record("StoreGradingPanelScaleGrade", item, grade, notifyUser)
+	if m.StoreGradingPanelScaleGradeFunc == nil {
+		return nil, notImplemented("GradeAPI", "StoreGradingPanelScaleGrade")
+	}
+	return m.StoreGradingPanelScaleGradeFunc(ctx, item, grade, notifyUser)
+}
+
+// GroupAPI is a mock of moodle.GroupAPI.
+// Each method calls the function field of the same name with Func suffix, and fails with ErrNotImplemented if it's nil.
+type GroupAPI struct {
+	GetCourseGroupsFunc          func(ctx context.Context, courseID int) ([]*moodle.Group, error)
+	GetCourseGroupingsFunc       func(ctx context.Context, courseID int) ([]*moodle.Grouping, error)
+	GetCourseUserGroupsFunc      func(ctx context.Context, courseID int, userID int, groupingID int) ([]*moodle.Group, error)
+	GetActivityAllowedGroupsFunc func(ctx context.Context, cmID int, userID int) (*moodle.ActivityAllowedGroups, error)
+	CreateGroupsFunc             func(ctx context.Context, groups []*moodle.CreateGroupParams) ([]*moodle.Group, error)
+	AddGroupMembersFunc          func(ctx context.Context, members []*moodle.GroupMember) error
+	DeleteGroupMembersFunc       func(ctx context.Context, members []*moodle.GroupMember) error
+
+	recorder
+}
+
+var _ moodle.GroupAPI = (*GroupAPI)(nil)
+
+func (m *GroupAPI) GetCourseGroups(ctx context.Context, courseID int) ([]*moodle.Group, error) {
+	m.record("GetCourseGroups", courseID)
+	if m.GetCourseGroupsFunc == nil {
+		return nil, notImplemented("GroupAPI", "GetCourseGroups")
+	}
+	return m.GetCourseGroupsFunc(ctx, courseID)
+}
+
+func (m *GroupAPI) GetCourseGroupings(ctx context.Context, courseID int) ([]*moodle.Grouping, error) {
+	m.record("GetCourseGroupings", courseID)
+	if m.GetCourseGroupingsFunc == nil {
+		return nil, notImplemented("GroupAPI", "GetCourseGroupings")
+	}
+	return m.GetCourseGroupingsFunc(ctx, courseID)
+}
+
+func (m *GroupAPI) GetCourseUserGroups(ctx context.Context, courseID int, userID int, groupingID int) ([]*moodle.Group, error) {
+	m.record("GetCourseUserGroups", courseID, userID, groupingID)
+	if m.GetCourseUserGroupsFunc == nil {
+		return nil, notImplemented("GroupAPI", "GetCourseUserGroups")
+	}
+	return m.GetCourseUserGroupsFunc(ctx, courseID, userID, groupingID)
+}
+
+func (m *GroupAPI) GetActivityAllowedGroups(ctx context.Context, cmID int, userID int) (*moodle.ActivityAllowedGroups, error) {
+	m.record("GetActivityAllowedGroups", cmID, userID)
+	if m.GetActivityAllowedGroupsFunc == nil {
+		return nil, notImplemented("GroupAPI", "GetActivityAllowedGroups")
+	}
+	return m.GetActivityAllowedGroupsFunc(ctx, cmID, userID)
+}
+
+func (m *GroupAPI) CreateGroups(ctx context.Context, groups []*moodle.CreateGroupParams) ([]*moodle.Group, error) {
+	m.record("CreateGroups", groups)
+	if m.CreateGroupsFunc == nil {
+		return nil, notImplemented("GroupAPI", "CreateGroups")
+	}
+	return m.CreateGroupsFunc(ctx, groups)
+}
+
+func (m *GroupAPI) AddGroupMembers(ctx context.Context, members []*moodle.GroupMember) error {
+	m.record("AddGroupMembers", members)
+	if m.AddGroupMembersFunc == nil {
+		return notImplemented("GroupAPI", "AddGroupMembers")
+	}
+	return m.AddGroupMembersFunc(ctx, members)
+}
+
+func (m *GroupAPI) DeleteGroupMembers(ctx context.Context, members []*moodle.GroupMember) error {
+	m.record("DeleteGroupMembers", members)
+	if m.DeleteGroupMembersFunc == nil {
+		return notImplemented("GroupAPI", "DeleteGroupMembers")
+	}
+	return m.DeleteGroupMembersFunc(ctx, members)
+}
+
+// QuizAPI is a mock of moodle.QuizAPI.
+// Each method calls the function field of the same name with Func suffix, and fails with ErrNotImplemented if it's nil.
+type QuizAPI struct {
+	GetQuizzesByCourseFunc          func(ctx context.Context, courseID int) ([]*moodle.Quiz, error)
+	GetUserAttemptsFunc             func(ctx context.Context, quizID int) ([]*moodle.QuizAttempt, error)
+	GetAttemptReviewFunc            func(ctx context.Context, attemptID int) (*moodle.QuizAttempt, []*moodle.QuizQuestion, error)
+	StartAttemptFunc                func(ctx context.Context, quizID int) (*moodle.QuizAttempt, error)
+	FinishAttemptFunc               func(ctx context.Context, attemptID int, timeUp bool) error
+	GetAttemptAccessInformationFunc func(ctx context.Context, quizID int, attemptID int) (*moodle.QuizAttemptAccessInformation, error)
+
+	recorder
+}
+
+var _ moodle.QuizAPI = (*QuizAPI)(nil)
+
+func (m *QuizAPI) GetQuizzesByCourse(ctx context.Context, courseID int) ([]*moodle.Quiz, error) {
+	m.record("GetQuizzesByCourse", courseID)
+	if m.GetQuizzesByCourseFunc == nil {
+		return nil, notImplemented("QuizAPI", "GetQuizzesByCourse")
+	}
+	return m.GetQuizzesByCourseFunc(ctx, courseID)
+}
+
+func (m *QuizAPI) GetUserAttempts(ctx context.Context, quizID int) ([]*moodle.QuizAttempt, error) {
+	m.record("GetUserAttempts", quizID)
+	if m.GetUserAttemptsFunc == nil {
+		return nil, notImplemented("QuizAPI", "GetUserAttempts")
+	}
+	return m.GetUserAttemptsFunc(ctx, quizID)
+}
+
+func (m *QuizAPI) GetAttemptReview(ctx context.Context, attemptID int) (*moodle.QuizAttempt, []*moodle.QuizQuestion, error) {
+	m.record("GetAttemptReview", attemptID)
+	if m.GetAttemptReviewFunc == nil {
+		return nil, nil, notImplemented("QuizAPI", "GetAttemptReview")
+	}
+	return m.GetAttemptReviewFunc(ctx, attemptID)
+}
+
+func (m *QuizAPI) StartAttempt(ctx context.Context, quizID int) (*moodle.QuizAttempt, error) {
+	m.record("StartAttempt", quizID)
+	if m.StartAttemptFunc == nil {
+		return nil, notImplemented("QuizAPI", "StartAttempt")
+	}
+	return m.StartAttemptFunc(ctx, quizID)
+}
+
+func (m *QuizAPI) FinishAttempt(ctx context.Context, attemptID int, timeUp bool) error {
+	m.record("FinishAttempt", attemptID, timeUp)
+	if m.FinishAttemptFunc == nil {
+		return notImplemented("QuizAPI", "FinishAttempt")
+	}
+	return m.FinishAttemptFunc(ctx, attemptID, timeUp)
+}
+
+func (m *QuizAPI) GetAttemptAccessInformation(ctx context.Context, quizID int, attemptID int) (*moodle.QuizAttemptAccessInformation, error) {
+	m.record("GetAttemptAccessInformation", quizID, attemptID)
+	if m.GetAttemptAccessInformationFunc == nil {
+		return nil, notImplemented("QuizAPI", "GetAttemptAccessInformation")
+	}
+	return m.GetAttemptAccessInformationFunc(ctx, quizID, attemptID)
+}
+
+// SiteAPI is a mock of moodle.SiteAPI.
+// Each method calls the function field of the same name with Func suffix, and fails with ErrNotImplemented if it's nil.
+type SiteAPI struct {
+	GetSiteInfoFunc func(ctx context.Context) (*moodle.SiteInfo, error)
+
+	recorder
+}
+
+var _ moodle.SiteAPI = (*SiteAPI)(nil)
+
+func (m *SiteAPI) GetSiteInfo(ctx context.Context) (*moodle.SiteInfo, error) {
+	m.record("GetSiteInfo")
+	if m.GetSiteInfoFunc == nil {
+		return nil, notImplemented("SiteAPI", "GetSiteInfo")
+	}
+	return m.GetSiteInfoFunc(ctx)
+}
+
+// UserAPI is a mock of moodle.UserAPI.
+// Each method calls the function field of the same name with Func suffix, and fails with ErrNotImplemented if it's nil.
+type UserAPI struct {
+	GetUsersByFieldFunc    func(ctx context.Context, field moodle.UserField, values []string) ([]*moodle.User, error)
+	CreateUsersFunc        func(ctx context.Context, users []*moodle.CreateUserParams) ([]*moodle.CreatedUser, error)
+	UpdateUsersFunc        func(ctx context.Context, users []*moodle.UpdateUserParams) error
+	SuspendUsersFunc       func(ctx context.Context, userIDs []int) error
+	DeleteUsersFunc        func(ctx context.Context, userIDs []int) error
+	GetUserPreferencesFunc func(ctx context.Context, userID int, name string) ([]*moodle.UserPreference, error)
+	SetUserPreferencesFunc func(ctx context.Context, preferences []*moodle.UserPreference) error
+
+	recorder
+}
+
+var _ moodle.UserAPI = (*UserAPI)(nil)
+
+func (m *UserAPI) GetUsersByField(ctx context.Context, field moodle.UserField, values []string) ([]*moodle.User, error) {
+	m.record("GetUsersByField", field, values)
+	if m.GetUsersByFieldFunc == nil {
+		return nil, notImplemented("UserAPI", "GetUsersByField")
+	}
+	return m.GetUsersByFieldFunc(ctx, field, values)
+}
+
+func (m *UserAPI) CreateUsers(ctx context.Context, users []*moodle.CreateUserParams) ([]*moodle.CreatedUser, error) {
+	m.record("CreateUsers", users)
+	if m.CreateUsersFunc == nil {
+		return nil, notImplemented("UserAPI", "CreateUsers")
+	}
+	return m.CreateUsersFunc(ctx, users)
+}
+
+func (m *UserAPI) UpdateUsers(ctx context.Context, users []*moodle.UpdateUserParams) error {
+	m.record("UpdateUsers", users)
+	if m.UpdateUsersFunc == nil {
+		return notImplemented("UserAPI", "UpdateUsers")
+	}
+	return m.UpdateUsersFunc(ctx, users)
+}
+
+func (m *UserAPI) SuspendUsers(ctx context.Context, userIDs []int) error {
+	m.record("SuspendUsers", userIDs)
+	if m.SuspendUsersFunc == nil {
+		return notImplemented("UserAPI", "SuspendUsers")
+	}
+	return m.SuspendUsersFunc(ctx, userIDs)
+}
+
+func (m *UserAPI) DeleteUsers(ctx context.Context, userIDs []int) error {
+	m.record("DeleteUsers", userIDs)
+	if m.DeleteUsersFunc == nil {
+		return notImplemented("UserAPI", "DeleteUsers")
+	}
+	return m.DeleteUsersFunc(ctx, userIDs)
+}
+
+func (m *UserAPI) GetUserPreferences(ctx context.Context, userID int, name string) ([]*moodle.UserPreference, error) {
+	m.record("GetUserPreferences", userID, name)
+	if m.GetUserPreferencesFunc == nil {
+		return nil, notImplemented("UserAPI", "GetUserPreferences")
+	}
+	return m.GetUserPreferencesFunc(ctx, userID, name)
+}
+
+func (m *UserAPI) SetUserPreferences(ctx context.Context, preferences []*moodle.UserPreference) error {
+	m.record("SetUserPreferences", preferences)
+	if m.SetUserPreferencesFunc == nil {
+		return notImplemented("UserAPI", "SetUserPreferences")
+	}
+	return m.SetUserPreferencesFunc(ctx, preferences)
+}
@@ -0,0 +1,113 @@
+// Package moodlemock provides mocks of the sub-APIs of the moodle client to unit test code built on it without HTTP.
+//
+// Each mock has a function field per method (e.g. CourseAPI.GetContentsFunc) and records the calls.
+//
+//	courseAPI := &moodlemock.CourseAPI{
+//		GetContentsFunc: func(ctx context.Context, courseID int) ([]*moodle.CourseSection, error) {
+//			return sections, nil
+//		},
+//	}
+//	client, _ := moodle.NewClient(ctx, serviceURL, token, moodle.WithCourseAPI(courseAPI))
+package moodlemock
+
+//go:generate go run ./internal/mockgen -src .. -out mocks_gen.go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/k-yomo/moodle"
+)
+
+// ErrNotImplemented is returned when the function of the called method is not set
+var ErrNotImplemented = errors.New("moodlemock: method is not implemented")
+
+func notImplemented(api, method string) error {
+	return fmt.Errorf("%w: %s.%sFunc is nil", ErrNotImplemented, api, method)
+}
+
+// Call represents a call of a mock method
+type Call struct {
+	Method string
+	// Args are the arguments except the context
+	Args []interface{}
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	calls []*Call
+}
+
+func (r *recorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, &Call{Method: method, Args: args})
+}
+
+// Calls returns the calls in order
+func (r *recorder) Calls() []*Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Call(nil), r.calls...)
+}
+
+// CallsOf returns the calls of the method in order
+func (r *recorder) CallsOf(method string) []*Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []*Call
+	for _, c := range r.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Mocks holds mocks of all the sub-APIs
+type Mocks struct {
+	AuthAPI       *AuthAPI
+	SiteAPI       *SiteAPI
+	UserAPI       *UserAPI
+	CourseAPI     *CourseAPI
+	QuizAPI       *QuizAPI
+	GradeAPI      *GradeAPI
+	EnrolAPI      *EnrolAPI
+	GroupAPI      *GroupAPI
+	CompletionAPI *CompletionAPI
+}
+
+// NewClient returns a moodle client whose sub-APIs are all mocks, and the mocks to set the functions.
+// The client must not be used for the calls sending requests directly (e.g. Call, NewBatch and DownloadFile).
+func NewClient() (*moodle.Client, *Mocks) {
+	mocks := &Mocks{
+		AuthAPI:       &AuthAPI{},
+		SiteAPI:       &SiteAPI{},
+		UserAPI:       &UserAPI{},
+		CourseAPI:     &CourseAPI{},
+		QuizAPI:       &QuizAPI{},
+		GradeAPI:      &GradeAPI{},
+		EnrolAPI:      &EnrolAPI{},
+		GroupAPI:      &GroupAPI{},
+		CompletionAPI: &CompletionAPI{},
+	}
+	serviceURL := &url.URL{Scheme: "http", Host: "moodlemock.invalid"}
+	client, _ := moodle.NewClient(
+		context.Background(),
+		serviceURL,
+		"moodlemock",
+		moodle.WithAuthAPI(mocks.AuthAPI),
+		moodle.WithSiteAPI(mocks.SiteAPI),
+		moodle.WithUserAPI(mocks.UserAPI),
+		moodle.WithCourseAPI(mocks.CourseAPI),
+		moodle.WithQuizAPI(mocks.QuizAPI),
+		moodle.WithGradeAPI(mocks.GradeAPI),
+		moodle.WithEnrolAPI(mocks.EnrolAPI),
+		moodle.WithGroupAPI(mocks.GroupAPI),
+		moodle.WithCompletionAPI(mocks.CompletionAPI),
+	)
+	return client, mocks
+}
@@ -0,0 +1,81 @@
+package moodlemock_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k-yomo/moodle"
+	"github.com/k-yomo/moodle/moodlemock"
+)
+
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+
+	client, mocks := moodlemock.NewClient()
+	mocks.CourseAPI.GetContentsFunc = func(ctx context.Context, courseID int) ([]*moodle.CourseSection, error) {
+		return []*moodle.CourseSection{{ID: 1, Name: "Week 1"}}, nil
+	}
+
+	got, err := client.CourseAPI.GetContents(context.Background(), 1111)
+	if err != nil {
+		t.Fatalf("GetContents() error = %v", err)
+	}
+	if diff := cmp.Diff(got, []*moodle.CourseSection{{ID: 1, Name: "Week 1"}}); diff != "" {
+		t.Errorf("GetContents() (-got, +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(mocks.CourseAPI.Calls(), []*moodlemock.Call{{Method: "GetContents", Args: []interface{}{1111}}}); diff != "" {
+		t.Errorf("Calls() (-got, +want)\n%s", diff)
+	}
+
+	_, err = client.QuizAPI.GetQuizzesByCourse(context.Background(), 1111)
+	if !errors.Is(err, moodlemock.ErrNotImplemented) {
+		t.Errorf("GetQuizzesByCourse() error = %v, want ErrNotImplemented", err)
+	}
+	if len(mocks.QuizAPI.CallsOf("GetQuizzesByCourse")) != 1 {
+		t.Errorf("CallsOf() = %v, want 1 call", mocks.QuizAPI.CallsOf("GetQuizzesByCourse"))
+	}
+}
+
+func TestNewClient_clientHelpers(t *testing.T) {
+	t.Parallel()
+
+	client, mocks := moodlemock.NewClient()
+	mocks.SiteAPI.GetSiteInfoFunc = func(ctx context.Context) (*moodle.SiteInfo, error) {
+		return &moodle.SiteInfo{Release: "3.11.2", Functions: []*moodle.SiteFunctionVersion{{Name: "core_course_get_contents"}}}, nil
+	}
+
+	capabilities, err := client.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Capabilities() error = %v", err)
+	}
+	if !capabilities.HasFunction("core_course_get_contents") || !capabilities.ReleaseAtLeast("3.9") {
+		t.Errorf("Capabilities() = %+v", capabilities)
+	}
+	if len(mocks.SiteAPI.Calls()) != 1 {
+		t.Errorf("SiteAPI.Calls() = %v, want 1 call", mocks.SiteAPI.Calls())
+	}
+}
+
+func TestAuthAPI_withLogin(t *testing.T) {
+	t.Parallel()
+
+	authAPI := &moodlemock.AuthAPI{
+		LoginFunc: func(ctx context.Context, username, password string) (*moodle.LoginResponse, error) {
+			return &moodle.LoginResponse{Token: "token"}, nil
+		},
+	}
+	serviceURL, _ := url.Parse("https://test.edu")
+	client, err := moodle.NewClientWithLogin(context.Background(), serviceURL, "user", "password", moodle.WithAuthAPI(authAPI))
+	if err != nil {
+		t.Fatalf("NewClientWithLogin() error = %v", err)
+	}
+	if client.AuthToken() != "token" {
+		t.Errorf("AuthToken() = %s, want token", client.AuthToken())
+	}
+	if diff := cmp.Diff(authAPI.Calls(), []*moodlemock.Call{{Method: "Login", Args: []interface{}{"user", "password"}}}); diff != "" {
+		t.Errorf("Calls() (-got, +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,353 @@
+package moodletest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrInteractionNotFound is returned in replay mode when no recorded interaction matches the request
+var ErrInteractionNotFound = errors.New("moodletest: no recorded interaction matches the request")
+
+// RecorderMode represents whether the recorder records or replays interactions
+type RecorderMode int
+
+const (
+	// ModeRecord sends requests to the site and records the interactions, which are saved with Save
+	ModeRecord RecorderMode = iota
+	// ModeReplay responds with the recorded interactions without sending requests
+	ModeReplay
+)
+
+// redacted replaces the secrets and the personal information in cassettes
+const redacted = "[REDACTED]"
+
+// defaultScrubbedKeys are the param and json keys whose values are replaced with redacted
+var defaultScrubbedKeys = []string{
+	"wstoken", "password", "token", "privatetoken",
+	"username", "firstname", "lastname", "userfullname", "email",
+	"phone1", "phone2", "address", "lastip", "profileimageurl", "profileimageurlsmall", "userpictureurl",
+}
+
+// userKeys identify a user object in responses, "fullname" is scrubbed only in user objects since courses have it too
+var userKeys = []string{"username", "firstname", "lastname", "email"}
+
+// ignoredParams are not used to match requests
+var ignoredParams = []string{"wstoken", "moodlewsrestformat"}
+
+// Cassette is the recorded interactions saved as a golden file
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a pair of a request and the response
+type Interaction struct {
+	Request  *RecordedRequest  `json:"request"`
+	Response *RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a scrubbed request
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Function is the wsfunction, empty for the login request
+	Function string     `json:"function,omitempty"`
+	Params   url.Values `json:"params"`
+}
+
+// BodyEncodingBase64 is the body encoding of the responses which are not json (e.g. files), whose bodies are base64 encoded
+const BodyEncodingBase64 = "base64"
+
+// RecordedResponse is a scrubbed response
+type RecordedResponse struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body"`
+	// BodyEncoding is BodyEncodingBase64 if Body is base64 encoded, empty if Body is json as is
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+// body returns the decoded body
+func (r *RecordedResponse) body() ([]byte, error) {
+	switch r.BodyEncoding {
+	case "":
+		return []byte(r.Body), nil
+	case BodyEncodingBase64:
+		return base64.StdEncoding.DecodeString(r.Body)
+	default:
+		return nil, fmt.Errorf("unknown body encoding %q", r.BodyEncoding)
+	}
+}
+
+// Recorder is a http.RoundTripper which records interactions with a real site to a cassette, and replays them.
+// Secrets (e.g. wstoken, password) and user PII in requests and responses are scrubbed.
+//
+// Use it with moodle.WithHTTPClient(recorder.Client()).
+type Recorder struct {
+	path        string
+	mode        RecorderMode
+	transport   http.RoundTripper
+	scrubbedKey map[string]bool
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     map[int]bool
+}
+
+// RecorderOption is a option to change recorder configuration.
+type RecorderOption interface {
+	apply(*Recorder)
+}
+
+type recorderOptionFunc struct {
+	f func(r *Recorder)
+}
+
+func (o *recorderOptionFunc) apply(r *Recorder) {
+	o.f(r)
+}
+
+// WithTransport sets the transport used in record mode, http.DefaultTransport is used by default
+func WithTransport(transport http.RoundTripper) RecorderOption {
+	return &recorderOptionFunc{f: func(r *Recorder) {
+		r.transport = transport
+	}}
+}
+
+// WithScrubbedKeys adds param and json keys to be scrubbed in addition to the secrets and user PII
+func WithScrubbedKeys(keys ...string) RecorderOption {
+	return &recorderOptionFunc{f: func(r *Recorder) {
+		for _, key := range keys {
+			r.scrubbedKey[key] = true
+		}
+	}}
+}
+
+// NewRecorder creates a recorder of the cassette at path. In replay mode, the cassette is loaded from path.
+func NewRecorder(path string, mode RecorderMode, opt ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		path:        path,
+		mode:        mode,
+		transport:   http.DefaultTransport,
+		scrubbedKey: map[string]bool{},
+		cassette:    &Cassette{},
+		used:        map[int]bool{},
+	}
+	for _, key := range defaultScrubbedKeys {
+		r.scrubbedKey[key] = true
+	}
+	for _, o := range opt {
+		o.apply(r)
+	}
+
+	if mode == ModeReplay {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, r.cassette); err != nil {
+			return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+		}
+	}
+	return r, nil
+}
+
+// Client returns a http client using the recorder
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Save writes the recorded interactions to the cassette file, it does nothing in replay mode
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(b, '\n'), 0644)
+}
+
+// RoundTrip records or replays the request
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recordedReq, err := r.recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.mode == ModeReplay {
+		return r.replay(req, recordedReq)
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	recordedResp := &RecordedResponse{StatusCode: resp.StatusCode, ContentType: resp.Header.Get("Content-Type")}
+	recordedResp.Body, recordedResp.BodyEncoding = r.scrubBody(body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{Request: recordedReq, Response: recordedResp})
+	return resp, nil
+}
+
+// replay responds with the first unused interaction matching the request
+func (r *Recorder) replay(req *http.Request, recordedReq *RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !matchRequest(interaction.Request, recordedReq) {
+			continue
+		}
+		body, err := interaction.Response.body()
+		if err != nil {
+			return nil, fmt.Errorf("decode response body of %s %s in %s: %w", recordedReq.Method, recordedReq.describe(), r.path, err)
+		}
+		r.used[i] = true
+		header := http.Header{}
+		if interaction.Response.ContentType != "" {
+			header.Set("Content-Type", interaction.Response.ContentType)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s with params %s in %s", ErrInteractionNotFound, recordedReq.Method, recordedReq.describe(), recordedReq.Params.Encode(), r.path)
+}
+
+func (r *RecordedRequest) describe() string {
+	if r.Function != "" {
+		return r.Function
+	}
+	return r.Path
+}
+
+func matchRequest(recorded, req *RecordedRequest) bool {
+	if recorded.Method != req.Method || recorded.Path != req.Path || recorded.Function != req.Function {
+		return false
+	}
+	return normalizeParams(recorded.Params) == normalizeParams(req.Params)
+}
+
+// normalizeParams encodes the params sorted by key without the params ignored for matching
+func normalizeParams(params url.Values) string {
+	normalized := url.Values{}
+	for k, v := range params {
+		normalized[k] = v
+	}
+	for _, k := range ignoredParams {
+		normalized.Del(k)
+	}
+	return normalized.Encode()
+}
+
+// recordRequest returns the scrubbed request, the params in the form body are merged into the query params
+func (r *Recorder) recordRequest(req *http.Request) (*RecordedRequest, error) {
+	params := url.Values{}
+	for k, v := range req.URL.Query() {
+		params[k] = v
+	}
+	if req.Body != nil && strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range form {
+			params[k] = append(params[k], v...)
+		}
+	}
+
+	function := params.Get("wsfunction")
+	params.Del("wsfunction")
+	for k := range params {
+		if r.scrubbedKey[paramName(k)] {
+			params[k] = []string{redacted}
+		}
+	}
+	return &RecordedRequest{Method: req.Method, Path: req.URL.Path, Function: function, Params: params}, nil
+}
+
+// paramName returns the last name of the param, e.g. "email" for "users[0][email]"
+func paramName(key string) string {
+	key = strings.TrimSuffix(key, "]")
+	if i := strings.LastIndex(key, "["); i >= 0 {
+		return key[i+1:]
+	}
+	return key
+}
+
+// scrubBody replaces the values of the scrubbed keys in the json body and returns it with the encoding.
+// The body which is not json (e.g. a downloaded file) is base64 encoded as is, since it may not be valid UTF-8.
+func (r *Recorder) scrubBody(body []byte) (string, string) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil || decoder.More() {
+		return base64.StdEncoding.EncodeToString(body), BodyEncodingBase64
+	}
+	b, err := json.Marshal(r.scrub(v))
+	if err != nil {
+		return base64.StdEncoding.EncodeToString(body), BodyEncodingBase64
+	}
+	return string(b), ""
+}
+
+func (r *Recorder) scrub(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		isUser := false
+		for _, k := range userKeys {
+			if _, ok := v[k]; ok {
+				isUser = true
+			}
+		}
+		for k, value := range v {
+			if _, isString := value.(string); isString && (r.scrubbedKey[k] || (isUser && k == "fullname")) {
+				v[k] = redacted
+				continue
+			}
+			v[k] = r.scrub(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = r.scrub(value)
+		}
+	}
+	return v
+}
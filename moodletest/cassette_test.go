@@ -0,0 +1,160 @@
+package moodletest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k-yomo/moodle"
+	"github.com/k-yomo/moodle/moodletest"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	s := moodletest.NewServer()
+	defer s.Close()
+	s.AddUser(&moodletest.User{Username: "student", Password: "P@ssw0rd", Firstname: "Taro", Lastname: "Yamada", Email: "taro@example.com"})
+	course := s.AddCourse(&moodletest.Course{ShortName: "math", FullName: "Mathematics"})
+	s.AddQuiz(&moodletest.Quiz{CourseID: course.ID, Name: "Quiz 1"})
+	cassettePath := filepath.Join(t.TempDir(), "testdata", "quizzes.json")
+	ctx := context.Background()
+
+	recorder, err := moodletest.NewRecorder(cassettePath, moodletest.ModeRecord)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	c, err := moodle.NewClientWithLogin(ctx, s.ServiceURL(), "student", "P@ssw0rd", moodle.WithHTTPClient(recorder.Client()))
+	if err != nil {
+		t.Fatalf("NewClientWithLogin() error = %v", err)
+	}
+	wantSiteInfo, err := c.SiteAPI.GetSiteInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetSiteInfo() error = %v", err)
+	}
+	admin, err := moodle.NewClient(ctx, s.ServiceURL(), moodletest.DefaultToken, moodle.WithHTTPClient(recorder.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantQuizzes, err := admin.QuizAPI.GetQuizzesByCourse(ctx, course.ID)
+	if err != nil {
+		t.Fatalf("GetQuizzesByCourse() error = %v", err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	b, err := ioutil.ReadFile(cassettePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"P@ssw0rd", moodletest.DefaultToken, s.TokenFor(wantSiteInfo.UserID), "taro@example.com", "Yamada", "Taro Yamada"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+	if !strings.Contains(string(b), "Quiz 1") {
+		t.Errorf("cassette doesn't contain the quiz name")
+	}
+
+	replayer, err := moodletest.NewRecorder(cassettePath, moodletest.ModeReplay)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	c, err = moodle.NewClientWithLogin(ctx, s.ServiceURL(), "someone", "other password", moodle.WithHTTPClient(replayer.Client()))
+	if err != nil {
+		t.Fatalf("NewClientWithLogin() error = %v", err)
+	}
+	gotSiteInfo, err := c.SiteAPI.GetSiteInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetSiteInfo() error = %v", err)
+	}
+	if gotSiteInfo.UserID != wantSiteInfo.UserID || gotSiteInfo.Fullname != "[REDACTED]" {
+		t.Errorf("GetSiteInfo() = %+v", gotSiteInfo)
+	}
+	gotQuizzes, err := c.QuizAPI.GetQuizzesByCourse(ctx, course.ID)
+	if err != nil {
+		t.Fatalf("GetQuizzesByCourse() error = %v", err)
+	}
+	if diff := cmp.Diff(gotQuizzes, wantQuizzes); diff != "" {
+		t.Errorf("GetQuizzesByCourse() (-got, +want)\n%s", diff)
+	}
+
+	// each interaction is replayed once
+	if _, err := c.QuizAPI.GetQuizzesByCourse(ctx, course.ID); !errors.Is(err, moodletest.ErrInteractionNotFound) {
+		t.Errorf("GetQuizzesByCourse() error = %v, want ErrInteractionNotFound", err)
+	}
+	if _, err := c.QuizAPI.GetQuizzesByCourse(ctx, course.ID+1); !errors.Is(err, moodletest.ErrInteractionNotFound) {
+		t.Errorf("GetQuizzesByCourse() error = %v, want ErrInteractionNotFound", err)
+	}
+}
+
+func TestRecorder_binaryFile(t *testing.T) {
+	t.Parallel()
+
+	// a file which is not valid UTF-8
+	file := []byte{0x25, 0x50, 0x44, 0x46, 0xff, 0xfe, 0x00, 0x80}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(file)
+	}))
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	fileURL := s.URL + "/webservice/pluginfile.php/88/mod_resource/content/1/slides.pdf"
+	cassettePath := filepath.Join(t.TempDir(), "file.json")
+	ctx := context.Background()
+
+	recorder, err := moodletest.NewRecorder(cassettePath, moodletest.ModeRecord)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	c, err := moodle.NewClient(ctx, serviceURL, "test", moodle.WithHTTPClient(recorder.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.DownloadFile(ctx, fileURL, ioutil.Discard); err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	b, err := ioutil.ReadFile(cassettePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"body_encoding": "base64"`) {
+		t.Errorf("cassette doesn't mark the body as base64\n%s", b)
+	}
+
+	replayer, err := moodletest.NewRecorder(cassettePath, moodletest.ModeReplay)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	c, err = moodle.NewClient(ctx, serviceURL, "test", moodle.WithHTTPClient(replayer.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if _, err := c.DownloadFile(ctx, fileURL, buf); err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), file) {
+		t.Errorf("DownloadFile() = %v, want %v", buf.Bytes(), file)
+	}
+}
+
+func TestNewRecorder_replayWithoutCassette(t *testing.T) {
+	t.Parallel()
+
+	if _, err := moodletest.NewRecorder(filepath.Join(t.TempDir(), "missing.json"), moodletest.ModeReplay); err == nil {
+		t.Errorf("NewRecorder() error = nil, want error")
+	}
+}
@@ -0,0 +1,719 @@
+package moodletest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type handlerFunc func(s *Server, c *call) (interface{}, *Exception)
+
+// handlers are the implemented functions, which are called with s.mu held
+var handlers map[string]handlerFunc
+
+func init() {
+	handlers = map[string]handlerFunc{
+		"core_webservice_get_site_info":                               (*Server).getSiteInfo,
+		"core_course_get_enrolled_courses_by_timeline_classification": (*Server).getEnrolledCoursesByTimelineClassification,
+		"core_course_get_courses_by_field":                            (*Server).getCoursesByField,
+		"mod_quiz_get_quizzes_by_courses":                             (*Server).getQuizzesByCourses,
+		"mod_quiz_get_user_attempts":                                  (*Server).getUserAttempts,
+		"mod_quiz_start_attempt":                                      (*Server).startAttempt,
+		"mod_quiz_process_attempt":                                    (*Server).processAttempt,
+		"mod_quiz_get_attempt_review":                                 (*Server).getAttemptReview,
+		"mod_quiz_get_attempt_access_information":                     (*Server).getAttemptAccessInformation,
+		"gradereport_user_get_grades_table":                           (*Server).getGradesTable,
+		"gradereport_overview_get_course_grades":                      (*Server).getCourseGrades,
+	}
+}
+
+var noPermissionsException = &Exception{
+	Exception: "required_capability_exception",
+	ErrorCode: "nopermissions",
+	Message:   "Sorry, but you do not currently have permissions to do that.",
+}
+
+func invalidRecordException(table string) *Exception {
+	return &Exception{
+		Exception: "dml_missing_record_exception",
+		ErrorCode: "invalidrecord",
+		Message:   fmt.Sprintf("Can't find data record in database table %s.", table),
+	}
+}
+
+func attemptErrorException(message string) *Exception {
+	return &Exception{Exception: "moodle_exception", ErrorCode: "attempterror", Message: message}
+}
+
+// targetUserID returns the userid param, or the caller if it's not set. Only the admin can target other users.
+func (c *call) targetUserID() (int, *Exception) {
+	userID := c.int("userid")
+	if userID == 0 {
+		return c.userID, nil
+	}
+	if userID != c.userID && c.userID != AdminUserID {
+		return 0, noPermissionsException
+	}
+	return userID, nil
+}
+
+func unix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func formatGrade(grade float64) string {
+	return strconv.FormatFloat(grade, 'f', 2, 64)
+}
+
+func (s *Server) getSiteInfo(c *call) (interface{}, *Exception) {
+	user := s.users[c.userID]
+	functions := s.opts.functions
+	if functions == nil {
+		for name := range handlers {
+			functions = append(functions, name)
+		}
+	}
+	sort.Strings(functions)
+	functionList := make([]map[string]interface{}, 0, len(functions))
+	for _, name := range functions {
+		functionList = append(functionList, map[string]interface{}{"name": name, "version": s.opts.version})
+	}
+	return map[string]interface{}{
+		"sitename":              s.opts.siteName,
+		"username":              user.Username,
+		"firstname":             user.Firstname,
+		"lastname":              user.Lastname,
+		"fullname":              user.fullname(),
+		"lang":                  "en",
+		"userid":                user.ID,
+		"siteurl":               s.URL,
+		"userpictureurl":        fmt.Sprintf("%s/theme/image.php/boost/core/1/u/f1", s.URL),
+		"functions":             functionList,
+		"downloadfiles":         1,
+		"uploadfiles":           1,
+		"release":               s.opts.release,
+		"version":               s.opts.version,
+		"mobilecssurl":          "",
+		"advancedfeatures":      []interface{}{},
+		"usercanmanageownfiles": true,
+		"userquota":             0,
+		"usermaxuploadfilesize": 0,
+		"userhomepage":          1,
+		"siteid":                1,
+		"sitecalendartype":      "gregorian",
+		"usercalendartype":      "gregorian",
+		"theme":                 "boost",
+	}, nil
+}
+
+// sortedCourses returns the courses ordered by ID
+func (s *Server) sortedCourses() []*Course {
+	courses := make([]*Course, 0, len(s.courses))
+	for _, course := range s.courses {
+		courses = append(courses, course)
+	}
+	sort.Slice(courses, func(i, j int) bool { return courses[i].ID < courses[j].ID })
+	return courses
+}
+
+func (s *Server) classifyCourse(course *Course) string {
+	now := s.now()
+	switch {
+	case !course.EndDate.IsZero() && course.EndDate.Before(now):
+		return "past"
+	case course.StartDate.After(now):
+		return "future"
+	default:
+		return "inprogress"
+	}
+}
+
+func (s *Server) getEnrolledCoursesByTimelineClassification(c *call) (interface{}, *Exception) {
+	classification := c.params.Get("classification")
+	switch classification {
+	case "all", "past", "inprogress", "future":
+	default:
+		return nil, &Exception{Exception: "invalid_parameter_exception", ErrorCode: "invalidparameter", Message: "Invalid parameter value detected"}
+	}
+	courses := make([]map[string]interface{}, 0)
+	for _, course := range s.sortedCourses() {
+		if !s.enrolments[course.ID][c.userID] {
+			continue
+		}
+		if classification != "all" && s.classifyCourse(course) != classification {
+			continue
+		}
+		courses = append(courses, map[string]interface{}{
+			"id":              course.ID,
+			"fullname":        course.FullName,
+			"shortname":       course.ShortName,
+			"idnumber":        course.IDNumber,
+			"summary":         course.Summary,
+			"summaryformat":   1,
+			"startdate":       unix(course.StartDate),
+			"enddate":         unix(course.EndDate),
+			"visible":         !course.Hidden,
+			"fullnamedisplay": course.FullName,
+			"viewurl":         fmt.Sprintf("%s/course/view.php?id=%d", s.URL, course.ID),
+			"courseimage":     "",
+			"progress":        0,
+			"hasprogress":     false,
+			"isfavourite":     false,
+			"hidden":          false,
+			"showshortname":   false,
+			"coursecategory":  "",
+		})
+	}
+	return map[string]interface{}{"courses": courses, "nextoffset": len(courses)}, nil
+}
+
+func (s *Server) getCoursesByField(c *call) (interface{}, *Exception) {
+	field, value := c.params.Get("field"), c.params.Get("value")
+	match := func(course *Course) bool {
+		switch field {
+		case "":
+			return true
+		case "id":
+			return strconv.Itoa(course.ID) == value
+		case "ids":
+			for _, id := range strings.Split(value, ",") {
+				if strings.TrimSpace(id) == strconv.Itoa(course.ID) {
+					return true
+				}
+			}
+			return false
+		case "shortname":
+			return course.ShortName == value
+		case "idnumber":
+			return course.IDNumber == value
+		case "category":
+			return strconv.Itoa(course.CategoryID) == value
+		default:
+			return false
+		}
+	}
+
+	courses := make([]map[string]interface{}, 0)
+	for _, course := range s.sortedCourses() {
+		if !match(course) || (course.Hidden && c.userID != AdminUserID) {
+			continue
+		}
+		visible := 1
+		if course.Hidden {
+			visible = 0
+		}
+		courses = append(courses, map[string]interface{}{
+			"id":            course.ID,
+			"fullname":      course.FullName,
+			"displayname":   course.FullName,
+			"shortname":     course.ShortName,
+			"idnumber":      course.IDNumber,
+			"categoryid":    course.CategoryID,
+			"categoryname":  "",
+			"summary":       course.Summary,
+			"summaryformat": 1,
+			"startdate":     unix(course.StartDate),
+			"enddate":       unix(course.EndDate),
+			"visible":       visible,
+		})
+	}
+	return map[string]interface{}{"courses": courses, "warnings": []*Warning{}}, nil
+}
+
+func (s *Server) getQuizzesByCourses(c *call) (interface{}, *Exception) {
+	var courseIDs []int
+	for i := 0; ; i++ {
+		key := fmt.Sprintf("courseids[%d]", i)
+		if _, ok := c.params[key]; !ok {
+			break
+		}
+		courseIDs = append(courseIDs, c.int(key))
+	}
+
+	quizzes := make([]map[string]interface{}, 0)
+	warnings := make([]*Warning, 0)
+	for _, courseID := range courseIDs {
+		if _, ok := s.courses[courseID]; !ok || !s.canAccessCourse(courseID, c.userID) {
+			warnings = append(warnings, &Warning{Item: "course", ItemID: courseID, WarningCode: "1", Message: "No access rights in course context"})
+			continue
+		}
+		for _, quiz := range s.sortedQuizzes() {
+			if quiz.CourseID == courseID {
+				quizzes = append(quizzes, s.quizResponse(quiz))
+			}
+		}
+	}
+	return map[string]interface{}{"quizzes": quizzes, "warnings": warnings}, nil
+}
+
+func (s *Server) canAccessCourse(courseID, userID int) bool {
+	return userID == AdminUserID || s.enrolments[courseID][userID]
+}
+
+func (s *Server) sortedQuizzes() []*Quiz {
+	quizzes := make([]*Quiz, 0, len(s.quizzes))
+	for _, quiz := range s.quizzes {
+		quizzes = append(quizzes, quiz)
+	}
+	sort.Slice(quizzes, func(i, j int) bool { return quizzes[i].ID < quizzes[j].ID })
+	return quizzes
+}
+
+func (s *Server) quizResponse(quiz *Quiz) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                    quiz.ID,
+		"course":                quiz.CourseID,
+		"coursemodule":          quiz.CourseModuleID,
+		"name":                  quiz.Name,
+		"intro":                 quiz.Intro,
+		"introformat":           1,
+		"timeopen":              unix(quiz.TimeOpen),
+		"timeclose":             unix(quiz.TimeClose),
+		"timelimit":             int(quiz.TimeLimit / time.Second),
+		"overduehandling":       "autosubmit",
+		"graceperiod":           0,
+		"preferredbehaviour":    "deferredfeedback",
+		"attempts":              quiz.Attempts,
+		"grademethod":           1,
+		"decimalpoints":         2,
+		"questiondecimalpoints": -1,
+		"sumgrades":             quiz.sumGrades(),
+		"grade":                 quiz.Grade,
+		"hasfeedback":           0,
+		"section":               1,
+		"visible":               1,
+		"groupmode":             0,
+		"groupingid":            0,
+	}
+}
+
+// quizOf returns the quiz which the user can access
+func (s *Server) quizOf(c *call) (*Quiz, *Exception) {
+	quiz, ok := s.quizzes[c.int("quizid")]
+	if !ok {
+		return nil, invalidRecordException("quiz")
+	}
+	if !s.canAccessCourse(quiz.CourseID, c.userID) {
+		return nil, &Exception{Exception: "require_login_exception", ErrorCode: "requireloginerror", Message: "Course or activity not accessible. (Not enrolled)"}
+	}
+	return quiz, nil
+}
+
+// attemptOf returns the attempt of the user
+func (s *Server) attemptOf(c *call) (*Attempt, *Exception) {
+	attempt, ok := s.attempts[c.int("attemptid")]
+	if !ok {
+		return nil, invalidRecordException("quiz_attempts")
+	}
+	if attempt.UserID != c.userID && c.userID != AdminUserID {
+		return nil, &Exception{Exception: "moodle_exception", ErrorCode: "notyourattempt", Message: "This is not your attempt!"}
+	}
+	return attempt, nil
+}
+
+func (s *Server) userAttempts(quizID, userID int) []*Attempt {
+	var attempts []*Attempt
+	for _, attempt := range s.attempts {
+		if attempt.QuizID == quizID && attempt.UserID == userID {
+			attempts = append(attempts, attempt)
+		}
+	}
+	sort.Slice(attempts, func(i, j int) bool { return attempts[i].ID < attempts[j].ID })
+	return attempts
+}
+
+func (s *Server) attemptResponse(attempt *Attempt) map[string]interface{} {
+	quiz := s.quizzes[attempt.QuizID]
+	var timeCheckState interface{}
+	if attempt.State == AttemptStateInProgress {
+		if endTime := s.attemptEndTime(quiz, attempt); !endTime.IsZero() {
+			timeCheckState = endTime.Unix()
+		}
+	}
+	var sumGrades interface{}
+	if attempt.State == AttemptStateFinished {
+		sumGrades = attempt.SumGrades
+	}
+	return map[string]interface{}{
+		"id":                  attempt.ID,
+		"quiz":                attempt.QuizID,
+		"userid":              attempt.UserID,
+		"attempt":             attempt.Attempt,
+		"uniqueid":            attempt.ID,
+		"layout":              "1,0",
+		"currentpage":         0,
+		"preview":             0,
+		"state":               attempt.State,
+		"timestart":           unix(attempt.TimeStart),
+		"timefinish":          unix(attempt.TimeFinish),
+		"timemodified":        unix(attempt.TimeStart),
+		"timemodifiedoffline": 0,
+		"timecheckstate":      timeCheckState,
+		"sumgrades":           sumGrades,
+	}
+}
+
+// attemptEndTime returns the time the attempt must be finished, zero if there is no limit
+func (s *Server) attemptEndTime(quiz *Quiz, attempt *Attempt) time.Time {
+	var endTime time.Time
+	if quiz.TimeLimit > 0 {
+		endTime = attempt.TimeStart.Add(quiz.TimeLimit)
+	}
+	if !quiz.TimeClose.IsZero() && (endTime.IsZero() || quiz.TimeClose.Before(endTime)) {
+		endTime = quiz.TimeClose
+	}
+	return endTime
+}
+
+func (s *Server) getUserAttempts(c *call) (interface{}, *Exception) {
+	quiz, exception := s.quizOf(c)
+	if exception != nil {
+		return nil, exception
+	}
+	userID, exception := c.targetUserID()
+	if exception != nil {
+		return nil, exception
+	}
+	status := c.params.Get("status")
+	if status == "" {
+		status = "finished"
+	}
+
+	attempts := make([]map[string]interface{}, 0)
+	for _, attempt := range s.userAttempts(quiz.ID, userID) {
+		isFinished := attempt.State == AttemptStateFinished
+		if (status == "finished" && !isFinished) || (status == "unfinished" && isFinished) {
+			continue
+		}
+		attempts = append(attempts, s.attemptResponse(attempt))
+	}
+	return map[string]interface{}{"attempts": attempts, "warnings": []*Warning{}}, nil
+}
+
+// preventNewAttemptReasons returns the reasons the user can't start a new attempt
+func (s *Server) preventNewAttemptReasons(quiz *Quiz, userID int) []string {
+	reasons := make([]string, 0)
+	now := s.now()
+	if (!quiz.TimeOpen.IsZero() && now.Before(quiz.TimeOpen)) || (!quiz.TimeClose.IsZero() && now.After(quiz.TimeClose)) {
+		reasons = append(reasons, "This quiz is not currently available")
+	}
+	if quiz.Attempts > 0 && len(s.userAttempts(quiz.ID, userID)) >= quiz.Attempts {
+		reasons = append(reasons, "No more attempts are allowed")
+	}
+	return reasons
+}
+
+func (s *Server) startAttempt(c *call) (interface{}, *Exception) {
+	quiz, exception := s.quizOf(c)
+	if exception != nil {
+		return nil, exception
+	}
+	attempts := s.userAttempts(quiz.ID, c.userID)
+	for _, attempt := range attempts {
+		if attempt.State == AttemptStateInProgress {
+			return nil, &Exception{Exception: "moodle_exception", ErrorCode: "attemptstillinprogress", Message: "Attempt is still in progress"}
+		}
+	}
+	if reasons := s.preventNewAttemptReasons(quiz, c.userID); len(reasons) > 0 {
+		return nil, attemptErrorException(reasons[0])
+	}
+
+	attempt := &Attempt{
+		ID:        s.newID(),
+		QuizID:    quiz.ID,
+		UserID:    c.userID,
+		Attempt:   len(attempts) + 1,
+		State:     AttemptStateInProgress,
+		TimeStart: s.now(),
+	}
+	s.attempts[attempt.ID] = attempt
+	return map[string]interface{}{"attempt": s.attemptResponse(attempt), "warnings": []*Warning{}}, nil
+}
+
+func (s *Server) processAttempt(c *call) (interface{}, *Exception) {
+	attempt, exception := s.attemptOf(c)
+	if exception != nil {
+		return nil, exception
+	}
+	if attempt.State == AttemptStateFinished {
+		return nil, &Exception{Exception: "moodle_exception", ErrorCode: "attemptalreadyclosed", Message: "This attempt has already been finished."}
+	}
+	if c.params.Get("finishattempt") == "1" {
+		s.finishAttempt(attempt)
+	}
+	return map[string]interface{}{"state": attempt.State, "warnings": []*Warning{}}, nil
+}
+
+// finishAttempt finishes the attempt and writes the highest grade to the grade item of the quiz
+func (s *Server) finishAttempt(attempt *Attempt) {
+	quiz := s.quizzes[attempt.QuizID]
+	attempt.State = AttemptStateFinished
+	attempt.TimeFinish = s.now()
+	attempt.SumGrades = 0
+	for _, question := range quiz.Questions {
+		attempt.SumGrades += question.Mark
+	}
+
+	if quiz.sumGrades() == 0 {
+		return
+	}
+	grade := attempt.SumGrades / quiz.sumGrades() * quiz.Grade
+	for _, item := range s.gradeItems {
+		if item.ItemModule != "quiz" || item.ItemInstance != quiz.ID {
+			continue
+		}
+		key := gradeKey{itemID: item.ID, userID: attempt.UserID}
+		if current, ok := s.grades[key]; !ok || current.Grade < grade {
+			s.grades[key] = &Grade{ItemID: item.ID, UserID: attempt.UserID, Grade: grade}
+		}
+	}
+}
+
+func (s *Server) getAttemptReview(c *call) (interface{}, *Exception) {
+	attempt, exception := s.attemptOf(c)
+	if exception != nil {
+		return nil, exception
+	}
+	if attempt.State != AttemptStateFinished {
+		return nil, &Exception{Exception: "moodle_exception", ErrorCode: "noreviewattempt", Message: "You are not allowed to review this attempt."}
+	}
+	quiz := s.quizzes[attempt.QuizID]
+
+	questions := make([]map[string]interface{}, 0, len(quiz.Questions))
+	for i, question := range quiz.Questions {
+		state := "gradedwrong"
+		switch {
+		case question.Mark >= question.MaxMark:
+			state = "gradedright"
+		case question.Mark > 0:
+			state = "gradedpartial"
+		}
+		questions = append(questions, map[string]interface{}{
+			"slot":              i + 1,
+			"type":              question.Type,
+			"page":              0,
+			"html":              fmt.Sprintf(`<div class="que %s"><div class="qtext">%s</div></div>`, question.Type, question.Text),
+			"sequencecheck":     2,
+			"lastactiontime":    unix(attempt.TimeFinish),
+			"hasautosavedstep":  false,
+			"flagged":           false,
+			"number":            i + 1,
+			"state":             state,
+			"status":            "",
+			"blockedbyprevious": false,
+			"mark":              formatGrade(question.Mark),
+			"maxmark":           question.MaxMark,
+		})
+	}
+	var grade float64
+	if quiz.sumGrades() > 0 {
+		grade = attempt.SumGrades / quiz.sumGrades() * quiz.Grade
+	}
+	return map[string]interface{}{
+		"grade":          grade,
+		"attempt":        s.attemptResponse(attempt),
+		"additionaldata": []interface{}{},
+		"questions":      questions,
+		"warnings":       []*Warning{},
+	}, nil
+}
+
+func (s *Server) getAttemptAccessInformation(c *call) (interface{}, *Exception) {
+	quiz, exception := s.quizOf(c)
+	if exception != nil {
+		return nil, exception
+	}
+	res := map[string]interface{}{
+		"isfinished":               false,
+		"ispreflightcheckrequired": false,
+		"preventnewattemptreasons": s.preventNewAttemptReasons(quiz, c.userID),
+		"warnings":                 []*Warning{},
+	}
+	if c.int("attemptid") != 0 {
+		attempt, exception := s.attemptOf(c)
+		if exception != nil {
+			return nil, exception
+		}
+		res["isfinished"] = attempt.State == AttemptStateFinished
+		if endTime := s.attemptEndTime(quiz, attempt); attempt.State == AttemptStateInProgress && !endTime.IsZero() {
+			res["endtime"] = endTime.Unix()
+		}
+	}
+	return res, nil
+}
+
+// courseGradeItems returns the grade items of the course ordered by ID
+func (s *Server) courseGradeItems(courseID int) []*GradeItem {
+	var items []*GradeItem
+	for _, item := range s.gradeItems {
+		if item.CourseID == courseID {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items
+}
+
+// courseTotal returns the sum of the grades and the sum of the max grades, graded is false if no item is graded
+func (s *Server) courseTotal(courseID, userID int) (total float64, max float64, graded bool) {
+	for _, item := range s.courseGradeItems(courseID) {
+		max += item.gradeMax()
+		if grade, ok := s.grades[gradeKey{itemID: item.ID, userID: userID}]; ok {
+			total += grade.Grade
+			graded = true
+		}
+	}
+	return total, max, graded
+}
+
+func (s *Server) getGradesTable(c *call) (interface{}, *Exception) {
+	course, ok := s.courses[c.int("courseid")]
+	if !ok {
+		return nil, invalidRecordException("course")
+	}
+	var userIDs []int
+	if c.int("userid") == 0 && c.userID == AdminUserID {
+		for userID := range s.enrolments[course.ID] {
+			userIDs = append(userIDs, userID)
+		}
+		sort.Ints(userIDs)
+	} else {
+		userID, exception := c.targetUserID()
+		if exception != nil {
+			return nil, exception
+		}
+		if !s.canAccessCourse(course.ID, userID) {
+			return nil, noPermissionsException
+		}
+		userIDs = []int{userID}
+	}
+
+	tables := make([]map[string]interface{}, 0, len(userIDs))
+	for _, userID := range userIDs {
+		user, ok := s.users[userID]
+		if !ok {
+			continue
+		}
+		tables = append(tables, map[string]interface{}{
+			"courseid":     course.ID,
+			"userid":       userID,
+			"userfullname": user.fullname(),
+			"maxdepth":     2,
+			"tabledata":    s.gradesTableData(course, userID),
+		})
+	}
+	return map[string]interface{}{"tables": tables, "warnings": []*Warning{}}, nil
+}
+
+func tableCell(class, content string) map[string]interface{} {
+	return map[string]interface{}{"class": class, "content": content, "headers": ""}
+}
+
+// gradesTableData builds the rows of the user report: the course category, the items and the course total
+func (s *Server) gradesTableData(course *Course, userID int) []interface{} {
+	items := s.courseGradeItems(course.ID)
+	total, totalMax, graded := s.courseTotal(course.ID, userID)
+
+	rows := []interface{}{
+		map[string]interface{}{
+			"itemname": map[string]interface{}{
+				"class":    "level1 levelodd oddd1 b1b b1t column-itemname",
+				"colspan":  6,
+				"content":  course.FullName,
+				"celltype": "th",
+				"id":       fmt.Sprintf("cat_%d_%d", course.ID, userID),
+			},
+			"leader": map[string]interface{}{
+				"class":   "level1 levelodd oddd1 b1t b2b b1l column-leader",
+				"rowspan": len(items) + 2,
+			},
+		},
+	}
+	for _, item := range items {
+		class := "level2 leveleven item b1b"
+		itemName := fmt.Sprintf(`<span class="gradeitemheader" title="%s">%s</span>`, item.Name, item.Name)
+		if item.ItemModule != "" {
+			var cmID int
+			if quiz, ok := s.quizzes[item.ItemInstance]; ok && item.ItemModule == "quiz" {
+				cmID = quiz.CourseModuleID
+			}
+			itemName = fmt.Sprintf(`<a title="%s" class="gradeitemheader" href="%s/mod/%s/view.php?id=%d">%s</a>`, item.Name, s.URL, item.ItemModule, cmID, item.Name)
+		}
+		gradeContent, percentage, contribution, feedback := "-", "-", "0.00 %", "&nbsp;"
+		if grade, ok := s.grades[gradeKey{itemID: item.ID, userID: userID}]; ok {
+			gradeContent = formatGrade(grade.Grade)
+			if rangeSize := item.gradeMax() - item.GradeMin; rangeSize > 0 {
+				percentage = formatGrade((grade.Grade-item.GradeMin)/rangeSize*100) + " %"
+			}
+			if totalMax > 0 {
+				contribution = formatGrade(grade.Grade/totalMax*100) + " %"
+			}
+			if grade.Feedback != "" {
+				feedback = grade.Feedback
+			}
+		}
+		rows = append(rows, map[string]interface{}{
+			"itemname": map[string]interface{}{
+				"class":    class + " column-itemname",
+				"colspan":  1,
+				"content":  itemName,
+				"celltype": "th",
+				"id":       fmt.Sprintf("row_%d_%d", item.ID, userID),
+			},
+			"grade":                     tableCell(class+" itemcenter column-grade", gradeContent),
+			"range":                     tableCell(class+" itemcenter column-range", formatGrade(item.GradeMin)+"&ndash;"+formatGrade(item.gradeMax())),
+			"percentage":                tableCell(class+" itemcenter column-percentage", percentage),
+			"feedback":                  tableCell(class+" feedbacktext column-feedback", feedback),
+			"contributiontocoursetotal": tableCell(class+" itemcenter column-contributiontocoursetotal", contribution),
+		})
+	}
+
+	class := "level1 levelodd oddd1 baggt b2b"
+	totalContent, totalPercentage := "-", "-"
+	if graded {
+		totalContent = formatGrade(total)
+		if totalMax > 0 {
+			totalPercentage = formatGrade(total/totalMax*100) + " %"
+		}
+	}
+	rows = append(rows, map[string]interface{}{
+		"itemname": map[string]interface{}{
+			"class":    class + " column-itemname",
+			"colspan":  2,
+			"content":  `<span class="gradeitemheader" title="Course total">Course total</span>`,
+			"celltype": "th",
+			"id":       fmt.Sprintf("row_total_%d_%d", course.ID, userID),
+		},
+		"grade":                     tableCell(class+" itemcenter column-grade", totalContent),
+		"range":                     tableCell(class+" itemcenter column-range", "0.00&ndash;"+formatGrade(totalMax)),
+		"percentage":                tableCell(class+" itemcenter column-percentage", totalPercentage),
+		"feedback":                  tableCell(class+" feedbacktext column-feedback", "&nbsp;"),
+		"contributiontocoursetotal": tableCell(class+" itemcenter column-contributiontocoursetotal", "-"),
+	})
+	return rows
+}
+
+func (s *Server) getCourseGrades(c *call) (interface{}, *Exception) {
+	userID, exception := c.targetUserID()
+	if exception != nil {
+		return nil, exception
+	}
+	grades := make([]map[string]interface{}, 0)
+	for _, course := range s.sortedCourses() {
+		if !s.enrolments[course.ID][userID] {
+			continue
+		}
+		grade := map[string]interface{}{"courseid": course.ID, "grade": "-", "rawgrade": nil}
+		if total, _, graded := s.courseTotal(course.ID, userID); graded {
+			grade["grade"] = formatGrade(total)
+			grade["rawgrade"] = strconv.FormatFloat(total, 'f', 5, 64)
+		}
+		grades = append(grades, grade)
+	}
+	return map[string]interface{}{"grades": grades, "warnings": []*Warning{}}, nil
+}
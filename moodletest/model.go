@@ -0,0 +1,121 @@
+package moodletest
+
+import (
+	"time"
+)
+
+// User represents a user of the fake site
+type User struct {
+	ID        int
+	Username  string
+	Password  string
+	Firstname string
+	Lastname  string
+	Email     string
+}
+
+func (u *User) fullname() string {
+	return u.Firstname + " " + u.Lastname
+}
+
+// Course represents a course of the fake site
+type Course struct {
+	ID         int
+	ShortName  string
+	FullName   string
+	IDNumber   string
+	CategoryID int
+	Summary    string
+	StartDate  time.Time
+	// EndDate is zero if the course has no end date
+	EndDate time.Time
+	Hidden  bool
+}
+
+// Quiz represents a quiz of the fake site
+type Quiz struct {
+	ID             int
+	CourseID       int
+	CourseModuleID int
+	Name           string
+	Intro          string
+	// TimeOpen and TimeClose are zero if the quiz is always open
+	TimeOpen  time.Time
+	TimeClose time.Time
+	// TimeLimit is zero if the quiz has no time limit
+	TimeLimit time.Duration
+	// Attempts is the maximum number of attempts, 0 means unlimited
+	Attempts int
+	// Grade is the maximum grade of the quiz, which is written to the grade item of the quiz
+	Grade     float64
+	Questions []*Question
+}
+
+func (q *Quiz) sumGrades() float64 {
+	var sum float64
+	for _, question := range q.Questions {
+		sum += question.MaxMark
+	}
+	return sum
+}
+
+// Question represents a question of a quiz
+type Question struct {
+	// Type is the question type (e.g. multichoice, truefalse)
+	Type    string
+	Text    string
+	MaxMark float64
+	// Mark is the mark given to every attempt when it's finished
+	Mark float64
+}
+
+// Attempt represents a quiz attempt
+type Attempt struct {
+	ID         int
+	QuizID     int
+	UserID     int
+	Attempt    int
+	State      string
+	TimeStart  time.Time
+	TimeFinish time.Time
+	SumGrades  float64
+}
+
+// Quiz attempt states
+const (
+	AttemptStateInProgress = "inprogress"
+	AttemptStateFinished   = "finished"
+)
+
+// GradeItem represents a grade item of a course
+type GradeItem struct {
+	ID       int
+	CourseID int
+	Name     string
+	// ItemModule is the module name of the activity (e.g. quiz), empty for a manual item
+	ItemModule   string
+	ItemInstance int
+	GradeMin     float64
+	// GradeMax is 100 if it's zero
+	GradeMax float64
+}
+
+func (g *GradeItem) gradeMax() float64 {
+	if g.GradeMax == 0 {
+		return 100
+	}
+	return g.GradeMax
+}
+
+// Grade represents a grade of a user for a grade item
+type Grade struct {
+	ItemID   int
+	UserID   int
+	Grade    float64
+	Feedback string
+}
+
+type gradeKey struct {
+	itemID int
+	userID int
+}
@@ -0,0 +1,69 @@
+package moodletest
+
+import "time"
+
+type serverOptions struct {
+	siteName  string
+	release   string
+	version   string
+	functions []string
+	now       func() time.Time
+}
+
+func newDefaultServerOptions() *serverOptions {
+	return &serverOptions{
+		siteName: "Moodle Test Site",
+		release:  "3.11.2 (Build: 20210809)",
+		version:  "2021051702",
+		now:      time.Now,
+	}
+}
+
+// Option is a option to change server configuration.
+type Option interface {
+	apply(*serverOptions)
+}
+
+type optionFunc struct {
+	f func(opts *serverOptions)
+}
+
+func (o *optionFunc) apply(opts *serverOptions) {
+	o.f(opts)
+}
+
+func newOptionFunc(f func(opts *serverOptions)) *optionFunc {
+	return &optionFunc{
+		f: f,
+	}
+}
+
+// WithSiteName sets the site name in the site info
+func WithSiteName(siteName string) Option {
+	return newOptionFunc(func(opts *serverOptions) {
+		opts.siteName = siteName
+	})
+}
+
+// WithRelease sets the release (e.g. "3.9.4 (Build: 20201224)") and the version in the site info
+func WithRelease(release, version string) Option {
+	return newOptionFunc(func(opts *serverOptions) {
+		opts.release = release
+		opts.version = version
+	})
+}
+
+// WithFunctions limits the functions available for the tokens, other functions fail with accessexception.
+// All the implemented functions are available by default.
+func WithFunctions(functions ...string) Option {
+	return newOptionFunc(func(opts *serverOptions) {
+		opts.functions = functions
+	})
+}
+
+// WithClock sets the clock used for the time of attempts and courses classification
+func WithClock(now func() time.Time) Option {
+	return newOptionFunc(func(opts *serverOptions) {
+		opts.now = now
+	})
+}
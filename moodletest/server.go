@@ -0,0 +1,480 @@
+// Package moodletest provides an in-process fake Moodle server for testing code built on the moodle package.
+//
+// The server implements the web service functions wrapped by the moodle package for site info, courses,
+// quizzes with the attempt lifecycle, grades and login, backed by an in-memory model which can be seeded.
+// Errors can be injected with Inject, and requests are recorded to be asserted with Requests.
+//
+// Recorder records interactions with a real site to a cassette and replays them in tests.
+package moodletest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultToken is the token of the admin user, which is created with the server
+const DefaultToken = "moodletest-token"
+
+// AdminUserID is the ID of the admin user
+const AdminUserID = 2
+
+// Server is a fake Moodle server
+type Server struct {
+	// URL is the base URL of the site, which is used as serviceURL of the moodle client
+	URL string
+
+	httpServer *httptest.Server
+	opts       *serverOptions
+
+	mu         sync.Mutex
+	nextID     int
+	tokens     map[string]int
+	users      map[int]*User
+	courses    map[int]*Course
+	enrolments map[int]map[int]bool
+	quizzes    map[int]*Quiz
+	attempts   map[int]*Attempt
+	gradeItems map[int]*GradeItem
+	grades     map[gradeKey]*Grade
+	faults     []*Fault
+	requests   []*Request
+}
+
+// NewServer starts a fake Moodle server with the admin user whose token is DefaultToken.
+// The caller should call Close when finished.
+func NewServer(opt ...Option) *Server {
+	opts := newDefaultServerOptions()
+	for _, o := range opt {
+		o.apply(opts)
+	}
+	s := &Server{
+		opts:       opts,
+		nextID:     100,
+		tokens:     map[string]int{DefaultToken: AdminUserID},
+		users:      map[int]*User{},
+		courses:    map[int]*Course{},
+		enrolments: map[int]map[int]bool{},
+		quizzes:    map[int]*Quiz{},
+		attempts:   map[int]*Attempt{},
+		gradeItems: map[int]*GradeItem{},
+		grades:     map[gradeKey]*Grade{},
+	}
+	s.users[AdminUserID] = &User{ID: AdminUserID, Username: "admin", Password: "admin", Firstname: "Admin", Lastname: "User", Email: "admin@example.com"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webservice/rest/server.php", s.handleWebService)
+	mux.HandleFunc("/login/token.php", s.handleLogin)
+	s.httpServer = httptest.NewServer(mux)
+	s.URL = s.httpServer.URL
+	return s
+}
+
+// ServiceURL returns the URL to be passed to moodle.NewClient
+func (s *Server) ServiceURL() *url.URL {
+	u, _ := url.Parse(s.URL)
+	return u
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+func (s *Server) now() time.Time {
+	return s.opts.now()
+}
+
+func (s *Server) newID() int {
+	s.nextID++
+	return s.nextID
+}
+
+// AddUser adds the user, the ID is assigned if it's 0
+func (s *Server) AddUser(user *User) *User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user.ID == 0 {
+		user.ID = s.newID()
+	}
+	s.users[user.ID] = user
+	return user
+}
+
+// TokenFor returns a token to call functions as the user
+func (s *Server) TokenFor(userID int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issueToken(userID)
+}
+
+func (s *Server) issueToken(userID int) string {
+	for token, id := range s.tokens {
+		if id == userID {
+			return token
+		}
+	}
+	token := fmt.Sprintf("moodletest-token-%d", userID)
+	s.tokens[token] = userID
+	return token
+}
+
+// RevokeToken makes the token invalid, calls with it fail with invalidtoken
+func (s *Server) RevokeToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, token)
+}
+
+// AddCourse adds the course, the ID is assigned if it's 0
+func (s *Server) AddCourse(course *Course) *Course {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if course.ID == 0 {
+		course.ID = s.newID()
+	}
+	s.courses[course.ID] = course
+	return course
+}
+
+// Enrol enrols the users in the course
+func (s *Server) Enrol(courseID int, userIDs ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.enrolments[courseID] == nil {
+		s.enrolments[courseID] = map[int]bool{}
+	}
+	for _, userID := range userIDs {
+		s.enrolments[courseID][userID] = true
+	}
+}
+
+// AddQuiz adds the quiz and its grade item, the ID and CourseModuleID are assigned if they are 0
+func (s *Server) AddQuiz(quiz *Quiz) *Quiz {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if quiz.ID == 0 {
+		quiz.ID = s.newID()
+	}
+	if quiz.CourseModuleID == 0 {
+		quiz.CourseModuleID = s.newID()
+	}
+	s.quizzes[quiz.ID] = quiz
+	item := &GradeItem{ID: s.newID(), CourseID: quiz.CourseID, Name: quiz.Name, ItemModule: "quiz", ItemInstance: quiz.ID, GradeMax: quiz.Grade}
+	s.gradeItems[item.ID] = item
+	return quiz
+}
+
+// Attempts returns the attempts of the quiz ordered by ID
+func (s *Server) Attempts(quizID int) []*Attempt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var attempts []*Attempt
+	for _, a := range s.attempts {
+		if a.QuizID == quizID {
+			attempt := *a
+			attempts = append(attempts, &attempt)
+		}
+	}
+	sort.Slice(attempts, func(i, j int) bool { return attempts[i].ID < attempts[j].ID })
+	return attempts
+}
+
+// AddGradeItem adds the grade item, the ID is assigned if it's 0
+func (s *Server) AddGradeItem(item *GradeItem) *GradeItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if item.ID == 0 {
+		item.ID = s.newID()
+	}
+	s.gradeItems[item.ID] = item
+	return item
+}
+
+// SetGrade sets the grade of the user for the grade item
+func (s *Server) SetGrade(grade *Grade) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grades[gradeKey{itemID: grade.ItemID, userID: grade.UserID}] = grade
+}
+
+// Exception represents an exception returned by Moodle
+type Exception struct {
+	Exception string `json:"exception"`
+	ErrorCode string `json:"errorcode"`
+	Message   string `json:"message"`
+}
+
+// Warning represents a warning returned by Moodle
+type Warning struct {
+	Item        string `json:"item"`
+	ItemID      int    `json:"itemid"`
+	WarningCode string `json:"warningcode"`
+	Message     string `json:"message"`
+}
+
+// InvalidTokenException is returned when the token is not valid
+var InvalidTokenException = &Exception{
+	Exception: "moodle_exception",
+	ErrorCode: "invalidtoken",
+	Message:   "Invalid token - token not found",
+}
+
+// Fault is an error injected to responses
+type Fault struct {
+	// Function is the wsfunction to fail, all functions fail if it's empty
+	Function string
+	// Times is the number of calls to fail, all calls fail if it's 0
+	Times int
+	// Latency delays the response
+	Latency time.Duration
+	// StatusCode is the HTTP status code of the response (e.g. 503) with an html body
+	StatusCode int
+	// Exception is returned instead of the response
+	Exception *Exception
+	// Warnings are set to the warnings of the response
+	Warnings []*Warning
+}
+
+// Inject injects the fault to the following calls
+func (s *Server) Inject(fault *Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := *fault
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all the injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// popFault returns the fault for the function and consumes it
+func (s *Server) popFault(function string) *Fault {
+	for i, f := range s.faults {
+		if f.Function != "" && f.Function != function {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// Request represents a request received by the server
+type Request struct {
+	// Function is the wsfunction, empty for the login request
+	Function string
+	Path     string
+	Token    string
+	Params   url.Values
+	Header   http.Header
+	Time     time.Time
+}
+
+// Requests returns the received requests in order
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Request(nil), s.requests...)
+}
+
+// RequestsFor returns the received requests for the function in order
+func (s *Server) RequestsFor(function string) []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var requests []*Request
+	for _, r := range s.requests {
+		if r.Function == function {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// ResetRequests clears the recorded requests
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+func (s *Server) record(r *http.Request) *Request {
+	params := url.Values{}
+	for k, v := range r.URL.Query() {
+		params[k] = v
+	}
+	if err := r.ParseForm(); err == nil {
+		for k, v := range r.PostForm {
+			params[k] = v
+		}
+	}
+	req := &Request{
+		Function: params.Get("wsfunction"),
+		Path:     r.URL.Path,
+		Token:    params.Get("wstoken"),
+		Params:   params,
+		Header:   r.Header.Clone(),
+		Time:     s.now(),
+	}
+	params.Del("wstoken")
+	params.Del("wsfunction")
+	params.Del("moodlewsrestformat")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	return req
+}
+
+// applyFault writes the response of the fault, true is returned if the response is written
+func applyFault(w http.ResponseWriter, r *http.Request, fault *Fault) bool {
+	if fault.Latency > 0 {
+		select {
+		case <-time.After(fault.Latency):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+	if fault.StatusCode != 0 {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(fault.StatusCode)
+		fmt.Fprintf(w, "<html><body><h1>%d %s</h1></body></html>", fault.StatusCode, http.StatusText(fault.StatusCode))
+		return true
+	}
+	if fault.Exception != nil {
+		writeJSON(w, fault.Exception)
+		return true
+	}
+	return false
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	req := s.record(r)
+	s.mu.Lock()
+	fault := s.popFault("")
+	s.mu.Unlock()
+	if fault != nil && applyFault(w, r, fault) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Username == req.Params.Get("username") && u.Password == req.Params.Get("password") {
+			writeJSON(w, map[string]interface{}{"token": s.issueToken(u.ID), "privatetoken": nil})
+			return
+		}
+	}
+	writeJSON(w, map[string]interface{}{
+		"error":            "Invalid login, please try again",
+		"errorcode":        "invalidlogin",
+		"stacktrace":       nil,
+		"debuginfo":        nil,
+		"reproductionlink": nil,
+	})
+}
+
+func (s *Server) handleWebService(w http.ResponseWriter, r *http.Request) {
+	req := s.record(r)
+	s.mu.Lock()
+	fault := s.popFault(req.Function)
+	s.mu.Unlock()
+	if fault != nil && applyFault(w, r, fault) {
+		return
+	}
+
+	s.mu.Lock()
+	res, exception := s.call(req)
+	s.mu.Unlock()
+	if exception != nil {
+		writeJSON(w, exception)
+		return
+	}
+	if fault != nil && len(fault.Warnings) > 0 {
+		res = withWarnings(res, fault.Warnings)
+	}
+	writeJSON(w, res)
+}
+
+// call calls the function as the user of the token, s.mu must be held
+func (s *Server) call(req *Request) (interface{}, *Exception) {
+	userID, ok := s.tokens[req.Token]
+	if !ok {
+		return nil, InvalidTokenException
+	}
+	handler, ok := handlers[req.Function]
+	if !ok {
+		return nil, &Exception{
+			Exception: "dml_missing_record_exception",
+			ErrorCode: "invalidrecord",
+			Message:   "Can't find data record in database table external_functions.",
+		}
+	}
+	if !s.isFunctionEnabled(req.Function) {
+		return nil, &Exception{
+			Exception: "webservice_access_exception",
+			ErrorCode: "accessexception",
+			Message:   "Access control exception",
+		}
+	}
+	return handler(s, &call{userID: userID, params: req.Params})
+}
+
+func (s *Server) isFunctionEnabled(function string) bool {
+	if s.opts.functions == nil {
+		return true
+	}
+	for _, f := range s.opts.functions {
+		if f == function {
+			return true
+		}
+	}
+	return false
+}
+
+// call is a function call by a user
+type call struct {
+	userID int
+	params url.Values
+}
+
+func (c *call) int(key string) int {
+	n, _ := strconv.Atoi(c.params.Get(key))
+	return n
+}
+
+// withWarnings replaces the warnings of the response object
+func withWarnings(res interface{}, warnings []*Warning) interface{} {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return res
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		// the response is not an object (e.g. an array), so warnings can't be added
+		return res
+	}
+	obj["warnings"] = warnings
+	return obj
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
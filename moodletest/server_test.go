@@ -0,0 +1,323 @@
+package moodletest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k-yomo/moodle"
+	"github.com/k-yomo/moodle/moodletest"
+)
+
+func newClient(t *testing.T, s *moodletest.Server, token string) *moodle.Client {
+	t.Helper()
+
+	c, err := moodle.NewClient(context.Background(), s.ServiceURL(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestServer_login(t *testing.T) {
+	t.Parallel()
+
+	s := moodletest.NewServer(moodletest.WithSiteName("Test School"))
+	defer s.Close()
+	student := s.AddUser(&moodletest.User{Username: "student", Password: "P@ssw0rd", Firstname: "Test", Lastname: "Student"})
+
+	c, err := moodle.NewClientWithLogin(context.Background(), s.ServiceURL(), "student", "P@ssw0rd")
+	if err != nil {
+		t.Fatalf("NewClientWithLogin() error = %v", err)
+	}
+	siteInfo, err := c.SiteAPI.GetSiteInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetSiteInfo() error = %v", err)
+	}
+	if siteInfo.SiteName != "Test School" || siteInfo.UserID != student.ID || siteInfo.Fullname != "Test Student" {
+		t.Errorf("GetSiteInfo() = %+v", siteInfo)
+	}
+
+	_, err = moodle.NewClientWithLogin(context.Background(), s.ServiceURL(), "student", "wrong")
+	if moodle.Code(err) != "invalidlogin" {
+		t.Errorf("NewClientWithLogin() error = %v, want invalidlogin", err)
+	}
+}
+
+func TestServer_courses(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	s := moodletest.NewServer(moodletest.WithClock(func() time.Time { return now }))
+	defer s.Close()
+	student := s.AddUser(&moodletest.User{Username: "student"})
+	past := s.AddCourse(&moodletest.Course{ShortName: "past", FullName: "Past", StartDate: now.AddDate(-1, 0, 0), EndDate: now.AddDate(0, -1, 0)})
+	current := s.AddCourse(&moodletest.Course{ShortName: "current", FullName: "Current", IDNumber: "C-1", StartDate: now.AddDate(0, -1, 0)})
+	s.AddCourse(&moodletest.Course{ShortName: "other", FullName: "Other"})
+	s.Enrol(past.ID, student.ID)
+	s.Enrol(current.ID, student.ID)
+	c := newClient(t, s, s.TokenFor(student.ID))
+
+	courses, err := c.CourseAPI.GetEnrolledCoursesByTimelineClassification(context.Background(), moodle.CourseClassificationInProgress)
+	if err != nil {
+		t.Fatalf("GetEnrolledCoursesByTimelineClassification() error = %v", err)
+	}
+	if len(courses) != 1 || courses[0].ID != current.ID {
+		t.Errorf("GetEnrolledCoursesByTimelineClassification() = %+v, want course %d", courses, current.ID)
+	}
+
+	courses, err = c.CourseAPI.GetCoursesByField(context.Background(), moodle.CourseFieldIDNumber, "C-1")
+	if err != nil {
+		t.Fatalf("GetCoursesByField() error = %v", err)
+	}
+	if len(courses) != 1 || courses[0].ShortName != "current" {
+		t.Errorf("GetCoursesByField() = %+v, want course current", courses)
+	}
+}
+
+func TestServer_quizAttemptLifecycle(t *testing.T) {
+	t.Parallel()
+
+	s := moodletest.NewServer()
+	defer s.Close()
+	student := s.AddUser(&moodletest.User{Username: "student", Firstname: "Test", Lastname: "Student"})
+	course := s.AddCourse(&moodletest.Course{ShortName: "math", FullName: "Math"})
+	s.Enrol(course.ID, student.ID)
+	quiz := s.AddQuiz(&moodletest.Quiz{
+		CourseID:  course.ID,
+		Name:      "Quiz 1",
+		TimeLimit: 30 * time.Minute,
+		Attempts:  1,
+		Grade:     10,
+		Questions: []*moodletest.Question{
+			{Type: "truefalse", Text: "1 + 1 = 2", MaxMark: 1, Mark: 1},
+			{Type: "truefalse", Text: "1 + 1 = 3", MaxMark: 1, Mark: 0},
+		},
+	})
+	ctx := context.Background()
+	c := newClient(t, s, s.TokenFor(student.ID))
+
+	quizzes, err := c.QuizAPI.GetQuizzesByCourse(ctx, course.ID)
+	if err != nil {
+		t.Fatalf("GetQuizzesByCourse() error = %v", err)
+	}
+	if len(quizzes) != 1 || quizzes[0].ID != quiz.ID || quizzes[0].TimeLimit != 1800 {
+		t.Fatalf("GetQuizzesByCourse() = %+v", quizzes)
+	}
+
+	attempt, err := c.QuizAPI.StartAttempt(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("StartAttempt() error = %v", err)
+	}
+	if attempt.State != moodletest.AttemptStateInProgress || attempt.TimeCheckState == nil {
+		t.Errorf("StartAttempt() = %+v", attempt)
+	}
+	if _, err := c.QuizAPI.StartAttempt(ctx, quiz.ID); moodle.Code(err) != "attemptstillinprogress" {
+		t.Errorf("StartAttempt() error = %v, want attemptstillinprogress", err)
+	}
+	accessInfo, err := c.QuizAPI.GetAttemptAccessInformation(ctx, quiz.ID, attempt.ID)
+	if err != nil {
+		t.Fatalf("GetAttemptAccessInformation() error = %v", err)
+	}
+	if accessInfo.IsFinished || accessInfo.EndTime == nil {
+		t.Errorf("GetAttemptAccessInformation() = %+v", accessInfo)
+	}
+
+	if err := c.QuizAPI.FinishAttempt(ctx, attempt.ID, false); err != nil {
+		t.Fatalf("FinishAttempt() error = %v", err)
+	}
+	attempts, err := c.QuizAPI.GetUserAttempts(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetUserAttempts() error = %v", err)
+	}
+	if len(attempts) != 1 || attempts[0].State != moodletest.AttemptStateFinished || attempts[0].SumGrades != 1 {
+		t.Errorf("GetUserAttempts() = %+v", attempts)
+	}
+	_, questions, err := c.QuizAPI.GetAttemptReview(ctx, attempt.ID)
+	if err != nil {
+		t.Fatalf("GetAttemptReview() error = %v", err)
+	}
+	if len(questions) != 2 || questions[0].State != "gradedright" || questions[1].State != "gradedwrong" {
+		t.Errorf("GetAttemptReview() questions = %+v", questions)
+	}
+	if _, err := c.QuizAPI.StartAttempt(ctx, quiz.ID); moodle.Code(err) != "attempterror" {
+		t.Errorf("StartAttempt() error = %v, want attempterror", err)
+	}
+
+	// the highest grade is written to the grade item of the quiz
+	courseGrades, err := c.GradeAPI.GetCourseGrades(ctx, 0)
+	if err != nil {
+		t.Fatalf("GetCourseGrades() error = %v", err)
+	}
+	rawGrade := 5.0
+	if diff := cmp.Diff(courseGrades, []*moodle.CourseGrade{{CourseID: course.ID, Grade: "5.00", RawGrade: &rawGrade}}); diff != "" {
+		t.Errorf("GetCourseGrades() (-got, +want)\n%s", diff)
+	}
+}
+
+func TestServer_gradesTable(t *testing.T) {
+	t.Parallel()
+
+	s := moodletest.NewServer()
+	defer s.Close()
+	student := s.AddUser(&moodletest.User{Username: "student", Firstname: "Test", Lastname: "Student"})
+	course := s.AddCourse(&moodletest.Course{ShortName: "math", FullName: "Math"})
+	s.Enrol(course.ID, student.ID)
+	homework := s.AddGradeItem(&moodletest.GradeItem{CourseID: course.ID, Name: "Homework", GradeMax: 50})
+	s.AddGradeItem(&moodletest.GradeItem{CourseID: course.ID, Name: "Exam", GradeMax: 50})
+	s.SetGrade(&moodletest.Grade{ItemID: homework.ID, UserID: student.ID, Grade: 40, Feedback: "Good"})
+	c := newClient(t, s, moodletest.DefaultToken)
+
+	tables, err := c.GradeAPI.GetGradesTable(context.Background(), student.ID, course.ID)
+	if err != nil {
+		t.Fatalf("GetGradesTable() error = %v", err)
+	}
+	if len(tables) != 1 {
+		t.Fatalf("GetGradesTable() returned %d tables, want 1", len(tables))
+	}
+	category := tables[0].Category
+	if category.Name != "Math" || len(category.Items) != 2 || category.Total == nil {
+		t.Fatalf("GetGradesTable() category = %+v", category)
+	}
+	homeworkItem, examItem := category.Items[0], category.Items[1]
+	if homeworkItem.ItemName != "Homework" || homeworkItem.Grade != 40 || homeworkItem.GradeRangeMax != 50 || homeworkItem.ContributionToCourseTotal != 40 || homeworkItem.Feedback != "Good" {
+		t.Errorf("GetGradesTable() homework = %+v", homeworkItem)
+	}
+	if examItem.IsGraded {
+		t.Errorf("GetGradesTable() exam = %+v, want not graded", examItem)
+	}
+	if category.Total.Grade != 40 || category.Total.GradeRangeMax != 100 {
+		t.Errorf("GetGradesTable() total = %+v", category.Total)
+	}
+}
+
+func TestServer_Inject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		fault       *moodletest.Fault
+		wantErrCode string
+		wantErr     bool
+	}{
+		{
+			name:        "Invalid token",
+			fault:       &moodletest.Fault{Exception: moodletest.InvalidTokenException},
+			wantErrCode: "invalidtoken",
+			wantErr:     true,
+		},
+		{
+			name:    "Warnings",
+			fault:   &moodletest.Fault{Function: "mod_quiz_start_attempt", Warnings: []*moodletest.Warning{{Item: "quiz", WarningCode: "1", Message: "test warning"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Server error",
+			fault:   &moodletest.Fault{StatusCode: 503},
+			wantErr: true,
+		},
+		{
+			name:  "Fault for other function",
+			fault: &moodletest.Fault{Function: "core_webservice_get_site_info", StatusCode: 503},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := moodletest.NewServer()
+			defer s.Close()
+			course := s.AddCourse(&moodletest.Course{ShortName: "math"})
+			quiz := s.AddQuiz(&moodletest.Quiz{CourseID: course.ID, Name: "Quiz 1"})
+			tt.fault.Times = 1
+			s.Inject(tt.fault)
+			c := newClient(t, s, moodletest.DefaultToken)
+
+			_, err := c.QuizAPI.StartAttempt(context.Background(), quiz.ID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("StartAttempt() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrCode != "" && moodle.Code(err) != tt.wantErrCode {
+				t.Errorf("StartAttempt() error code = %s, want %s", moodle.Code(err), tt.wantErrCode)
+			}
+			// the fault is consumed
+			if _, err := c.QuizAPI.GetQuizzesByCourse(context.Background(), course.ID); err != nil {
+				t.Errorf("GetQuizzesByCourse() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestServer_Inject_latency(t *testing.T) {
+	t.Parallel()
+
+	s := moodletest.NewServer()
+	defer s.Close()
+	s.Inject(&moodletest.Fault{Latency: time.Second})
+	c := newClient(t, s, moodletest.DefaultToken)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.SiteAPI.GetSiteInfo(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetSiteInfo() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestServer_invalidToken(t *testing.T) {
+	t.Parallel()
+
+	s := moodletest.NewServer()
+	defer s.Close()
+	token := s.TokenFor(moodletest.AdminUserID)
+	s.RevokeToken(token)
+	c := newClient(t, s, token)
+
+	if _, err := c.SiteAPI.GetSiteInfo(context.Background()); moodle.Code(err) != "invalidtoken" {
+		t.Errorf("GetSiteInfo() error = %v, want invalidtoken", err)
+	}
+}
+
+func TestServer_WithFunctions(t *testing.T) {
+	t.Parallel()
+
+	s := moodletest.NewServer(moodletest.WithFunctions("core_webservice_get_site_info"))
+	defer s.Close()
+	c, _ := moodle.NewClient(context.Background(), s.ServiceURL(), moodletest.DefaultToken, moodle.WithFunctionCheck())
+
+	_, err := c.QuizAPI.GetQuizzesByCourse(context.Background(), 1)
+	if !errors.Is(err, moodle.ErrFunctionNotAvailable) {
+		t.Errorf("GetQuizzesByCourse() error = %v, want ErrFunctionNotAvailable", err)
+	}
+	if got := len(s.RequestsFor("mod_quiz_get_quizzes_by_courses")); got != 0 {
+		t.Errorf("mod_quiz_get_quizzes_by_courses is called %d times, want 0", got)
+	}
+}
+
+func TestServer_Requests(t *testing.T) {
+	t.Parallel()
+
+	s := moodletest.NewServer()
+	defer s.Close()
+	course := s.AddCourse(&moodletest.Course{ShortName: "math"})
+	c := newClient(t, s, moodletest.DefaultToken)
+
+	if _, err := c.QuizAPI.GetQuizzesByCourse(context.Background(), course.ID); err != nil {
+		t.Fatalf("GetQuizzesByCourse() error = %v", err)
+	}
+	requests := s.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("Requests() returned %d requests, want 1", len(requests))
+	}
+	if requests[0].Function != "mod_quiz_get_quizzes_by_courses" || requests[0].Token != moodletest.DefaultToken || requests[0].Params.Get("courseids[0]") != fmt.Sprint(course.ID) {
+		t.Errorf("Requests()[0] = %+v", requests[0])
+	}
+	s.ResetRequests()
+	if len(s.Requests()) != 0 {
+		t.Errorf("Requests() is not empty after ResetRequests()")
+	}
+}
@@ -6,6 +6,26 @@ type ClientOptions struct {
 	AuthToken  string
 	HttpClient *http.Client
 	Debug      bool
+	Logger     Logger
+	// LogBodyLimit is the max bytes of the response body logged at debug level, 0 disables and negative means no limit
+	LogBodyLimit int
+	// Middlewares wrap the calls in order, the first one is the outermost
+	Middlewares []Middleware
+	// TransportMiddlewares wrap the transport of HttpClient in order, the first one is the outermost
+	TransportMiddlewares []TransportMiddleware
+	// FunctionCheck loads the capabilities at the first call to check if functions are available
+	FunctionCheck bool
+
+	// Custom implementations of the sub-APIs, the default implementations are used if they are nil
+	AuthAPI       AuthAPI
+	SiteAPI       SiteAPI
+	UserAPI       UserAPI
+	CourseAPI     CourseAPI
+	QuizAPI       QuizAPI
+	GradeAPI      GradeAPI
+	EnrolAPI      EnrolAPI
+	GroupAPI      GroupAPI
+	CompletionAPI CompletionAPI
 }
 
 func newDefaultClientOptions() *ClientOptions {
@@ -40,16 +60,129 @@ func WithHTTPClient(httpClient *http.Client) ClientOption {
 	})
 }
 
-// WithDebugEnabled enable debug logs
+// WithDebugEnabled enable debug logs with the standard log package including the response bodies.
 // this option is should be used in development only.
+//
+// Deprecated: Use WithLogger, which is used instead if both are set.
 func WithDebugEnabled() ClientOption {
 	return newClientOptionFunc(func(c *ClientOptions) {
 		c.Debug = true
 	})
 }
 
+// WithLogger logs calls with the structured logger (e.g. *slog.Logger).
+// Each call is logged at info level with wsfunction, duration, status and response size,
+// failed calls at warn level with the Moodle error code or at error level with the error.
+// Secrets like wstoken and password are always redacted.
+func WithLogger(logger Logger) ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.Logger = logger
+	})
+}
+
+// WithLogBody logs the response bodies truncated to maxBytes with the logger, negative maxBytes means no limit.
+// Bodies are not logged by default since they contain personal information.
+func WithLogBody(maxBytes int) ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.LogBodyLimit = maxBytes
+	})
+}
+
+// WithMiddleware wraps the calls of the client with the middleware, e.g. to add headers, audit or short-circuit calls.
+// It can be set multiple times, and the first one is the outermost.
+func WithMiddleware(middleware Middleware) ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.Middlewares = append(c.Middlewares, middleware)
+	})
+}
+
+// WithCache caches the responses of the client with the cache.
+// The cache can be shared between clients since the responses are cached per token.
+func WithCache(cache *Cache) ClientOption {
+	return WithMiddleware(cache.Middleware())
+}
+
+// WithTransportMiddleware wraps the transport of the http client without changing the given http client,
+// e.g. to trace requests with the otelmoodle package. It can be set multiple times, and the first one is the outermost.
+func WithTransportMiddleware(middleware TransportMiddleware) ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.TransportMiddlewares = append(c.TransportMiddlewares, middleware)
+	})
+}
+
 func withAuthToken(authToken string) ClientOption {
 	return newClientOptionFunc(func(c *ClientOptions) {
 		c.AuthToken = authToken
 	})
 }
+
+// WithFunctionCheck makes calls fail fast with ErrFunctionNotAvailable if the function is not available for the token,
+// instead of the server error. The capabilities are loaded from the site info at the first call.
+func WithFunctionCheck() ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.FunctionCheck = true
+	})
+}
+
+// WithAuthAPI replaces AuthAPI of the client with the implementation, e.g. a mock in moodlemock package
+func WithAuthAPI(authAPI AuthAPI) ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.AuthAPI = authAPI
+	})
+}
+
+// WithSiteAPI replaces SiteAPI of the client with the implementation
+func WithSiteAPI(siteAPI SiteAPI) ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.SiteAPI = siteAPI
+	})
+}
+
+// WithUserAPI replaces UserAPI of the client with the implementation
+func WithUserAPI(userAPI UserAPI) ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.UserAPI = userAPI
+	})
+}
+
+// WithCourseAPI replaces CourseAPI of the client with the implementation
+func WithCourseAPI(courseAPI CourseAPI) ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.CourseAPI = courseAPI
+	})
+}
+
+// WithQuizAPI replaces QuizAPI of the client with the implementation
+func WithQuizAPI(quizAPI QuizAPI) ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.QuizAPI = quizAPI
+	})
+}
+
+// WithGradeAPI replaces GradeAPI of the client with the implementation
+func WithGradeAPI(gradeAPI GradeAPI) ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.GradeAPI = gradeAPI
+	})
+}
+
+// WithEnrolAPI replaces EnrolAPI of the client with the implementation
+func WithEnrolAPI(enrolAPI EnrolAPI) ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.EnrolAPI = enrolAPI
+	})
+}
+
+// WithGroupAPI replaces GroupAPI of the client with the implementation
+func WithGroupAPI(groupAPI GroupAPI) ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.GroupAPI = groupAPI
+	})
+}
+
+// WithCompletionAPI replaces CompletionAPI of the client with the implementation
+func WithCompletionAPI(completionAPI CompletionAPI) ClientOption {
+	return newClientOptionFunc(func(c *ClientOptions) {
+		c.CompletionAPI = completionAPI
+	})
+}
@@ -26,3 +26,15 @@ func TestWithDebugEnabled(t *testing.T) {
 		t.Errorf("WithDebugEnabled() = %v, want %v", clientOptions.Debug, true)
 	}
 }
+
+func TestWithLogger(t *testing.T) {
+	t.Parallel()
+
+	clientOptions := ClientOptions{}
+	logger := &recordingLogger{}
+	WithLogger(logger).apply(&clientOptions)
+	WithLogBody(100).apply(&clientOptions)
+	if clientOptions.Logger != logger || clientOptions.LogBodyLimit != 100 {
+		t.Errorf("WithLogger() and WithLogBody() = %v, %v, want %v, %v", clientOptions.Logger, clientOptions.LogBodyLimit, logger, 100)
+	}
+}
@@ -0,0 +1,220 @@
+// Package otelmoodle instruments the moodle client with OpenTelemetry.
+//
+// A span is created per web service call named after the wsfunction as a child of the span in the caller's context,
+// and the request count, latency, errors by Moodle error code and retries are recorded as metrics.
+//
+//	client, err := moodle.NewClient(ctx, serviceURL, token, otelmoodle.WithTelemetry())
+package otelmoodle
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/k-yomo/moodle"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/k-yomo/moodle/otelmoodle"
+
+// Attribute keys set on spans and metrics
+const (
+	WSFunctionKey   = attribute.Key("moodle.wsfunction")
+	ErrorCodeKey    = attribute.Key("moodle.error_code")
+	ExceptionKey    = attribute.Key("moodle.exception")
+	RetryAttemptKey = attribute.Key("moodle.retry_attempt")
+	serverAddrKey   = attribute.Key("server.address")
+	statusCodeKey   = attribute.Key("http.response.status_code")
+	methodKey       = attribute.Key("http.request.method")
+)
+
+// errorCodeTransport is the error code of the calls failed without a response
+const errorCodeTransport = "transport"
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagators    propagation.TextMapPropagator
+}
+
+// Option is a option to change instrumentation configuration.
+type Option interface {
+	apply(*config)
+}
+
+type optionFunc func(c *config)
+
+func (o optionFunc) apply(c *config) {
+	o(c)
+}
+
+// WithTracerProvider sets the tracer provider, the global one is used by default
+func WithTracerProvider(tracerProvider trace.TracerProvider) Option {
+	return optionFunc(func(c *config) {
+		c.tracerProvider = tracerProvider
+	})
+}
+
+// WithMeterProvider sets the meter provider, the global one is used by default
+func WithMeterProvider(meterProvider metric.MeterProvider) Option {
+	return optionFunc(func(c *config) {
+		c.meterProvider = meterProvider
+	})
+}
+
+// WithPropagators sets the propagators injecting the trace context to requests, the global one is used by default
+func WithPropagators(propagators propagation.TextMapPropagator) Option {
+	return optionFunc(func(c *config) {
+		c.propagators = propagators
+	})
+}
+
+// WithTelemetry returns a client option to trace and measure the calls of the client
+func WithTelemetry(opt ...Option) moodle.ClientOption {
+	return moodle.WithTransportMiddleware(func(next http.RoundTripper) http.RoundTripper {
+		return NewTransport(next, opt...)
+	})
+}
+
+// Transport is a http.RoundTripper instrumenting Moodle requests
+type Transport struct {
+	next        http.RoundTripper
+	tracer      trace.Tracer
+	propagators propagation.TextMapPropagator
+
+	requests metric.Int64Counter
+	duration metric.Float64Histogram
+	errors   metric.Int64Counter
+	retries  metric.Int64Counter
+}
+
+// NewTransport returns a transport instrumenting the requests sent with next, http.DefaultTransport is used if next is nil
+func NewTransport(next http.RoundTripper, opt ...Option) *Transport {
+	c := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagators:    otel.GetTextMapPropagator(),
+	}
+	for _, o := range opt {
+		o.apply(c)
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	meter := c.meterProvider.Meter(instrumentationName)
+	t := &Transport{
+		next:        next,
+		tracer:      c.tracerProvider.Tracer(instrumentationName),
+		propagators: c.propagators,
+	}
+	// errors creating instruments are passed to the global error handler, and the returned instruments are still usable
+	var err error
+	if t.requests, err = meter.Int64Counter("moodle.client.requests", metric.WithDescription("Number of Moodle requests")); err != nil {
+		otel.Handle(err)
+	}
+	if t.duration, err = meter.Float64Histogram("moodle.client.duration", metric.WithDescription("Duration of Moodle requests"), metric.WithUnit("s")); err != nil {
+		otel.Handle(err)
+	}
+	if t.errors, err = meter.Int64Counter("moodle.client.errors", metric.WithDescription("Number of failed Moodle requests by error code")); err != nil {
+		otel.Handle(err)
+	}
+	if t.retries, err = meter.Int64Counter("moodle.client.retries", metric.WithDescription("Number of retried Moodle requests")); err != nil {
+		otel.Handle(err)
+	}
+	return t
+}
+
+// RoundTrip sends the request in a span
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	wsfunction := req.URL.Query().Get("wsfunction")
+	spanName := wsfunction
+	if spanName == "" {
+		spanName = strings.TrimSuffix(path.Base(req.URL.Path), ".php")
+	}
+	metricAttrs := []attribute.KeyValue{WSFunctionKey.String(spanName)}
+	spanAttrs := []attribute.KeyValue{
+		WSFunctionKey.String(spanName),
+		serverAddrKey.String(req.URL.Hostname()),
+		methodKey.String(req.Method),
+	}
+	if attempt := moodle.RetryAttempt(ctx); attempt > 0 {
+		spanAttrs = append(spanAttrs, RetryAttemptKey.Int(attempt))
+		t.retries.Add(ctx, 1, metric.WithAttributes(metricAttrs...))
+	}
+
+	ctx, span := t.tracer.Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(spanAttrs...))
+	defer span.End()
+	req = req.Clone(ctx)
+	t.propagators.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Seconds()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.record(req, metricAttrs, elapsed, 0, errorCodeTransport)
+		return nil, err
+	}
+
+	span.SetAttributes(statusCodeKey.Int(resp.StatusCode))
+	errorCode, exception, err := peekError(resp)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.record(req, metricAttrs, elapsed, resp.StatusCode, errorCodeTransport)
+		return nil, err
+	}
+	switch {
+	case errorCode != "":
+		span.SetAttributes(ErrorCodeKey.String(errorCode), ExceptionKey.String(exception))
+		span.SetStatus(codes.Error, errorCode)
+	case resp.StatusCode >= http.StatusBadRequest:
+		errorCode = http.StatusText(resp.StatusCode)
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	t.record(req, metricAttrs, elapsed, resp.StatusCode, errorCode)
+	return resp, nil
+}
+
+func (t *Transport) record(req *http.Request, attrs []attribute.KeyValue, elapsed float64, statusCode int, errorCode string) {
+	ctx := req.Context()
+	t.requests.Add(ctx, 1, metric.WithAttributes(append(attrs, statusCodeKey.Int(statusCode))...))
+	t.duration.Record(ctx, elapsed, metric.WithAttributes(attrs...))
+	if errorCode != "" {
+		t.errors.Add(ctx, 1, metric.WithAttributes(append(attrs, ErrorCodeKey.String(errorCode))...))
+	}
+}
+
+// peekError returns the Moodle error code of the json response, the body is buffered only for json responses
+func peekError(resp *http.Response) (errorCode string, exception string, err error) {
+	if !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return "", "", nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return "", "", err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var apiError struct {
+		ErrorCode string `json:"errorcode"`
+		Exception string `json:"exception"`
+	}
+	if err := json.Unmarshal(body, &apiError); err != nil {
+		return "", "", nil
+	}
+	return apiError.ErrorCode, apiError.Exception, nil
+}
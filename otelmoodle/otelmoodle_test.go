@@ -0,0 +1,115 @@
+package otelmoodle_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k-yomo/moodle"
+	"github.com/k-yomo/moodle/moodletest"
+	"github.com/k-yomo/moodle/otelmoodle"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTelemetry(t *testing.T) {
+	t.Parallel()
+
+	s := moodletest.NewServer()
+	defer s.Close()
+	spans := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	c, _ := moodle.NewClient(context.Background(), s.ServiceURL(), moodletest.DefaultToken, otelmoodle.WithTelemetry(
+		otelmoodle.WithTracerProvider(tracerProvider),
+		otelmoodle.WithMeterProvider(meterProvider),
+		otelmoodle.WithPropagators(propagation.TraceContext{}),
+	))
+
+	ctx, parent := tracerProvider.Tracer("test").Start(context.Background(), "parent")
+	if _, err := c.SiteAPI.GetSiteInfo(ctx); err != nil {
+		t.Fatalf("GetSiteInfo() error = %v", err)
+	}
+	if _, err := c.QuizAPI.StartAttempt(moodle.ContextWithRetryAttempt(ctx, 1), 12345); moodle.Code(err) != "invalidrecord" {
+		t.Fatalf("StartAttempt() error = %v, want invalidrecord", err)
+	}
+	parent.End()
+
+	ended := spans.Ended()
+	if len(ended) != 3 {
+		t.Fatalf("%d spans are ended, want 3", len(ended))
+	}
+	siteInfoSpan, quizSpan := ended[0], ended[1]
+	if siteInfoSpan.Name() != "core_webservice_get_site_info" || siteInfoSpan.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span = %s with parent %s, want core_webservice_get_site_info with parent %s", siteInfoSpan.Name(), siteInfoSpan.Parent().SpanID(), parent.SpanContext().SpanID())
+	}
+	if siteInfoSpan.Status().Code == codes.Error {
+		t.Errorf("span status = %v, want not error", siteInfoSpan.Status())
+	}
+	wantAttrs := map[attribute.Key]attribute.Value{
+		otelmoodle.WSFunctionKey:    attribute.StringValue("mod_quiz_start_attempt"),
+		otelmoodle.ErrorCodeKey:     attribute.StringValue("invalidrecord"),
+		otelmoodle.RetryAttemptKey:  attribute.IntValue(1),
+		"server.address":            attribute.StringValue("127.0.0.1"),
+		"http.response.status_code": attribute.IntValue(200),
+	}
+	gotAttrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range quizSpan.Attributes() {
+		gotAttrs[kv.Key] = kv.Value
+	}
+	for k, v := range wantAttrs {
+		if gotAttrs[k] != v {
+			t.Errorf("span attribute %s = %v, want %v", k, gotAttrs[k].Emit(), v.Emit())
+		}
+	}
+	if quizSpan.Status().Code != codes.Error {
+		t.Errorf("span status = %v, want error", quizSpan.Status())
+	}
+
+	// the trace context is propagated to the server
+	requests := s.RequestsFor("core_webservice_get_site_info")
+	if len(requests) != 1 {
+		t.Fatalf("site info is requested %d times, want 1", len(requests))
+	}
+	if traceparent := requests[0].Header.Get("Traceparent"); !strings.Contains(traceparent, siteInfoSpan.SpanContext().SpanID().String()) {
+		t.Errorf("traceparent = %q, want span %s", traceparent, siteInfoSpan.SpanContext().SpanID())
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					got[m.Name] += dp.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					got[m.Name] += int64(dp.Count)
+				}
+			}
+		}
+	}
+	want := map[string]int64{
+		"moodle.client.requests": 2,
+		"moodle.client.duration": 2,
+		"moodle.client.errors":   1,
+		"moodle.client.retries":  1,
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("metric %s = %d, want %d", name, got[name], v)
+		}
+	}
+}
@@ -4,6 +4,13 @@ import (
 	"time"
 )
 
+const (
+	QuizAttemptStateInProgress = "inprogress"
+	QuizAttemptStateOverdue    = "overdue"
+	QuizAttemptStateFinished   = "finished"
+	QuizAttemptStateAbandoned  = "abandoned"
+)
+
 type Quiz struct {
 	ID                    int
 	CourseID              int
@@ -14,6 +21,8 @@ type Quiz struct {
 	TimeOpen              time.Time
 	TimeClose             time.Time
 	TimeLimit             int
+	OverdueHandling       string
+	GracePeriod           int
 	PreferredBehaviour    string
 	Attempts              int
 	GradeMethod           int
@@ -62,3 +71,12 @@ type QuizQuestion struct {
 	Mark              string
 	MaxMark           int
 }
+
+// QuizAttemptAccessInformation represents the access rules applied to an attempt
+type QuizAttemptAccessInformation struct {
+	// EndTime is when the attempt must be submitted, including user and group overrides
+	EndTime                  *time.Time
+	IsFinished               bool
+	IsPreflightCheckRequired bool
+	PreventNewAttemptReasons []string
+}
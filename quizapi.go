@@ -12,6 +12,7 @@ type QuizAPI interface {
 	GetAttemptReview(ctx context.Context, attemptID int) (*QuizAttempt, []*QuizQuestion, error)
 	StartAttempt(ctx context.Context, quizID int) (*QuizAttempt, error)
 	FinishAttempt(ctx context.Context, attemptID int, timeUp bool) error
+	GetAttemptAccessInformation(ctx context.Context, quizID int, attemptID int) (*QuizAttemptAccessInformation, error)
 }
 
 type quizAPI struct {
@@ -32,6 +33,8 @@ type quizResponse struct {
 	TimeOpenUnix          int64  `json:"timeopen"`
 	TimeCloseUnix         int64  `json:"timeclose"`
 	TimeLimit             int    `json:"timelimit"`
+	OverdueHandling       string `json:"overduehandling"`
+	GracePeriod           int    `json:"graceperiod"`
 	PreferredBehaviour    string `json:"preferredbehaviour"`
 	Attempts              int    `json:"attempts"`
 	GradeMethod           int    `json:"grademethod"`
@@ -149,7 +152,7 @@ type startAttemptResponse struct {
 
 func (q *quizAPI) StartAttempt(ctx context.Context, quizID int) (*QuizAttempt, error) {
 	res := startAttemptResponse{}
-	err := q.callMoodleFunction(
+	err := q.postMoodleFunction(
 		ctx,
 		&res,
 		map[string]string{
@@ -173,7 +176,7 @@ type finishAttemptResponse struct {
 
 func (q *quizAPI) FinishAttempt(ctx context.Context, attemptID int, timeUp bool) error {
 	res := finishAttemptResponse{}
-	err := q.callMoodleFunction(
+	err := q.postMoodleFunction(
 		ctx,
 		&res,
 		map[string]string{
@@ -192,6 +195,34 @@ func (q *quizAPI) FinishAttempt(ctx context.Context, attemptID int, timeUp bool)
 	return nil
 }
 
+type getAttemptAccessInformationResponse struct {
+	EndTimeUnix              *int64   `json:"endtime,omitempty"`
+	IsFinished               bool     `json:"isfinished"`
+	IsPreflightCheckRequired bool     `json:"ispreflightcheckrequired"`
+	PreventNewAttemptReasons []string `json:"preventnewattemptreasons"`
+	Warnings                 Warnings `json:"warnings,omitempty"`
+}
+
+func (q *quizAPI) GetAttemptAccessInformation(ctx context.Context, quizID int, attemptID int) (*QuizAttemptAccessInformation, error) {
+	res := getAttemptAccessInformationResponse{}
+	err := q.callMoodleFunction(
+		ctx,
+		&res,
+		map[string]string{
+			"wsfunction": "mod_quiz_get_attempt_access_information",
+			"quizid":     strconv.Itoa(quizID),
+			"attemptid":  strconv.Itoa(attemptID),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+	return mapToQuizAttemptAccessInformation(&res), nil
+}
+
 func mapToQuizList(quizResList []*quizResponse) []*Quiz {
 	quizzes := make([]*Quiz, 0, len(quizResList))
 	for _, quizRes := range quizResList {
@@ -211,6 +242,8 @@ func mapToQuiz(quizRes *quizResponse) *Quiz {
 		TimeOpen:              time.Unix(quizRes.TimeOpenUnix, 0),
 		TimeClose:             time.Unix(quizRes.TimeCloseUnix, 0),
 		TimeLimit:             quizRes.TimeLimit,
+		OverdueHandling:       quizRes.OverdueHandling,
+		GracePeriod:           quizRes.GracePeriod,
 		PreferredBehaviour:    quizRes.PreferredBehaviour,
 		Attempts:              quizRes.Attempts,
 		GradeMethod:           quizRes.GradeMethod,
@@ -289,3 +322,17 @@ func mapToQuizQuestion(quizQuestionRes *quizQuestionResponse) *QuizQuestion {
 		MaxMark:           quizQuestionRes.MaxMark,
 	}
 }
+
+func mapToQuizAttemptAccessInformation(accessInfoRes *getAttemptAccessInformationResponse) *QuizAttemptAccessInformation {
+	var endTime *time.Time
+	if accessInfoRes.EndTimeUnix != nil && *accessInfoRes.EndTimeUnix > 0 {
+		t := time.Unix(*accessInfoRes.EndTimeUnix, 0)
+		endTime = &t
+	}
+	return &QuizAttemptAccessInformation{
+		EndTime:                  endTime,
+		IsFinished:               accessInfoRes.IsFinished,
+		IsPreflightCheckRequired: accessInfoRes.IsPreflightCheckRequired,
+		PreventNewAttemptReasons: accessInfoRes.PreventNewAttemptReasons,
+	}
+}
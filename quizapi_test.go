@@ -456,6 +456,78 @@ func Test_quizAPI_FinishAttempt(t *testing.T) {
 	}
 }
 
+func Test_quizAPI_GetAttemptAccessInformation(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		ctx       context.Context
+		quizID    int
+		attemptID int
+	}
+	tests := []struct {
+		name     string
+		args     args
+		response string
+		want     *QuizAttemptAccessInformation
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			args:     args{ctx: context.Background(), quizID: 1111, attemptID: 2222},
+			response: `{"endtime":1577840400,"isfinished":false,"ispreflightcheckrequired":false,"preventnewattemptreasons":["You have already made an attempt"],"warnings":[]}`,
+			want: &QuizAttemptAccessInformation{
+				EndTime:                  func() *time.Time { t := time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC); return &t }(),
+				IsFinished:               false,
+				IsPreflightCheckRequired: false,
+				PreventNewAttemptReasons: []string{"You have already made an attempt"},
+			},
+		},
+		{
+			name:     "Successful response without end time",
+			args:     args{ctx: context.Background(), quizID: 1111, attemptID: 2222},
+			response: `{"isfinished":true,"preventnewattemptreasons":[],"warnings":[]}`,
+			want: &QuizAttemptAccessInformation{
+				IsFinished:               true,
+				PreventNewAttemptReasons: []string{},
+			},
+		},
+		{
+			name:     "Warning response",
+			args:     args{ctx: context.Background(), quizID: 1111, attemptID: 2222},
+			response: `{"isfinished":false,"warnings":[{"item":"quiz","itemid":1111,"warningcode":"1","message":"Test message"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Error response",
+			args:     args{ctx: context.Background(), quizID: 0000, attemptID: 0000},
+			response: `{"exception":"dml_missing_record_exception","errorcode":"invalidrecord","message":"Can't find data record in database table quiz."}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			args:     args{ctx: context.Background(), quizID: 0000, attemptID: 0000},
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			q := mockQuizAPI(t, tt.response)
+			got, err := q.GetAttemptAccessInformation(tt.args.ctx, tt.args.quizID, tt.args.attemptID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetAttemptAccessInformation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetAttemptAccessInformation() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
 func mockQuizAPI(t *testing.T, response string) *quizAPI {
 	t.Helper()
 
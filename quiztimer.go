@@ -0,0 +1,140 @@
+package moodle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const quizOverdueHandlingGracePeriod = "graceperiod"
+
+// ErrNoTimeLimit is returned when waiting for the time limit of an attempt which doesn't have one.
+var ErrNoTimeLimit = errors.New("quiz attempt has no time limit")
+
+// QuizAttemptTimer keeps track of the remaining time of a running quiz attempt.
+// All the times are based on the server clock, so the clock skew between the server and local is compensated.
+type QuizAttemptTimer struct {
+	quizAPI     QuizAPI
+	attemptID   int
+	deadline    *time.Time
+	gracePeriod time.Duration
+	clockSkew   time.Duration
+	now         func() time.Time
+}
+
+// NewQuizAttemptTimer creates a timer for the given running attempt.
+// The deadline is taken from the attempt access information, so user and group overrides are respected.
+// If the server doesn't return it, the deadline is calculated from the attempt and quiz settings.
+func (c *Client) NewQuizAttemptTimer(ctx context.Context, quiz *Quiz, attempt *QuizAttempt) (*QuizAttemptTimer, error) {
+	if attempt.State != QuizAttemptStateInProgress && attempt.State != QuizAttemptStateOverdue {
+		return nil, fmt.Errorf("quiz attempt %d is not running, state: %s", attempt.ID, attempt.State)
+	}
+	accessInfo, err := c.QuizAPI.GetAttemptAccessInformation(ctx, quiz.ID, attempt.ID)
+	if err != nil {
+		return nil, err
+	}
+	clockSkew, _ := c.apiClient.serverClockSkew()
+	return newQuizAttemptTimer(c.QuizAPI, quiz, attempt, accessInfo, clockSkew), nil
+}
+
+func newQuizAttemptTimer(quizAPI QuizAPI, quiz *Quiz, attempt *QuizAttempt, accessInfo *QuizAttemptAccessInformation, clockSkew time.Duration) *QuizAttemptTimer {
+	var gracePeriod time.Duration
+	if quiz.OverdueHandling == quizOverdueHandlingGracePeriod {
+		gracePeriod = time.Duration(quiz.GracePeriod) * time.Second
+	}
+	return &QuizAttemptTimer{
+		quizAPI:     quizAPI,
+		attemptID:   attempt.ID,
+		deadline:    quizAttemptDeadline(quiz, attempt, accessInfo),
+		gracePeriod: gracePeriod,
+		clockSkew:   clockSkew,
+		now:         time.Now,
+	}
+}
+
+func quizAttemptDeadline(quiz *Quiz, attempt *QuizAttempt, accessInfo *QuizAttemptAccessInformation) *time.Time {
+	if accessInfo != nil && accessInfo.EndTime != nil {
+		return accessInfo.EndTime
+	}
+	// timecheckstate of an in progress attempt is the time the attempt becomes overdue
+	if attempt.State == QuizAttemptStateInProgress && attempt.TimeCheckState != nil {
+		return attempt.TimeCheckState
+	}
+
+	var deadline *time.Time
+	if quiz.TimeLimit > 0 {
+		t := attempt.TimeStart.Add(time.Duration(quiz.TimeLimit) * time.Second)
+		deadline = &t
+	}
+	if quiz.TimeClose.Unix() > 0 && (deadline == nil || quiz.TimeClose.Before(*deadline)) {
+		t := quiz.TimeClose
+		deadline = &t
+	}
+	return deadline
+}
+
+// Deadline returns the time the attempt must be finished by.
+// false is returned if the attempt doesn't have any time limit.
+func (q *QuizAttemptTimer) Deadline() (time.Time, bool) {
+	if q.deadline == nil {
+		return time.Time{}, false
+	}
+	return *q.deadline, true
+}
+
+// SubmissionDeadline returns the deadline including the grace period
+// during which an overdue attempt can still be submitted.
+func (q *QuizAttemptTimer) SubmissionDeadline() (time.Time, bool) {
+	if q.deadline == nil {
+		return time.Time{}, false
+	}
+	return q.deadline.Add(q.gracePeriod), true
+}
+
+// ClockSkew returns the difference between the server clock and the local clock (server - local).
+func (q *QuizAttemptTimer) ClockSkew() time.Duration {
+	return q.clockSkew
+}
+
+// Remaining returns the remaining time until the deadline. It never returns negative duration.
+// false is returned if the attempt doesn't have any time limit.
+func (q *QuizAttemptTimer) Remaining() (time.Duration, bool) {
+	if q.deadline == nil {
+		return 0, false
+	}
+	remaining := q.deadline.Sub(q.serverNow())
+	if remaining < 0 {
+		return 0, true
+	}
+	return remaining, true
+}
+
+// WhenTimeUp blocks until the time runs out and calls f with ctx.
+// It returns ctx.Err() if ctx is done before the deadline, and ErrNoTimeLimit if the attempt doesn't have any time limit.
+func (q *QuizAttemptTimer) WhenTimeUp(ctx context.Context, f func(ctx context.Context) error) error {
+	remaining, ok := q.Remaining()
+	if !ok {
+		return ErrNoTimeLimit
+	}
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return f(ctx)
+	}
+}
+
+// AutoFinish blocks until the time runs out and then finishes the attempt with timeUp = true.
+func (q *QuizAttemptTimer) AutoFinish(ctx context.Context) error {
+	return q.WhenTimeUp(ctx, func(ctx context.Context) error {
+		return q.quizAPI.FinishAttempt(ctx, q.attemptID, true)
+	})
+}
+
+func (q *QuizAttemptTimer) serverNow() time.Time {
+	return q.now().Add(q.clockSkew)
+}
@@ -0,0 +1,181 @@
+package moodle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestClient_NewQuizAttemptTimer(t *testing.T) {
+	t.Parallel()
+
+	serverNow := time.Now().Add(10 * time.Minute).UTC().Truncate(time.Second)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", serverNow.Format(http.TimeFormat))
+		fmt.Fprintf(w, `{"endtime":%d,"isfinished":false,"warnings":[]}`, serverNow.Add(time.Hour).Unix())
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test")
+
+	quiz := &Quiz{ID: 1111, TimeLimit: 3600, OverdueHandling: "graceperiod", GracePeriod: 300}
+	attempt := &QuizAttempt{ID: 2222, State: QuizAttemptStateInProgress, TimeStart: serverNow}
+	got, err := c.NewQuizAttemptTimer(context.Background(), quiz, attempt)
+	if err != nil {
+		t.Fatalf("NewQuizAttemptTimer() error = %v", err)
+	}
+
+	if deadline, _ := got.Deadline(); !deadline.Equal(serverNow.Add(time.Hour)) {
+		t.Errorf("Deadline() = %v, want %v", deadline, serverNow.Add(time.Hour))
+	}
+	if deadline, _ := got.SubmissionDeadline(); !deadline.Equal(serverNow.Add(time.Hour + 5*time.Minute)) {
+		t.Errorf("SubmissionDeadline() = %v, want %v", deadline, serverNow.Add(time.Hour+5*time.Minute))
+	}
+	if skew := got.ClockSkew(); skew < 9*time.Minute || skew > 11*time.Minute {
+		t.Errorf("ClockSkew() = %v, want around %v", skew, 10*time.Minute)
+	}
+	if remaining, _ := got.Remaining(); remaining < 59*time.Minute || remaining > 61*time.Minute {
+		t.Errorf("Remaining() = %v, want around %v", remaining, time.Hour)
+	}
+
+	if _, err := c.NewQuizAttemptTimer(context.Background(), quiz, &QuizAttempt{ID: 2222, State: QuizAttemptStateFinished}); err == nil {
+		t.Errorf("NewQuizAttemptTimer() with finished attempt, error = nil")
+	}
+}
+
+func Test_quizAttemptDeadline(t *testing.T) {
+	t.Parallel()
+
+	timeStart := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	timePtr := func(t time.Time) *time.Time { return &t }
+
+	type args struct {
+		quiz       *Quiz
+		attempt    *QuizAttempt
+		accessInfo *QuizAttemptAccessInformation
+	}
+	tests := []struct {
+		name string
+		args args
+		want *time.Time
+	}{
+		{
+			name: "end time in access information takes precedence",
+			args: args{
+				quiz:       &Quiz{TimeLimit: 3600},
+				attempt:    &QuizAttempt{State: QuizAttemptStateInProgress, TimeStart: timeStart, TimeCheckState: timePtr(timeStart.Add(time.Hour))},
+				accessInfo: &QuizAttemptAccessInformation{EndTime: timePtr(timeStart.Add(2 * time.Hour))},
+			},
+			want: timePtr(timeStart.Add(2 * time.Hour)),
+		},
+		{
+			name: "time check state is used for in progress attempt",
+			args: args{
+				quiz:       &Quiz{TimeLimit: 3600},
+				attempt:    &QuizAttempt{State: QuizAttemptStateInProgress, TimeStart: timeStart, TimeCheckState: timePtr(timeStart.Add(90 * time.Minute))},
+				accessInfo: &QuizAttemptAccessInformation{},
+			},
+			want: timePtr(timeStart.Add(90 * time.Minute)),
+		},
+		{
+			name: "time limit is used when quiz closes later",
+			args: args{
+				quiz:    &Quiz{TimeLimit: 3600, TimeClose: timeStart.Add(24 * time.Hour)},
+				attempt: &QuizAttempt{State: QuizAttemptStateInProgress, TimeStart: timeStart},
+			},
+			want: timePtr(timeStart.Add(time.Hour)),
+		},
+		{
+			name: "quiz close time is used when it comes before time limit",
+			args: args{
+				quiz:    &Quiz{TimeLimit: 3600, TimeClose: timeStart.Add(30 * time.Minute)},
+				attempt: &QuizAttempt{State: QuizAttemptStateInProgress, TimeStart: timeStart},
+			},
+			want: timePtr(timeStart.Add(30 * time.Minute)),
+		},
+		{
+			name: "no deadline without time limit and close time",
+			args: args{
+				quiz:    &Quiz{TimeClose: time.Unix(0, 0)},
+				attempt: &QuizAttempt{State: QuizAttemptStateInProgress, TimeStart: timeStart},
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := quizAttemptDeadline(tt.args.quiz, tt.args.attempt, tt.args.accessInfo)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("quizAttemptDeadline() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestQuizAttemptTimer_AutoFinish(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	newTimer := func(q QuizAPI, deadline *time.Time) *QuizAttemptTimer {
+		return &QuizAttemptTimer{
+			quizAPI:   q,
+			attemptID: 2222,
+			deadline:  deadline,
+			clockSkew: time.Minute,
+			now:       func() time.Time { return now },
+		}
+	}
+
+	t.Run("finishes the attempt when time is up", func(t *testing.T) {
+		t.Parallel()
+
+		var gotQuery url.Values
+		h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			gotQuery = r.Form
+			fmt.Fprintln(w, `{"state":"finished","warnings":[]}`)
+		})
+		s := httptest.NewServer(h)
+		defer s.Close()
+		apiURL, _ := url.Parse(s.URL)
+		q := &quizAPI{&apiClient{httpClient: http.DefaultClient, apiURL: apiURL}}
+
+		// the deadline has already passed on the server clock
+		deadline := now.Add(30 * time.Second)
+		if err := newTimer(q, &deadline).AutoFinish(context.Background()); err != nil {
+			t.Fatalf("AutoFinish() error = %v", err)
+		}
+		if gotQuery.Get("wsfunction") != "mod_quiz_process_attempt" || gotQuery.Get("attemptid") != "2222" || gotQuery.Get("timeup") != "1" {
+			t.Errorf("AutoFinish() query = %v", gotQuery)
+		}
+	})
+
+	t.Run("returns context error when context is done first", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		deadline := now.Add(time.Hour)
+		if err := newTimer(nil, &deadline).AutoFinish(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("AutoFinish() error = %v, want %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("returns ErrNoTimeLimit when attempt has no deadline", func(t *testing.T) {
+		t.Parallel()
+
+		if err := newTimer(nil, nil).AutoFinish(context.Background()); !errors.Is(err, ErrNoTimeLimit) {
+			t.Errorf("AutoFinish() error = %v, want %v", err, ErrNoTimeLimit)
+		}
+	})
+}
@@ -3,13 +3,12 @@ package moodle
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 func mapResponseBodyToStruct(body []byte, to interface{}) error {
-
-	apiError := APIError{}
-	if err := json.Unmarshal(body, &apiError); err == nil && apiError.ErrorCode != "" {
-		return &apiError
+	if apiError := parseAPIError(body); apiError != nil {
+		return apiError
 	}
 
 	if err := json.Unmarshal(body, to); err != nil {
@@ -19,6 +18,15 @@ func mapResponseBodyToStruct(body []byte, to interface{}) error {
 	return nil
 }
 
+// parseAPIError returns the error if the body is an error response, otherwise nil
+func parseAPIError(body []byte) *APIError {
+	apiError := APIError{}
+	if err := json.Unmarshal(body, &apiError); err == nil && apiError.ErrorCode != "" {
+		return &apiError
+	}
+	return nil
+}
+
 func mapStrArrayToQueryParams(key string, strs []string) map[string]string {
 	queries := make(map[string]string)
 	for i, str := range strs {
@@ -46,3 +54,19 @@ func mapBoolToBitStr(b bool) string {
 func mapBitToBool(b int) bool {
 	return b == 1
 }
+
+// moodle returns 0 for unix time which is not set
+func mapUnixToTimePtr(unix int64) *time.Time {
+	if unix <= 0 {
+		return nil
+	}
+	t := time.Unix(unix, 0)
+	return &t
+}
+
+// setIfNotEmpty sets the value to params only if it's not empty, so that moodle uses the default value
+func setIfNotEmpty(params map[string]string, key string, value string) {
+	if value != "" {
+		params[key] = value
+	}
+}
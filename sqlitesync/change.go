@@ -0,0 +1,92 @@
+package sqlitesync
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ChangeKind represents the kind of a change
+type ChangeKind string
+
+const (
+	ChangeKindCreated ChangeKind = "created"
+	ChangeKindUpdated ChangeKind = "updated"
+	ChangeKindDeleted ChangeKind = "deleted"
+)
+
+// Change is a change of the synced data found by a sync.
+// An update is recorded as a change per field.
+type Change struct {
+	// Seq is the position of the change in the feed, which increases monotonically
+	Seq      int64
+	SyncID   int64
+	Entity   Entity
+	EntityID int
+	CourseID int
+	Kind     ChangeKind
+	// Field, Old and New are set for updates, times are formatted in RFC3339
+	Field string
+	Old   string
+	New   string
+	Time  time.Time
+}
+
+// IsNewGrade reports whether the change is a grade given to an ungraded item
+func (c *Change) IsNewGrade() bool {
+	return c.Entity == EntityGradeItem && c.Kind == ChangeKindUpdated && c.Field == "grade" && c.Old == ""
+}
+
+// IsNewQuiz reports whether the change is a quiz added to a course
+func (c *Change) IsNewQuiz() bool {
+	return c.Entity == EntityQuiz && c.Kind == ChangeKindCreated
+}
+
+// IsDeadlineChange reports whether the change is a change of the close time of a quiz
+func (c *Change) IsDeadlineChange() bool {
+	return c.Entity == EntityQuiz && c.Kind == ChangeKindUpdated && c.Field == "time_close"
+}
+
+// String returns the change in the format of "quiz 1 updated: time_close "old" -> "new""
+func (c *Change) String() string {
+	if c.Kind != ChangeKindUpdated {
+		return fmt.Sprintf("%s %d %s", c.Entity, c.EntityID, c.Kind)
+	}
+	return fmt.Sprintf("%s %d %s: %s %q -> %q", c.Entity, c.EntityID, c.Kind, c.Field, c.Old, c.New)
+}
+
+// Changes returns the changes after the seq in order, all the changes are returned if afterSeq is 0.
+// The feed can be consumed incrementally by passing the seq of the last change consumed.
+func Changes(ctx context.Context, db *sql.DB, afterSeq int64) ([]*Change, error) {
+	rows, err := db.QueryContext(ctx, `SELECT seq, sync_id, entity, entity_id, course_id, kind, field, old_value, new_value, created_at
+FROM changes WHERE seq > ? ORDER BY seq`, afterSeq)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*Change
+	for rows.Next() {
+		var change Change
+		var createdAt int64
+		if err := rows.Scan(&change.Seq, &change.SyncID, &change.Entity, &change.EntityID, &change.CourseID,
+			&change.Kind, &change.Field, &change.Old, &change.New, &createdAt); err != nil {
+			return nil, err
+		}
+		change.Time = time.Unix(createdAt, 0)
+		changes = append(changes, &change)
+	}
+	return changes, rows.Err()
+}
+
+func insertChange(ctx context.Context, tx *sql.Tx, change *Change) error {
+	result, err := tx.ExecContext(ctx, `INSERT INTO changes (sync_id, entity, entity_id, course_id, kind, field, old_value, new_value, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		change.SyncID, change.Entity, change.EntityID, change.CourseID, change.Kind, change.Field, change.Old, change.New, change.Time.Unix())
+	if err != nil {
+		return err
+	}
+	change.Seq, err = result.LastInsertId()
+	return err
+}
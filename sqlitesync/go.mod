@@ -0,0 +1,16 @@
+module github.com/k-yomo/moodle/sqlitesync
+
+go 1.21
+
+require (
+	github.com/google/go-cmp v0.5.5
+	github.com/k-yomo/moodle v0.1.0
+	github.com/mattn/go-sqlite3 v1.14.52
+)
+
+require (
+	github.com/PuerkitoBio/goquery v1.6.1 // indirect
+	github.com/andybalholm/cascadia v1.1.0 // indirect
+	golang.org/x/net v0.0.0-20200202094626-16171245cfb2 // indirect
+	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
+)
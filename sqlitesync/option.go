@@ -0,0 +1,47 @@
+package sqlitesync
+
+import "time"
+
+type syncerOptions struct {
+	userID int
+	now    func() time.Time
+}
+
+func newDefaultSyncerOptions() *syncerOptions {
+	return &syncerOptions{
+		now: time.Now,
+	}
+}
+
+// Option is a option to change syncer configuration.
+type Option interface {
+	apply(*syncerOptions)
+}
+
+type optionFunc struct {
+	f func(opts *syncerOptions)
+}
+
+func (o *optionFunc) apply(opts *syncerOptions) {
+	o.f(opts)
+}
+
+func newOptionFunc(f func(opts *syncerOptions)) *optionFunc {
+	return &optionFunc{
+		f: f,
+	}
+}
+
+// WithUserID sets the user whose grades are synced, the user of the token is used by default
+func WithUserID(userID int) Option {
+	return newOptionFunc(func(opts *syncerOptions) {
+		opts.userID = userID
+	})
+}
+
+// WithClock sets the clock used for the time of syncs and changes
+func WithClock(now func() time.Time) Option {
+	return newOptionFunc(func(opts *syncerOptions) {
+		opts.now = now
+	})
+}
@@ -0,0 +1,208 @@
+package sqlitesync
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	// registers sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Entity represents the type of the synced data
+type Entity string
+
+const (
+	EntityCourse    Entity = "course"
+	EntitySection   Entity = "section"
+	EntityModule    Entity = "module"
+	EntityQuiz      Entity = "quiz"
+	EntityAttempt   Entity = "attempt"
+	EntityGradeItem Entity = "grade_item"
+)
+
+// column is a column of the synced data, id, deleted_at and updated_at are added to every table
+type column struct {
+	name    string
+	sqlType string
+	// isTime is true if the value is a unix time, which is formatted in the change feed
+	isTime bool
+}
+
+type table struct {
+	name   string
+	entity Entity
+	// courseColumn is the column of the course ID, empty for courses
+	courseColumn string
+	// timeModifiedColumn is the column of the time the data is modified,
+	// the rows whose time modified is not changed are assumed to be unchanged.
+	timeModifiedColumn string
+	columns            []*column
+}
+
+var (
+	coursesTable = &table{
+		name:   "courses",
+		entity: EntityCourse,
+		columns: []*column{
+			{name: "short_name", sqlType: "TEXT"},
+			{name: "full_name", sqlType: "TEXT"},
+			{name: "category_id", sqlType: "INTEGER"},
+			{name: "start_date", sqlType: "INTEGER", isTime: true},
+			{name: "end_date", sqlType: "INTEGER", isTime: true},
+			{name: "visible", sqlType: "INTEGER"},
+		},
+	}
+	sectionsTable = &table{
+		name:         "sections",
+		entity:       EntitySection,
+		courseColumn: "course_id",
+		columns: []*column{
+			{name: "course_id", sqlType: "INTEGER"},
+			{name: "section", sqlType: "INTEGER"},
+			{name: "name", sqlType: "TEXT"},
+			{name: "visible", sqlType: "INTEGER"},
+		},
+	}
+	modulesTable = &table{
+		name:         "modules",
+		entity:       EntityModule,
+		courseColumn: "course_id",
+		columns: []*column{
+			{name: "course_id", sqlType: "INTEGER"},
+			{name: "section_id", sqlType: "INTEGER"},
+			{name: "name", sqlType: "TEXT"},
+			{name: "mod_name", sqlType: "TEXT"},
+			{name: "instance", sqlType: "INTEGER"},
+			{name: "url", sqlType: "TEXT"},
+			{name: "visible", sqlType: "INTEGER"},
+			{name: "time_modified", sqlType: "INTEGER", isTime: true},
+		},
+	}
+	quizzesTable = &table{
+		name:         "quizzes",
+		entity:       EntityQuiz,
+		courseColumn: "course_id",
+		columns: []*column{
+			{name: "course_id", sqlType: "INTEGER"},
+			{name: "course_module_id", sqlType: "INTEGER"},
+			{name: "name", sqlType: "TEXT"},
+			{name: "time_open", sqlType: "INTEGER", isTime: true},
+			{name: "time_close", sqlType: "INTEGER", isTime: true},
+			{name: "time_limit", sqlType: "INTEGER"},
+			{name: "max_attempts", sqlType: "INTEGER"},
+			{name: "grade", sqlType: "INTEGER"},
+		},
+	}
+	attemptsTable = &table{
+		name:               "attempts",
+		entity:             EntityAttempt,
+		courseColumn:       "course_id",
+		timeModifiedColumn: "time_modified",
+		columns: []*column{
+			{name: "course_id", sqlType: "INTEGER"},
+			{name: "quiz_id", sqlType: "INTEGER"},
+			{name: "attempt", sqlType: "INTEGER"},
+			{name: "state", sqlType: "TEXT"},
+			{name: "time_start", sqlType: "INTEGER", isTime: true},
+			{name: "time_finish", sqlType: "INTEGER", isTime: true},
+			{name: "time_modified", sqlType: "INTEGER", isTime: true},
+			{name: "sum_grades", sqlType: "INTEGER"},
+		},
+	}
+	gradeItemsTable = &table{
+		name:         "grade_items",
+		entity:       EntityGradeItem,
+		courseColumn: "course_id",
+		columns: []*column{
+			{name: "course_id", sqlType: "INTEGER"},
+			{name: "item_name", sqlType: "TEXT"},
+			{name: "item_type", sqlType: "TEXT"},
+			{name: "item_module", sqlType: "TEXT"},
+			{name: "item_instance", sqlType: "INTEGER"},
+			{name: "grade", sqlType: "REAL"},
+			{name: "grade_formatted", sqlType: "TEXT"},
+			{name: "grade_max", sqlType: "INTEGER"},
+			{name: "date_submitted", sqlType: "INTEGER", isTime: true},
+			{name: "date_graded", sqlType: "INTEGER", isTime: true},
+		},
+	}
+
+	tables = []*table{coursesTable, sectionsTable, modulesTable, quizzesTable, attemptsTable, gradeItemsTable}
+)
+
+func (t *table) columnNames() []string {
+	names := make([]string, 0, len(t.columns))
+	for _, c := range t.columns {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+func (t *table) columnIndex(name string) int {
+	for i, c := range t.columns {
+		if c.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *table) createStatement() string {
+	defs := []string{"id INTEGER PRIMARY KEY"}
+	for _, c := range t.columns {
+		defs = append(defs, fmt.Sprintf("%s %s", c.name, c.sqlType))
+	}
+	// deleted_at is set when the data is not returned by the site any more
+	defs = append(defs, "deleted_at INTEGER", "updated_at INTEGER NOT NULL")
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", t.name, strings.Join(defs, ", "))
+}
+
+const (
+	createSyncsTable = `CREATE TABLE IF NOT EXISTS syncs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	started_at INTEGER NOT NULL,
+	finished_at INTEGER NOT NULL
+)`
+	createChangesTable = `CREATE TABLE IF NOT EXISTS changes (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	sync_id INTEGER NOT NULL REFERENCES syncs(id),
+	entity TEXT NOT NULL,
+	entity_id INTEGER NOT NULL,
+	course_id INTEGER NOT NULL,
+	kind TEXT NOT NULL,
+	field TEXT NOT NULL,
+	old_value TEXT NOT NULL,
+	new_value TEXT NOT NULL,
+	created_at INTEGER NOT NULL
+)`
+)
+
+// Open opens the SQLite database at the path
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000", path))
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// migrate creates the tables if they don't exist
+func migrate(ctx context.Context, db *sql.DB) error {
+	statements := []string{createSyncsTable, createChangesTable}
+	for _, t := range tables {
+		statements = append(statements, t.createStatement())
+	}
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("create table: %w", err)
+		}
+	}
+	return nil
+}
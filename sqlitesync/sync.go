@@ -0,0 +1,495 @@
+// Package sqlitesync mirrors the courses, sections, modules, quizzes, attempts and grades of a user into SQLite.
+//
+// Each sync compares the data returned by the site with the stored data, writes only the changed rows
+// and records what changed in the change feed (e.g. a new grade, a new quiz or a changed deadline).
+// The data not returned any more is kept with deleted_at set.
+//
+//	db, _ := sqlitesync.Open("moodle.db")
+//	syncer, _ := sqlitesync.NewSyncer(ctx, db, client)
+//	result, _ := syncer.Sync(ctx)
+//	for _, change := range result.Changes {
+//		if change.IsNewGrade() {
+//			fmt.Println(change)
+//		}
+//	}
+package sqlitesync
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k-yomo/moodle"
+)
+
+// ErrUserMismatch is returned when the database has been synced for another user
+var ErrUserMismatch = errors.New("sqlitesync: database is synced for another user")
+
+// Syncer syncs the data of a user into the database
+type Syncer struct {
+	db     *sql.DB
+	client *moodle.Client
+	userID int
+	now    func() time.Time
+}
+
+// Result is the result of a sync
+type Result struct {
+	SyncID     int64
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Changes    []*Change
+}
+
+// NewSyncer creates a syncer, and creates the tables if they don't exist
+func NewSyncer(ctx context.Context, db *sql.DB, client *moodle.Client, opt ...Option) (*Syncer, error) {
+	opts := newDefaultSyncerOptions()
+	for _, o := range opt {
+		o.apply(opts)
+	}
+	if err := migrate(ctx, db); err != nil {
+		return nil, err
+	}
+	return &Syncer{
+		db:     db,
+		client: client,
+		userID: opts.userID,
+		now:    opts.now,
+	}, nil
+}
+
+// snapshot is the data fetched from the site, the values of the records are in the order of the table columns
+type snapshot struct {
+	userID  int
+	records map[*table]map[int][]interface{}
+	// skippedQuizIDs are the quizzes whose attempts are not fetched
+	skippedQuizIDs map[int]bool
+}
+
+// Sync fetches the data from the site and stores the changes.
+// Nothing is stored if fetching fails.
+func (s *Syncer) Sync(ctx context.Context) (*Result, error) {
+	startedAt := s.now()
+	userID := s.userID
+	if userID == 0 {
+		siteInfo, err := s.client.SiteAPI.GetSiteInfo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get site info: %w", err)
+		}
+		userID = siteInfo.UserID
+	}
+
+	prevUserID, prevStartedAt, err := s.lastSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if prevUserID != 0 && prevUserID != userID {
+		return nil, fmt.Errorf("%w: synced for user %d, syncing for user %d", ErrUserMismatch, prevUserID, userID)
+	}
+	skippableQuizIDs, err := s.skippableQuizIDs(ctx, prevStartedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := s.fetch(ctx, userID, prevStartedAt, skippableQuizIDs)
+	if err != nil {
+		return nil, err
+	}
+	return s.store(ctx, snap, startedAt)
+}
+
+// lastSync returns the user and the start time of the last sync, zero values are returned if it's never synced
+func (s *Syncer) lastSync(ctx context.Context) (int, time.Time, error) {
+	var userID int
+	var startedAt int64
+	err := s.db.QueryRowContext(ctx, "SELECT user_id, started_at FROM syncs ORDER BY id DESC LIMIT 1").Scan(&userID, &startedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return userID, time.Unix(startedAt, 0), nil
+}
+
+// skippableQuizIDs returns the quizzes closed before the last sync without attempts in progress,
+// whose attempts can't be changed by the user any more.
+func (s *Syncer) skippableQuizIDs(ctx context.Context, prevStartedAt time.Time) (map[int]bool, error) {
+	quizIDs := map[int]bool{}
+	if prevStartedAt.IsZero() {
+		return quizIDs, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT q.id FROM quizzes q
+WHERE q.deleted_at IS NULL AND q.time_close IS NOT NULL AND q.time_close < ?
+AND NOT EXISTS (
+	SELECT 1 FROM attempts a WHERE a.quiz_id = q.id AND a.deleted_at IS NULL AND a.state IN (?, ?)
+)`, prevStartedAt.Unix(), moodle.QuizAttemptStateInProgress, moodle.QuizAttemptStateOverdue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var quizID int
+		if err := rows.Scan(&quizID); err != nil {
+			return nil, err
+		}
+		quizIDs[quizID] = true
+	}
+	return quizIDs, rows.Err()
+}
+
+func (s *Syncer) fetch(ctx context.Context, userID int, prevStartedAt time.Time, skippableQuizIDs map[int]bool) (*snapshot, error) {
+	snap := &snapshot{
+		userID:         userID,
+		records:        map[*table]map[int][]interface{}{},
+		skippedQuizIDs: map[int]bool{},
+	}
+	for _, t := range tables {
+		snap.records[t] = map[int][]interface{}{}
+	}
+
+	courses, err := s.client.CourseAPI.GetEnrolledCoursesByTimelineClassification(ctx, moodle.CourseClassificationAll)
+	if err != nil {
+		return nil, fmt.Errorf("get courses: %w", err)
+	}
+	for _, course := range courses {
+		snap.records[coursesTable][course.ID] = []interface{}{
+			course.ShortName, course.FullName, course.CategoryID, course.StartDate, course.EndDate, course.Visible,
+		}
+
+		sections, err := s.client.CourseAPI.GetContents(ctx, course.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get contents of course %d: %w", course.ID, err)
+		}
+		for _, section := range sections {
+			snap.records[sectionsTable][section.ID] = []interface{}{course.ID, section.Section, section.Name, section.Visible}
+			for _, module := range section.Modules {
+				snap.records[modulesTable][module.ID] = []interface{}{
+					course.ID, section.ID, module.Name, module.ModName, module.Instance, module.URL, module.Visible,
+					moduleTimeModified(module),
+				}
+			}
+		}
+
+		quizzes, err := s.client.QuizAPI.GetQuizzesByCourse(ctx, course.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get quizzes of course %d: %w", course.ID, err)
+		}
+		for _, quiz := range quizzes {
+			snap.records[quizzesTable][quiz.ID] = []interface{}{
+				course.ID, quiz.CourseModuleID, quiz.Name, quiz.TimeOpen, quiz.TimeClose, quiz.TimeLimit, quiz.Attempts, quiz.Grade,
+			}
+			// the quiz is fetched again if the close time is extended or removed after the last sync
+			if skippableQuizIDs[quiz.ID] && !isUnsetTime(quiz.TimeClose) && quiz.TimeClose.Before(prevStartedAt) {
+				snap.skippedQuizIDs[quiz.ID] = true
+				continue
+			}
+			attempts, err := s.client.QuizAPI.GetUserAttempts(ctx, quiz.ID)
+			if err != nil {
+				return nil, fmt.Errorf("get attempts of quiz %d: %w", quiz.ID, err)
+			}
+			for _, attempt := range attempts {
+				snap.records[attemptsTable][attempt.ID] = []interface{}{
+					course.ID, quiz.ID, attempt.Attempt, attempt.State, attempt.TimeStart, attempt.TimeFinish, attempt.TimeModified, attempt.SumGrades,
+				}
+			}
+		}
+
+		userGrades, err := s.client.GradeAPI.GetGradeItems(ctx, userID, course.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get grade items of course %d: %w", course.ID, err)
+		}
+		for _, userGrade := range userGrades {
+			if userGrade.UserID != userID {
+				continue
+			}
+			for _, item := range userGrade.GradeItems {
+				// the raw grade is 0 for the ungraded items, which are formatted as "-"
+				var grade interface{}
+				if item.GradeFormatted != "" && item.GradeFormatted != "-" {
+					grade = item.GradeRaw
+				}
+				snap.records[gradeItemsTable][item.ID] = []interface{}{
+					course.ID, item.ItemName, item.ItemType, item.ItemModule, item.ItemInstance, grade, item.GradeFormatted, item.GradeMax,
+					item.GradeDateSubmitted, item.GradeDateGraded,
+				}
+			}
+		}
+	}
+	return snap, nil
+}
+
+// moduleTimeModified returns the latest time the contents of the module are modified
+func moduleTimeModified(module *moodle.CourseModule) *time.Time {
+	var latest *time.Time
+	for _, content := range module.Contents {
+		if content.TimeModified != nil && (latest == nil || content.TimeModified.After(*latest)) {
+			latest = content.TimeModified
+		}
+	}
+	return latest
+}
+
+func (s *Syncer) store(ctx context.Context, snap *snapshot, startedAt time.Time) (*Result, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	finishedAt := s.now()
+	res, err := tx.ExecContext(ctx, "INSERT INTO syncs (user_id, started_at, finished_at) VALUES (?, ?, ?)",
+		snap.userID, startedAt.Unix(), finishedAt.Unix())
+	if err != nil {
+		return nil, err
+	}
+	syncID, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{SyncID: syncID, StartedAt: startedAt, FinishedAt: finishedAt}
+	for _, t := range tables {
+		var untouched func(id int, values []interface{}) bool
+		if t == attemptsTable {
+			quizIndex := t.columnIndex("quiz_id")
+			untouched = func(id int, values []interface{}) bool {
+				quizID, _ := values[quizIndex].(int64)
+				return snap.skippedQuizIDs[int(quizID)]
+			}
+		}
+		changes, err := syncTable(ctx, tx, t, snap.records[t], untouched, finishedAt)
+		if err != nil {
+			return nil, fmt.Errorf("sync %s: %w", t.name, err)
+		}
+		for _, change := range changes {
+			change.SyncID = syncID
+			change.Time = finishedAt
+			if err := insertChange(ctx, tx, change); err != nil {
+				return nil, err
+			}
+		}
+		result.Changes = append(result.Changes, changes...)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// storedRow is a row in the database, the values are normalized with dbValue
+type storedRow struct {
+	values  []interface{}
+	deleted bool
+}
+
+// syncTable writes the fetched records to the table and returns the changes.
+// The stored rows not fetched are marked as deleted unless untouched returns true.
+func syncTable(ctx context.Context, tx *sql.Tx, t *table, records map[int][]interface{}, untouched func(id int, values []interface{}) bool, syncedAt time.Time) ([]*Change, error) {
+	stored, err := loadRows(ctx, tx, t)
+	if err != nil {
+		return nil, err
+	}
+	now := syncedAt.Unix()
+	timeModifiedIndex := t.columnIndex(t.timeModifiedColumn)
+
+	var changes []*Change
+	for _, id := range sortedRecordIDs(records) {
+		values := make([]interface{}, len(t.columns))
+		for i, v := range records[id] {
+			values[i] = dbValue(v)
+		}
+		row, ok := stored[id]
+		switch {
+		case !ok || row.deleted:
+			changes = append(changes, &Change{Entity: t.entity, EntityID: id, CourseID: t.courseID(id, values), Kind: ChangeKindCreated})
+		case timeModifiedIndex >= 0 && values[timeModifiedIndex] != nil &&
+			formatValue(t.columns[timeModifiedIndex], values[timeModifiedIndex]) == formatValue(t.columns[timeModifiedIndex], row.values[timeModifiedIndex]):
+			continue
+		default:
+			fieldChanges := diffRow(t, id, row.values, values)
+			if len(fieldChanges) == 0 {
+				continue
+			}
+			changes = append(changes, fieldChanges...)
+		}
+		if err := upsertRow(ctx, tx, t, id, values, now); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range sortedRowIDs(stored) {
+		row := stored[id]
+		if _, ok := records[id]; ok || row.deleted || (untouched != nil && untouched(id, row.values)) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET deleted_at = ?, updated_at = ? WHERE id = ?", t.name), now, now, id); err != nil {
+			return nil, err
+		}
+		changes = append(changes, &Change{Entity: t.entity, EntityID: id, CourseID: t.courseID(id, row.values), Kind: ChangeKindDeleted})
+	}
+	return changes, nil
+}
+
+func loadRows(ctx context.Context, tx *sql.Tx, t *table) (map[int]*storedRow, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT id, %s, deleted_at FROM %s", strings.Join(t.columnNames(), ", "), t.name))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stored := map[int]*storedRow{}
+	for rows.Next() {
+		var id int
+		var deletedAt sql.NullInt64
+		values := make([]interface{}, len(t.columns))
+		dest := []interface{}{&id}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		dest = append(dest, &deletedAt)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		stored[id] = &storedRow{values: values, deleted: deletedAt.Valid}
+	}
+	return stored, rows.Err()
+}
+
+func upsertRow(ctx context.Context, tx *sql.Tx, t *table, id int, values []interface{}, now int64) error {
+	names := t.columnNames()
+	placeholders := make([]string, len(names))
+	updates := make([]string, len(names))
+	for i, name := range names {
+		placeholders[i] = "?"
+		updates[i] = fmt.Sprintf("%s = excluded.%s", name, name)
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (id, %s, deleted_at, updated_at) VALUES (?, %s, NULL, ?)
+ON CONFLICT(id) DO UPDATE SET %s, deleted_at = NULL, updated_at = excluded.updated_at`,
+		t.name, strings.Join(names, ", "), strings.Join(placeholders, ", "), strings.Join(updates, ", "))
+	args := append([]interface{}{id}, values...)
+	args = append(args, now)
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func diffRow(t *table, id int, old, new []interface{}) []*Change {
+	var changes []*Change
+	for i, c := range t.columns {
+		oldValue, newValue := formatValue(c, old[i]), formatValue(c, new[i])
+		if oldValue == newValue {
+			continue
+		}
+		changes = append(changes, &Change{
+			Entity:   t.entity,
+			EntityID: id,
+			CourseID: t.courseID(id, new),
+			Kind:     ChangeKindUpdated,
+			Field:    c.name,
+			Old:      oldValue,
+			New:      newValue,
+		})
+	}
+	return changes
+}
+
+func (t *table) courseID(id int, values []interface{}) int {
+	if t.courseColumn == "" {
+		return id
+	}
+	courseID, _ := values[t.columnIndex(t.courseColumn)].(int64)
+	return int(courseID)
+}
+
+// isUnsetTime reports whether the time is zero or the unix epoch Moodle uses for unset times
+func isUnsetTime(t time.Time) bool {
+	return t.IsZero() || t.Unix() == 0
+}
+
+// dbValue converts the value to int64, float64, string or nil as stored in SQLite.
+// Zero times (including the unix epoch Moodle uses for unset times) and nil pointers are converted to nil.
+func dbValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int64, float64, string:
+		return v
+	case bool:
+		if v {
+			return int64(1)
+		}
+		return int64(0)
+	case time.Time:
+		if isUnsetTime(v) {
+			return nil
+		}
+		return v.Unix()
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return dbValue(*v)
+	case *string:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *int:
+		if v == nil {
+			return nil
+		}
+		return int64(*v)
+	case *float64:
+		if v == nil {
+			return nil
+		}
+		return *v
+	default:
+		return nil
+	}
+}
+
+// formatValue formats the stored value for comparison and the change feed, times are formatted in RFC3339
+func formatValue(c *column, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case int64:
+		if c.isTime {
+			return time.Unix(v, 0).UTC().Format(time.RFC3339)
+		}
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func sortedRecordIDs(records map[int][]interface{}) []int {
+	ids := make([]int, 0, len(records))
+	for id := range records {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func sortedRowIDs(rows map[int]*storedRow) []int {
+	ids := make([]int, 0, len(rows))
+	for id := range rows {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,234 @@
+package sqlitesync
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/k-yomo/moodle"
+	"github.com/k-yomo/moodle/moodlemock"
+)
+
+// fakeSite holds the data returned by the mocks
+type fakeSite struct {
+	mu       sync.Mutex
+	sections []*moodle.CourseSection
+	quizzes  []*moodle.Quiz
+	attempts map[int][]*moodle.QuizAttempt
+	items    []*moodle.GradeItem
+}
+
+func (f *fakeSite) update(fn func(f *fakeSite)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f)
+}
+
+func newFakeSite(t *testing.T, site *fakeSite) (*moodle.Client, *moodlemock.Mocks) {
+	t.Helper()
+	client, mocks := moodlemock.NewClient()
+	mocks.SiteAPI.GetSiteInfoFunc = func(ctx context.Context) (*moodle.SiteInfo, error) {
+		return &moodle.SiteInfo{UserID: 2}, nil
+	}
+	mocks.CourseAPI.GetEnrolledCoursesByTimelineClassificationFunc = func(ctx context.Context, classification moodle.CourseClassification) ([]*moodle.Course, error) {
+		return []*moodle.Course{{ID: 1, ShortName: "MATH1111", FullName: "Math", Visible: true}}, nil
+	}
+	mocks.CourseAPI.GetContentsFunc = func(ctx context.Context, courseID int) ([]*moodle.CourseSection, error) {
+		site.mu.Lock()
+		defer site.mu.Unlock()
+		return site.sections, nil
+	}
+	mocks.QuizAPI.GetQuizzesByCourseFunc = func(ctx context.Context, courseID int) ([]*moodle.Quiz, error) {
+		site.mu.Lock()
+		defer site.mu.Unlock()
+		return site.quizzes, nil
+	}
+	mocks.QuizAPI.GetUserAttemptsFunc = func(ctx context.Context, quizID int) ([]*moodle.QuizAttempt, error) {
+		site.mu.Lock()
+		defer site.mu.Unlock()
+		return site.attempts[quizID], nil
+	}
+	mocks.GradeAPI.GetGradeItemsFunc = func(ctx context.Context, userID int, courseID int) ([]*moodle.UserGrade, error) {
+		site.mu.Lock()
+		defer site.mu.Unlock()
+		return []*moodle.UserGrade{{CourseID: courseID, UserID: userID, GradeItems: site.items}}, nil
+	}
+	return client, mocks
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	site := &fakeSite{
+		sections: []*moodle.CourseSection{{ID: 10, Name: "Week 1", Section: 1, Visible: true, Modules: []*moodle.CourseModule{
+			{ID: 100, Name: "Slides", ModName: "resource", Visible: true},
+		}}},
+		quizzes: []*moodle.Quiz{
+			{ID: 5, CourseID: 1, Name: "Quiz 1", TimeOpen: time.Unix(0, 0), TimeClose: now.Add(24 * time.Hour), Grade: 10},
+			{ID: 6, CourseID: 1, Name: "Quiz 0", TimeOpen: time.Unix(0, 0), TimeClose: now.Add(-24 * time.Hour), Grade: 10},
+		},
+		attempts: map[int][]*moodle.QuizAttempt{
+			5: {{ID: 50, QuizID: 5, Attempt: 1, State: moodle.QuizAttemptStateInProgress, TimeStart: now, TimeModified: now}},
+		},
+		items: []*moodle.GradeItem{{ID: 7, ItemName: "Quiz 1", ItemType: "mod", GradeFormatted: "-", GradeMax: 10}},
+	}
+	client, mocks := newFakeSite(t, site)
+	db, err := Open(filepath.Join(t.TempDir(), "moodle.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+	syncer, err := NewSyncer(ctx, db, client, WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewSyncer() error = %v", err)
+	}
+
+	ignoreMeta := cmpopts.IgnoreFields(Change{}, "Seq", "SyncID", "Time")
+
+	// first sync
+	result, err := syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	want := []*Change{
+		{Entity: EntityCourse, EntityID: 1, CourseID: 1, Kind: ChangeKindCreated},
+		{Entity: EntitySection, EntityID: 10, CourseID: 1, Kind: ChangeKindCreated},
+		{Entity: EntityModule, EntityID: 100, CourseID: 1, Kind: ChangeKindCreated},
+		{Entity: EntityQuiz, EntityID: 5, CourseID: 1, Kind: ChangeKindCreated},
+		{Entity: EntityQuiz, EntityID: 6, CourseID: 1, Kind: ChangeKindCreated},
+		{Entity: EntityAttempt, EntityID: 50, CourseID: 1, Kind: ChangeKindCreated},
+		{Entity: EntityGradeItem, EntityID: 7, CourseID: 1, Kind: ChangeKindCreated},
+	}
+	if diff := cmp.Diff(result.Changes, want, ignoreMeta); diff != "" {
+		t.Errorf("Sync() changes of first sync (-got, +want)\n%s", diff)
+	}
+
+	// second sync with changes
+	now = now.Add(time.Hour)
+	site.update(func(f *fakeSite) {
+		f.sections[0].Modules = nil
+		f.quizzes[0].TimeClose = f.quizzes[0].TimeClose.Add(24 * time.Hour)
+		f.quizzes = append(f.quizzes, &moodle.Quiz{ID: 8, CourseID: 1, Name: "Quiz 2", Grade: 10})
+		finishedAt := now
+		f.attempts[5][0] = &moodle.QuizAttempt{
+			ID: 50, QuizID: 5, Attempt: 1, State: moodle.QuizAttemptStateFinished,
+			TimeStart: now.Add(-time.Hour), TimeFinish: &finishedAt, TimeModified: now, SumGrades: 8,
+		}
+		f.items[0] = &moodle.GradeItem{ID: 7, ItemName: "Quiz 1", ItemType: "mod", GradeRaw: 8.5, GradeFormatted: "8.50", GradeMax: 10, GradeDateGraded: &finishedAt}
+	})
+	result, err = syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	want = []*Change{
+		{Entity: EntityModule, EntityID: 100, CourseID: 1, Kind: ChangeKindDeleted},
+		{Entity: EntityQuiz, EntityID: 5, CourseID: 1, Kind: ChangeKindUpdated, Field: "time_close", Old: "2021-05-02T00:00:00Z", New: "2021-05-03T00:00:00Z"},
+		{Entity: EntityQuiz, EntityID: 8, CourseID: 1, Kind: ChangeKindCreated},
+		{Entity: EntityAttempt, EntityID: 50, CourseID: 1, Kind: ChangeKindUpdated, Field: "state", Old: "inprogress", New: "finished"},
+		{Entity: EntityAttempt, EntityID: 50, CourseID: 1, Kind: ChangeKindUpdated, Field: "time_finish", Old: "", New: "2021-05-01T01:00:00Z"},
+		{Entity: EntityAttempt, EntityID: 50, CourseID: 1, Kind: ChangeKindUpdated, Field: "time_modified", Old: "2021-05-01T00:00:00Z", New: "2021-05-01T01:00:00Z"},
+		{Entity: EntityAttempt, EntityID: 50, CourseID: 1, Kind: ChangeKindUpdated, Field: "sum_grades", Old: "0", New: "8"},
+		{Entity: EntityGradeItem, EntityID: 7, CourseID: 1, Kind: ChangeKindUpdated, Field: "grade", Old: "", New: "8.5"},
+		{Entity: EntityGradeItem, EntityID: 7, CourseID: 1, Kind: ChangeKindUpdated, Field: "grade_formatted", Old: "-", New: "8.50"},
+		{Entity: EntityGradeItem, EntityID: 7, CourseID: 1, Kind: ChangeKindUpdated, Field: "date_graded", Old: "", New: "2021-05-01T01:00:00Z"},
+	}
+	if diff := cmp.Diff(result.Changes, want, ignoreMeta); diff != "" {
+		t.Errorf("Sync() changes of second sync (-got, +want)\n%s", diff)
+	}
+	var newGrades, newQuizzes, deadlineChanges int
+	for _, change := range result.Changes {
+		if change.IsNewGrade() {
+			newGrades++
+		}
+		if change.IsNewQuiz() {
+			newQuizzes++
+		}
+		if change.IsDeadlineChange() {
+			deadlineChanges++
+		}
+	}
+	if newGrades != 1 || newQuizzes != 1 || deadlineChanges != 1 {
+		t.Errorf("new grades = %d, new quizzes = %d, deadline changes = %d, want 1 each", newGrades, newQuizzes, deadlineChanges)
+	}
+
+	// the attempts of the quiz closed before the last sync are not fetched again
+	attemptCalls := len(mocks.QuizAPI.CallsOf("GetUserAttempts"))
+	now = now.Add(time.Hour)
+	result, err = syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(result.Changes) != 0 {
+		t.Errorf("Sync() changes without updates = %v, want empty", result.Changes)
+	}
+	if got := len(mocks.QuizAPI.CallsOf("GetUserAttempts")) - attemptCalls; got != 2 {
+		t.Errorf("GetUserAttempts() calls = %d, want 2", got)
+	}
+
+	// change feed
+	changes, err := Changes(ctx, db, 7)
+	if err != nil {
+		t.Fatalf("Changes() error = %v", err)
+	}
+	if diff := cmp.Diff(changes, want, ignoreMeta); diff != "" {
+		t.Errorf("Changes() (-got, +want)\n%s", diff)
+	}
+	if changes[0].Seq != 8 || changes[0].SyncID != 2 {
+		t.Errorf("Changes()[0] seq = %d, sync ID = %d, want 8, 2", changes[0].Seq, changes[0].SyncID)
+	}
+
+	// the attempts of the closed quiz are fetched again when the close time is removed
+	attemptCalls = len(mocks.QuizAPI.CallsOf("GetUserAttempts"))
+	now = now.Add(time.Hour)
+	site.update(func(f *fakeSite) {
+		f.quizzes[1].TimeClose = time.Unix(0, 0)
+	})
+	result, err = syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	want = []*Change{
+		{Entity: EntityQuiz, EntityID: 6, CourseID: 1, Kind: ChangeKindUpdated, Field: "time_close", Old: "2021-04-30T00:00:00Z", New: ""},
+	}
+	if diff := cmp.Diff(result.Changes, want, ignoreMeta); diff != "" {
+		t.Errorf("Sync() changes after removing close time (-got, +want)\n%s", diff)
+	}
+	if got := len(mocks.QuizAPI.CallsOf("GetUserAttempts")) - attemptCalls; got != 3 {
+		t.Errorf("GetUserAttempts() calls after removing close time = %d, want 3", got)
+	}
+}
+
+func TestSyncer_Sync_userMismatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client, _ := newFakeSite(t, &fakeSite{})
+	db, err := Open(filepath.Join(t.TempDir(), "moodle.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	syncer, err := NewSyncer(ctx, db, client)
+	if err != nil {
+		t.Fatalf("NewSyncer() error = %v", err)
+	}
+	if _, err := syncer.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	otherSyncer, err := NewSyncer(ctx, db, client, WithUserID(3))
+	if err != nil {
+		t.Fatalf("NewSyncer() error = %v", err)
+	}
+	if _, err := otherSyncer.Sync(ctx); !errors.Is(err, ErrUserMismatch) {
+		t.Errorf("Sync() error = %v, want ErrUserMismatch", err)
+	}
+}
@@ -0,0 +1,40 @@
+package moodle
+
+import (
+	"context"
+)
+
+// TranscriptEntry represents a course total grade with the course it belongs to
+type TranscriptEntry struct {
+	// Course is nil when the course is not listed in the enrolled courses (e.g. hidden course)
+	Course *Course
+	Grade  *CourseGrade
+}
+
+// GetTranscript returns the course total grades of the current user across every enrolled course.
+func (c *Client) GetTranscript(ctx context.Context) ([]*TranscriptEntry, error) {
+	courses, err := c.CourseAPI.GetEnrolledCoursesByTimelineClassification(ctx, CourseClassificationAll)
+	if err != nil {
+		return nil, err
+	}
+	courseGrades, err := c.GradeAPI.GetCourseGrades(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	return mapToTranscriptEntryList(courses, courseGrades), nil
+}
+
+func mapToTranscriptEntryList(courses []*Course, courseGrades []*CourseGrade) []*TranscriptEntry {
+	courseMap := make(map[int]*Course, len(courses))
+	for _, course := range courses {
+		courseMap[course.ID] = course
+	}
+	entries := make([]*TranscriptEntry, 0, len(courseGrades))
+	for _, courseGrade := range courseGrades {
+		entries = append(entries, &TranscriptEntry{
+			Course: courseMap[courseGrade.CourseID],
+			Grade:  courseGrade,
+		})
+	}
+	return entries
+}
@@ -0,0 +1,55 @@
+package moodle
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestClient_GetTranscript(t *testing.T) {
+	t.Parallel()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("wsfunction") {
+		case "core_course_get_enrolled_courses_by_timeline_classification":
+			fmt.Fprintln(w, `{"courses":[{"id":1111,"fullname":"Test Course","shortname":"TC","startdate":1577836800,"enddate":1577836800}],"nextoffset":1}`)
+		case "gradereport_overview_get_course_grades":
+			fmt.Fprintln(w, `{"grades":[{"courseid":1111,"grade":"90.00","rawgrade":"90.00000"},{"courseid":2222,"grade":"-","rawgrade":null}],"warnings":[]}`)
+		default:
+			t.Errorf("unexpected wsfunction: %s", r.URL.Query().Get("wsfunction"))
+		}
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test")
+
+	got, err := c.GetTranscript(context.Background())
+	if err != nil {
+		t.Fatalf("GetTranscript() error = %v", err)
+	}
+	want := []*TranscriptEntry{
+		{
+			Course: &Course{
+				ID:              1111,
+				FullName:        "Test Course",
+				ShortName:       "TC",
+				StartDate:       time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:         time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				FullNameDisplay: "Test Course",
+			},
+			Grade: &CourseGrade{CourseID: 1111, Grade: "90.00", RawGrade: func() *float64 { f := 90.0; return &f }()},
+		},
+		{
+			Grade: &CourseGrade{CourseID: 2222, Grade: "-"},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("GetTranscript() (-got, +want)\n%s", diff)
+	}
+}
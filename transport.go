@@ -0,0 +1,41 @@
+package moodle
+
+import (
+	"context"
+	"net/http"
+)
+
+// TransportMiddleware wraps the transport of the http client, e.g. to instrument requests
+type TransportMiddleware func(next http.RoundTripper) http.RoundTripper
+
+// wrapHTTPClient returns a copy of the http client whose transport is wrapped with the middlewares,
+// the first middleware is the outermost.
+func wrapHTTPClient(httpClient *http.Client, middlewares []TransportMiddleware) *http.Client {
+	if len(middlewares) == 0 {
+		return httpClient
+	}
+	wrapped := *httpClient
+	transport := wrapped.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+	wrapped.Transport = transport
+	return &wrapped
+}
+
+type retryAttemptKey struct{}
+
+// ContextWithRetryAttempt returns the context marking the call as the nth retry of the same call (1 for the first retry),
+// which is used by instrumentation to count retries.
+func ContextWithRetryAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, retryAttemptKey{}, attempt)
+}
+
+// RetryAttempt returns the retry attempt of the call set by ContextWithRetryAttempt, 0 for the first try
+func RetryAttempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(retryAttemptKey{}).(int)
+	return attempt
+}
@@ -0,0 +1,66 @@
+package moodle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithTransportMiddleware(t *testing.T) {
+	t.Parallel()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"sitename":%q}`, r.Header.Get("X-Order"))
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+
+	appendHeader := func(value string) TransportMiddleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				req.Header.Set("X-Order", req.Header.Get("X-Order")+value)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	httpClient := &http.Client{}
+	c, _ := NewClient(context.Background(), serviceURL, "test",
+		WithHTTPClient(httpClient),
+		WithTransportMiddleware(appendHeader("a")),
+		WithTransportMiddleware(appendHeader("b")),
+	)
+
+	got, err := c.SiteAPI.GetSiteInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetSiteInfo() error = %v", err)
+	}
+	if diff := cmp.Diff(got.SiteName, "ab"); diff != "" {
+		t.Errorf("GetSiteInfo().SiteName (-got, +want)\n%s", diff)
+	}
+	if httpClient.Transport != nil {
+		t.Errorf("the given http client is changed")
+	}
+}
+
+func TestRetryAttempt(t *testing.T) {
+	t.Parallel()
+
+	if got := RetryAttempt(context.Background()); got != 0 {
+		t.Errorf("RetryAttempt() = %d, want 0", got)
+	}
+	if got := RetryAttempt(ContextWithRetryAttempt(context.Background(), 2)); got != 2 {
+		t.Errorf("RetryAttempt() = %d, want 2", got)
+	}
+}
@@ -0,0 +1,93 @@
+package moodle
+
+import (
+	"time"
+)
+
+// UserField represents a field to search users by
+type UserField string
+
+const (
+	UserFieldID       UserField = "id"
+	UserFieldIDNumber UserField = "idnumber"
+	UserFieldUsername UserField = "username"
+	UserFieldEmail    UserField = "email"
+)
+
+type User struct {
+	ID              int
+	Username        string
+	Firstname       string
+	Lastname        string
+	Fullname        string
+	Email           string
+	IDNumber        string
+	Auth            string
+	Suspended       bool
+	Confirmed       bool
+	Department      string
+	Institution     string
+	City            string
+	Country         string
+	Lang            string
+	Timezone        string
+	FirstAccess     *time.Time
+	LastAccess      *time.Time
+	ProfileImageURL string
+}
+
+// CreateUserParams represents a user to be created
+type CreateUserParams struct {
+	// Username must be lowercase
+	Username string
+	// Password is not required when CreatePassword is true or Auth doesn't use the password
+	Password string
+	// CreatePassword generates a password and sends it to the user by email
+	CreatePassword bool
+	Auth           string
+	Firstname      string
+	Lastname       string
+	Email          string
+	IDNumber       string
+	Department     string
+	Institution    string
+	City           string
+	Country        string
+	Lang           string
+	Timezone       string
+	Preferences    []*UserPreference
+}
+
+// CreatedUser represents the user created by CreateUsers
+type CreatedUser struct {
+	ID       int
+	Username string
+}
+
+// UpdateUserParams represents a user to be updated.
+// Empty string and nil fields are left unchanged.
+type UpdateUserParams struct {
+	ID          int
+	Username    string
+	Password    string
+	Auth        string
+	Suspended   *bool
+	Firstname   string
+	Lastname    string
+	Email       string
+	IDNumber    string
+	Department  string
+	Institution string
+	City        string
+	Country     string
+	Lang        string
+	Timezone    string
+	Preferences []*UserPreference
+}
+
+// UserPreference represents a user preference, UserID is ignored in CreateUsers and UpdateUsers
+type UserPreference struct {
+	UserID int
+	Name   string
+	Value  string
+}
@@ -1,6 +1,20 @@
 package moodle
 
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
 type UserAPI interface {
+	GetUsersByField(ctx context.Context, field UserField, values []string) ([]*User, error)
+	CreateUsers(ctx context.Context, users []*CreateUserParams) ([]*CreatedUser, error)
+	UpdateUsers(ctx context.Context, users []*UpdateUserParams) error
+	SuspendUsers(ctx context.Context, userIDs []int) error
+	DeleteUsers(ctx context.Context, userIDs []int) error
+	// GetUserPreferences returns the preferences of the user, all preferences are returned if name is empty
+	GetUserPreferences(ctx context.Context, userID int, name string) ([]*UserPreference, error)
+	SetUserPreferences(ctx context.Context, preferences []*UserPreference) error
 }
 
 type userAPI struct {
@@ -10,3 +24,237 @@ type userAPI struct {
 func newUserAPI(apiClient *apiClient) *userAPI {
 	return &userAPI{apiClient}
 }
+
+type userResponse struct {
+	ID              int    `json:"id"`
+	Username        string `json:"username"`
+	Firstname       string `json:"firstname"`
+	Lastname        string `json:"lastname"`
+	Fullname        string `json:"fullname"`
+	Email           string `json:"email"`
+	IDNumber        string `json:"idnumber"`
+	Auth            string `json:"auth"`
+	Suspended       bool   `json:"suspended"`
+	Confirmed       bool   `json:"confirmed"`
+	Department      string `json:"department"`
+	Institution     string `json:"institution"`
+	City            string `json:"city"`
+	Country         string `json:"country"`
+	Lang            string `json:"lang"`
+	Timezone        string `json:"timezone"`
+	FirstAccessUnix int64  `json:"firstaccess"`
+	LastAccessUnix  int64  `json:"lastaccess"`
+	ProfileImageURL string `json:"profileimageurl"`
+}
+
+func (u *userAPI) GetUsersByField(ctx context.Context, field UserField, values []string) ([]*User, error) {
+	params := map[string]string{
+		"wsfunction": "core_user_get_users_by_field",
+		"field":      string(field),
+	}
+	for k, v := range mapStrArrayToQueryParams("values", values) {
+		params[k] = v
+	}
+	var res []*userResponse
+	if err := u.callMoodleFunction(ctx, &res, params); err != nil {
+		return nil, err
+	}
+	return mapToUserList(res), nil
+}
+
+type createdUserResponse struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+func (u *userAPI) CreateUsers(ctx context.Context, users []*CreateUserParams) ([]*CreatedUser, error) {
+	params := map[string]string{
+		"wsfunction": "core_user_create_users",
+	}
+	for i, user := range users {
+		key := func(field string) string { return fmt.Sprintf("users[%d][%s]", i, field) }
+		params[key("username")] = user.Username
+		params[key("firstname")] = user.Firstname
+		params[key("lastname")] = user.Lastname
+		params[key("email")] = user.Email
+		if user.CreatePassword {
+			params[key("createpassword")] = mapBoolToBitStr(user.CreatePassword)
+		}
+		setIfNotEmpty(params, key("password"), user.Password)
+		setIfNotEmpty(params, key("auth"), user.Auth)
+		setIfNotEmpty(params, key("idnumber"), user.IDNumber)
+		setIfNotEmpty(params, key("department"), user.Department)
+		setIfNotEmpty(params, key("institution"), user.Institution)
+		setIfNotEmpty(params, key("city"), user.City)
+		setIfNotEmpty(params, key("country"), user.Country)
+		setIfNotEmpty(params, key("lang"), user.Lang)
+		setIfNotEmpty(params, key("timezone"), user.Timezone)
+		for j, preference := range user.Preferences {
+			params[fmt.Sprintf("users[%d][preferences][%d][type]", i, j)] = preference.Name
+			params[fmt.Sprintf("users[%d][preferences][%d][value]", i, j)] = preference.Value
+		}
+	}
+
+	var res []*createdUserResponse
+	if err := u.postMoodleFunction(ctx, &res, params); err != nil {
+		return nil, err
+	}
+	createdUsers := make([]*CreatedUser, 0, len(res))
+	for _, userRes := range res {
+		createdUsers = append(createdUsers, &CreatedUser{ID: userRes.ID, Username: userRes.Username})
+	}
+	return createdUsers, nil
+}
+
+type updateUsersResponse struct {
+	Warnings Warnings `json:"warnings"`
+}
+
+func (u *userAPI) UpdateUsers(ctx context.Context, users []*UpdateUserParams) error {
+	params := map[string]string{
+		"wsfunction": "core_user_update_users",
+	}
+	for i, user := range users {
+		key := func(field string) string { return fmt.Sprintf("users[%d][%s]", i, field) }
+		params[key("id")] = strconv.Itoa(user.ID)
+		if user.Suspended != nil {
+			params[key("suspended")] = mapBoolToBitStr(*user.Suspended)
+		}
+		setIfNotEmpty(params, key("username"), user.Username)
+		setIfNotEmpty(params, key("password"), user.Password)
+		setIfNotEmpty(params, key("auth"), user.Auth)
+		setIfNotEmpty(params, key("firstname"), user.Firstname)
+		setIfNotEmpty(params, key("lastname"), user.Lastname)
+		setIfNotEmpty(params, key("email"), user.Email)
+		setIfNotEmpty(params, key("idnumber"), user.IDNumber)
+		setIfNotEmpty(params, key("department"), user.Department)
+		setIfNotEmpty(params, key("institution"), user.Institution)
+		setIfNotEmpty(params, key("city"), user.City)
+		setIfNotEmpty(params, key("country"), user.Country)
+		setIfNotEmpty(params, key("lang"), user.Lang)
+		setIfNotEmpty(params, key("timezone"), user.Timezone)
+		for j, preference := range user.Preferences {
+			params[fmt.Sprintf("users[%d][preferences][%d][type]", i, j)] = preference.Name
+			params[fmt.Sprintf("users[%d][preferences][%d][value]", i, j)] = preference.Value
+		}
+	}
+
+	// old versions of moodle return null
+	res := updateUsersResponse{}
+	if err := u.postMoodleFunction(ctx, &res, params); err != nil {
+		return err
+	}
+	if len(res.Warnings) > 0 {
+		return res.Warnings
+	}
+	return nil
+}
+
+func (u *userAPI) SuspendUsers(ctx context.Context, userIDs []int) error {
+	suspended := true
+	users := make([]*UpdateUserParams, 0, len(userIDs))
+	for _, userID := range userIDs {
+		users = append(users, &UpdateUserParams{ID: userID, Suspended: &suspended})
+	}
+	return u.UpdateUsers(ctx, users)
+}
+
+func (u *userAPI) DeleteUsers(ctx context.Context, userIDs []int) error {
+	params := map[string]string{
+		"wsfunction": "core_user_delete_users",
+	}
+	for i, userID := range userIDs {
+		params[fmt.Sprintf("userids[%d]", i)] = strconv.Itoa(userID)
+	}
+	var res interface{}
+	return u.postMoodleFunction(ctx, &res, params)
+}
+
+type getUserPreferencesResponse struct {
+	Preferences []*struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"preferences"`
+	Warnings Warnings `json:"warnings"`
+}
+
+func (u *userAPI) GetUserPreferences(ctx context.Context, userID int, name string) ([]*UserPreference, error) {
+	params := map[string]string{
+		"wsfunction": "core_user_get_user_preferences",
+		"userid":     strconv.Itoa(userID),
+	}
+	setIfNotEmpty(params, "name", name)
+
+	res := getUserPreferencesResponse{}
+	if err := u.callMoodleFunction(ctx, &res, params); err != nil {
+		return nil, err
+	}
+	if len(res.Warnings) > 0 {
+		return nil, res.Warnings
+	}
+	preferences := make([]*UserPreference, 0, len(res.Preferences))
+	for _, preferenceRes := range res.Preferences {
+		preferences = append(preferences, &UserPreference{
+			UserID: userID,
+			Name:   preferenceRes.Name,
+			Value:  preferenceRes.Value,
+		})
+	}
+	return preferences, nil
+}
+
+type setUserPreferencesResponse struct {
+	Saved []*struct {
+		Name   string `json:"name"`
+		UserID int    `json:"userid"`
+	} `json:"saved"`
+	Warnings Warnings `json:"warnings"`
+}
+
+func (u *userAPI) SetUserPreferences(ctx context.Context, preferences []*UserPreference) error {
+	params := map[string]string{
+		"wsfunction": "core_user_set_user_preferences",
+	}
+	for i, preference := range preferences {
+		params[fmt.Sprintf("preferences[%d][name]", i)] = preference.Name
+		params[fmt.Sprintf("preferences[%d][value]", i)] = preference.Value
+		params[fmt.Sprintf("preferences[%d][userid]", i)] = strconv.Itoa(preference.UserID)
+	}
+
+	res := setUserPreferencesResponse{}
+	if err := u.postMoodleFunction(ctx, &res, params); err != nil {
+		return err
+	}
+	if len(res.Warnings) > 0 {
+		return res.Warnings
+	}
+	return nil
+}
+
+func mapToUserList(userResList []*userResponse) []*User {
+	users := make([]*User, 0, len(userResList))
+	for _, userRes := range userResList {
+		users = append(users, &User{
+			ID:              userRes.ID,
+			Username:        userRes.Username,
+			Firstname:       userRes.Firstname,
+			Lastname:        userRes.Lastname,
+			Fullname:        userRes.Fullname,
+			Email:           userRes.Email,
+			IDNumber:        userRes.IDNumber,
+			Auth:            userRes.Auth,
+			Suspended:       userRes.Suspended,
+			Confirmed:       userRes.Confirmed,
+			Department:      userRes.Department,
+			Institution:     userRes.Institution,
+			City:            userRes.City,
+			Country:         userRes.Country,
+			Lang:            userRes.Lang,
+			Timezone:        userRes.Timezone,
+			FirstAccess:     mapUnixToTimePtr(userRes.FirstAccessUnix),
+			LastAccess:      mapUnixToTimePtr(userRes.LastAccessUnix),
+			ProfileImageURL: userRes.ProfileImageURL,
+		})
+	}
+	return users
+}
@@ -0,0 +1,321 @@
+package moodle
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_userAPI_GetUsersByField(t *testing.T) {
+	t.Parallel()
+
+	lastAccess := time.Unix(1609459200, 0)
+
+	tests := []struct {
+		name     string
+		response string
+		want     []*User
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `[{"id":123456,"username":"alice","firstname":"Alice","lastname":"Smith","fullname":"Alice Smith","email":"alice@example.com","idnumber":"s001","auth":"manual","suspended":false,"confirmed":true,"department":"","institution":"","city":"Tokyo","country":"JP","lang":"en","timezone":"99","firstaccess":0,"lastaccess":1609459200,"profileimageurl":"https://example.com/pluginfile.php/5/user/icon/f1"}]`,
+			want: []*User{
+				{
+					ID:              123456,
+					Username:        "alice",
+					Firstname:       "Alice",
+					Lastname:        "Smith",
+					Fullname:        "Alice Smith",
+					Email:           "alice@example.com",
+					IDNumber:        "s001",
+					Auth:            "manual",
+					Confirmed:       true,
+					City:            "Tokyo",
+					Country:         "JP",
+					Lang:            "en",
+					Timezone:        "99",
+					LastAccess:      &lastAccess,
+					ProfileImageURL: "https://example.com/pluginfile.php/5/user/icon/f1",
+				},
+			},
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"invalid_parameter_exception","errorcode":"invalidparameter","message":"Invalid parameter value detected"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			u := mockUserAPI(t, tt.response)
+			got, err := u.GetUsersByField(context.Background(), UserFieldUsername, []string{"alice"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetUsersByField() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetUsersByField() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_userAPI_CreateUsers(t *testing.T) {
+	t.Parallel()
+
+	var gotMethod string
+	var gotQuery, gotURLQuery url.Values
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotURLQuery = r.Method, r.URL.Query()
+		r.ParseForm()
+		gotQuery = r.Form
+		fmt.Fprintln(w, `[{"id":123456,"username":"alice"}]`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	apiURL, _ := url.Parse(s.URL)
+	u := &userAPI{&apiClient{httpClient: http.DefaultClient, apiURL: apiURL}}
+
+	got, err := u.CreateUsers(context.Background(), []*CreateUserParams{
+		{
+			Username:       "alice",
+			CreatePassword: true,
+			Firstname:      "Alice",
+			Lastname:       "Smith",
+			Email:          "alice@example.com",
+			Preferences:    []*UserPreference{{Name: "auth_forcepasswordchange", Value: "1"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateUsers() error = %v", err)
+	}
+	if diff := cmp.Diff(got, []*CreatedUser{{ID: 123456, Username: "alice"}}); diff != "" {
+		t.Errorf("CreateUsers() (-got, +want)\n%s", diff)
+	}
+	wantQuery := map[string]string{
+		"users[0][username]":              "alice",
+		"users[0][createpassword]":        "1",
+		"users[0][email]":                 "alice@example.com",
+		"users[0][preferences][0][type]":  "auth_forcepasswordchange",
+		"users[0][preferences][0][value]": "1",
+	}
+	for k, v := range wantQuery {
+		if gotQuery.Get(k) != v {
+			t.Errorf("CreateUsers() query %s = %q, want %q", k, gotQuery.Get(k), v)
+		}
+	}
+	if _, ok := gotQuery["users[0][password]"]; ok {
+		t.Errorf("CreateUsers() sent empty password")
+	}
+	// the users are sent in the form body not to leave them in the access logs
+	if gotMethod != http.MethodPost || gotURLQuery.Get("users[0][username]") != "" {
+		t.Errorf("CreateUsers() method = %s, url query = %v, want POST with the users in the body", gotMethod, gotURLQuery)
+	}
+}
+
+func Test_userAPI_UpdateUsers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"warnings":[]}`,
+		},
+		{
+			name:     "Successful null response",
+			response: "null",
+		},
+		{
+			name:     "Warning response",
+			response: `{"warnings":[{"item":"user","itemid":123456,"warningcode":"usernotupdated","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"invalid_parameter_exception","errorcode":"invalidparameter","message":"Invalid parameter value detected"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			u := mockUserAPI(t, tt.response)
+			if err := u.UpdateUsers(context.Background(), []*UpdateUserParams{{ID: 123456, City: "Osaka"}}); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateUsers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_userAPI_SuspendUsers(t *testing.T) {
+	t.Parallel()
+
+	var gotQuery url.Values
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		gotQuery = r.Form
+		fmt.Fprintln(w, `{"warnings":[]}`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	apiURL, _ := url.Parse(s.URL)
+	u := &userAPI{&apiClient{httpClient: http.DefaultClient, apiURL: apiURL}}
+
+	if err := u.SuspendUsers(context.Background(), []int{123456, 123457}); err != nil {
+		t.Fatalf("SuspendUsers() error = %v", err)
+	}
+	if gotQuery.Get("wsfunction") != "core_user_update_users" ||
+		gotQuery.Get("users[1][id]") != "123457" || gotQuery.Get("users[1][suspended]") != "1" {
+		t.Errorf("SuspendUsers() query = %v", gotQuery)
+	}
+}
+
+func Test_userAPI_DeleteUsers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: "null",
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"moodle_exception","errorcode":"nopermissions","message":"Sorry, but you do not currently have permissions to do that"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			u := mockUserAPI(t, tt.response)
+			if err := u.DeleteUsers(context.Background(), []int{123456}); (err != nil) != tt.wantErr {
+				t.Errorf("DeleteUsers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_userAPI_GetUserPreferences(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		want     []*UserPreference
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"preferences":[{"name":"auth_forcepasswordchange","value":"0"},{"name":"email_bounce_count","value":"1"}],"warnings":[]}`,
+			want: []*UserPreference{
+				{UserID: 123456, Name: "auth_forcepasswordchange", Value: "0"},
+				{UserID: 123456, Name: "email_bounce_count", Value: "1"},
+			},
+		},
+		{
+			name:     "Warning response",
+			response: `{"preferences":[],"warnings":[{"item":"user","itemid":123456,"warningcode":"1","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid json response",
+			response: "{",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			u := mockUserAPI(t, tt.response)
+			got, err := u.GetUserPreferences(context.Background(), 123456, "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetUserPreferences() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetUserPreferences() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_userAPI_SetUserPreferences(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "Successful response",
+			response: `{"saved":[{"name":"auth_forcepasswordchange","userid":123456}],"warnings":[]}`,
+		},
+		{
+			name:     "Warning response",
+			response: `{"saved":[],"warnings":[{"item":"user","itemid":123456,"warningcode":"nopermission","message":"test warning"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Error response",
+			response: `{"exception":"invalid_parameter_exception","errorcode":"invalidparameter","message":"Invalid parameter value detected"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			u := mockUserAPI(t, tt.response)
+			err := u.SetUserPreferences(context.Background(), []*UserPreference{{UserID: 123456, Name: "auth_forcepasswordchange", Value: "1"}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetUserPreferences() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mockUserAPI(t *testing.T, response string) *userAPI {
+	t.Helper()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, response)
+	})
+	s := httptest.NewServer(h)
+	apiURL, _ := url.Parse(s.URL)
+	return &userAPI{
+		&apiClient{
+			httpClient: http.DefaultClient,
+			apiURL:     apiURL,
+		},
+	}
+}
@@ -0,0 +1,242 @@
+package moodle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// DefaultUserCreateBatchSize is the number of users sent in one request when batch size is not specified
+const DefaultUserCreateBatchSize = 50
+
+// DefaultUserUpdateBatchSize is the number of users updated, suspended or deleted in one request
+// when batch size is not specified
+const DefaultUserUpdateBatchSize = 50
+
+var (
+	ErrInvalidUsername = errors.New("username must be non-empty lowercase and consist of alphanumeric characters, '_', '-', '.' or '@'")
+	ErrInvalidEmail    = errors.New("email is invalid")
+	ErrUsernameTaken   = errors.New("username is already taken")
+	ErrEmailTaken      = errors.New("email is already used")
+)
+
+var usernameRegexp = regexp.MustCompile(`^[a-z0-9_\-.@]+$`)
+
+// BulkCreateUsersOptions represents options for BulkCreateUsers
+type BulkCreateUsersOptions struct {
+	// BatchSize is the number of users sent in one request, DefaultUserCreateBatchSize is used if it's 0
+	BatchSize int
+	// DryRun only validates users without creating them
+	DryRun bool
+	// AllowDuplicateEmails skips the email uniqueness check, set it if allowaccountssameemail is enabled on the site
+	AllowDuplicateEmails bool
+}
+
+// UserCreateReport represents the result of BulkCreateUsers
+type UserCreateReport struct {
+	CreatedUsers []*CreatedUser
+	// ValidUsernames are the usernames passed the validation in dry run
+	ValidUsernames []string
+	Failures       []*UserCreateFailure
+}
+
+// UserCreateFailure represents a user failed to be validated or created
+type UserCreateFailure struct {
+	Username string
+	Err      error
+}
+
+// HasFailures returns true if any user failed to be validated or created
+func (u *UserCreateReport) HasFailures() bool {
+	return len(u.Failures) > 0
+}
+
+// BulkCreateUsers creates users splitting them into chunks of opts.BatchSize.
+// The users are sent in the form body of POST requests, so that the passwords are not left in the access logs.
+// When a chunk fails with an error response, the users in the chunk are created one by one to find out which users failed,
+// since moodle rolls back the whole request on an error.
+// In dry run, usernames and emails are validated and checked against existing users and each other instead.
+// Failures of each user are aggregated into the report, and error is returned only when ctx is done
+// or existing users can't be fetched in dry run.
+func (c *Client) BulkCreateUsers(ctx context.Context, users []*CreateUserParams, opts *BulkCreateUsersOptions) (*UserCreateReport, error) {
+	if opts == nil {
+		opts = &BulkCreateUsersOptions{}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultUserCreateBatchSize
+	}
+	if opts.DryRun {
+		return c.validateUsersToCreate(ctx, users, batchSize, opts.AllowDuplicateEmails)
+	}
+
+	report := &UserCreateReport{}
+	err := callInChunks(ctx, len(users), batchSize, func(start, end int) error {
+		createdUsers, err := c.UserAPI.CreateUsers(ctx, users[start:end])
+		if err == nil {
+			report.CreatedUsers = append(report.CreatedUsers, createdUsers...)
+		}
+		return err
+	}, func(i int, err error) {
+		report.Failures = append(report.Failures, &UserCreateFailure{Username: users[i].Username, Err: err})
+	})
+	return report, err
+}
+
+// UserUpdateReport represents the result of BulkUpdateUsers, BulkSuspendUsers and BulkDeleteUsers
+type UserUpdateReport struct {
+	UpdatedUserIDs []int
+	Failures       []*UserUpdateFailure
+}
+
+// UserUpdateFailure represents a user failed to be updated, suspended or deleted
+type UserUpdateFailure struct {
+	UserID int
+	Err    error
+}
+
+// HasFailures returns true if any user failed to be updated, suspended or deleted
+func (u *UserUpdateReport) HasFailures() bool {
+	return len(u.Failures) > 0
+}
+
+// BulkUpdateUsers updates users splitting them into chunks of batchSize in the same way as BulkCreateUsers.
+// The users with warnings in the response are reported as failures without retrying, since the others are updated.
+// Failures of each user are aggregated into the report, and error is returned only when ctx is done.
+func (c *Client) BulkUpdateUsers(ctx context.Context, users []*UpdateUserParams, batchSize int) (*UserUpdateReport, error) {
+	userIDs := make([]int, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	return c.bulkUpdateUsers(ctx, userIDs, batchSize, func(start, end int) error {
+		return c.UserAPI.UpdateUsers(ctx, users[start:end])
+	})
+}
+
+// BulkSuspendUsers suspends users splitting them into chunks of batchSize in the same way as BulkUpdateUsers
+func (c *Client) BulkSuspendUsers(ctx context.Context, userIDs []int, batchSize int) (*UserUpdateReport, error) {
+	return c.bulkUpdateUsers(ctx, userIDs, batchSize, func(start, end int) error {
+		return c.UserAPI.SuspendUsers(ctx, userIDs[start:end])
+	})
+}
+
+// BulkDeleteUsers deletes users splitting them into chunks of batchSize.
+// When a chunk fails with an error response, the users in the chunk are deleted one by one to find out which users failed.
+// Failures of each user are aggregated into the report, and error is returned only when ctx is done.
+func (c *Client) BulkDeleteUsers(ctx context.Context, userIDs []int, batchSize int) (*UserUpdateReport, error) {
+	return c.bulkUpdateUsers(ctx, userIDs, batchSize, func(start, end int) error {
+		return c.UserAPI.DeleteUsers(ctx, userIDs[start:end])
+	})
+}
+
+// bulkUpdateUsers calls call for the chunks of the users and reports the results by user ID
+func (c *Client) bulkUpdateUsers(ctx context.Context, userIDs []int, batchSize int, call func(start, end int) error) (*UserUpdateReport, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultUserUpdateBatchSize
+	}
+
+	report := &UserUpdateReport{}
+	err := callInChunks(ctx, len(userIDs), batchSize, func(start, end int) error {
+		err := call(start, end)
+		var warnings Warnings
+		if err != nil && !errors.As(err, &warnings) {
+			return err
+		}
+		warned := make(map[int]*Warning)
+		for _, warning := range warnings {
+			warned[warning.ItemID] = warning
+		}
+		for _, userID := range userIDs[start:end] {
+			if warning, ok := warned[userID]; ok {
+				report.Failures = append(report.Failures, &UserUpdateFailure{UserID: userID, Err: Warnings{warning}})
+				continue
+			}
+			report.UpdatedUserIDs = append(report.UpdatedUserIDs, userID)
+		}
+		return nil
+	}, func(i int, err error) {
+		report.Failures = append(report.Failures, &UserUpdateFailure{UserID: userIDs[i], Err: err})
+	})
+	return report, err
+}
+
+func (c *Client) validateUsersToCreate(ctx context.Context, users []*CreateUserParams, batchSize int, allowDuplicateEmails bool) (*UserCreateReport, error) {
+	var usernames, emails []string
+	for _, user := range users {
+		usernames = append(usernames, user.Username)
+		emails = append(emails, user.Email)
+	}
+	existingUsernames, err := c.findExistingUserFieldValues(ctx, UserFieldUsername, usernames, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	existingEmails := make(map[string]bool)
+	if !allowDuplicateEmails {
+		existingEmails, err = c.findExistingUserFieldValues(ctx, UserFieldEmail, emails, batchSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report := &UserCreateReport{}
+	seenUsernames := make(map[string]bool)
+	seenEmails := make(map[string]bool)
+	for _, user := range users {
+		email := strings.ToLower(user.Email)
+		var err error
+		switch {
+		case !usernameRegexp.MatchString(user.Username):
+			err = ErrInvalidUsername
+		case existingUsernames[user.Username]:
+			err = ErrUsernameTaken
+		case seenUsernames[user.Username]:
+			err = fmt.Errorf("%w: duplicated in the input", ErrUsernameTaken)
+		case !isValidEmail(user.Email):
+			err = ErrInvalidEmail
+		case !allowDuplicateEmails && existingEmails[email]:
+			err = ErrEmailTaken
+		case !allowDuplicateEmails && seenEmails[email]:
+			err = fmt.Errorf("%w: duplicated in the input", ErrEmailTaken)
+		}
+		seenUsernames[user.Username] = true
+		seenEmails[email] = true
+		if err != nil {
+			report.Failures = append(report.Failures, &UserCreateFailure{Username: user.Username, Err: err})
+			continue
+		}
+		report.ValidUsernames = append(report.ValidUsernames, user.Username)
+	}
+	return report, nil
+}
+
+// findExistingUserFieldValues returns the lowercased values of the field used by existing users
+func (c *Client) findExistingUserFieldValues(ctx context.Context, field UserField, values []string, batchSize int) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	for start := 0; start < len(values); start += batchSize {
+		end := start + batchSize
+		if end > len(values) {
+			end = len(values)
+		}
+		users, err := c.UserAPI.GetUsersByField(ctx, field, values[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			switch field {
+			case UserFieldUsername:
+				existing[strings.ToLower(user.Username)] = true
+			case UserFieldEmail:
+				existing[strings.ToLower(user.Email)] = true
+			}
+		}
+	}
+	return existing, nil
+}
+
+func isValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
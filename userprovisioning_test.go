@@ -0,0 +1,282 @@
+package moodle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestClient_BulkCreateUsers(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var requestCount int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestCount++
+		mu.Unlock()
+
+		// username "taken" already exists
+		r.ParseForm()
+		q := r.Form
+		var created []string
+		for i := 0; q.Get(fmt.Sprintf("users[%d][username]", i)) != ""; i++ {
+			username := q.Get(fmt.Sprintf("users[%d][username]", i))
+			if username == "taken" {
+				fmt.Fprintln(w, `{"exception":"invalid_parameter_exception","errorcode":"invalidparameter","message":"Username already exists: taken"}`)
+				return
+			}
+			created = append(created, fmt.Sprintf(`{"id":%d,"username":%q}`, 100+i, username))
+		}
+		fmt.Fprintf(w, "[%s]\n", strings.Join(created, ","))
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test")
+
+	users := []*CreateUserParams{
+		{Username: "alice", Email: "alice@example.com"},
+		{Username: "taken", Email: "taken@example.com"},
+		{Username: "bob", Email: "bob@example.com"},
+	}
+	got, err := c.BulkCreateUsers(context.Background(), users, &BulkCreateUsersOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("BulkCreateUsers() error = %v", err)
+	}
+	var gotUsernames []string
+	for _, user := range got.CreatedUsers {
+		gotUsernames = append(gotUsernames, user.Username)
+	}
+	if diff := cmp.Diff(gotUsernames, []string{"alice", "bob"}); diff != "" {
+		t.Errorf("BulkCreateUsers() CreatedUsers (-got, +want)\n%s", diff)
+	}
+	if len(got.Failures) != 1 || got.Failures[0].Username != "taken" || Code(got.Failures[0].Err) != "invalidparameter" {
+		t.Errorf("BulkCreateUsers() Failures = %v, want failure of taken", got.Failures)
+	}
+	// 2 chunks + 2 retries for the failed chunk
+	if requestCount != 4 {
+		t.Errorf("BulkCreateUsers() request count = %d, want %d", requestCount, 4)
+	}
+}
+
+func TestClient_BulkCreateUsers_DryRun(t *testing.T) {
+	t.Parallel()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		q := r.Form
+		switch {
+		case q.Get("wsfunction") != "core_user_get_users_by_field":
+			t.Errorf("BulkCreateUsers() called %s in dry run", q.Get("wsfunction"))
+			fmt.Fprintln(w, "[]")
+		case q.Get("field") == "username":
+			fmt.Fprintln(w, `[{"id":1,"username":"taken","email":"other@example.com"}]`)
+		default:
+			fmt.Fprintln(w, `[{"id":2,"username":"someone","email":"used@example.com"}]`)
+		}
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test")
+
+	users := []*CreateUserParams{
+		{Username: "alice", Email: "alice@example.com"},
+		{Username: "taken", Email: "taken@example.com"},
+		{Username: "Bob", Email: "bob@example.com"},
+		{Username: "carol", Email: "Used@example.com"},
+		{Username: "dave", Email: "dave"},
+		{Username: "alice", Email: "alice2@example.com"},
+		{Username: "erin", Email: "alice@example.com"},
+	}
+	got, err := c.BulkCreateUsers(context.Background(), users, &BulkCreateUsersOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("BulkCreateUsers() error = %v", err)
+	}
+	if diff := cmp.Diff(got.ValidUsernames, []string{"alice"}); diff != "" {
+		t.Errorf("BulkCreateUsers() ValidUsernames (-got, +want)\n%s", diff)
+	}
+	wantErrs := map[string]error{
+		"taken": ErrUsernameTaken,
+		"Bob":   ErrInvalidUsername,
+		"carol": ErrEmailTaken,
+		"dave":  ErrInvalidEmail,
+		"alice": ErrUsernameTaken,
+		"erin":  ErrEmailTaken,
+	}
+	if len(got.Failures) != len(wantErrs) {
+		t.Errorf("BulkCreateUsers() Failures = %v, want %d failures", got.Failures, len(wantErrs))
+	}
+	for _, failure := range got.Failures {
+		if !errors.Is(failure.Err, wantErrs[failure.Username]) {
+			t.Errorf("BulkCreateUsers() failure of %s = %v, want %v", failure.Username, failure.Err, wantErrs[failure.Username])
+		}
+	}
+	if len(got.CreatedUsers) != 0 {
+		t.Errorf("BulkCreateUsers() CreatedUsers = %v in dry run", got.CreatedUsers)
+	}
+}
+
+func TestClient_BulkUpdateUsers(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var requestCount int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestCount++
+		mu.Unlock()
+
+		// user 13 doesn't exist, and the others are updated
+		r.ParseForm()
+		q := r.Form
+		for i := 0; q.Get(fmt.Sprintf("users[%d][id]", i)) != ""; i++ {
+			if q.Get(fmt.Sprintf("users[%d][id]", i)) == "13" {
+				fmt.Fprintln(w, `{"warnings":[{"item":"user","itemid":13,"warningcode":"invaliduserid","message":"Invalid user ID"}]}`)
+				return
+			}
+		}
+		fmt.Fprintln(w, `{"warnings":[]}`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test")
+
+	users := []*UpdateUserParams{{ID: 11, Email: "alice@example.com"}, {ID: 13, Email: "nobody@example.com"}, {ID: 12, Email: "bob@example.com"}}
+	got, err := c.BulkUpdateUsers(context.Background(), users, 2)
+	if err != nil {
+		t.Fatalf("BulkUpdateUsers() error = %v", err)
+	}
+	if diff := cmp.Diff(got.UpdatedUserIDs, []int{11, 12}); diff != "" {
+		t.Errorf("BulkUpdateUsers() UpdatedUserIDs (-got, +want)\n%s", diff)
+	}
+	var warnings Warnings
+	if len(got.Failures) != 1 || got.Failures[0].UserID != 13 || !errors.As(got.Failures[0].Err, &warnings) {
+		t.Errorf("BulkUpdateUsers() Failures = %v, want warning of user 13", got.Failures)
+	}
+	// the users with warnings are not retried since the others are updated
+	if requestCount != 2 {
+		t.Errorf("BulkUpdateUsers() request count = %d, want %d", requestCount, 2)
+	}
+}
+
+func TestClient_BulkSuspendUsers(t *testing.T) {
+	t.Parallel()
+
+	var gotSuspended []string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		q := r.Form
+		if q.Get("wsfunction") != "core_user_update_users" {
+			t.Errorf("BulkSuspendUsers() called %s", q.Get("wsfunction"))
+		}
+		for i := 0; q.Get(fmt.Sprintf("users[%d][id]", i)) != ""; i++ {
+			gotSuspended = append(gotSuspended, q.Get(fmt.Sprintf("users[%d][id]", i))+":"+q.Get(fmt.Sprintf("users[%d][suspended]", i)))
+		}
+		fmt.Fprintln(w, `{"warnings":[]}`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	serviceURL, _ := url.Parse(s.URL)
+	c, _ := NewClient(context.Background(), serviceURL, "test")
+
+	got, err := c.BulkSuspendUsers(context.Background(), []int{11, 12, 13}, 2)
+	if err != nil {
+		t.Fatalf("BulkSuspendUsers() error = %v", err)
+	}
+	if diff := cmp.Diff(got.UpdatedUserIDs, []int{11, 12, 13}); diff != "" {
+		t.Errorf("BulkSuspendUsers() UpdatedUserIDs (-got, +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(gotSuspended, []string{"11:1", "12:1", "13:1"}); diff != "" {
+		t.Errorf("BulkSuspendUsers() sent users (-got, +want)\n%s", diff)
+	}
+}
+
+func TestClient_BulkDeleteUsers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		status           int
+		wantDeletedIDs   []int
+		wantFailedIDs    []int
+		wantRequestCount int
+	}{
+		{
+			name:           "Failed user is found by deleting the chunk one by one",
+			status:         http.StatusOK,
+			wantDeletedIDs: []int{11, 12},
+			wantFailedIDs:  []int{13},
+			// 2 chunks + 2 retries for the failed chunk
+			wantRequestCount: 4,
+		},
+		{
+			name:          "Chunks are not retried one by one on server errors",
+			status:        http.StatusInternalServerError,
+			wantFailedIDs: []int{11, 13, 12},
+			// the chunks only
+			wantRequestCount: 2,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var mu sync.Mutex
+			var requestCount int
+			h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requestCount++
+				mu.Unlock()
+
+				if tt.status != http.StatusOK {
+					w.WriteHeader(tt.status)
+					fmt.Fprintln(w, "Internal Server Error")
+					return
+				}
+				// user 13 can't be deleted, which rolls back the whole request
+				r.ParseForm()
+				q := r.Form
+				for i := 0; q.Get(fmt.Sprintf("userids[%d]", i)) != ""; i++ {
+					if q.Get(fmt.Sprintf("userids[%d]", i)) == "13" {
+						fmt.Fprintln(w, `{"exception":"moodle_exception","errorcode":"invaliduser","message":"Invalid user"}`)
+						return
+					}
+				}
+				fmt.Fprintln(w, "null")
+			})
+			s := httptest.NewServer(h)
+			defer s.Close()
+			serviceURL, _ := url.Parse(s.URL)
+			c, _ := NewClient(context.Background(), serviceURL, "test")
+
+			got, err := c.BulkDeleteUsers(context.Background(), []int{11, 13, 12}, 2)
+			if err != nil {
+				t.Fatalf("BulkDeleteUsers() error = %v", err)
+			}
+			if diff := cmp.Diff(got.UpdatedUserIDs, tt.wantDeletedIDs); diff != "" {
+				t.Errorf("BulkDeleteUsers() UpdatedUserIDs (-got, +want)\n%s", diff)
+			}
+			var gotFailedIDs []int
+			for _, failure := range got.Failures {
+				gotFailedIDs = append(gotFailedIDs, failure.UserID)
+			}
+			if diff := cmp.Diff(gotFailedIDs, tt.wantFailedIDs); diff != "" {
+				t.Errorf("BulkDeleteUsers() failed user IDs (-got, +want)\n%s", diff)
+			}
+			if requestCount != tt.wantRequestCount {
+				t.Errorf("BulkDeleteUsers() request count = %d, want %d", requestCount, tt.wantRequestCount)
+			}
+		})
+	}
+}
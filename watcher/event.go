@@ -0,0 +1,92 @@
+package watcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// EventType represents the type of an event
+type EventType string
+
+const (
+	// EventGradeReleased is emitted when an ungraded item is graded, or a hidden grade is shown
+	EventGradeReleased EventType = "grade.released"
+	// EventGradeChanged is emitted when a released grade is changed
+	EventGradeChanged EventType = "grade.changed"
+	// EventQuizOpened is emitted when a quiz becomes open, including when it's reopened by extending the close time
+	EventQuizOpened EventType = "quiz.opened"
+	// EventDeadlineMoved is emitted when the close time of a quiz is changed
+	EventDeadlineMoved EventType = "deadline.moved"
+)
+
+// Event is a change detected by the watcher
+type Event struct {
+	// ID identifies the change, which is the same when the event is delivered again after a failure
+	ID       string    `json:"id"`
+	Type     EventType `json:"type"`
+	UserID   int       `json:"userId"`
+	CourseID int       `json:"courseId"`
+	// Time is the time the change is detected
+	Time time.Time `json:"time"`
+	// Grade is set for the grade events
+	Grade *GradeEvent `json:"grade,omitempty"`
+	// Quiz is set for the quiz events
+	Quiz *QuizEvent `json:"quiz,omitempty"`
+}
+
+// GradeEvent is the details of a grade event
+type GradeEvent struct {
+	ItemID   int    `json:"itemId"`
+	ItemName string `json:"itemName"`
+	// OldGrade is the formatted grade before the change, empty if it's released
+	OldGrade string `json:"oldGrade,omitempty"`
+	// NewGrade is the formatted grade, e.g. "8.50", "85.00 %" or "B+"
+	NewGrade    string  `json:"newGrade"`
+	NewGradeRaw float64 `json:"newGradeRaw"`
+}
+
+// QuizEvent is the details of a quiz event
+type QuizEvent struct {
+	QuizID int    `json:"quizId"`
+	Name   string `json:"name"`
+	// TimeOpen and TimeClose are nil if they are not set
+	TimeOpen  *time.Time `json:"timeOpen,omitempty"`
+	TimeClose *time.Time `json:"timeClose,omitempty"`
+	// OldTimeClose is the close time before the change, set only for EventDeadlineMoved
+	OldTimeClose *time.Time `json:"oldTimeClose,omitempty"`
+}
+
+// Handler handles the events.
+// The events are delivered at least once: if any handler returns an error for an event of a user,
+// the snapshot of the user is not updated and all the events of the user in the poll are delivered again
+// to all the handlers in the next poll, including the events already handled.
+// Handlers must dedupe the events by ID, which webhooks send in DeliveryHeader (X-Moodle-Delivery).
+type Handler interface {
+	HandleEvent(ctx context.Context, event *Event) error
+}
+
+// HandlerFunc is an adapter to use a function as Handler
+type HandlerFunc func(ctx context.Context, event *Event) error
+
+// HandleEvent calls f(ctx, event)
+func (f HandlerFunc) HandleEvent(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+// eventID returns the ID derived from the event type, the user, the target and the new state,
+// so that the same change has the same ID.
+func eventID(eventType EventType, userID, targetID int, state string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/%d/%d/%s", eventType, userID, targetID, state)))
+	return hex.EncodeToString(hash[:16])
+}
+
+func formatUnix(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return strconv.FormatInt(t.Unix(), 10)
+}
@@ -0,0 +1,75 @@
+package watcher
+
+import (
+	"log"
+	"time"
+)
+
+type watcherOptions struct {
+	handlers     []Handler
+	interval     time.Duration
+	now          func() time.Time
+	errorHandler func(err error)
+}
+
+func newDefaultWatcherOptions() *watcherOptions {
+	return &watcherOptions{
+		interval: 5 * time.Minute,
+		now:      time.Now,
+		errorHandler: func(err error) {
+			log.Printf("watcher: %v", err)
+		},
+	}
+}
+
+// Option is a option to change watcher configuration.
+type Option interface {
+	apply(*watcherOptions)
+}
+
+type optionFunc struct {
+	f func(opts *watcherOptions)
+}
+
+func (o *optionFunc) apply(opts *watcherOptions) {
+	o.f(opts)
+}
+
+func newOptionFunc(f func(opts *watcherOptions)) *optionFunc {
+	return &optionFunc{
+		f: f,
+	}
+}
+
+// WithHandler registers the handler of the events, it can be set multiple times
+func WithHandler(handler Handler) Option {
+	return newOptionFunc(func(opts *watcherOptions) {
+		opts.handlers = append(opts.handlers, handler)
+	})
+}
+
+// WithWebhook registers a webhook posting the events to the url signed with the secret
+func WithWebhook(url, secret string, webhookOpts WebhookOptions) Option {
+	return WithHandler(NewWebhook(url, secret, webhookOpts))
+}
+
+// WithInterval sets the interval of the polls in Run, 5 minutes by default
+func WithInterval(interval time.Duration) Option {
+	return newOptionFunc(func(opts *watcherOptions) {
+		opts.interval = interval
+	})
+}
+
+// WithClock sets the clock used to detect opened quizzes and for the time of events
+func WithClock(now func() time.Time) Option {
+	return newOptionFunc(func(opts *watcherOptions) {
+		opts.now = now
+	})
+}
+
+// WithErrorHandler sets the function called with the errors of the polls in Run, which are logged by default
+func WithErrorHandler(errorHandler func(err error)) Option {
+	return newOptionFunc(func(opts *watcherOptions) {
+		opts.errorHandler = errorHandler
+	})
+}
@@ -0,0 +1,86 @@
+package watcher
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshot is the state of the watched data persisted between polls
+type snapshot struct {
+	// Users are the snapshots per user ID
+	Users map[int]*userSnapshot `json:"users"`
+}
+
+type userSnapshot struct {
+	TakenAt time.Time `json:"takenAt"`
+	// Grades are the grade items by ID
+	Grades map[int]*gradeState `json:"grades"`
+	// Quizzes are the quizzes by ID
+	Quizzes map[int]*quizState `json:"quizzes"`
+}
+
+type gradeState struct {
+	CourseID int     `json:"courseId"`
+	ItemName string  `json:"itemName"`
+	Released bool    `json:"released"`
+	Grade    string  `json:"grade"`
+	GradeRaw float64 `json:"gradeRaw"`
+}
+
+type quizState struct {
+	CourseID  int        `json:"courseId"`
+	Name      string     `json:"name"`
+	TimeOpen  *time.Time `json:"timeOpen,omitempty"`
+	TimeClose *time.Time `json:"timeClose,omitempty"`
+}
+
+// isOpen reports whether the quiz is open at the time
+func (q *quizState) isOpen(t time.Time) bool {
+	if q.TimeOpen != nil && t.Before(*q.TimeOpen) {
+		return false
+	}
+	return q.TimeClose == nil || t.Before(*q.TimeClose)
+}
+
+func loadSnapshot(path string) (*snapshot, error) {
+	b, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &snapshot{Users: map[int]*userSnapshot{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return nil, err
+	}
+	if snap.Users == nil {
+		snap.Users = map[int]*userSnapshot{}
+	}
+	return &snap, nil
+}
+
+// save writes the snapshot to a temporary file and renames it not to leave a broken file
+func (s *snapshot) save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
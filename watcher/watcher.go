@@ -0,0 +1,305 @@
+// Package watcher detects the changes of grades and quizzes by polling, and dispatches them as events
+// to handlers or HTTP webhooks since Moodle has no outbound webhooks.
+//
+// Each poll takes a snapshot of the grade items and the quizzes of the watched users,
+// compares it with the previous snapshot persisted to a file, and emits the events of the differences.
+// The first poll of a user only takes the snapshot.
+//
+//	w := watcher.New("snapshot.json", []*watcher.User{{Client: client}},
+//		watcher.WithWebhook("https://example.com/hooks/moodle", secret, watcher.WebhookOptions{}),
+//	)
+//	err := w.Run(ctx)
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k-yomo/moodle"
+)
+
+// User is a user to be watched
+type User struct {
+	// ID is the user ID, the user of the client's token is watched if it's 0.
+	// The client must be able to see the grades of the user.
+	ID     int
+	Client *moodle.Client
+}
+
+// PollError is returned when the data of some users can't be fetched or the events can't be delivered.
+// The snapshots of the failed users are not updated, so the events are emitted again in the next poll.
+type PollError struct {
+	// Errors are the errors by the index of the user in the users of the watcher,
+	// since the ID of a user without ID is unknown when the site info can't be fetched
+	Errors map[int]error
+}
+
+func (p *PollError) Error() string {
+	indexes := make([]int, 0, len(p.Errors))
+	for i := range p.Errors {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	messages := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		messages = append(messages, fmt.Sprintf("users[%d]: %v", i, p.Errors[i]))
+	}
+	return "poll failed: " + strings.Join(messages, "; ")
+}
+
+// Watcher polls the data of the users and dispatches the events of the changes
+type Watcher struct {
+	path         string
+	users        []*User
+	handlers     []Handler
+	interval     time.Duration
+	now          func() time.Time
+	errorHandler func(err error)
+
+	// mu serializes the polls sharing the snapshot file
+	mu sync.Mutex
+}
+
+// New creates a watcher of the users persisting the snapshots to the file at the path
+func New(path string, users []*User, opt ...Option) *Watcher {
+	opts := newDefaultWatcherOptions()
+	for _, o := range opt {
+		o.apply(opts)
+	}
+	return &Watcher{
+		path:         path,
+		users:        users,
+		handlers:     opts.handlers,
+		interval:     opts.interval,
+		now:          opts.now,
+		errorHandler: opts.errorHandler,
+	}
+}
+
+// Run polls at the interval until the context is done
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.Poll(ctx); err != nil && ctx.Err() == nil {
+			w.errorHandler(err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Poll takes the snapshots of the users, and dispatches the events of the changes to the handlers.
+// It returns the events delivered to all the handlers.
+func (w *Watcher) Poll(ctx context.Context) ([]*Event, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	snap, err := loadSnapshot(w.path)
+	if err != nil {
+		return nil, fmt.Errorf("load snapshot: %w", err)
+	}
+
+	var delivered []*Event
+	errs := map[int]error{}
+	for i, user := range w.users {
+		userID, events, current, err := w.pollUser(ctx, user, snap)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		snap.Users[userID] = current
+		delivered = append(delivered, events...)
+	}
+	if err := snap.save(w.path); err != nil {
+		return delivered, fmt.Errorf("save snapshot: %w", err)
+	}
+	if len(errs) > 0 {
+		return delivered, &PollError{Errors: errs}
+	}
+	return delivered, nil
+}
+
+// pollUser returns the events of the user delivered to all the handlers and the current snapshot
+func (w *Watcher) pollUser(ctx context.Context, user *User, snap *snapshot) (int, []*Event, *userSnapshot, error) {
+	userID := user.ID
+	if userID == 0 {
+		siteInfo, err := user.Client.SiteAPI.GetSiteInfo(ctx)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("get site info: %w", err)
+		}
+		userID = siteInfo.UserID
+	}
+
+	current, err := w.takeSnapshot(ctx, user.Client, userID)
+	if err != nil {
+		return userID, nil, nil, err
+	}
+	prev, ok := snap.Users[userID]
+	if !ok {
+		return userID, nil, current, nil
+	}
+
+	events := diff(userID, prev, current)
+	for _, event := range events {
+		for _, handler := range w.handlers {
+			if err := handler.HandleEvent(ctx, event); err != nil {
+				return userID, nil, nil, fmt.Errorf("handle event %s: %w", event.ID, err)
+			}
+		}
+	}
+	return userID, events, current, nil
+}
+
+func (w *Watcher) takeSnapshot(ctx context.Context, client *moodle.Client, userID int) (*userSnapshot, error) {
+	current := &userSnapshot{
+		TakenAt: w.now(),
+		Grades:  map[int]*gradeState{},
+		Quizzes: map[int]*quizState{},
+	}
+	courses, err := client.EnrolAPI.GetUsersCourses(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get courses: %w", err)
+	}
+	for _, course := range courses {
+		userGrades, err := client.GradeAPI.GetGradeItems(ctx, userID, course.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get grade items of course %d: %w", course.ID, err)
+		}
+		for _, userGrade := range userGrades {
+			if userGrade.UserID != userID {
+				continue
+			}
+			for _, item := range userGrade.GradeItems {
+				current.Grades[item.ID] = &gradeState{
+					CourseID: course.ID,
+					ItemName: item.ItemName,
+					Released: isReleased(item),
+					Grade:    item.GradeFormatted,
+					GradeRaw: item.GradeRaw,
+				}
+			}
+		}
+
+		quizzes, err := client.QuizAPI.GetQuizzesByCourse(ctx, course.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get quizzes of course %d: %w", course.ID, err)
+		}
+		for _, quiz := range quizzes {
+			current.Quizzes[quiz.ID] = &quizState{
+				CourseID:  course.ID,
+				Name:      quiz.Name,
+				TimeOpen:  optionalTime(quiz.TimeOpen),
+				TimeClose: optionalTime(quiz.TimeClose),
+			}
+		}
+	}
+	return current, nil
+}
+
+// isReleased reports whether the grade is visible to the user, the grades not released are formatted as "-"
+func isReleased(item *moodle.GradeItem) bool {
+	if item.GradeIsHidden || item.GradeHiddenByDate {
+		return false
+	}
+	return item.GradeFormatted != "" && item.GradeFormatted != "-"
+}
+
+// optionalTime returns nil for the unix epoch Moodle uses for unset times
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() || t.Unix() == 0 {
+		return nil
+	}
+	return &t
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// diff returns the events of the changes from prev to current ordered by the item and quiz IDs
+func diff(userID int, prev, current *userSnapshot) []*Event {
+	var events []*Event
+
+	itemIDs := make([]int, 0, len(current.Grades))
+	for itemID := range current.Grades {
+		itemIDs = append(itemIDs, itemID)
+	}
+	sort.Ints(itemIDs)
+	for _, itemID := range itemIDs {
+		grade := current.Grades[itemID]
+		if !grade.Released {
+			continue
+		}
+		prevGrade, ok := prev.Grades[itemID]
+		gradeEvent := &GradeEvent{ItemID: itemID, ItemName: grade.ItemName, NewGrade: grade.Grade, NewGradeRaw: grade.GradeRaw}
+		switch {
+		case !ok || !prevGrade.Released:
+			events = append(events, &Event{
+				ID:       eventID(EventGradeReleased, userID, itemID, grade.Grade),
+				Type:     EventGradeReleased,
+				UserID:   userID,
+				CourseID: grade.CourseID,
+				Time:     current.TakenAt,
+				Grade:    gradeEvent,
+			})
+		case prevGrade.Grade != grade.Grade:
+			gradeEvent.OldGrade = prevGrade.Grade
+			events = append(events, &Event{
+				ID:       eventID(EventGradeChanged, userID, itemID, prevGrade.Grade+"->"+grade.Grade),
+				Type:     EventGradeChanged,
+				UserID:   userID,
+				CourseID: grade.CourseID,
+				Time:     current.TakenAt,
+				Grade:    gradeEvent,
+			})
+		}
+	}
+
+	quizIDs := make([]int, 0, len(current.Quizzes))
+	for quizID := range current.Quizzes {
+		quizIDs = append(quizIDs, quizID)
+	}
+	sort.Ints(quizIDs)
+	for _, quizID := range quizIDs {
+		quiz := current.Quizzes[quizID]
+		prevQuiz, ok := prev.Quizzes[quizID]
+		quizEvent := func() *QuizEvent {
+			return &QuizEvent{QuizID: quizID, Name: quiz.Name, TimeOpen: quiz.TimeOpen, TimeClose: quiz.TimeClose}
+		}
+		if ok && !sameTime(prevQuiz.TimeClose, quiz.TimeClose) {
+			e := quizEvent()
+			e.OldTimeClose = prevQuiz.TimeClose
+			events = append(events, &Event{
+				ID:       eventID(EventDeadlineMoved, userID, quizID, formatUnix(prevQuiz.TimeClose)+"->"+formatUnix(quiz.TimeClose)),
+				Type:     EventDeadlineMoved,
+				UserID:   userID,
+				CourseID: quiz.CourseID,
+				Time:     current.TakenAt,
+				Quiz:     e,
+			})
+		}
+		wasOpen := ok && prevQuiz.isOpen(prev.TakenAt)
+		if !wasOpen && quiz.isOpen(current.TakenAt) {
+			events = append(events, &Event{
+				ID:       eventID(EventQuizOpened, userID, quizID, formatUnix(quiz.TimeOpen)+"/"+formatUnix(quiz.TimeClose)),
+				Type:     EventQuizOpened,
+				UserID:   userID,
+				CourseID: quiz.CourseID,
+				Time:     current.TakenAt,
+				Quiz:     quizEvent(),
+			})
+		}
+	}
+	return events
+}
@@ -0,0 +1,195 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/k-yomo/moodle"
+	"github.com/k-yomo/moodle/moodlemock"
+)
+
+// fakeSite holds the data returned by the mocks
+type fakeSite struct {
+	mu      sync.Mutex
+	items   []*moodle.GradeItem
+	quizzes []*moodle.Quiz
+}
+
+func (f *fakeSite) update(fn func(f *fakeSite)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f)
+}
+
+func newFakeClient(site *fakeSite) *moodle.Client {
+	client, mocks := moodlemock.NewClient()
+	mocks.SiteAPI.GetSiteInfoFunc = func(ctx context.Context) (*moodle.SiteInfo, error) {
+		return &moodle.SiteInfo{UserID: 2}, nil
+	}
+	mocks.EnrolAPI.GetUsersCoursesFunc = func(ctx context.Context, userID int) ([]*moodle.UserCourse, error) {
+		return []*moodle.UserCourse{{ID: 1, ShortName: "MATH1111"}}, nil
+	}
+	mocks.GradeAPI.GetGradeItemsFunc = func(ctx context.Context, userID int, courseID int) ([]*moodle.UserGrade, error) {
+		site.mu.Lock()
+		defer site.mu.Unlock()
+		return []*moodle.UserGrade{{CourseID: courseID, UserID: userID, GradeItems: site.items}}, nil
+	}
+	mocks.QuizAPI.GetQuizzesByCourseFunc = func(ctx context.Context, courseID int) ([]*moodle.Quiz, error) {
+		site.mu.Lock()
+		defer site.mu.Unlock()
+		return site.quizzes, nil
+	}
+	return client
+}
+
+type recordingHandler struct {
+	mu     sync.Mutex
+	events []*Event
+	err    error
+}
+
+func (r *recordingHandler) HandleEvent(ctx context.Context, event *Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	site := &fakeSite{
+		items: []*moodle.GradeItem{
+			{ID: 7, ItemName: "Quiz 1", GradeFormatted: "-"},
+			{ID: 8, ItemName: "Assignment 1", GradeRaw: 7, GradeFormatted: "7.00"},
+		},
+		quizzes: []*moodle.Quiz{
+			{ID: 5, Name: "Quiz 1", TimeOpen: now.Add(time.Hour), TimeClose: now.Add(24 * time.Hour)},
+			{ID: 6, Name: "Quiz 2", TimeOpen: time.Unix(0, 0), TimeClose: now.Add(48 * time.Hour)},
+		},
+	}
+	handler := &recordingHandler{}
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	newWatcher := func() *Watcher {
+		return New(path, []*User{{Client: newFakeClient(site)}}, WithHandler(handler), WithClock(func() time.Time { return now }))
+	}
+
+	// the first poll only takes the snapshot
+	events, err := newWatcher().Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Poll() events of first poll = %v, want empty", events)
+	}
+
+	now = now.Add(2 * time.Hour)
+	site.update(func(f *fakeSite) {
+		f.items[0] = &moodle.GradeItem{ID: 7, ItemName: "Quiz 1", GradeRaw: 8.5, GradeFormatted: "8.50"}
+		f.items[1] = &moodle.GradeItem{ID: 8, ItemName: "Assignment 1", GradeRaw: 9, GradeFormatted: "9.00"}
+		f.quizzes[1] = &moodle.Quiz{ID: 6, Name: "Quiz 2", TimeOpen: time.Unix(0, 0), TimeClose: now.Add(72 * time.Hour)}
+	})
+	// the snapshot is loaded from the file by a new watcher
+	events, err = newWatcher().Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	want := []*Event{
+		{Type: EventGradeReleased, UserID: 2, CourseID: 1, Time: now, Grade: &GradeEvent{ItemID: 7, ItemName: "Quiz 1", NewGrade: "8.50", NewGradeRaw: 8.5}},
+		{Type: EventGradeChanged, UserID: 2, CourseID: 1, Time: now, Grade: &GradeEvent{ItemID: 8, ItemName: "Assignment 1", OldGrade: "7.00", NewGrade: "9.00", NewGradeRaw: 9}},
+		{Type: EventQuizOpened, UserID: 2, CourseID: 1, Time: now, Quiz: &QuizEvent{
+			QuizID: 5, Name: "Quiz 1", TimeOpen: timePtr(now.Add(-time.Hour)), TimeClose: timePtr(now.Add(22 * time.Hour)),
+		}},
+		{Type: EventDeadlineMoved, UserID: 2, CourseID: 1, Time: now, Quiz: &QuizEvent{
+			QuizID: 6, Name: "Quiz 2", TimeClose: timePtr(now.Add(72 * time.Hour)), OldTimeClose: timePtr(now.Add(46 * time.Hour)),
+		}},
+	}
+	opts := []cmp.Option{cmpopts.IgnoreFields(Event{}, "ID"), cmpopts.EquateApproxTime(0)}
+	if diff := cmp.Diff(events, want, opts...); diff != "" {
+		t.Errorf("Poll() events (-got, +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(handler.events, want, opts...); diff != "" {
+		t.Errorf("handled events (-got, +want)\n%s", diff)
+	}
+
+	// no changes
+	now = now.Add(time.Hour)
+	events, err = newWatcher().Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Poll() events without changes = %v, want empty", events)
+	}
+}
+
+func TestWatcher_Poll_redeliverOnFailure(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	site := &fakeSite{items: []*moodle.GradeItem{{ID: 7, ItemName: "Quiz 1", GradeFormatted: "-"}}}
+	handler := &recordingHandler{}
+	w := New(filepath.Join(t.TempDir(), "snapshot.json"), []*User{{ID: 3, Client: newFakeClient(site)}}, WithHandler(handler))
+	if _, err := w.Poll(ctx); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+
+	site.update(func(f *fakeSite) {
+		f.items[0] = &moodle.GradeItem{ID: 7, ItemName: "Quiz 1", GradeRaw: 8.5, GradeFormatted: "8.50"}
+	})
+	errHandler := errors.New("unavailable")
+	handler.err = errHandler
+	_, err := w.Poll(ctx)
+	var pollErr *PollError
+	if !errors.As(err, &pollErr) || !errors.Is(pollErr.Errors[0], errHandler) {
+		t.Fatalf("Poll() error = %v, want PollError of users[0]", err)
+	}
+
+	handler.err = nil
+	events, err := w.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Type != EventGradeReleased || events[0].UserID != 3 {
+		t.Errorf("Poll() events after failure = %v, want grade released of user 3", events)
+	}
+}
+
+func TestWatcher_Poll_errorsOfUsersWithoutID(t *testing.T) {
+	t.Parallel()
+
+	errSiteInfo := errors.New("invalid token")
+	newFailingClient := func() *moodle.Client {
+		client, mocks := moodlemock.NewClient()
+		mocks.SiteAPI.GetSiteInfoFunc = func(ctx context.Context) (*moodle.SiteInfo, error) {
+			return nil, errSiteInfo
+		}
+		return client
+	}
+	users := []*User{{Client: newFailingClient()}, {ID: 3, Client: newFakeClient(&fakeSite{})}, {Client: newFailingClient()}}
+	w := New(filepath.Join(t.TempDir(), "snapshot.json"), users)
+
+	_, err := w.Poll(context.Background())
+	var pollErr *PollError
+	if !errors.As(err, &pollErr) {
+		t.Fatalf("Poll() error = %v, want PollError", err)
+	}
+	if len(pollErr.Errors) != 2 || !errors.Is(pollErr.Errors[0], errSiteInfo) || !errors.Is(pollErr.Errors[2], errSiteInfo) {
+		t.Errorf("PollError.Errors = %v, want the errors of users[0] and users[2]", pollErr.Errors)
+	}
+}
@@ -0,0 +1,151 @@
+package watcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// SignatureHeader is the header of the HMAC-SHA256 signature of the timestamp and the body, e.g. "sha256=<hex>"
+	SignatureHeader = "X-Moodle-Signature"
+	// TimestampHeader is the header of the unix time the request is signed at
+	TimestampHeader = "X-Moodle-Timestamp"
+	// EventHeader is the header of the event type
+	EventHeader = "X-Moodle-Event"
+	// DeliveryHeader is the header of the event ID, which is the same for the retries
+	DeliveryHeader = "X-Moodle-Delivery"
+)
+
+// WebhookError is returned when the endpoint responds with a non 2xx status
+type WebhookError struct {
+	StatusCode int
+	Body       string
+}
+
+func (w *WebhookError) Error() string {
+	return fmt.Sprintf("webhook responded with status %d: %s", w.StatusCode, w.Body)
+}
+
+// WebhookOptions represents options of Webhook
+type WebhookOptions struct {
+	// HTTPClient is used to send the requests, http.DefaultClient is used if it's nil
+	HTTPClient *http.Client
+	// MaxRetries is the max number of retries after the first try, 3 if it's 0 and no retry if it's negative
+	MaxRetries int
+	// Backoff is the wait before the first retry, which is doubled for each retry. 1 second if it's 0
+	Backoff time.Duration
+}
+
+// Webhook is a Handler posting the events as JSON to the endpoint with HMAC signature.
+// The requests failed with network errors, 408, 429 or 5xx status are retried.
+type Webhook struct {
+	url        string
+	secret     []byte
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+	now        func() time.Time
+}
+
+// NewWebhook creates a webhook posting to the url signed with the secret
+func NewWebhook(url, secret string, opts WebhookOptions) *Webhook {
+	w := &Webhook{
+		url:        url,
+		secret:     []byte(secret),
+		httpClient: opts.HTTPClient,
+		maxRetries: opts.MaxRetries,
+		backoff:    opts.Backoff,
+		now:        time.Now,
+	}
+	if w.httpClient == nil {
+		w.httpClient = http.DefaultClient
+	}
+	if w.maxRetries == 0 {
+		w.maxRetries = 3
+	} else if w.maxRetries < 0 {
+		w.maxRetries = 0
+	}
+	if w.backoff == 0 {
+		w.backoff = time.Second
+	}
+	return w
+}
+
+// HandleEvent posts the event to the endpoint
+func (w *Webhook) HandleEvent(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	backoff := w.backoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := w.post(ctx, event, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= w.maxRetries {
+			return fmt.Errorf("deliver event %s to %s: %w", event.ID, w.url, err)
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// post sends the event, and returns whether the request can be retried if it fails
+func (w *Webhook) post(ctx context.Context, event *Event, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	// the timestamp is signed for each try so that receivers can reject old requests
+	timestamp := strconv.FormatInt(w.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(event.Type))
+	req.Header.Set(DeliveryHeader, event.ID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(string(w.secret), timestamp, body))
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return false, nil
+	}
+	respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	retryable := resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError
+	return retryable, &WebhookError{StatusCode: resp.StatusCode, Body: string(respBody)}
+}
+
+// Sign returns the signature of the timestamp and the body in the format of "sha256=<hex>"
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether the signature is valid for the timestamp and the body, used by the receivers
+func VerifySignature(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,110 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWebhook_HandleEvent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{
+			name:      "Delivered",
+			statuses:  []int{http.StatusNoContent},
+			wantCalls: 1,
+		},
+		{
+			name:      "Retried after server errors",
+			statuses:  []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			wantCalls: 3,
+		},
+		{
+			name:      "Give up after max retries",
+			statuses:  []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "Not retried on client errors",
+			statuses:  []int{http.StatusBadRequest},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			event := &Event{ID: "abc", Type: EventGradeReleased, UserID: 2, CourseID: 1, Time: time.Unix(1600000000, 0).UTC(),
+				Grade: &GradeEvent{ItemID: 7, ItemName: "Quiz 1", NewGrade: "8.50", NewGradeRaw: 8.5}}
+			var calls int32
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				body, _ := ioutil.ReadAll(r.Body)
+				if !VerifySignature("secret", r.Header.Get(TimestampHeader), body, r.Header.Get(SignatureHeader)) {
+					t.Errorf("invalid signature %q", r.Header.Get(SignatureHeader))
+				}
+				if got := r.Header.Get(DeliveryHeader); got != "abc" {
+					t.Errorf("%s = %q, want abc", DeliveryHeader, got)
+				}
+				if got := r.Header.Get(EventHeader); got != string(EventGradeReleased) {
+					t.Errorf("%s = %q, want %s", EventHeader, got, EventGradeReleased)
+				}
+				var got Event
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Errorf("unmarshal body error = %v", err)
+				}
+				if diff := cmp.Diff(&got, event); diff != "" {
+					t.Errorf("body (-got, +want)\n%s", diff)
+				}
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer s.Close()
+
+			webhook := NewWebhook(s.URL, "secret", WebhookOptions{MaxRetries: 2, Backoff: time.Millisecond})
+			err := webhook.HandleEvent(context.Background(), event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HandleEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var webhookErr *WebhookError
+			if tt.wantErr && !errors.As(err, &webhookErr) {
+				t.Errorf("HandleEvent() error = %v, want WebhookError", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"id":"abc"}`)
+	signature := Sign("secret", "1600000000", body)
+	if !VerifySignature("secret", "1600000000", body, signature) {
+		t.Errorf("VerifySignature() = false, want true")
+	}
+	if VerifySignature("secret", "1600000001", body, signature) {
+		t.Errorf("VerifySignature() with other timestamp = true, want false")
+	}
+	if VerifySignature("other", "1600000000", body, signature) {
+		t.Errorf("VerifySignature() with other secret = true, want false")
+	}
+}